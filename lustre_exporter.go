@@ -14,18 +14,26 @@
 package main
 
 import (
-	"fmt"
+	"context"
+	"encoding/json"
+	"math"
 	"net/http"
 	"os"
+	"regexp"
+	"time"
 
 	_ "net/http/pprof"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/prometheus/common/version"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 	"gopkg.in/alecthomas/kingpin.v2"
 
 	"lustre_exporter/log"
+	"lustre_exporter/remotewrite"
 	"lustre_exporter/sources"
 )
 
@@ -60,44 +68,387 @@ func (l LustreSource) Collect(ch chan<- prometheus.Metric) {
 	sources.Runner().Update(l.sourceList, scrapeDurations, ch)
 }
 
+// collectedMetrics replays one collection, described by the descriptors of
+// its metrics so the registry checks them against each other.
+type collectedMetrics []prometheus.Metric
+
+func (c collectedMetrics) Describe(ch chan<- *prometheus.Desc) {
+	seen := map[string]bool{}
+	for _, m := range c {
+		desc := m.Desc()
+		if !seen[desc.String()] {
+			seen[desc.String()] = true
+			ch <- desc
+		}
+	}
+}
+
+func (c collectedMetrics) Collect(ch chan<- prometheus.Metric) {
+	for _, m := range c {
+		ch <- m
+	}
+}
+
+// selfCheck runs one collection of c through a fresh pedantic registry and
+// returns the errors Prometheus finds in it: invalid metric or label names,
+// metrics of the same name with different labels or help, duplicate series.
+func selfCheck(c prometheus.Collector) error {
+	ch := make(chan prometheus.Metric)
+	var metrics collectedMetrics
+	done := make(chan struct{})
+	go func() {
+		for m := range ch {
+			metrics = append(metrics, m)
+		}
+		close(done)
+	}()
+	c.Collect(ch)
+	close(ch)
+	<-done
+
+	reg := prometheus.NewPedanticRegistry()
+	if err := reg.Register(metrics); err != nil {
+		return err
+	}
+	_, err := reg.Gather()
+	return err
+}
+
 func loadSources(list []string) (map[string]sources.LustreSource, error) {
+	cfg := sources.NewConfig()
 	sourceList := map[string]sources.LustreSource{}
 	for _, name := range list {
-		fn, ok := sources.Factories[name]
-		if !ok {
-			return nil, fmt.Errorf("source %q not available", name)
+		c, err := sources.NewSource(name, cfg)
+		if err != nil {
+			return nil, err
 		}
-		c := fn()
 		sourceList[name] = c
 	}
 	return sourceList, nil
 }
 
+// writeAudit dumps the tunables of the node to path, see --audit.output.
+func writeAudit(path string, format string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	cfg := sources.NewConfig()
+	if err := sources.WriteAudit(f, format, sources.AuditTunables(cfg)); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// limitRequests serves at most max requests at a time through next, the ones
+// above the limit are answered with a 503 right away instead of piling more
+// proc reads onto a loaded server.
+func limitRequests(next http.Handler, max int) http.Handler {
+	sem := make(chan struct{}, max)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case sem <- struct{}{}:
+			defer func() { <-sem }()
+			next.ServeHTTP(w, r)
+		default:
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, "too many concurrent scrapes, try again later", http.StatusServiceUnavailable)
+		}
+	})
+}
+
+// countingResponseWriter counts the bytes of the body written through it.
+type countingResponseWriter struct {
+	http.ResponseWriter
+	status int
+	n      int
+}
+
+func (w *countingResponseWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *countingResponseWriter) Write(b []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(b)
+	w.n += n
+	return n, err
+}
+
+// exposedBytesGauge exports the size of the last exposition, see
+// withExposedBytes.
+func exposedBytesGauge() prometheus.Gauge {
+	return prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: sources.Namespace,
+		Subsystem: "exporter",
+		Name:      "exposition_bytes",
+		Help:      "Size in bytes of the body of the last successful scrape response, compressed when the scraper asked for it, to see the payload grow before hitting the scrape limits of Prometheus.",
+	})
+}
+
+// withExposedBytes sets g to the number of bytes next wrote for every
+// successful response, the value is thus reported by the following scrape.
+func withExposedBytes(next http.Handler, g prometheus.Gauge) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cw := &countingResponseWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(cw, r)
+		if cw.status == http.StatusOK {
+			g.Set(float64(cw.n))
+		}
+	})
+}
+
+// exposedSamplesGauge exports the number of samples of the last exposition,
+// see samplesGatherer.
+func exposedSamplesGauge() prometheus.Gauge {
+	return prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: sources.Namespace,
+		Subsystem: "exporter",
+		Name:      "samples_exposed",
+		Help:      "Number of samples of the last scrape response, like scrape_samples_scraped on the Prometheus side, to follow the cardinality of the node over time.",
+	})
+}
+
+// samplesGatherer sets g to the number of samples of every gathering, the
+// value is thus reported by the following scrape.
+type samplesGatherer struct {
+	prometheus.Gatherer
+	g prometheus.Gauge
+}
+
+func (s samplesGatherer) Gather() ([]*dto.MetricFamily, error) {
+	families, err := s.Gatherer.Gather()
+	s.g.Set(float64(countSamples(families)))
+	return families, err
+}
+
+// countSamples returns the number of samples of families in the text
+// exposition: a summary has its quantiles, _sum and _count, a histogram its
+// buckets including +Inf, _sum and _count.
+func countSamples(families []*dto.MetricFamily) int {
+	n := 0
+	for _, family := range families {
+		for _, m := range family.GetMetric() {
+			switch family.GetType() {
+			case dto.MetricType_SUMMARY:
+				n += len(m.GetSummary().GetQuantile()) + 2
+			case dto.MetricType_HISTOGRAM:
+				buckets := m.GetHistogram().GetBucket()
+				n += len(buckets) + 2
+				if len(buckets) == 0 || !math.IsInf(buckets[len(buckets)-1].GetUpperBound(), 1) {
+					n++
+				}
+			default:
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// withH2C lets next be served over HTTP/2 without TLS (h2c, both prior
+// knowledge and Upgrade), plain HTTP/1.1 requests keep working.
+func withH2C(next http.Handler) http.Handler {
+	return h2c.NewHandler(next, &http2.Server{})
+}
+
+// withVersionLabel returns a Registerer adding lustre_version="<version>" to
+// every metric of the collectors registered through it.
+func withVersionLabel(reg prometheus.Registerer, version string) prometheus.Registerer {
+	return prometheus.WrapRegistererWith(prometheus.Labels{"lustre_version": version}, reg)
+}
+
+// markSet returns a flag action recording that the flag was given, kingpin
+// only runs the actions of the flags present on the command line.
+func markSet(set *bool) kingpin.Action {
+	return func(*kingpin.ParseContext) error {
+		*set = true
+		return nil
+	}
+}
+
+// metricsHandlerOpts returns the options of the /metrics handler: a failed
+// gathering answers with a 500 in strict mode, the metrics gathered are
+// served otherwise.
+func metricsHandlerOpts(strict bool) promhttp.HandlerOpts {
+	opts := promhttp.HandlerOpts{ErrorLog: log.NewErrorLogger(), ErrorHandling: promhttp.ContinueOnError}
+	if strict {
+		opts.ErrorHandling = promhttp.HTTPErrorOnError
+	}
+	return opts
+}
+
+// summaryHandler serves the metrics of c from a registry of its own,
+// independent of the main telemetry path.
+func summaryHandler(c prometheus.Collector) http.Handler {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(c)
+	return promhttp.HandlerFor(reg, promhttp.HandlerOpts{ErrorLog: log.NewErrorLogger(), ErrorHandling: promhttp.ContinueOnError})
+}
+
+// collectHandler runs one collection of sourceList on every request and
+// answers with how each collector did, without the metrics.
+func collectHandler(sourceList map[string]sources.LustreSource) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(sources.CollectOnce(sourceList)); err != nil {
+			log.Errorf("Unable to write the /collect response: %s", err)
+		}
+	})
+}
+
+// metricNamesHandler answers with the metric families sourceList can emit,
+// their help, type and labels, whether or not this node has their files.
+func metricNamesHandler(sourceList map[string]sources.LustreSource) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(sources.MetricNames(sourceList)); err != nil {
+			log.Errorf("Unable to write the /metrics/names response: %s", err)
+		}
+	})
+}
+
+// scrapeIntervalGauge exports the --collector.scrape-interval the exporter was
+// configured with.
+func scrapeIntervalGauge(interval time.Duration) prometheus.Gauge {
+	g := prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: sources.Namespace,
+		Subsystem: "exporter",
+		Name:      "expected_scrape_interval_seconds",
+		Help:      "Interval the exporter expects to be scraped at (--collector.scrape-interval), the time base of the frozen and stalled target detectors.",
+	})
+	g.Set(interval.Seconds())
+	return g
+}
+
+// serverRoleGauge exports the role of the node, --collector.server-role or the
+// inferred one.
+func serverRoleGauge(role string) prometheus.Gauge {
+	g := prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace:   sources.Namespace,
+		Name:        "server_role_info",
+		Help:        "Role of the node (oss, mds, mgs, combined or client) in the role label, always 1.",
+		ConstLabels: prometheus.Labels{"role": role},
+	})
+	g.Set(1)
+	return g
+}
+
+// startTime is when the exporter started, set once when the package is
+// initialized.
+var startTime = time.Now()
+
+// startTimeGauge exports the start time of the exporter, for the uptime and
+// to catch crash-looping exporters.
+func startTimeGauge(start time.Time) prometheus.Gauge {
+	g := prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: sources.Namespace,
+		Subsystem: "exporter",
+		Name:      "start_time_seconds",
+		Help:      "Start time of the exporter since unix epoch in seconds, time() minus it is the uptime.",
+	})
+	g.Set(float64(start.UnixNano()) / 1e9)
+	return g
+}
+
 func init() {
 	prometheus.MustRegister(version.NewCollector("lustre_exporter"))
+	prometheus.MustRegister(startTimeGauge(startTime))
 }
 
 func main() {
 	kingpin.Version(version.Print("lustre_exporter"))
 	kingpin.HelpFlag.Short('h')
 
+	// collectors and sub-toggles given on the command line, left alone by --preset
+	setByUser := map[string]*bool{}
+	for _, name := range []string{"ost", "mdt", "mgs", "mds", "client", "generic", "lnet", "ldlm", "health", "jobstats", "brw-stats"} {
+		setByUser[name] = new(bool)
+	}
+
 	var (
-		clientEnabled       = kingpin.Flag("collector.client", "Set client metric level. Valid levels: [extended, core, disabled]").Default("extended").Enum("extended", "core", "disabled")
-		genericEnabled      = kingpin.Flag("collector.generic", "Set generic metric level. Valid levels: [extended, core, disabled]").Default("extended").Enum("extended", "core", "disabled")
-		lnetEnabled         = kingpin.Flag("collector.lnet", "Set LNET metric level. Valid levels: [extended, core, disabled]").Default("extended").Enum("extended", "core", "disabled")
-		mdsEnabled          = kingpin.Flag("collector.mds", "Set MDS metric level. Valid levels: [extended, core, disabled]").Default("extended").Enum("extended", "core", "disabled")
-		mdtEnabled          = kingpin.Flag("collector.mdt", "Set MDT metric level. Valid levels: [extended, core, disabled]").Default("extended").Enum("extended", "core", "disabled")
-		mgsEnabled          = kingpin.Flag("collector.mgs", "Set MGS metric level. Valid levels: [extended, core, disabled]").Default("extended").Enum("extended", "core", "disabled")
-		ostEnabled          = kingpin.Flag("collector.ost", "Set OST metric level. Valid levels: [extended, core, disabled]").Default("extended").Enum("extended", "core", "disabled")
-		healthStatusEnabled = kingpin.Flag("collector.health", "Set Health metric level. Valid levels: [extended, core, disabled]").Default("extended").Enum("extended", "core", "disabled")
+		clientEnabled       = kingpin.Flag("collector.client", "Set client metric level. Valid levels: [extended, core, disabled]").Action(markSet(setByUser["client"])).Default("extended").Enum("extended", "core", "disabled")
+		genericEnabled      = kingpin.Flag("collector.generic", "Set generic metric level. Valid levels: [extended, core, disabled]").Action(markSet(setByUser["generic"])).Default("extended").Enum("extended", "core", "disabled")
+		lnetEnabled         = kingpin.Flag("collector.lnet", "Set LNET metric level. Valid levels: [extended, core, disabled]").Action(markSet(setByUser["lnet"])).Default("extended").Enum("extended", "core", "disabled")
+		mdsEnabled          = kingpin.Flag("collector.mds", "Set MDS metric level. Valid levels: [extended, core, disabled]").Action(markSet(setByUser["mds"])).Default("extended").Enum("extended", "core", "disabled")
+		mdtEnabled          = kingpin.Flag("collector.mdt", "Set MDT metric level. Valid levels: [extended, core, disabled]").Action(markSet(setByUser["mdt"])).Default("extended").Enum("extended", "core", "disabled")
+		mgsEnabled          = kingpin.Flag("collector.mgs", "Set MGS metric level. Valid levels: [extended, core, disabled]").Action(markSet(setByUser["mgs"])).Default("extended").Enum("extended", "core", "disabled")
+		ostEnabled          = kingpin.Flag("collector.ost", "Set OST metric level. Valid levels: [extended, core, disabled]").Action(markSet(setByUser["ost"])).Default("extended").Enum("extended", "core", "disabled")
+		ldlmEnabled         = kingpin.Flag("collector.ldlm", "Set LDLM metric level. Valid levels: [extended, core, disabled]").Action(markSet(setByUser["ldlm"])).Default("extended").Enum("extended", "core", "disabled")
+		healthStatusEnabled = kingpin.Flag("collector.health", "Set Health metric level. Valid levels: [extended, core, disabled]").Action(markSet(setByUser["health"])).Default("extended").Enum("extended", "core", "disabled")
+		preset              = kingpin.Flag("preset", "Curated minimal set of collectors for an incident, the per-collector flags and sub-toggles given explicitly override it. Valid presets: [oss-lite, mds-lite, client-lite]").Default("").Enum("", "oss-lite", "mds-lite", "client-lite")
+		jobStats            = kingpin.Flag("collector.jobstats", "collect the job_stats files of the OSTs and MDTs (--no-collector.jobstats to disable)").Action(markSet(setByUser["jobstats"])).Default("true").Bool()
+		brwStats            = kingpin.Flag("collector.brw-stats", "collect the brw_stats histograms of the OSTs and MDTs (--no-collector.brw-stats to disable)").Action(markSet(setByUser["brw-stats"])).Default("true").Bool()
+		components          = kingpin.Flag("collector.components", "Comma separated allow-list of collectors to enable (extended), all others are disabled. Overrides the per-collector flags. Valid names: [ost, oss, mdt, mgs, mds, client, generic, lnet, ldlm, health]").Default("").String()
+		sourceNames         = kingpin.Flag("collector.sources", "Comma separated list of the registered sources to run, all of them when empty. Built-in sources: [procfs, procsys, sysfs]").Default("").String()
 		listenAddress       = kingpin.Flag("web.listen-address", "Address to use to expose Lustre metrics.").Default(":9169").String()
 		metricsPath         = kingpin.Flag("web.telemetry-path", "Path to use to expose Lustre metrics.").Default("/metrics").String()
+		summaryPath         = kingpin.Flag("web.summary-path", "Path to expose only the overview metrics (target count, capacity, free space, health) at, from a registry of their own. Empty to disable.").Default("/metrics/summary").String()
 
 		procPath            = kingpin.Flag("collector.path.proc", "Path to collect data from proc").Default("/proc").String()
 		sysPath             = kingpin.Flag("collector.path.sys" , "Path to collect data from sys").Default("/sys").String()
+		procfsPath          = kingpin.Flag("path.procfs", "Lustre procfs tree, e.g. /host/proc/fs/lustre in a container. Empty for <collector.path.proc>/fs/lustre, i.e. /proc/fs/lustre.").Default("").String()
+		sysfsPath           = kingpin.Flag("path.sysfs", "Lustre sysfs tree, e.g. /host/sys/fs/lustre in a container. Empty for <collector.path.sys>/fs/lustre, i.e. /sys/fs/lustre.").Default("").String()
+		preferSysfs         = kingpin.Flag("collector.prefer-sysfs", "Read every Lustre file from <collector.path.sys>/fs/lustre or <collector.path.sys>/kernel/debug/lustre when it is there, falling back to <collector.path.proc>/fs/lustre.").Default("true").Bool()
+		hostProcPath        = kingpin.Flag("path.host-proc", "Host /proc bind mounted in the container, used when Lustre is not found below --collector.path.proc. Empty to disable.").Default("/host/proc").String()
 		collectVer          = kingpin.Flag("collector.collect.ver" , "collect version").Default("v2").String()
 		workers             = kingpin.Flag("collector.v2.workers", "max collecting workers can create in the same time").Default("4").Int()
 		shelflife           = kingpin.Flag("collector.v2.shelflife", "data shelf life, no repeated collection during the shelf life").Default("1s").Duration()
+		normalizeUnits      = kingpin.Flag("collector.normalize-units", "also export the kilobytes and megabytes metrics in bytes (e.g. lustre_brw_size_bytes next to lustre_brw_size_megabytes)").Default("false").Bool()
+		mdtExportStats      = kingpin.Flag("collector.mdt.export-stats", "collect per-client (export) metrics of the MDT, high cardinality: one series per client and target").Default("false").Bool()
+		exportNidAllow      = kingpin.Flag("collector.export-nid-allow", "regexp, only collect per-client metrics of the NIDs matching it").Default("").String()
+		exportNidDeny       = kingpin.Flag("collector.export-nid-deny", "regexp, do not collect per-client metrics of the NIDs matching it").Default("").String()
+		nidAggregate        = kingpin.Flag("collector.nid-aggregate", "IPv4 prefix length (e.g. /24) to also count the per-client NIDs by subnet in lustre_clients_by_subnet, empty to disable").Default("").String()
+		rawOperationNames   = kingpin.Flag("collector.raw-operation-names", "do not normalize operation aliases (e.g. getinfo -> get_info), only the canonical spellings are recognized").Default("false").Bool()
+		addVersionLabel     = kingpin.Flag("collector.add-version-label", "attach a lustre_version label (major.minor, e.g. 2.15) to every metric, this changes the identity of all series").Default("false").Bool()
+		subsystemNamespace  = kingpin.Flag("collector.subsystem-namespace", "insert the collector name as the subsystem of its metrics (e.g. lustre_ost_stats_total instead of lustre_stats_total), this renames the metrics and breaks the existing dashboards").Default("false").Bool()
+		addUUIDLabel        = kingpin.Flag("collector.add-uuid-label", "report the uuid of the OST and MDT targets, read from their uuid file, in lustre_target_info").Default("false").Bool()
+		latencyStats        = kingpin.Flag("collector.latency-stats", "export the mean and stddev of the operation latencies ([usec] lines of the stats files), these are not quantiles").Default("false").Bool()
+		throughputRates     = kingpin.Flag("collector.throughput-rates", "export lustre_write_bytes_rate, the write throughput of every target computed by the exporter between two of its scrapes, for sparse scrape intervals").Default("false").Bool()
+		healthStateAge      = kingpin.Flag("collector.health-state-age", "export lustre_health_state_age_seconds, for how long the health_check and the OST degraded signals have held their current value, tracked by the exporter").Default("false").Bool()
+		emitAverageRates    = kingpin.Flag("collector.emit-average-rates", "export lustre_op_avg_rate, the samples of the [usec] lines of the stats files divided by their elapsed time: an average since the stats were reset, not a rate (v2 only)").Default("false").Bool()
+		skipInactive        = kingpin.Flag("collector.skip-inactive-targets", "skip the targets which are not UP in the lustre device list, e.g. failover standby targets").Default("false").Bool()
+		sanitizeLabels      = kingpin.Flag("collector.sanitize-labels", "strip control characters and surrounding whitespace from label values (e.g. corrupted jobids) before emitting them").Default("false").Bool()
+		maxFileBytes        = kingpin.Flag("collector.max-file-bytes", "files larger than this are skipped instead of parsed (counted by lustre_skipped_files_total), 0 for no limit").Default("268435456").Int64()
+		negativeLookups     = kingpin.Flag("collector.cache-negative-lookups", "remember the directories found missing (e.g. llite on a pure OSS) for --collector.negative-cache-ttl and skip globbing below them meanwhile").Default("false").Bool()
+		negativeCacheTTL    = kingpin.Flag("collector.negative-cache-ttl", "how long a missing directory is remembered by --collector.cache-negative-lookups before it is looked up again").Default("5m").Duration()
+		targetGlobs         = kingpin.Flag("collector.target-glob", "lctl style shell pattern (e.g. lustrefs-OST*, *-OST0004) matched against the whole OST/MDT name, only the matching targets are collected, can be repeated").Strings()
+		pathAllow           = kingpin.Flag("collector.path-allow", "path glob (e.g. /proc/fs/lustre/obdfilter), when set only the files below a matching path, symlinks resolved, are ever read whatever the enabled collectors, can be repeated").Strings()
+		extraParams         = kingpin.Flag("collector.extra-params", "export an additional single value parameter, as glob=metric_name[:gauge|counter] where glob is an lctl get_param pattern (e.g. osc.*.max_dirty_mb), can be repeated").Strings()
+		ioTimeHistogram     = kingpin.Flag("collector.io-time-histogram", "also export the brw_stats 'I/O time' section of the OSTs as a histogram (lustre_io_time_milliseconds) with cumulative buckets").Default("false").Bool()
+		recovery            = kingpin.Flag("collector.recovery", "also collect the stale locks and clients of the OST and MDT recoveries, when Lustre reports them, and the number of recoveries seen by the exporter from recovery_status").Default("false").Bool()
+		quota               = kingpin.Flag("collector.quota", "collect the per user, group and project quota usage and limits of the OSTs and MDTs from the accounting of their quota slave (collector.ost and collector.mdt)").Default("false").Bool()
+		quotaMaxIDs         = kingpin.Flag("collector.quota.max-ids", "only report the IDs using the most space, at most this many per target and quota type, 0 for no limit").Default("0").Int()
+		quotaEnforcedOnly   = kingpin.Flag("collector.quota.enforced-only", "only report the quota of the IDs with a hard limit").Default("false").Bool()
+		hsm                 = kingpin.Flag("collector.hsm", "collect the HSM coordinator queue of the MDTs (active, waiting and per action requests) from hsm/actions and hsm/active_requests").Default("false").Bool()
+		ptlrpc              = kingpin.Flag("collector.ptlrpc", "collect the RPC error counters (resend, timeout, out of memory) of the client osc and mdc imports (collector.client)").Default("false").Bool()
+		serviceStats        = kingpin.Flag("collector.service-stats", "collect the request queue depth and active requests of the MDT services (collector.mds)").Default("false").Bool()
+		lastScrapeError     = kingpin.Flag("collector.last-scrape-error", "export the error of the collectors which failed in the last scrape as lustre_last_scrape_error{collector,error}").Default("false").Bool()
+		strict              = kingpin.Flag("collector.strict", "fail the whole scrape with an HTTP 500 when any collector failed, instead of serving the metrics of the others").Default("false").Bool()
+		emitZeroOnMissing   = kingpin.Flag("collector.emit-zero-on-missing", "report the always expected OST and MDT metrics (space, inodes, exports) as 0 when their file is missing or unreadable, instead of leaving the series out").Default("false").Bool()
+		dropZeroJobStats    = kingpin.Flag("collector.drop-zero-jobstats", "drop the OST job_stats blocks whose read and write samples are both zero (v2 only)").Default("false").Bool()
+		jobstatsInclude     = kingpin.Flag("collector.jobstats.include", "regexp, only collect the OST and MDT job_stats of the jobids matching it").Default("").String()
+		jobstatsExclude     = kingpin.Flag("collector.jobstats.exclude", "regexp, do not collect the OST and MDT job_stats of the jobids matching it").Default("").String()
+		jobstatsMaxJobs     = kingpin.Flag("collector.jobstats.max-jobs", "maximum number of jobids collected per OST and MDT job_stats file, 0 means no limit").Default("0").Int()
+		grantThreshold      = kingpin.Flag("collector.grant-exhaustion-threshold", "ratio of tot_granted to the space available on the OST above which lustre_grant_exhausted is 1").Default("0.95").Float64()
+		frozenThreshold     = kingpin.Flag("collector.frozen-threshold", "number of consecutive scrapes with unchanged stats after which a target is reported as frozen, 0 to disable").Default("0").Int()
+		scrapeInterval      = kingpin.Flag("collector.scrape-interval", "interval the exporter is expected to be scraped at, when set the frozen and stalled detectors wait for threshold * interval instead of threshold scrapes. Informational otherwise, 0 to leave unset").Default("0s").Duration()
+		pingStallThreshold  = kingpin.Flag("collector.ping-stall-threshold", "number of consecutive scrapes without new ping requests after which a target is reported as stalled while other targets are pinged, 0 to disable").Default("0").Int()
+
+		serverRole          = kingpin.Flag("collector.server-role", "Role of the node exported as lustre_server_role_info{role}, inferred from the Lustre devices present when empty. Valid roles: [oss, mds, mgs, combined, client]").Default("").Enum("", "oss", "mds", "mgs", "combined", "client")
+		selfCheckEnabled    = kingpin.Flag("collector.self-check", "Run one collection at startup and exit nonzero if Prometheus finds an invalid name, inconsistent labels or a duplicate series in it.").Default("false").Bool()
+		auditOutput         = kingpin.Flag("audit.output", "Write the raw value of every single value file (tunables) the exporter knows how to read to this file and exit, instead of serving metrics.").Default("").String()
+		auditFormat         = kingpin.Flag("audit.format", "Format of --audit.output. Valid formats: [json, csv]").Default("json").Enum("json", "csv")
+		remoteWriteURL      = kingpin.Flag("remote-write.url", "Push metrics to this Prometheus remote_write endpoint instead of (or in addition to) being scraped.").Default("").String()
+		remoteWriteInterval = kingpin.Flag("remote-write.interval", "Interval between two remote_write pushes.").Default("15s").Duration()
+		remoteWriteTimeout  = kingpin.Flag("remote-write.timeout", "Timeout of a single remote_write request.").Default("30s").Duration()
+		remoteWriteUser     = kingpin.Flag("remote-write.username", "Username for basic auth against the remote_write endpoint.").Default("").String()
+		remoteWritePassFile = kingpin.Flag("remote-write.password-file", "File holding the password for basic auth against the remote_write endpoint.").Default("").String()
+		maxRequests         = kingpin.Flag("web.max-requests", "Maximum number of concurrent scrape requests, further requests get a 503. 0 means no limit.").Default("2").Int()
+		enableH2C           = kingpin.Flag("web.enable-h2c", "Also serve HTTP/2 without TLS (h2c) on the listen address.").Default("false").Bool()
+		webDisable          = kingpin.Flag("web.disable", "Do not serve HTTP at all, only push via --remote-write.url.").Default("false").Bool()
+		enableDebug         = kingpin.Flag("web.enable-debug", "Serve /collect, which runs one collection and returns the per-collector success, duration and series count as JSON, and /metrics/names, which lists all the metrics the collectors can emit.").Default("false").Bool()
 	)
 
 	kingpin.Parse()
@@ -105,27 +456,55 @@ func main() {
 	log.Infoln("Starting lustre_exporter", version.Info())
 	log.Infoln("Build context", version.BuildContext())
 
-	log.Infof("Collector status:")
 	sources.OstEnabled = *ostEnabled
-	log.Infof(" - OST State: %s", sources.OstEnabled)
 	sources.MdtEnabled = *mdtEnabled
-	log.Infof(" - MDT State: %s", sources.MdtEnabled)
 	sources.MgsEnabled = *mgsEnabled
-	log.Infof(" - MGS State: %s", sources.MgsEnabled)
 	sources.MdsEnabled = *mdsEnabled
-	log.Infof(" - MDS State: %s", sources.MdsEnabled)
 	sources.ClientEnabled = *clientEnabled
-	log.Infof(" - Client State: %s", sources.ClientEnabled)
 	sources.GenericEnabled = *genericEnabled
-	log.Infof(" - Generic State: %s", sources.GenericEnabled)
 	sources.LnetEnabled = *lnetEnabled
-	log.Infof(" - Lnet State: %s", sources.LnetEnabled)
+	sources.LdlmEnabled = *ldlmEnabled
 	sources.HealthStatusEnabled = *healthStatusEnabled
+	sources.JobStatsEnabled = *jobStats
+	sources.BrwStatsEnabled = *brwStats
+	if *preset != "" {
+		explicit := map[string]bool{}
+		for name, set := range setByUser {
+			explicit[name] = *set
+		}
+		if err := sources.ApplyPreset(*preset, explicit); err != nil {
+			log.Fatalf("Invalid --preset: %s", err)
+		}
+	}
+	if *components != "" {
+		if err := sources.ApplyComponents(*components); err != nil {
+			log.Fatalf("Invalid --collector.components: %s", err)
+		}
+	}
+
+	log.Infof("Collector status:")
+	log.Infof(" - OST State: %s", sources.OstEnabled)
+	log.Infof(" - MDT State: %s", sources.MdtEnabled)
+	log.Infof(" - MGS State: %s", sources.MgsEnabled)
+	log.Infof(" - MDS State: %s", sources.MdsEnabled)
+	log.Infof(" - Client State: %s", sources.ClientEnabled)
+	log.Infof(" - Generic State: %s", sources.GenericEnabled)
+	log.Infof(" - Lnet State: %s", sources.LnetEnabled)
+	log.Infof(" - Ldlm State: %s", sources.LdlmEnabled)
 	log.Infof(" - Health State: %s", sources.HealthStatusEnabled)
-	sources.ProcLocation = *procPath
+	log.Infof(" - Job Stats: %t", sources.JobStatsEnabled)
+	log.Infof(" - Brw Stats: %t", sources.BrwStatsEnabled)
+	sources.ProcLocation = sources.ResolveProcLocation(*procPath, *hostProcPath)
 	log.Infof(" - Proc Path: %s", sources.ProcLocation)
 	sources.SysLocation = *sysPath
 	log.Infof(" - Sys  Path: %s", sources.SysLocation)
+	sources.ProcPath = *procfsPath
+	sources.SysPath = *sysfsPath
+	cfg := sources.NewConfig()
+	log.Infof(" - Lustre Procfs Path: %s", cfg.LustreProcPath())
+	log.Infof(" - Lustre Sysfs Path: %s", cfg.LustreSysPath())
+	sources.PreferSysfs = *preferSysfs
+	log.Infof(" - Prefer Sysfs: %t", sources.PreferSysfs)
 	sources.CollectVersion = *collectVer
 	if sources.CollectVersion != "v2"{
 		sources.CollectVersion = "v1"
@@ -141,7 +520,176 @@ func main() {
 	sources.SHELF_LIFE = *shelflife
 	log.Infof(" - V2 Shelf Life : %s", sources.SHELF_LIFE)
 
-	enabledSources := []string{"procfs", "procsys", "sysfs"}
+	sources.NormalizeUnits = *normalizeUnits
+	log.Infof(" - Normalize Units: %t", sources.NormalizeUnits)
+	sources.AddUUIDLabel = *addUUIDLabel
+	log.Infof(" - Add UUID Label: %t", sources.AddUUIDLabel)
+	sources.SubsystemNamespace = *subsystemNamespace
+	log.Infof(" - Subsystem Namespace: %t", sources.SubsystemNamespace)
+
+	sources.RawOperationNames = *rawOperationNames
+	log.Infof(" - Raw Operation Names: %t", sources.RawOperationNames)
+	if lustreVersion, err := sources.LustreVersion(); err == nil {
+		sources.ApplyOperationVersion(lustreVersion)
+		log.Infof(" - Operation Renames: Lustre %s", lustreVersion)
+	} else {
+		log.Infof(" - Operation Renames: unknown Lustre version (%s), only the renames of every version apply", err)
+	}
+
+	sources.MdtExportStats = *mdtExportStats
+	log.Infof(" - MDT Export Stats: %t", sources.MdtExportStats)
+	if *exportNidAllow != "" {
+		re, err := regexp.Compile(*exportNidAllow)
+		if err != nil {
+			log.Fatalf("Invalid --collector.export-nid-allow: %s", err)
+		}
+		sources.ExportNidAllow = re
+		log.Infof(" - Export NID Allow: %s", *exportNidAllow)
+	}
+	if *exportNidDeny != "" {
+		re, err := regexp.Compile(*exportNidDeny)
+		if err != nil {
+			log.Fatalf("Invalid --collector.export-nid-deny: %s", err)
+		}
+		sources.ExportNidDeny = re
+		log.Infof(" - Export NID Deny: %s", *exportNidDeny)
+	}
+	if err := sources.ApplyNidAggregate(*nidAggregate); err != nil {
+		log.Fatalf("Invalid --collector.nid-aggregate: %s", err)
+	}
+	log.Infof(" - NID Aggregate: %q", *nidAggregate)
+
+	sources.LatencyStats = *latencyStats
+	log.Infof(" - Latency Stats: %t", sources.LatencyStats)
+	sources.EmitAverageRates = *emitAverageRates
+	log.Infof(" - Emit Average Rates: %t", sources.EmitAverageRates)
+	sources.ThroughputRates = *throughputRates
+	log.Infof(" - Throughput Rates: %t", sources.ThroughputRates)
+	sources.HealthStateAge = *healthStateAge
+	log.Infof(" - Health State Age: %t", sources.HealthStateAge)
+
+	sources.SkipInactiveTargets = *skipInactive
+	log.Infof(" - Skip Inactive Targets: %t", sources.SkipInactiveTargets)
+
+	sources.SanitizeLabels = *sanitizeLabels
+	log.Infof(" - Sanitize Labels: %t", sources.SanitizeLabels)
+
+	sources.MaxFileBytes = *maxFileBytes
+	log.Infof(" - Max File Bytes: %d", sources.MaxFileBytes)
+	if *negativeLookups {
+		sources.NegativeCacheTTL = *negativeCacheTTL
+	}
+	log.Infof(" - Negative Cache TTL: %s", sources.NegativeCacheTTL)
+
+	if err := sources.ApplyTargetGlobs(*targetGlobs); err != nil {
+		log.Fatalf("Invalid --collector.target-glob: %s", err)
+	}
+	log.Infof(" - Target Globs: %v", sources.TargetGlobs)
+
+	if err := sources.ApplyPathAllow(*pathAllow); err != nil {
+		log.Fatalf("Invalid --collector.path-allow: %s", err)
+	}
+	log.Infof(" - Path Allow: %v", sources.PathAllow)
+
+	if err := sources.ApplyExtraParams(*extraParams); err != nil {
+		log.Fatalf("Invalid --collector.extra-params: %s", err)
+	}
+	log.Infof(" - Extra Params: %d", len(sources.ExtraParams))
+
+	sources.IOTimeHistogram = *ioTimeHistogram
+	log.Infof(" - IO Time Histogram: %t", sources.IOTimeHistogram)
+
+	sources.RecoveryEnabled = *recovery
+	log.Infof(" - Recovery: %t", sources.RecoveryEnabled)
+
+	sources.QuotaEnabled = *quota
+	sources.QuotaMaxIDs = *quotaMaxIDs
+	sources.QuotaEnforcedOnly = *quotaEnforcedOnly
+	log.Infof(" - Quota: %t (max IDs: %d, enforced only: %t)", sources.QuotaEnabled, sources.QuotaMaxIDs, sources.QuotaEnforcedOnly)
+
+	sources.HsmEnabled = *hsm
+	log.Infof(" - HSM: %t", sources.HsmEnabled)
+	sources.PtlrpcEnabled = *ptlrpc
+	log.Infof(" - Ptlrpc: %t", sources.PtlrpcEnabled)
+
+	sources.ServiceStatsEnabled = *serviceStats
+	log.Infof(" - Service Stats: %t", sources.ServiceStatsEnabled)
+
+	sources.LastScrapeError = *lastScrapeError
+	log.Infof(" - Last Scrape Error: %t", sources.LastScrapeError)
+	sources.Strict = *strict
+	log.Infof(" - Strict: %t", sources.Strict)
+	sources.EmitZeroOnMissing = *emitZeroOnMissing
+	log.Infof(" - Emit Zero On Missing: %t", sources.EmitZeroOnMissing)
+	sources.DropZeroJobStats = *dropZeroJobStats
+	log.Infof(" - Drop Zero Jobstats: %t", sources.DropZeroJobStats)
+	if *jobstatsInclude != "" {
+		re, err := regexp.Compile(*jobstatsInclude)
+		if err != nil {
+			log.Fatalf("Invalid --collector.jobstats.include: %s", err)
+		}
+		sources.JobstatsInclude = re
+		log.Infof(" - Jobstats Include: %s", *jobstatsInclude)
+	}
+	if *jobstatsExclude != "" {
+		re, err := regexp.Compile(*jobstatsExclude)
+		if err != nil {
+			log.Fatalf("Invalid --collector.jobstats.exclude: %s", err)
+		}
+		sources.JobstatsExclude = re
+		log.Infof(" - Jobstats Exclude: %s", *jobstatsExclude)
+	}
+	sources.JobstatsMaxJobs = *jobstatsMaxJobs
+	log.Infof(" - Jobstats Max Jobs: %d", sources.JobstatsMaxJobs)
+
+	sources.GrantExhaustionThreshold = *grantThreshold
+	log.Infof(" - Grant Exhaustion Threshold: %g", sources.GrantExhaustionThreshold)
+
+	sources.FrozenThreshold = *frozenThreshold
+	log.Infof(" - Frozen Threshold: %d", sources.FrozenThreshold)
+
+	sources.PingStallThreshold = *pingStallThreshold
+	log.Infof(" - Ping Stall Threshold: %d", sources.PingStallThreshold)
+
+	sources.ScrapeInterval = *scrapeInterval
+	log.Infof(" - Scrape Interval: %s", sources.ScrapeInterval)
+	prometheus.MustRegister(scrapeIntervalGauge(sources.ScrapeInterval))
+
+	role := *serverRole
+	if role == "" {
+		role = sources.InferServerRole(sources.NewConfig())
+		if role == "" {
+			log.Infof(" - Server Role: unknown, no Lustre device found")
+		} else {
+			log.Infof(" - Server Role: %s (inferred from the Lustre devices present)", role)
+		}
+	} else {
+		log.Infof(" - Server Role: %s", role)
+	}
+	if role != "" {
+		prometheus.MustRegister(serverRoleGauge(role))
+	}
+
+	access := sources.NewAccessCollector(sources.NewConfig())
+	for class, ok := range access.Check() {
+		if !ok {
+			log.Warnf("Can't read the %s paths (running as uid %d), their metrics will be missing", class, os.Geteuid())
+		}
+	}
+	prometheus.MustRegister(access)
+
+	if *auditOutput != "" {
+		if err := writeAudit(*auditOutput, *auditFormat); err != nil {
+			log.Fatalf("Couldn't write the audit: %s", err)
+		}
+		log.Infof("Wrote the audit of the tunables to %s", *auditOutput)
+		return
+	}
+
+	enabledSources, err := sources.SelectSources(*sourceNames)
+	if err != nil {
+		log.Fatalf("Invalid --collector.sources: %s", err)
+	}
 
 	sourceList, err := loadSources(enabledSources)
 	if err != nil {
@@ -153,14 +701,73 @@ func main() {
 		log.Infof(" - %s", s)
 	}
 
-	prometheus.MustRegister(LustreSource{sourceList: sourceList})
-	handler := promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{ErrorLog: log.NewErrorLogger(), ErrorHandling: promhttp.ContinueOnError})
+	var registerer prometheus.Registerer = prometheus.DefaultRegisterer
+	if *addVersionLabel {
+		lustreVersion, err := sources.LustreVersion()
+		if err != nil {
+			log.Fatalf("Couldn't detect the lustre version for --collector.add-version-label: %s", err)
+		}
+		log.Infof("Adding lustre_version=%q to all metrics", lustreVersion)
+		registerer = withVersionLabel(registerer, lustreVersion)
+	}
+	if *selfCheckEnabled {
+		if err := selfCheck(LustreSource{sourceList: sourceList}); err != nil {
+			log.Fatalf("Self check failed: %s", err)
+		}
+		log.Infof("Self check passed")
+	}
+	registerer.MustRegister(LustreSource{sourceList: sourceList})
+	registerer.MustRegister(sources.NewReaderStatsCollector())
+
+	if *remoteWriteURL != "" {
+		client, err := remotewrite.NewClient(remotewrite.Config{
+			URL:          *remoteWriteURL,
+			Username:     *remoteWriteUser,
+			PasswordFile: *remoteWritePassFile,
+			Timeout:      *remoteWriteTimeout,
+		})
+		if err != nil {
+			log.Fatalf("Couldn't create remote_write client: %s", err)
+		}
+		if *remoteWriteInterval <= 0 {
+			log.Fatalf("Invalid remote_write interval: %s", *remoteWriteInterval)
+		}
+		log.Infof("Pushing to %s every %s", *remoteWriteURL, *remoteWriteInterval)
+		if *webDisable {
+			client.Run(context.Background(), prometheus.DefaultGatherer, *remoteWriteInterval)
+			return
+		}
+		go client.Run(context.Background(), prometheus.DefaultGatherer, *remoteWriteInterval)
+	} else if *webDisable {
+		log.Fatalf("--web.disable requires --remote-write.url")
+	}
+
+	exposedSamples := exposedSamplesGauge()
+	prometheus.MustRegister(exposedSamples)
+	handler := promhttp.HandlerFor(samplesGatherer{prometheus.DefaultGatherer, exposedSamples}, metricsHandlerOpts(sources.Strict))
+	exposedBytes := exposedBytesGauge()
+	prometheus.MustRegister(exposedBytes)
+	handler = withExposedBytes(handler, exposedBytes)
+
+	if *maxRequests > 0 {
+		handler = limitRequests(handler, *maxRequests)
+	}
+	log.Infof("Max concurrent requests: %d", *maxRequests)
 
 	http.Handle(*metricsPath, promhttp.InstrumentMetricHandler(prometheus.DefaultRegisterer, handler))
 	http.HandleFunc("/-/exit", func(w http.ResponseWriter, r *http.Request){
 		log.Infof("Exit(1) on remote call")
 		os.Exit(1)
 	})
+	if *summaryPath != "" {
+		http.Handle(*summaryPath, summaryHandler(sources.NewSummaryCollector(sources.NewConfig())))
+		log.Infof("Serving the summary metrics on %s", *summaryPath)
+	}
+	if *enableDebug {
+		http.Handle("/collect", collectHandler(sourceList))
+		http.Handle("/metrics/names", metricNamesHandler(sourceList))
+	}
+	log.Infof("Debug endpoints enabled: %t", *enableDebug)
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		var num int
 		num, err = w.Write([]byte(`<html>
@@ -175,8 +782,14 @@ func main() {
 		}
 	})
 
+	var root http.Handler = http.DefaultServeMux
+	if *enableH2C {
+		root = withH2C(root)
+	}
+	log.Infof("H2C enabled: %t", *enableH2C)
+
 	log.Infoln("Listening on", *listenAddress)
-	err = http.ListenAndServe(*listenAddress, nil)
+	err = http.ListenAndServe(*listenAddress, root)
 	if err != nil {
 		log.Fatal(err)
 	}
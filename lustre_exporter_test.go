@@ -15,18 +15,25 @@ package main
 
 import (
 	"bytes"
+	"crypto/tls"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"golang.org/x/net/http2"
 	"github.com/prometheus/common/expfmt"
 
 	"lustre_exporter/log"
@@ -98,6 +105,7 @@ func toggleCollectors(target string) {
 		sources.ClientEnabled = "disabled"
 		sources.GenericEnabled = "disabled"
 		sources.LnetEnabled = "disabled"
+		sources.LdlmEnabled = "disabled"
 		sources.HealthStatusEnabled = "disabled"
 	case "MDT":
 		sources.OstEnabled = "disabled"
@@ -107,6 +115,7 @@ func toggleCollectors(target string) {
 		sources.ClientEnabled = "disabled"
 		sources.GenericEnabled = "disabled"
 		sources.LnetEnabled = "disabled"
+		sources.LdlmEnabled = "disabled"
 		sources.HealthStatusEnabled = "disabled"
 	case "MGS":
 		sources.OstEnabled = "disabled"
@@ -116,6 +125,7 @@ func toggleCollectors(target string) {
 		sources.ClientEnabled = "disabled"
 		sources.GenericEnabled = "disabled"
 		sources.LnetEnabled = "disabled"
+		sources.LdlmEnabled = "disabled"
 		sources.HealthStatusEnabled = "disabled"
 	case "MDS":
 		sources.OstEnabled = "disabled"
@@ -125,6 +135,7 @@ func toggleCollectors(target string) {
 		sources.ClientEnabled = "disabled"
 		sources.GenericEnabled = "disabled"
 		sources.LnetEnabled = "disabled"
+		sources.LdlmEnabled = "disabled"
 		sources.HealthStatusEnabled = "disabled"
 	case "Client":
 		sources.OstEnabled = "disabled"
@@ -134,6 +145,7 @@ func toggleCollectors(target string) {
 		sources.ClientEnabled = "extended"
 		sources.GenericEnabled = "disabled"
 		sources.LnetEnabled = "disabled"
+		sources.LdlmEnabled = "disabled"
 		sources.HealthStatusEnabled = "disabled"
 	case "Generic":
 		sources.OstEnabled = "disabled"
@@ -143,6 +155,7 @@ func toggleCollectors(target string) {
 		sources.ClientEnabled = "disabled"
 		sources.GenericEnabled = "extended"
 		sources.LnetEnabled = "disabled"
+		sources.LdlmEnabled = "disabled"
 		sources.HealthStatusEnabled = "disabled"
 	case "LNET":
 		sources.OstEnabled = "disabled"
@@ -152,6 +165,7 @@ func toggleCollectors(target string) {
 		sources.ClientEnabled = "disabled"
 		sources.GenericEnabled = "disabled"
 		sources.LnetEnabled = "extended"
+		sources.LdlmEnabled = "disabled"
 		sources.HealthStatusEnabled = "disabled"
 	case "Health":
 		sources.OstEnabled = "disabled"
@@ -161,6 +175,7 @@ func toggleCollectors(target string) {
 		sources.ClientEnabled = "disabled"
 		sources.GenericEnabled = "disabled"
 		sources.LnetEnabled = "disabled"
+		sources.LdlmEnabled = "disabled"
 		sources.HealthStatusEnabled = "extended"
 	}
 }
@@ -260,6 +275,15 @@ var	expectedMetrics = []promType{
 		{"lustre_stats_total", "Number of operations the filesystem has performed.", counter, []labelPair{{"component", "ost"}, {"operation", "connect"}, {"target", "lustrefs-OST0002"}}, 1, false},
 		{"lustre_stats_total", "Number of operations the filesystem has performed.", counter, []labelPair{{"component", "ost"}, {"operation", "connect"}, {"target", "lustrefs-OST0004"}}, 1, false},
 		{"lustre_stats_total", "Number of operations the filesystem has performed.", counter, []labelPair{{"component", "ost"}, {"operation", "connect"}, {"target", "lustrefs-OST0006"}}, 1, false},
+		{"lustre_stats_total", "Number of operations the filesystem has performed.", counter, []labelPair{{"component", "ost"}, {"operation", "reconnect"}, {"target", "lustrefs-OST0000"}}, 1, false},
+		{"lustre_stats_total", "Number of operations the filesystem has performed.", counter, []labelPair{{"component", "ost"}, {"operation", "reconnect"}, {"target", "lustrefs-OST0002"}}, 1, false},
+		{"lustre_stats_total", "Number of operations the filesystem has performed.", counter, []labelPair{{"component", "ost"}, {"operation", "reconnect"}, {"target", "lustrefs-OST0004"}}, 1, false},
+		{"lustre_stats_total", "Number of operations the filesystem has performed.", counter, []labelPair{{"component", "ost"}, {"operation", "reconnect"}, {"target", "lustrefs-OST0006"}}, 1, false},
+		{"lustre_stats_total", "Number of operations the filesystem has performed.", counter, []labelPair{{"component", "ost"}, {"operation", "disconnect"}, {"target", "lustrefs-OST0000"}}, 1, false},
+		{"lustre_connection_churn_total", "Total number of connect, reconnect and disconnect requests handled by the target, from its stats. A high rate with a stable number of exports means flapping clients", counter, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0000"}}, 3, false},
+		{"lustre_connection_churn_total", "Total number of connect, reconnect and disconnect requests handled by the target, from its stats. A high rate with a stable number of exports means flapping clients", counter, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0002"}}, 2, false},
+		{"lustre_connection_churn_total", "Total number of connect, reconnect and disconnect requests handled by the target, from its stats. A high rate with a stable number of exports means flapping clients", counter, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0004"}}, 2, false},
+		{"lustre_connection_churn_total", "Total number of connect, reconnect and disconnect requests handled by the target, from its stats. A high rate with a stable number of exports means flapping clients", counter, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0006"}}, 2, false},
 		{"lustre_stats_total", "Number of operations the filesystem has performed.", counter, []labelPair{{"component", "ost"}, {"operation", "create"}, {"target", "lustrefs-OST0000"}}, 2, false},
 		{"lustre_stats_total", "Number of operations the filesystem has performed.", counter, []labelPair{{"component", "ost"}, {"operation", "create"}, {"target", "lustrefs-OST0002"}}, 2, false},
 		{"lustre_stats_total", "Number of operations the filesystem has performed.", counter, []labelPair{{"component", "ost"}, {"operation", "create"}, {"target", "lustrefs-OST0004"}}, 2, false},
@@ -314,17 +338,26 @@ var	expectedMetrics = []promType{
 		{"lustre_job_write_samples_total", "Total number of writes that have been recorded.", counter, []labelPair{{"component", "ost"}, {"jobid", "55"}, {"target", "lustrefs-OST0000"}}, 7609, false},
 		{"lustre_job_write_samples_total", "Total number of writes that have been recorded.", counter, []labelPair{{"component", "ost"}, {"jobid", "56"}, {"target", "lustrefs-OST0000"}}, 7656, false},
 		{"lustre_job_write_samples_total", "Total number of writes that have been recorded.", counter, []labelPair{{"component", "ost"}, {"jobid", "57"}, {"target", "lustrefs-OST0000"}}, 7722, false},
-		{"lustre_inodes_maximum", "The maximum number of inodes (objects) the filesystem can hold", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0000"}}, 4.5927284e+07, false},
+		{"lustre_inodes_maximum", "The maximum number of inodes (objects) the filesystem can hold", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0000"}}, 4.5927444e+07, false},
 		{"lustre_inodes_maximum", "The maximum number of inodes (objects) the filesystem can hold", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0002"}}, 1.474012704e+09, false},
 		{"lustre_inodes_maximum", "The maximum number of inodes (objects) the filesystem can hold", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0004"}}, 9.82675104e+08, false},
 		{"lustre_inodes_maximum", "The maximum number of inodes (objects) the filesystem can hold", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0006"}}, 9.82675104e+08, false},
 		{"lustre_recovery_time_hard_seconds", "Maximum timeout 'recover_time_soft' can increment to for a single server", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0002"}}, 900, false},
 		{"lustre_recovery_time_hard_seconds", "Maximum timeout 'recover_time_soft' can increment to for a single server", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0004"}}, 900, false},
 		{"lustre_recovery_time_hard_seconds", "Maximum timeout 'recover_time_soft' can increment to for a single server", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0006"}}, 900, false},
+		{"lustre_recovery_status", "Recovery state of the target from the status field of recovery_status: 0 inactive, 1 waiting, 2 recovering, 3 complete", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0000"}}, 3, false},
+		{"lustre_recovery_status", "Recovery state of the target from the status field of recovery_status: 0 inactive, 1 waiting, 2 recovering, 3 complete", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0002"}}, 3, false},
+		{"lustre_recovery_status", "Recovery state of the target from the status field of recovery_status: 0 inactive, 1 waiting, 2 recovering, 3 complete", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0004"}}, 3, false},
+		{"lustre_recovery_status", "Recovery state of the target from the status field of recovery_status: 0 inactive, 1 waiting, 2 recovering, 3 complete", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0006"}}, 3, false},
+		{"lustre_recovery_completed_clients", "Number of clients which completed the recovery of the target, only reported when recovery_status has the field", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0000"}}, 1, false},
+		{"lustre_recovery_completed_clients", "Number of clients which completed the recovery of the target, only reported when recovery_status has the field", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0002"}}, 1, false},
+		{"lustre_recovery_completed_clients", "Number of clients which completed the recovery of the target, only reported when recovery_status has the field", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0004"}}, 1, false},
+		{"lustre_recovery_completed_clients", "Number of clients which completed the recovery of the target, only reported when recovery_status has the field", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0006"}}, 1, false},
 		{"lustre_exports_total", "Total number of times the pool has been exported", counter, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0002"}}, 3, false},
 		{"lustre_exports_total", "Total number of times the pool has been exported", counter, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0004"}}, 3, false},
 		{"lustre_exports_total", "Total number of times the pool has been exported", counter, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0006"}}, 3, false},
 		{"lustre_write_samples_total", "Total number of writes that have been recorded.", counter, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0000"}}, 4.298711e+06, false},
+		{"lustre_read_samples_total", "Total number of reads that have been recorded.", counter, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0000"}}, 1.193211e+06, false},
 		{"lustre_job_read_maximum_size_bytes", "The maximum read size in bytes.", gauge, []labelPair{{"component", "ost"}, {"jobid", "23"}, {"target", "lustrefs-OST0000"}}, 0, false},
 		{"lustre_job_read_maximum_size_bytes", "The maximum read size in bytes.", gauge, []labelPair{{"component", "ost"}, {"jobid", "24"}, {"target", "lustrefs-OST0000"}}, 0, false},
 		{"lustre_job_read_maximum_size_bytes", "The maximum read size in bytes.", gauge, []labelPair{{"component", "ost"}, {"jobid", "25"}, {"target", "lustrefs-OST0000"}}, 0, false},
@@ -361,8 +394,13 @@ var	expectedMetrics = []promType{
 		{"lustre_job_read_maximum_size_bytes", "The maximum read size in bytes.", gauge, []labelPair{{"component", "ost"}, {"jobid", "56"}, {"target", "lustrefs-OST0000"}}, 0, false},
 		{"lustre_job_read_maximum_size_bytes", "The maximum read size in bytes.", gauge, []labelPair{{"component", "ost"}, {"jobid", "57"}, {"target", "lustrefs-OST0000"}}, 0, false},
 		{"lustre_write_bytes_total", "The total number of bytes that have been written.", counter, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0000"}}, 1.6552048697344e+13, false},
+		{"lustre_oss_write_bytes_total", "The total number of bytes that have been written to all OSTs of this OSS.", counter, nil, 1.6552048697344e+13, false},
+		{"lustre_read_bytes_total", "The total number of bytes that have been read.", counter, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0000"}}, 4.826395774976e+12, false},
+		{"lustre_oss_read_bytes_total", "The total number of bytes that have been read from all OSTs of this OSS.", counter, nil, 4.826395774976e+12, false},
+		{"lustre_jobstats_resets_total", "Total number of jobids whose job_stats counters went backwards between two scrapes, usually a job purged by job_cleanup_interval and started again", counter, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0000"}}, 0, false},
 		{"lustre_write_maximum_size_bytes", "The maximum write size in bytes.", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0000"}}, 4.194304e+06, false},
-		{"lustre_available_kilobytes", "Number of kilobytes readily available in the pool", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0000"}}, 4.7029274624e+10, false},
+		{"lustre_read_maximum_size_bytes", "The maximum read size in bytes.", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0000"}}, 4.194304e+06, false},
+		{"lustre_available_kilobytes", "Number of kilobytes readily available in the pool", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0000"}}, 4.7025124352e+10, false},
 		{"lustre_available_kilobytes", "Number of kilobytes readily available in the pool", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0002"}}, 4.7168396288e+10, false},
 		{"lustre_available_kilobytes", "Number of kilobytes readily available in the pool", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0004"}}, 3.1445593088e+10, false},
 		{"lustre_available_kilobytes", "Number of kilobytes readily available in the pool", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0006"}}, 3.1445593088e+10, false},
@@ -646,7 +684,7 @@ var	expectedMetrics = []promType{
 		{"lustre_brw_size_megabytes", "Block read/write size in megabytes", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0002"}}, 4, false},
 		{"lustre_brw_size_megabytes", "Block read/write size in megabytes", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0004"}}, 4, false},
 		{"lustre_brw_size_megabytes", "Block read/write size in megabytes", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0006"}}, 4, false},
-		{"lustre_inodes_free", "The number of inodes (objects) available", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0000"}}, 4.5927028e+07, false},
+		{"lustre_inodes_free", "The number of inodes (objects) available", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0000"}}, 4.5927188e+07, false},
 		{"lustre_inodes_free", "The number of inodes (objects) available", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0002"}}, 1.474012448e+09, false},
 		{"lustre_inodes_free", "The number of inodes (objects) available", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0004"}}, 9.82674848e+08, false},
 		{"lustre_inodes_free", "The number of inodes (objects) available", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0006"}}, 9.82674848e+08, false},
@@ -685,6 +723,86 @@ var	expectedMetrics = []promType{
 		{"lustre_job_read_samples_total", "Total number of reads that have been recorded.", counter, []labelPair{{"component", "ost"}, {"jobid", "55"}, {"target", "lustrefs-OST0000"}}, 0, false},
 		{"lustre_job_read_samples_total", "Total number of reads that have been recorded.", counter, []labelPair{{"component", "ost"}, {"jobid", "56"}, {"target", "lustrefs-OST0000"}}, 0, false},
 		{"lustre_job_read_samples_total", "Total number of reads that have been recorded.", counter, []labelPair{{"component", "ost"}, {"jobid", "57"}, {"target", "lustrefs-OST0000"}}, 0, false},
+		{"lustre_ost_cache_policy_info", "Cache policy of the OST, always 1: the read_cache and writethrough labels tell whether the read cache (read_cache_enable) and the writethrough cache (writethrough_cache_enable) are enabled", gauge, []labelPair{{"component", "ost"}, {"read_cache", "enabled"}, {"target", "lustrefs-OST0000"}, {"writethrough", "disabled"}}, 1, false},
+		{"lustre_ost_cache_policy_info", "Cache policy of the OST, always 1: the read_cache and writethrough labels tell whether the read cache (read_cache_enable) and the writethrough cache (writethrough_cache_enable) are enabled", gauge, []labelPair{{"component", "ost"}, {"read_cache", "enabled"}, {"target", "lustrefs-OST0002"}, {"writethrough", "enabled"}}, 1, false},
+		{"lustre_ost_cache_policy_info", "Cache policy of the OST, always 1: the read_cache and writethrough labels tell whether the read cache (read_cache_enable) and the writethrough cache (writethrough_cache_enable) are enabled", gauge, []labelPair{{"component", "ost"}, {"read_cache", "enabled"}, {"target", "lustrefs-OST0004"}, {"writethrough", "enabled"}}, 1, false},
+		{"lustre_ost_cache_policy_info", "Cache policy of the OST, always 1: the read_cache and writethrough labels tell whether the read cache (read_cache_enable) and the writethrough cache (writethrough_cache_enable) are enabled", gauge, []labelPair{{"component", "ost"}, {"read_cache", "enabled"}, {"target", "lustrefs-OST0006"}, {"writethrough", "enabled"}}, 1, false},
+		{"lustre_readcache_max_filesize_bytes", "Maximum size in bytes of the files the OST keeps in its read cache, larger files are read without caching (readcache_max_filesize)", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0000"}}, 33554432, false},
+		{"lustre_readcache_max_filesize_bytes", "Maximum size in bytes of the files the OST keeps in its read cache, larger files are read without caching (readcache_max_filesize)", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0002"}}, 33554432, false},
+		{"lustre_readcache_max_filesize_bytes", "Maximum size in bytes of the files the OST keeps in its read cache, larger files are read without caching (readcache_max_filesize)", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0004"}}, 33554432, false},
+		{"lustre_readcache_max_filesize_bytes", "Maximum size in bytes of the files the OST keeps in its read cache, larger files are read without caching (readcache_max_filesize)", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0006"}}, 33554432, false},
+		{"lustre_jobstats_file_bytes", "Size in bytes of the job_stats file of the target, a steadily growing size means parsing it will take longer and longer", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0000"}}, 29271, false},
+		{"lustre_jobstats_file_bytes", "Size in bytes of the job_stats file of the target, a steadily growing size means parsing it will take longer and longer", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0002"}}, 11, false},
+		{"lustre_jobstats_file_bytes", "Size in bytes of the job_stats file of the target, a steadily growing size means parsing it will take longer and longer", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0004"}}, 11, false},
+		{"lustre_jobstats_file_bytes", "Size in bytes of the job_stats file of the target, a steadily growing size means parsing it will take longer and longer", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0006"}}, 11, false},
+		{"lustre_jobstats_file_bytes", "Size in bytes of the job_stats file of the target, a steadily growing size means parsing it will take longer and longer", gauge, []labelPair{{"component", "mdt"}, {"target", "lustrefs-MDT0000"}}, 14471, false},
+		{"lustre_module_refcount", "Number of references to the Lustre related kernel module, a nonzero value blocks unloading it (/proc/modules)", gauge, []labelPair{{"module", "osp"}}, 1, false},
+		{"lustre_module_refcount", "Number of references to the Lustre related kernel module, a nonzero value blocks unloading it (/proc/modules)", gauge, []labelPair{{"module", "mdd"}}, 1, false},
+		{"lustre_module_refcount", "Number of references to the Lustre related kernel module, a nonzero value blocks unloading it (/proc/modules)", gauge, []labelPair{{"module", "lod"}}, 2, false},
+		{"lustre_module_refcount", "Number of references to the Lustre related kernel module, a nonzero value blocks unloading it (/proc/modules)", gauge, []labelPair{{"module", "mdt"}}, 6, false},
+		{"lustre_module_refcount", "Number of references to the Lustre related kernel module, a nonzero value blocks unloading it (/proc/modules)", gauge, []labelPair{{"module", "lfsck"}}, 3, false},
+		{"lustre_module_refcount", "Number of references to the Lustre related kernel module, a nonzero value blocks unloading it (/proc/modules)", gauge, []labelPair{{"module", "mgs"}}, 1, false},
+		{"lustre_module_refcount", "Number of references to the Lustre related kernel module, a nonzero value blocks unloading it (/proc/modules)", gauge, []labelPair{{"module", "mgc"}}, 1, false},
+		{"lustre_module_refcount", "Number of references to the Lustre related kernel module, a nonzero value blocks unloading it (/proc/modules)", gauge, []labelPair{{"module", "osd_zfs"}}, 7, false},
+		{"lustre_module_refcount", "Number of references to the Lustre related kernel module, a nonzero value blocks unloading it (/proc/modules)", gauge, []labelPair{{"module", "lquota"}}, 6, false},
+		{"lustre_module_refcount", "Number of references to the Lustre related kernel module, a nonzero value blocks unloading it (/proc/modules)", gauge, []labelPair{{"module", "lustre"}}, 2, false},
+		{"lustre_module_refcount", "Number of references to the Lustre related kernel module, a nonzero value blocks unloading it (/proc/modules)", gauge, []labelPair{{"module", "lmv"}}, 1, false},
+		{"lustre_module_refcount", "Number of references to the Lustre related kernel module, a nonzero value blocks unloading it (/proc/modules)", gauge, []labelPair{{"module", "mdc"}}, 1, false},
+		{"lustre_module_refcount", "Number of references to the Lustre related kernel module, a nonzero value blocks unloading it (/proc/modules)", gauge, []labelPair{{"module", "lov"}}, 1, false},
+		{"lustre_module_refcount", "Number of references to the Lustre related kernel module, a nonzero value blocks unloading it (/proc/modules)", gauge, []labelPair{{"module", "osc"}}, 8, false},
+		{"lustre_module_refcount", "Number of references to the Lustre related kernel module, a nonzero value blocks unloading it (/proc/modules)", gauge, []labelPair{{"module", "fid"}}, 4, false},
+		{"lustre_module_refcount", "Number of references to the Lustre related kernel module, a nonzero value blocks unloading it (/proc/modules)", gauge, []labelPair{{"module", "fld"}}, 6, false},
+		{"lustre_module_refcount", "Number of references to the Lustre related kernel module, a nonzero value blocks unloading it (/proc/modules)", gauge, []labelPair{{"module", "ptlrpc"}}, 21, false},
+		{"lustre_module_refcount", "Number of references to the Lustre related kernel module, a nonzero value blocks unloading it (/proc/modules)", gauge, []labelPair{{"module", "obdclass"}}, 40, false},
+		{"lustre_module_refcount", "Number of references to the Lustre related kernel module, a nonzero value blocks unloading it (/proc/modules)", gauge, []labelPair{{"module", "ksocklnd"}}, 1, false},
+		{"lustre_module_refcount", "Number of references to the Lustre related kernel module, a nonzero value blocks unloading it (/proc/modules)", gauge, []labelPair{{"module", "lnet"}}, 8, false},
+		{"lustre_module_refcount", "Number of references to the Lustre related kernel module, a nonzero value blocks unloading it (/proc/modules)", gauge, []labelPair{{"module", "libcfs"}}, 18, false},
+		{"lustre_module_loaded", "Whether the Lustre related kernel module is loaded, always 1 (/proc/modules)", gauge, []labelPair{{"module", "osp"}}, 1, false},
+		{"lustre_module_loaded", "Whether the Lustre related kernel module is loaded, always 1 (/proc/modules)", gauge, []labelPair{{"module", "mdd"}}, 1, false},
+		{"lustre_module_loaded", "Whether the Lustre related kernel module is loaded, always 1 (/proc/modules)", gauge, []labelPair{{"module", "lod"}}, 1, false},
+		{"lustre_module_loaded", "Whether the Lustre related kernel module is loaded, always 1 (/proc/modules)", gauge, []labelPair{{"module", "mdt"}}, 1, false},
+		{"lustre_module_loaded", "Whether the Lustre related kernel module is loaded, always 1 (/proc/modules)", gauge, []labelPair{{"module", "lfsck"}}, 1, false},
+		{"lustre_module_loaded", "Whether the Lustre related kernel module is loaded, always 1 (/proc/modules)", gauge, []labelPair{{"module", "mgs"}}, 1, false},
+		{"lustre_module_loaded", "Whether the Lustre related kernel module is loaded, always 1 (/proc/modules)", gauge, []labelPair{{"module", "mgc"}}, 1, false},
+		{"lustre_module_loaded", "Whether the Lustre related kernel module is loaded, always 1 (/proc/modules)", gauge, []labelPair{{"module", "osd_zfs"}}, 1, false},
+		{"lustre_module_loaded", "Whether the Lustre related kernel module is loaded, always 1 (/proc/modules)", gauge, []labelPair{{"module", "lquota"}}, 1, false},
+		{"lustre_module_loaded", "Whether the Lustre related kernel module is loaded, always 1 (/proc/modules)", gauge, []labelPair{{"module", "lustre"}}, 1, false},
+		{"lustre_module_loaded", "Whether the Lustre related kernel module is loaded, always 1 (/proc/modules)", gauge, []labelPair{{"module", "lmv"}}, 1, false},
+		{"lustre_module_loaded", "Whether the Lustre related kernel module is loaded, always 1 (/proc/modules)", gauge, []labelPair{{"module", "mdc"}}, 1, false},
+		{"lustre_module_loaded", "Whether the Lustre related kernel module is loaded, always 1 (/proc/modules)", gauge, []labelPair{{"module", "lov"}}, 1, false},
+		{"lustre_module_loaded", "Whether the Lustre related kernel module is loaded, always 1 (/proc/modules)", gauge, []labelPair{{"module", "osc"}}, 1, false},
+		{"lustre_module_loaded", "Whether the Lustre related kernel module is loaded, always 1 (/proc/modules)", gauge, []labelPair{{"module", "fid"}}, 1, false},
+		{"lustre_module_loaded", "Whether the Lustre related kernel module is loaded, always 1 (/proc/modules)", gauge, []labelPair{{"module", "fld"}}, 1, false},
+		{"lustre_module_loaded", "Whether the Lustre related kernel module is loaded, always 1 (/proc/modules)", gauge, []labelPair{{"module", "ptlrpc"}}, 1, false},
+		{"lustre_module_loaded", "Whether the Lustre related kernel module is loaded, always 1 (/proc/modules)", gauge, []labelPair{{"module", "obdclass"}}, 1, false},
+		{"lustre_module_loaded", "Whether the Lustre related kernel module is loaded, always 1 (/proc/modules)", gauge, []labelPair{{"module", "ksocklnd"}}, 1, false},
+		{"lustre_module_loaded", "Whether the Lustre related kernel module is loaded, always 1 (/proc/modules)", gauge, []labelPair{{"module", "lnet"}}, 1, false},
+		{"lustre_module_loaded", "Whether the Lustre related kernel module is loaded, always 1 (/proc/modules)", gauge, []labelPair{{"module", "libcfs"}}, 1, false},
+		{"lustre_client_lov_stripe_count", "Default stripe count of the files created by the client, as seen by its lov device (stripecount)", gauge, []labelPair{{"component", "client"}, {"target", "lustrefs-clilov-ffff88105db50000"}}, 1, false},
+		{"lustre_client_lmv_mdt_count", "Number of MDTs the lmv device of the client spreads the metadata over (numobd)", gauge, []labelPair{{"component", "client"}, {"target", "lustrefs-clilmv-ffff88105db50000"}}, 1, false},
+		{"lustre_exports_active", "Number of clients currently connected to the target, from its 'exports' directory (exports_total is the cumulative count)", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0000"}}, 3, false},
+		{"lustre_qmt_global_used_kilobytes", "Space in kilobytes granted by the quota master to the ID over the whole filesystem, the global quota usage (granted)", gauge, []labelPair{{"fs", "lustrefs"}, {"id", "1000"}, {"type", "usr"}}, 2097152, false},
+		{"lustre_qmt_global_used_kilobytes", "Space in kilobytes granted by the quota master to the ID over the whole filesystem, the global quota usage (granted)", gauge, []labelPair{{"fs", "lustrefs"}, {"id", "1001"}, {"type", "usr"}}, 524288, false},
+		{"lustre_qmt_global_used_kilobytes", "Space in kilobytes granted by the quota master to the ID over the whole filesystem, the global quota usage (granted)", gauge, []labelPair{{"fs", "lustrefs"}, {"id", "100"}, {"type", "grp"}}, 2621440, false},
+		{"lustre_qmt_global_limit_kilobytes", "Global hard block quota limit of the ID in kilobytes, 0 means no limit (hard)", gauge, []labelPair{{"fs", "lustrefs"}, {"id", "1000"}, {"type", "usr"}}, 10485760, false},
+		{"lustre_qmt_global_limit_kilobytes", "Global hard block quota limit of the ID in kilobytes, 0 means no limit (hard)", gauge, []labelPair{{"fs", "lustrefs"}, {"id", "1001"}, {"type", "usr"}}, 0, false},
+		{"lustre_qmt_global_limit_kilobytes", "Global hard block quota limit of the ID in kilobytes, 0 means no limit (hard)", gauge, []labelPair{{"fs", "lustrefs"}, {"id", "100"}, {"type", "grp"}}, 52428800, false},
+		{"lustre_qmt_global_used_inodes", "Inodes granted by the quota master to the ID over the whole filesystem, the global quota usage (granted)", gauge, []labelPair{{"fs", "lustrefs"}, {"id", "1000"}, {"type", "usr"}}, 2048, false},
+		{"lustre_qmt_global_used_inodes", "Inodes granted by the quota master to the ID over the whole filesystem, the global quota usage (granted)", gauge, []labelPair{{"fs", "lustrefs"}, {"id", "1001"}, {"type", "usr"}}, 512, false},
+		{"lustre_qmt_global_limit_inodes", "Global hard inode quota limit of the ID, 0 means no limit (hard)", gauge, []labelPair{{"fs", "lustrefs"}, {"id", "1000"}, {"type", "usr"}}, 100000, false},
+		{"lustre_qmt_global_limit_inodes", "Global hard inode quota limit of the ID, 0 means no limit (hard)", gauge, []labelPair{{"fs", "lustrefs"}, {"id", "1001"}, {"type", "usr"}}, 0, false},
+		{"lustre_exports_active", "Number of clients currently connected to the target, from its 'exports' directory (exports_total is the cumulative count)", gauge, []labelPair{{"component", "mdt"}, {"target", "lustrefs-MDT0000"}}, 2, false},
+		{"lustre_recovery_status", "Recovery state of the target from the status field of recovery_status: 0 inactive, 1 waiting, 2 recovering, 3 complete", gauge, []labelPair{{"component", "mdt"}, {"target", "lustrefs-MDT0000"}}, 2, false},
+		{"lustre_recovery_connected_clients", "Number of clients which reconnected to the target during its current or last recovery, only reported when recovery_status has the field", gauge, []labelPair{{"component", "mdt"}, {"target", "lustrefs-MDT0000"}}, 1, false},
+		{"lustre_recovery_completed_clients", "Number of clients which completed the recovery of the target, only reported when recovery_status has the field", gauge, []labelPair{{"component", "mdt"}, {"target", "lustrefs-MDT0000"}}, 0, false},
+		{"lustre_recovery_time_remaining_seconds", "Number of seconds left before the recovery window of the target closes, only reported while it is recovering", gauge, []labelPair{{"component", "mdt"}, {"target", "lustrefs-MDT0000"}}, 120, false},
+		{"lustre_recovery_evicted_clients", "Number of clients evicted by the current or last recovery of the target (evicted_clients), only reported when recovery_status has the field. The evictions outside of a recovery are not counted", gauge, []labelPair{{"component", "mdt"}, {"target", "lustrefs-MDT0000"}}, 1, false},
+		{"lustre_mdt_rename_samples_total", "Number of renames of the MDT by size of the directories involved (type same_dir, crossdir_src or crossdir_tgt), expensive cross-directory rename patterns", counter, []labelPair{{"component", "mdt"}, {"size", "4KB"}, {"target", "lustrefs-MDT0000"}, {"type", "same_dir"}}, 4, false},
+		{"lustre_mdt_rename_samples_total", "Number of renames of the MDT by size of the directories involved (type same_dir, crossdir_src or crossdir_tgt), expensive cross-directory rename patterns", counter, []labelPair{{"component", "mdt"}, {"size", "8KB"}, {"target", "lustrefs-MDT0000"}, {"type", "same_dir"}}, 6, false},
+		{"lustre_mdt_rename_samples_total", "Number of renames of the MDT by size of the directories involved (type same_dir, crossdir_src or crossdir_tgt), expensive cross-directory rename patterns", counter, []labelPair{{"component", "mdt"}, {"size", "4KB"}, {"target", "lustrefs-MDT0000"}, {"type", "crossdir_src"}}, 2, false},
+		{"lustre_mdt_rename_samples_total", "Number of renames of the MDT by size of the directories involved (type same_dir, crossdir_src or crossdir_tgt), expensive cross-directory rename patterns", counter, []labelPair{{"component", "mdt"}, {"size", "16KB"}, {"target", "lustrefs-MDT0000"}, {"type", "crossdir_src"}}, 2, false},
+		{"lustre_mdt_rename_samples_total", "Number of renames of the MDT by size of the directories involved (type same_dir, crossdir_src or crossdir_tgt), expensive cross-directory rename patterns", counter, []labelPair{{"component", "mdt"}, {"size", "4KB"}, {"target", "lustrefs-MDT0000"}, {"type", "crossdir_tgt"}}, 3, false},
+		{"lustre_mdt_rename_samples_total", "Number of renames of the MDT by size of the directories involved (type same_dir, crossdir_src or crossdir_tgt), expensive cross-directory rename patterns", counter, []labelPair{{"component", "mdt"}, {"size", "8KB"}, {"target", "lustrefs-MDT0000"}, {"type", "crossdir_tgt"}}, 1, false},
 		{"lustre_job_stats_total", "Number of operations the filesystem has performed.", counter, []labelPair{{"component", "ost"}, {"jobid", "23"}, {"operation", "create"}, {"target", "lustrefs-OST0000"}}, 0, false},
 		{"lustre_job_stats_total", "Number of operations the filesystem has performed.", counter, []labelPair{{"component", "ost"}, {"jobid", "23"}, {"operation", "destroy"}, {"target", "lustrefs-OST0000"}}, 0, false},
 		{"lustre_job_stats_total", "Number of operations the filesystem has performed.", counter, []labelPair{{"component", "ost"}, {"jobid", "23"}, {"operation", "get_info"}, {"target", "lustrefs-OST0000"}}, 0, false},
@@ -1090,7 +1208,7 @@ var	expectedMetrics = []promType{
 		{"lustre_exports_pending_total", "Total number of exports that have been marked pending", counter, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0002"}}, 0, false},
 		{"lustre_exports_pending_total", "Total number of exports that have been marked pending", counter, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0004"}}, 0, false},
 		{"lustre_exports_pending_total", "Total number of exports that have been marked pending", counter, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0006"}}, 0, false},
-		{"lustre_free_kilobytes", "Number of kilobytes allocated to the pool", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0000"}}, 4.7029276672e+10, false},
+		{"lustre_free_kilobytes", "Number of kilobytes allocated to the pool", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0000"}}, 4.7029440512e+10, false},
 		{"lustre_free_kilobytes", "Number of kilobytes allocated to the pool", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0002"}}, 4.7168398336e+10, false},
 		{"lustre_free_kilobytes", "Number of kilobytes allocated to the pool", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0004"}}, 3.1445595136e+10, false},
 		{"lustre_free_kilobytes", "Number of kilobytes allocated to the pool", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0006"}}, 3.1445595136e+10, false},
@@ -1209,9 +1327,18 @@ var	expectedMetrics = []promType{
 		{"lustre_discontiguous_pages_total", "Total number of logical discontinuities per RPC.", counter, []labelPair{{"component", "ost"}, {"operation", "read"}, {"size", "7"}, {"target", "lustrefs-OST0000"}}, 0, false},
 		{"lustre_discontiguous_pages_total", "Total number of logical discontinuities per RPC.", counter, []labelPair{{"component", "ost"}, {"operation", "read"}, {"size", "8"}, {"target", "lustrefs-OST0000"}}, 0, false},
 		{"lustre_discontiguous_pages_total", "Total number of logical discontinuities per RPC.", counter, []labelPair{{"component", "ost"}, {"operation", "read"}, {"size", "9"}, {"target", "lustrefs-OST0000"}}, 0, false},
+		{"lustre_discontiguous_blocks_total", "Total number of on-disk discontinuities per RPC, the disk level fragmentation of the target.", counter, []labelPair{{"component", "ost"}, {"operation", "read"}, {"size", "0"}, {"target", "lustrefs-OST0000"}}, 20, false},
+		{"lustre_discontiguous_blocks_total", "Total number of on-disk discontinuities per RPC, the disk level fragmentation of the target.", counter, []labelPair{{"component", "ost"}, {"operation", "read"}, {"size", "1"}, {"target", "lustrefs-OST0000"}}, 3, false},
+		{"lustre_discontiguous_blocks_total", "Total number of on-disk discontinuities per RPC, the disk level fragmentation of the target.", counter, []labelPair{{"component", "ost"}, {"operation", "read"}, {"size", "2"}, {"target", "lustrefs-OST0000"}}, 0, false},
+		{"lustre_discontiguous_blocks_total", "Total number of on-disk discontinuities per RPC, the disk level fragmentation of the target.", counter, []labelPair{{"component", "ost"}, {"operation", "read"}, {"size", "3"}, {"target", "lustrefs-OST0000"}}, 0, false},
+		{"lustre_discontiguous_blocks_total", "Total number of on-disk discontinuities per RPC, the disk level fragmentation of the target.", counter, []labelPair{{"component", "ost"}, {"operation", "write"}, {"size", "0"}, {"target", "lustrefs-OST0000"}}, 3810, false},
+		{"lustre_discontiguous_blocks_total", "Total number of on-disk discontinuities per RPC, the disk level fragmentation of the target.", counter, []labelPair{{"component", "ost"}, {"operation", "write"}, {"size", "1"}, {"target", "lustrefs-OST0000"}}, 412, false},
+		{"lustre_discontiguous_blocks_total", "Total number of on-disk discontinuities per RPC, the disk level fragmentation of the target.", counter, []labelPair{{"component", "ost"}, {"operation", "write"}, {"size", "2"}, {"target", "lustrefs-OST0000"}}, 97, false},
+		{"lustre_discontiguous_blocks_total", "Total number of on-disk discontinuities per RPC, the disk level fragmentation of the target.", counter, []labelPair{{"component", "ost"}, {"operation", "write"}, {"size", "3"}, {"target", "lustrefs-OST0000"}}, 21, false},
 		{"lustre_degraded", "Binary indicator as to whether or not the pool is degraded - 0 for not degraded, 1 for degraded", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0000"}}, 0, false},
 		{"lustre_exports_total", "Total number of times the pool has been exported", counter, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0000"}}, 3, false},
 		{"lustre_write_minimum_size_bytes", "The minimum write size in bytes.", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0000"}}, 4096, false},
+		{"lustre_read_minimum_size_bytes", "The minimum read size in bytes.", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0000"}}, 4096, false},
 		{"lustre_grant_compat_disabled", "Binary indicator as to whether clients with OBD_CONNECT_GRANT_PARAM setting will be granted space", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0000"}}, 0, false},
 		{"lustre_recovery_time_hard_seconds", "Maximum timeout 'recover_time_soft' can increment to for a single server", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0000"}}, 900, false},
 		{"lustre_lfsck_speed_limit", "Maximum operations per second LFSCK (Lustre filesystem verification) can run", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0000"}}, 0, false},
@@ -1234,6 +1361,38 @@ var	expectedMetrics = []promType{
 		{"lustre_precreate_batch", "Maximum number of objects that can be included in a single transaction", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0000"}}, 128, false},
 		{"lustre_sync_journal_enabled", "Binary indicator as to whether or not the journal is set for asynchronous commits", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0000"}}, 0, false},
 		{"lustre_blocksize_bytes", "Filesystem block size in bytes", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0000"}}, 1.048576e+06, false},
+		{"lustre_lock_timeout_total", "Number of lock timeouts", counter, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0000"}}, 7, false},
+		{"lustre_lock_timeout_total", "Number of lock timeouts", counter, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0002"}}, 0, false},
+		{"lustre_lock_timeout_total", "Number of lock timeouts", counter, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0004"}}, 0, false},
+		{"lustre_lock_timeout_total", "Number of lock timeouts", counter, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0006"}}, 0, false},
+		{"lustre_lock_contended_total", "Number of contended locks", counter, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0000"}}, 32, false},
+		{"lustre_lock_contended_total", "Number of contended locks", counter, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0002"}}, 32, false},
+		{"lustre_lock_contended_total", "Number of contended locks", counter, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0004"}}, 32, false},
+		{"lustre_lock_contended_total", "Number of contended locks", counter, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0006"}}, 32, false},
+		{"lustre_lock_grant_plan", "Number of planned lock grants per second", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0000"}}, 32207, false},
+		{"lustre_lock_grant_plan", "Number of planned lock grants per second", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0002"}}, 128827, false},
+		{"lustre_lock_grant_plan", "Number of planned lock grants per second", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0004"}}, 32207, false},
+		{"lustre_lock_grant_plan", "Number of planned lock grants per second", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0006"}}, 128827, false},
+		{"lustre_lock_contention_seconds_total", "Time in seconds during which locks were contended", counter, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0000"}}, 2, false},
+		{"lustre_lock_contention_seconds_total", "Time in seconds during which locks were contended", counter, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0002"}}, 2, false},
+		{"lustre_lock_contention_seconds_total", "Time in seconds during which locks were contended", counter, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0004"}}, 2, false},
+		{"lustre_lock_contention_seconds_total", "Time in seconds during which locks were contended", counter, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0006"}}, 2, false},
+		{"lustre_lock_grant_rate", "Lock grant rate", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0000"}}, 0, false},
+		{"lustre_lock_grant_rate", "Lock grant rate", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0002"}}, 31, false},
+		{"lustre_lock_grant_rate", "Lock grant rate", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0004"}}, 31, false},
+		{"lustre_lock_grant_rate", "Lock grant rate", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0006"}}, 31, false},
+		{"lustre_lock_count_total", "Number of locks", counter, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0000"}}, 1, false},
+		{"lustre_lock_count_total", "Number of locks", counter, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0002"}}, 0, false},
+		{"lustre_lock_count_total", "Number of locks", counter, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0004"}}, 0, false},
+		{"lustre_lock_count_total", "Number of locks", counter, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0006"}}, 0, false},
+		{"lustre_lock_cancel_rate", "Lock cancel rate", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0000"}}, 0, false},
+		{"lustre_lock_cancel_rate", "Lock cancel rate", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0002"}}, 32, false},
+		{"lustre_lock_cancel_rate", "Lock cancel rate", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0004"}}, 32, false},
+		{"lustre_lock_cancel_rate", "Lock cancel rate", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0006"}}, 32, false},
+		{"lustre_locks_granted", "Number of granted less cancelled locks", untyped, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0000"}}, 1, false},
+		{"lustre_locks_granted", "Number of granted less cancelled locks", untyped, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0002"}}, 0, false},
+		{"lustre_locks_granted", "Number of granted less cancelled locks", untyped, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0004"}}, 0, false},
+		{"lustre_locks_granted", "Number of granted less cancelled locks", untyped, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0006"}}, 0, false},
 
 		// MDT Metrics
 		{"lustre_job_stats_total", "Number of operations the filesystem has performed.", counter, []labelPair{{"component", "mdt"}, {"jobid", "43"}, {"operation", "close"}, {"target", "lustrefs-MDT0000"}}, 0, false},
@@ -1482,6 +1641,12 @@ var	expectedMetrics = []promType{
 		{"lustre_stats_total", "Number of operations the filesystem has performed.", counter, []labelPair{{"component", "mdt"}, {"operation", "setattr"}, {"target", "lustrefs-MDT0000"}}, 57, false},
 		{"lustre_stats_total", "Number of operations the filesystem has performed.", counter, []labelPair{{"component", "mdt"}, {"operation", "statfs"}, {"target", "lustrefs-MDT0000"}}, 1, false},
 		{"lustre_stats_total", "Number of operations the filesystem has performed.", counter, []labelPair{{"component", "mdt"}, {"operation", "getxattr"}, {"target", "lustrefs-MDT0000"}}, 2, false},
+		{"lustre_mdt_reint_total", "Number of modifying (reintegration) requests of the given type the MDT has handled.", counter, []labelPair{{"component", "mdt"}, {"operation", "setattr"}, {"target", "lustrefs-MDT0000"}}, 57, false},
+		{"lustre_mdt_reint_total", "Number of modifying (reintegration) requests of the given type the MDT has handled.", counter, []labelPair{{"component", "mdt"}, {"operation", "create"}, {"target", "lustrefs-MDT0000"}}, 3, false},
+		{"lustre_mdt_reint_total", "Number of modifying (reintegration) requests of the given type the MDT has handled.", counter, []labelPair{{"component", "mdt"}, {"operation", "open"}, {"target", "lustrefs-MDT0000"}}, 10, false},
+		{"lustre_operation_errors_total", "Number of operations of the given type which failed, only reported when the stats file has an error counter for it.", counter, []labelPair{{"component", "mdt"}, {"operation", "setattr"}, {"target", "lustrefs-MDT0000"}}, 4, false},
+		{"lustre_operation_errors_total", "Number of operations of the given type which failed, only reported when the stats file has an error counter for it.", counter, []labelPair{{"component", "ost"}, {"operation", "create"}, {"target", "lustrefs-OST0000"}}, 1, false},
+		{"lustre_mdt_reint_total", "Number of modifying (reintegration) requests of the given type the MDT has handled.", counter, []labelPair{{"component", "mdt"}, {"operation", "unlink"}, {"target", "lustrefs-MDT0000"}}, 2, false},
 		{"lustre_exports_total", "Total number of times the pool has been exported", counter, []labelPair{{"component", "mdt"}, {"target", "lustrefs-MDT0000"}}, 10, false},
 		{"lustre_blocksize_bytes", "Filesystem block size in bytes", gauge, []labelPair{{"component", "mdt"}, {"target", "lustrefs-MDT0000"}}, 131072, false},
 		{"lustre_capacity_kilobytes", "Capacity of the pool in kilobytes", gauge, []labelPair{{"component", "mdt"}, {"target", "lustrefs-MDT0000"}}, 2.24150656e+09, false},
@@ -1489,6 +1654,17 @@ var	expectedMetrics = []promType{
 		{"lustre_available_kilobytes", "Number of kilobytes readily available in the pool", gauge, []labelPair{{"component", "mdt"}, {"target", "lustrefs-MDT0000"}}, 2.241498368e+09, false},
 		{"lustre_inodes_free", "The number of inodes (objects) available", gauge, []labelPair{{"component", "mdt"}, {"target", "lustrefs-MDT0000"}}, 4.30405292e+08, false},
 		{"lustre_free_kilobytes", "Number of kilobytes allocated to the pool", gauge, []labelPair{{"component", "mdt"}, {"target", "lustrefs-MDT0000"}}, 2.241500416e+09, false},
+		{"lustre_default_stripe_count", "Default number of OSTs a new file is striped over, -1 means all OSTs.", gauge, []labelPair{{"fs", "lustrefs"}}, 1, false},
+		{"lustre_default_stripe_size_bytes", "Default stripe size of new files in bytes.", gauge, []labelPair{{"fs", "lustrefs"}}, 1.048576e+06, false},
+		{"lustre_ost_pool_member", "Returns 1 for every OST which is a member of the OST pool, as listed by the MDT 'lod' devices.", gauge, []labelPair{{"fs", "lustrefs"}, {"pool", "archive"}, {"target", "lustrefs-OST0002"}}, 1, false},
+		{"lustre_ost_pool_member", "Returns 1 for every OST which is a member of the OST pool, as listed by the MDT 'lod' devices.", gauge, []labelPair{{"fs", "lustrefs"}, {"pool", "archive"}, {"target", "lustrefs-OST0003"}}, 1, false},
+		{"lustre_ost_pool_member", "Returns 1 for every OST which is a member of the OST pool, as listed by the MDT 'lod' devices.", gauge, []labelPair{{"fs", "lustrefs"}, {"pool", "archive"}, {"target", "lustrefs-OST0004"}}, 1, false},
+		{"lustre_ost_pool_member", "Returns 1 for every OST which is a member of the OST pool, as listed by the MDT 'lod' devices.", gauge, []labelPair{{"fs", "lustrefs"}, {"pool", "archive"}, {"target", "lustrefs-OST0005"}}, 1, false},
+		{"lustre_ost_pool_member", "Returns 1 for every OST which is a member of the OST pool, as listed by the MDT 'lod' devices.", gauge, []labelPair{{"fs", "lustrefs"}, {"pool", "archive"}, {"target", "lustrefs-OST0006"}}, 1, false},
+		{"lustre_ost_pool_member", "Returns 1 for every OST which is a member of the OST pool, as listed by the MDT 'lod' devices.", gauge, []labelPair{{"fs", "lustrefs"}, {"pool", "flash"}, {"target", "lustrefs-OST0000"}}, 1, false},
+		{"lustre_ost_pool_member", "Returns 1 for every OST which is a member of the OST pool, as listed by the MDT 'lod' devices.", gauge, []labelPair{{"fs", "lustrefs"}, {"pool", "flash"}, {"target", "lustrefs-OST0001"}}, 1, false},
+		{"lustre_ost_pool_member", "Returns 1 for every OST which is a member of the OST pool, as listed by the MDT 'lod' devices.", gauge, []labelPair{{"fs", "lustrefs"}, {"pool", "flash"}, {"target", "lustrefs-OST0002"}}, 1, false},
+		{"lustre_jobstats_resets_total", "Total number of jobids whose job_stats counters went backwards between two scrapes, usually a job purged by job_cleanup_interval and started again", counter, []labelPair{{"component", "mdt"}, {"target", "lustrefs-MDT0000"}}, 0, false},
 
 		// MGS Metrics
 		{"lustre_available_kilobytes", "Number of kilobytes readily available in the pool", gauge, []labelPair{{"target", "osd"}, {"component", "mgs"}}, 1.12074688e+09, false},
@@ -1498,6 +1674,57 @@ var	expectedMetrics = []promType{
 		{"lustre_inodes_maximum", "The maximum number of inodes (objects) the filesystem can hold", gauge, []labelPair{{"component", "mgs"}, {"target", "osd"}}, 2.31004127e+08, false},
 		{"lustre_free_kilobytes", "Number of kilobytes allocated to the pool", gauge, []labelPair{{"component", "mgs"}, {"target", "osd"}}, 1.120748928e+09, false},
 
+		// MDS Metrics
+		{"lustre_osp_prealloc_gap", "Number of objects precreated on the OST and not used yet by the MDS (prealloc_last_id - prealloc_next_id + 1), 0 means creates will stall", gauge, []labelPair{{"component", "mds"}, {"target", "lustrefs-OST0000-osc-MDT0000"}}, 31, false},
+		{"lustre_osp_prealloc_gap", "Number of objects precreated on the OST and not used yet by the MDS (prealloc_last_id - prealloc_next_id + 1), 0 means creates will stall", gauge, []labelPair{{"component", "mds"}, {"target", "lustrefs-OST0001-osc-MDT0000"}}, 32, false},
+		{"lustre_osp_prealloc_gap", "Number of objects precreated on the OST and not used yet by the MDS (prealloc_last_id - prealloc_next_id + 1), 0 means creates will stall", gauge, []labelPair{{"component", "mds"}, {"target", "lustrefs-OST0002-osc-MDT0000"}}, 32, false},
+		{"lustre_osp_prealloc_gap", "Number of objects precreated on the OST and not used yet by the MDS (prealloc_last_id - prealloc_next_id + 1), 0 means creates will stall", gauge, []labelPair{{"component", "mds"}, {"target", "lustrefs-OST0003-osc-MDT0000"}}, 32, false},
+		{"lustre_osp_prealloc_gap", "Number of objects precreated on the OST and not used yet by the MDS (prealloc_last_id - prealloc_next_id + 1), 0 means creates will stall", gauge, []labelPair{{"component", "mds"}, {"target", "lustrefs-OST0004-osc-MDT0000"}}, 32, false},
+		{"lustre_osp_prealloc_gap", "Number of objects precreated on the OST and not used yet by the MDS (prealloc_last_id - prealloc_next_id + 1), 0 means creates will stall", gauge, []labelPair{{"component", "mds"}, {"target", "lustrefs-OST0005-osc-MDT0000"}}, 32, false},
+		{"lustre_osp_prealloc_gap", "Number of objects precreated on the OST and not used yet by the MDS (prealloc_last_id - prealloc_next_id + 1), 0 means creates will stall", gauge, []labelPair{{"component", "mds"}, {"target", "lustrefs-OST0006-osc-MDT0000"}}, 32, false},
+		{"lustre_lod_qos_prio_free", "Weight in percent given to the free space of the OSTs over their load by the QoS object allocator of the MDT", gauge, []labelPair{{"component", "mds"}, {"target", "lustrefs-MDT0000-mdtlov"}}, 91, false},
+		{"lustre_lod_qos_threshold_rr", "Free space imbalance in percent between the OSTs above which the MDT switches from round-robin to QoS weighted object allocation", gauge, []labelPair{{"component", "mds"}, {"target", "lustrefs-MDT0000-mdtlov"}}, 17, false},
+		{"lustre_osp_prealloc_reserved", "Number of precreated objects reserved for creates in progress", gauge, []labelPair{{"component", "mds"}, {"target", "lustrefs-OST0000-osc-MDT0000"}}, 0, false},
+		{"lustre_osp_prealloc_reserved", "Number of precreated objects reserved for creates in progress", gauge, []labelPair{{"component", "mds"}, {"target", "lustrefs-OST0001-osc-MDT0000"}}, 0, false},
+		{"lustre_osp_prealloc_reserved", "Number of precreated objects reserved for creates in progress", gauge, []labelPair{{"component", "mds"}, {"target", "lustrefs-OST0002-osc-MDT0000"}}, 0, false},
+		{"lustre_osp_prealloc_reserved", "Number of precreated objects reserved for creates in progress", gauge, []labelPair{{"component", "mds"}, {"target", "lustrefs-OST0003-osc-MDT0000"}}, 0, false},
+		{"lustre_osp_prealloc_reserved", "Number of precreated objects reserved for creates in progress", gauge, []labelPair{{"component", "mds"}, {"target", "lustrefs-OST0004-osc-MDT0000"}}, 0, false},
+		{"lustre_osp_prealloc_reserved", "Number of precreated objects reserved for creates in progress", gauge, []labelPair{{"component", "mds"}, {"target", "lustrefs-OST0005-osc-MDT0000"}}, 0, false},
+		{"lustre_osp_prealloc_reserved", "Number of precreated objects reserved for creates in progress", gauge, []labelPair{{"component", "mds"}, {"target", "lustrefs-OST0006-osc-MDT0000"}}, 0, false},
+		{"lustre_osp_sync_in_flight", "Number of OST object destroy/setattr RPCs currently in flight", gauge, []labelPair{{"component", "mds"}, {"target", "lustrefs-OST0000-osc-MDT0000"}}, 0, false},
+		{"lustre_osp_sync_in_flight", "Number of OST object destroy/setattr RPCs currently in flight", gauge, []labelPair{{"component", "mds"}, {"target", "lustrefs-OST0001-osc-MDT0000"}}, 0, false},
+		{"lustre_osp_sync_in_flight", "Number of OST object destroy/setattr RPCs currently in flight", gauge, []labelPair{{"component", "mds"}, {"target", "lustrefs-OST0002-osc-MDT0000"}}, 0, false},
+		{"lustre_osp_sync_in_flight", "Number of OST object destroy/setattr RPCs currently in flight", gauge, []labelPair{{"component", "mds"}, {"target", "lustrefs-OST0003-osc-MDT0000"}}, 0, false},
+		{"lustre_osp_sync_in_flight", "Number of OST object destroy/setattr RPCs currently in flight", gauge, []labelPair{{"component", "mds"}, {"target", "lustrefs-OST0004-osc-MDT0000"}}, 0, false},
+		{"lustre_osp_sync_in_flight", "Number of OST object destroy/setattr RPCs currently in flight", gauge, []labelPair{{"component", "mds"}, {"target", "lustrefs-OST0005-osc-MDT0000"}}, 0, false},
+		{"lustre_osp_sync_in_flight", "Number of OST object destroy/setattr RPCs currently in flight", gauge, []labelPair{{"component", "mds"}, {"target", "lustrefs-OST0006-osc-MDT0000"}}, 0, false},
+		{"lustre_osp_sync_in_progress", "Number of OST object destroy/setattr changes currently being processed", gauge, []labelPair{{"component", "mds"}, {"target", "lustrefs-OST0000-osc-MDT0000"}}, 0, false},
+		{"lustre_osp_sync_in_progress", "Number of OST object destroy/setattr changes currently being processed", gauge, []labelPair{{"component", "mds"}, {"target", "lustrefs-OST0001-osc-MDT0000"}}, 0, false},
+		{"lustre_osp_sync_in_progress", "Number of OST object destroy/setattr changes currently being processed", gauge, []labelPair{{"component", "mds"}, {"target", "lustrefs-OST0002-osc-MDT0000"}}, 0, false},
+		{"lustre_osp_sync_in_progress", "Number of OST object destroy/setattr changes currently being processed", gauge, []labelPair{{"component", "mds"}, {"target", "lustrefs-OST0003-osc-MDT0000"}}, 0, false},
+		{"lustre_osp_sync_in_progress", "Number of OST object destroy/setattr changes currently being processed", gauge, []labelPair{{"component", "mds"}, {"target", "lustrefs-OST0004-osc-MDT0000"}}, 0, false},
+		{"lustre_osp_sync_in_progress", "Number of OST object destroy/setattr changes currently being processed", gauge, []labelPair{{"component", "mds"}, {"target", "lustrefs-OST0005-osc-MDT0000"}}, 0, false},
+		{"lustre_osp_sync_in_progress", "Number of OST object destroy/setattr changes currently being processed", gauge, []labelPair{{"component", "mds"}, {"target", "lustrefs-OST0006-osc-MDT0000"}}, 0, false},
+		{"lustre_osp_sync_changes", "Number of OST object destroy/setattr changes waiting to be synced", gauge, []labelPair{{"component", "mds"}, {"target", "lustrefs-OST0000-osc-MDT0000"}}, 0, false},
+		{"lustre_osp_sync_changes", "Number of OST object destroy/setattr changes waiting to be synced", gauge, []labelPair{{"component", "mds"}, {"target", "lustrefs-OST0001-osc-MDT0000"}}, 0, false},
+		{"lustre_osp_sync_changes", "Number of OST object destroy/setattr changes waiting to be synced", gauge, []labelPair{{"component", "mds"}, {"target", "lustrefs-OST0002-osc-MDT0000"}}, 0, false},
+		{"lustre_osp_sync_changes", "Number of OST object destroy/setattr changes waiting to be synced", gauge, []labelPair{{"component", "mds"}, {"target", "lustrefs-OST0003-osc-MDT0000"}}, 0, false},
+		{"lustre_osp_sync_changes", "Number of OST object destroy/setattr changes waiting to be synced", gauge, []labelPair{{"component", "mds"}, {"target", "lustrefs-OST0004-osc-MDT0000"}}, 0, false},
+		{"lustre_osp_sync_changes", "Number of OST object destroy/setattr changes waiting to be synced", gauge, []labelPair{{"component", "mds"}, {"target", "lustrefs-OST0005-osc-MDT0000"}}, 0, false},
+		{"lustre_osp_sync_changes", "Number of OST object destroy/setattr changes waiting to be synced", gauge, []labelPair{{"component", "mds"}, {"target", "lustrefs-OST0006-osc-MDT0000"}}, 0, false},
+		{"lustre_seq_allocated", "First FID sequence of the space not handed out yet by the sequence controller/server, the space ends at 0xffffffffffffffff on the controller", gauge, []labelPair{{"component", "mds"}, {"target", "ctl-lustrefs-MDT0000"}}, 9.66367744e+09, false},
+		{"lustre_seq_width", "Number of sequences (controller) or FIDs per sequence (server) handed out at a time", gauge, []labelPair{{"component", "mds"}, {"target", "ctl-lustrefs-MDT0000"}}, 1.073741824e+09, false},
+		{"lustre_seq_allocated", "First FID sequence of the space not handed out yet by the sequence controller/server, the space ends at 0xffffffffffffffff on the controller", gauge, []labelPair{{"component", "mds"}, {"target", "srv-lustrefs-MDT0000"}}, 8.589939616e+09, false},
+		{"lustre_seq_width", "Number of sequences (controller) or FIDs per sequence (server) handed out at a time", gauge, []labelPair{{"component", "mds"}, {"target", "srv-lustrefs-MDT0000"}}, 1, false},
+		{"lustre_seq_allocated", "First FID sequence of the space not handed out yet by the sequence controller/server, the space ends at 0xffffffffffffffff on the controller", gauge, []labelPair{{"component", "mds"}, {"target", "srv-lustrefs-OST0000"}}, 0, false},
+		{"lustre_seq_width", "Number of sequences (controller) or FIDs per sequence (server) handed out at a time", gauge, []labelPair{{"component", "mds"}, {"target", "srv-lustrefs-OST0000"}}, 1, false},
+		{"lustre_seq_allocated", "First FID sequence of the space not handed out yet by the sequence controller/server, the space ends at 0xffffffffffffffff on the controller", gauge, []labelPair{{"component", "mds"}, {"target", "srv-lustrefs-OST0002"}}, 0, false},
+		{"lustre_seq_width", "Number of sequences (controller) or FIDs per sequence (server) handed out at a time", gauge, []labelPair{{"component", "mds"}, {"target", "srv-lustrefs-OST0002"}}, 1, false},
+		{"lustre_seq_allocated", "First FID sequence of the space not handed out yet by the sequence controller/server, the space ends at 0xffffffffffffffff on the controller", gauge, []labelPair{{"component", "mds"}, {"target", "srv-lustrefs-OST0004"}}, 0, false},
+		{"lustre_seq_width", "Number of sequences (controller) or FIDs per sequence (server) handed out at a time", gauge, []labelPair{{"component", "mds"}, {"target", "srv-lustrefs-OST0004"}}, 1, false},
+		{"lustre_seq_allocated", "First FID sequence of the space not handed out yet by the sequence controller/server, the space ends at 0xffffffffffffffff on the controller", gauge, []labelPair{{"component", "mds"}, {"target", "srv-lustrefs-OST0006"}}, 0, false},
+		{"lustre_seq_width", "Number of sequences (controller) or FIDs per sequence (server) handed out at a time", gauge, []labelPair{{"component", "mds"}, {"target", "srv-lustrefs-OST0006"}}, 1, false},
+
 		// Client Metrics
 		{"lustre_pages_per_rpc_total", "Total number of pages per RPC.", counter, []labelPair{{"component", "client"}, {"operation", "read"}, {"size", "1"}, {"target", "lustrefs-OST0000-osc-ffff88105db50000"}}, 0, false},
 		{"lustre_pages_per_rpc_total", "Total number of pages per RPC.", counter, []labelPair{{"component", "client"}, {"operation", "read"}, {"size", "1"}, {"target", "lustrefs-OST0001-osc-ffff88105db50000"}}, 0, false},
@@ -1535,10 +1762,10 @@ var	expectedMetrics = []promType{
 		{"lustre_pages_per_rpc_total", "Total number of pages per RPC.", counter, []labelPair{{"component", "client"}, {"operation", "write"}, {"size", "8"}, {"target", "lustrefs-OST0000-osc-ffff88105db50000"}}, 5860, false},
 		{"lustre_inodes_maximum", "The maximum number of inodes (objects) the filesystem can hold", gauge, []labelPair{{"component", "client"}, {"target", "lustrefs-ffff88105db50000"}}, 4.30405497e+08, false},
 		{"lustre_xattr_cache_enabled", "Returns '1' if extended attribute cache is enabled", gauge, []labelPair{{"component", "client"}, {"target", "lustrefs-ffff88105db50000"}}, 1, false},
+		{"lustre_client_unstable_pages", "Number of pages sent to the OSTs but not yet committed to stable storage, pinned in client memory until then.", gauge, []labelPair{{"component", "client"}, {"target", "lustrefs-ffff88105db50000"}}, 2816, false},
 		{"lustre_read_bytes_total", "The total number of bytes that have been read.", counter, []labelPair{{"component", "client"}, {"target", "lustrefs-ffff88105db50000"}}, 4.194304e+06, false},
 		{"lustre_write_samples_total", "Total number of writes that have been recorded.", counter, []labelPair{{"component", "client"}, {"target", "lustrefs-ffff88105db50000"}}, 8.946781e+07, false},
 		{"lustre_available_kilobytes", "Number of kilobytes readily available in the pool", gauge, []labelPair{{"component", "client"}, {"target", "lustrefs-ffff88105db50000"}}, 2.8300029952e+11, false},
-		{"lustre_maximum_read_ahead_megabytes", "Maximum number of megabytes to read ahead", gauge, []labelPair{{"component", "client"}, {"target", "lustrefs-ffff88105db50000"}}, 64, false},
 		{"lustre_statahead_agl_enabled", "Returns '1' if the Asynchronous Glimpse Lock (AGL) for statahead is enabled", gauge, []labelPair{{"component", "client"}, {"target", "lustrefs-ffff88105db50000"}}, 1, false},
 		{"lustre_checksum_pages_enabled", "Returns '1' if data checksumming is enabled for the client", gauge, []labelPair{{"component", "client"}, {"target", "lustrefs-ffff88105db50000"}}, 1, false},
 		{"lustre_read_maximum_size_bytes", "The maximum read size in bytes.", gauge, []labelPair{{"component", "client"}, {"target", "lustrefs-ffff88105db50000"}}, 4.194304e+06, false},
@@ -1623,8 +1850,25 @@ var	expectedMetrics = []promType{
 		{"lustre_inodes_free", "The number of inodes (objects) available", gauge, []labelPair{{"component", "client"}, {"target", "lustrefs-ffff88105db50000"}}, 4.30405267e+08, false},
 		{"lustre_capacity_kilobytes", "Capacity of the pool in kilobytes", gauge, []labelPair{{"component", "client"}, {"target", "lustrefs-ffff88105db50000"}}, 2.83010362368e+11, false},
 		{"lustre_default_ea_size_bytes", "Default Extended Attribute (EA) size in bytes", gauge, []labelPair{{"component", "client"}, {"target", "lustrefs-ffff88105db50000"}}, 128, false},
-		{"lustre_maximum_read_ahead_whole_megabytes", "Maximum file size in megabytes for a file to be read in its entirety", gauge, []labelPair{{"component", "client"}, {"target", "lustrefs-ffff88105db50000"}}, 2, false},
-		{"lustre_maximum_read_ahead_per_file_megabytes", "Maximum number of megabytes per file to read ahead", gauge, []labelPair{{"component", "client"}, {"target", "lustrefs-ffff88105db50000"}}, 64, false},
+		{"lustre_client_ldlm_lru_size", "Maximum number of locks the client keeps in the LRU of the ldlm namespace, 0 means the size is set dynamically (lru_size)", gauge, []labelPair{{"component", "client"}, {"target", "lustrefs-MDT0000-mdc-ffff88105db50000"}}, 400, false},
+		{"lustre_client_ldlm_lru_size", "Maximum number of locks the client keeps in the LRU of the ldlm namespace, 0 means the size is set dynamically (lru_size)", gauge, []labelPair{{"component", "client"}, {"target", "lustrefs-OST0000-osc-ffff88105db50000"}}, 103, false},
+		{"lustre_client_ldlm_lru_size", "Maximum number of locks the client keeps in the LRU of the ldlm namespace, 0 means the size is set dynamically (lru_size)", gauge, []labelPair{{"component", "client"}, {"target", "lustrefs-OST0001-osc-ffff88105db50000"}}, 106, false},
+		{"lustre_client_ldlm_lru_size", "Maximum number of locks the client keeps in the LRU of the ldlm namespace, 0 means the size is set dynamically (lru_size)", gauge, []labelPair{{"component", "client"}, {"target", "lustrefs-OST0002-osc-ffff88105db50000"}}, 109, false},
+		{"lustre_client_ldlm_lru_size", "Maximum number of locks the client keeps in the LRU of the ldlm namespace, 0 means the size is set dynamically (lru_size)", gauge, []labelPair{{"component", "client"}, {"target", "lustrefs-OST0003-osc-ffff88105db50000"}}, 112, false},
+		{"lustre_client_ldlm_lru_size", "Maximum number of locks the client keeps in the LRU of the ldlm namespace, 0 means the size is set dynamically (lru_size)", gauge, []labelPair{{"component", "client"}, {"target", "lustrefs-OST0004-osc-ffff88105db50000"}}, 115, false},
+		{"lustre_client_ldlm_lru_size", "Maximum number of locks the client keeps in the LRU of the ldlm namespace, 0 means the size is set dynamically (lru_size)", gauge, []labelPair{{"component", "client"}, {"target", "lustrefs-OST0005-osc-ffff88105db50000"}}, 118, false},
+		{"lustre_client_ldlm_lru_size", "Maximum number of locks the client keeps in the LRU of the ldlm namespace, 0 means the size is set dynamically (lru_size)", gauge, []labelPair{{"component", "client"}, {"target", "lustrefs-OST0006-osc-ffff88105db50000"}}, 121, false},
+		{"lustre_client_ldlm_lock_count", "Number of locks the client currently holds in the ldlm namespace (lock_count)", gauge, []labelPair{{"component", "client"}, {"target", "lustrefs-MDT0000-mdc-ffff88105db50000"}}, 212, false},
+		{"lustre_client_ldlm_lock_count", "Number of locks the client currently holds in the ldlm namespace (lock_count)", gauge, []labelPair{{"component", "client"}, {"target", "lustrefs-OST0000-osc-ffff88105db50000"}}, 14, false},
+		{"lustre_client_ldlm_lock_count", "Number of locks the client currently holds in the ldlm namespace (lock_count)", gauge, []labelPair{{"component", "client"}, {"target", "lustrefs-OST0001-osc-ffff88105db50000"}}, 18, false},
+		{"lustre_client_ldlm_lock_count", "Number of locks the client currently holds in the ldlm namespace (lock_count)", gauge, []labelPair{{"component", "client"}, {"target", "lustrefs-OST0002-osc-ffff88105db50000"}}, 22, false},
+		{"lustre_client_ldlm_lock_count", "Number of locks the client currently holds in the ldlm namespace (lock_count)", gauge, []labelPair{{"component", "client"}, {"target", "lustrefs-OST0003-osc-ffff88105db50000"}}, 26, false},
+		{"lustre_client_ldlm_lock_count", "Number of locks the client currently holds in the ldlm namespace (lock_count)", gauge, []labelPair{{"component", "client"}, {"target", "lustrefs-OST0004-osc-ffff88105db50000"}}, 30, false},
+		{"lustre_client_ldlm_lock_count", "Number of locks the client currently holds in the ldlm namespace (lock_count)", gauge, []labelPair{{"component", "client"}, {"target", "lustrefs-OST0005-osc-ffff88105db50000"}}, 34, false},
+		{"lustre_client_ldlm_lock_count", "Number of locks the client currently holds in the ldlm namespace (lock_count)", gauge, []labelPair{{"component", "client"}, {"target", "lustrefs-OST0006-osc-ffff88105db50000"}}, 38, false},
+		{"lustre_client_max_read_ahead_mb", "Maximum number of megabytes the client reads ahead, across all files (max_read_ahead_mb)", gauge, []labelPair{{"component", "client"}, {"target", "lustrefs-ffff88105db50000"}}, 64, false},
+		{"lustre_client_max_read_ahead_per_file_mb", "Maximum number of megabytes the client reads ahead for a single file (max_read_ahead_per_file_mb)", gauge, []labelPair{{"component", "client"}, {"target", "lustrefs-ffff88105db50000"}}, 64, false},
+		{"lustre_client_max_read_ahead_whole_mb", "Maximum size in megabytes of a file the client reads in its entirety (max_read_ahead_whole_mb)", gauge, []labelPair{{"component", "client"}, {"target", "lustrefs-ffff88105db50000"}}, 2, false},
 		{"lustre_statahead_maximum", "Maximum window size for statahead", gauge, []labelPair{{"component", "client"}, {"target", "lustrefs-ffff88105db50000"}}, 32, false},
 		{"lustre_stats_total", "Number of operations the filesystem has performed.", counter, []labelPair{{"component", "client"}, {"operation", "alloc_inode"}, {"target", "lustrefs-ffff88105db50000"}}, 2, false},
 		{"lustre_stats_total", "Number of operations the filesystem has performed.", counter, []labelPair{{"component", "client"}, {"operation", "close"}, {"target", "lustrefs-ffff88105db50000"}}, 96, false},
@@ -1688,6 +1932,64 @@ var	expectedMetrics = []promType{
 		{"lustre_rpcs_in_flight", "Current number of RPCs that are processing during the snapshot.", gauge, []labelPair{{"component", "client"}, {"operation", "write"}, {"size", "7"}, {"target", "lustrefs-OST0000-osc-ffff88105db50000"}, {"type", "osc"}}, 832325, false},
 		{"lustre_rpcs_in_flight", "Current number of RPCs that are processing during the snapshot.", gauge, []labelPair{{"component", "client"}, {"operation", "write"}, {"size", "8"}, {"target", "lustrefs-OST0000-osc-ffff88105db50000"}, {"type", "osc"}}, 497409, false},
 		{"lustre_rpcs_in_flight", "Current number of RPCs that are processing during the snapshot.", gauge, []labelPair{{"component", "client"}, {"operation", "write"}, {"size", "9"}, {"target", "lustrefs-OST0000-osc-ffff88105db50000"}, {"type", "osc"}}, 272560, false},
+		{"lustre_max_pages_per_rpc", "Maximum number of pages the client puts in a single bulk RPC to the OST, negotiated with the OST brw_size on connect", gauge, []labelPair{{"component", "client"}, {"target", "lustrefs-OST0000-osc-ffff88105db50000"}}, 1024, false},
+		{"lustre_max_pages_per_rpc", "Maximum number of pages the client puts in a single bulk RPC to the OST, negotiated with the OST brw_size on connect", gauge, []labelPair{{"component", "client"}, {"target", "lustrefs-OST0001-osc-ffff88105db50000"}}, 1024, false},
+		{"lustre_max_pages_per_rpc", "Maximum number of pages the client puts in a single bulk RPC to the OST, negotiated with the OST brw_size on connect", gauge, []labelPair{{"component", "client"}, {"target", "lustrefs-OST0002-osc-ffff88105db50000"}}, 1024, false},
+		{"lustre_max_pages_per_rpc", "Maximum number of pages the client puts in a single bulk RPC to the OST, negotiated with the OST brw_size on connect", gauge, []labelPair{{"component", "client"}, {"target", "lustrefs-OST0003-osc-ffff88105db50000"}}, 1024, false},
+		{"lustre_max_pages_per_rpc", "Maximum number of pages the client puts in a single bulk RPC to the OST, negotiated with the OST brw_size on connect", gauge, []labelPair{{"component", "client"}, {"target", "lustrefs-OST0004-osc-ffff88105db50000"}}, 1024, false},
+		{"lustre_max_pages_per_rpc", "Maximum number of pages the client puts in a single bulk RPC to the OST, negotiated with the OST brw_size on connect", gauge, []labelPair{{"component", "client"}, {"target", "lustrefs-OST0005-osc-ffff88105db50000"}}, 1024, false},
+		{"lustre_max_pages_per_rpc", "Maximum number of pages the client puts in a single bulk RPC to the OST, negotiated with the OST brw_size on connect", gauge, []labelPair{{"component", "client"}, {"target", "lustrefs-OST0006-osc-ffff88105db50000"}}, 1024, false},
+		{"lustre_target_stats_reset_seconds", "Number of seconds since the stats of the target were started or last cleared, from the elapsed_time (or snapshot_time - start_time) header of its stats file. Only reported by the Lustre versions which have these headers", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0000"}}, 176905.789180921, false},
+		{"lustre_brw_size_consistent", "Returns 1 if every OST of the filesystem on this node uses the same brw_size, 0 if some differ", gauge, []labelPair{{"fs", "lustrefs"}}, 1, false},
+		{"lustre_grant_exhausted", "Binary indicator as to whether the space granted to the clients (tot_granted) reached --collector.grant-exhaustion-threshold of the space available on the OST (kbytesavail) - 1 when the clients are about to be throttled to synchronous writes", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0000"}}, 0, false},
+		{"lustre_grant_exhausted", "Binary indicator as to whether the space granted to the clients (tot_granted) reached --collector.grant-exhaustion-threshold of the space available on the OST (kbytesavail) - 1 when the clients are about to be throttled to synchronous writes", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0002"}}, 0, false},
+		{"lustre_grant_exhausted", "Binary indicator as to whether the space granted to the clients (tot_granted) reached --collector.grant-exhaustion-threshold of the space available on the OST (kbytesavail) - 1 when the clients are about to be throttled to synchronous writes", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0004"}}, 0, false},
+		{"lustre_grant_exhausted", "Binary indicator as to whether the space granted to the clients (tot_granted) reached --collector.grant-exhaustion-threshold of the space available on the OST (kbytesavail) - 1 when the clients are about to be throttled to synchronous writes", gauge, []labelPair{{"component", "ost"}, {"target", "lustrefs-OST0006"}}, 0, false},
+		{"lustre_ost_space_imbalance_ratio", "Spread of the free space of the OSTs of the filesystem seen by this node, (max_free - min_free) / max_free, 0 when all OSTs have the same free space and close to 1 when some are full while others are empty", gauge, []labelPair{{"fs", "lustrefs"}}, (47168398336.0 - 31445595136) / 47168398336, false},
+		{"lustre_osc_reconnects_total", "Total number of reconnections of the import to the target, a rising rate on many clients points to a server or network problem", counter, []labelPair{{"component", "client"}, {"target", "lustrefs-OST0000-osc-MDT0000"}}, 22, false},
+		{"lustre_osc_timeouts_total", "Total number of RPCs to the target which timed out", counter, []labelPair{{"component", "client"}, {"target", "lustrefs-OST0000-osc-MDT0000"}}, 21, false},
+		{"lustre_osc_reconnects_total", "Total number of reconnections of the import to the target, a rising rate on many clients points to a server or network problem", counter, []labelPair{{"component", "client"}, {"target", "lustrefs-OST0000-osc-ffff88105db50000"}}, 0, false},
+		{"lustre_osc_timeouts_total", "Total number of RPCs to the target which timed out", counter, []labelPair{{"component", "client"}, {"target", "lustrefs-OST0000-osc-ffff88105db50000"}}, 0, false},
+		{"lustre_osc_reconnects_total", "Total number of reconnections of the import to the target, a rising rate on many clients points to a server or network problem", counter, []labelPair{{"component", "client"}, {"target", "lustrefs-OST0001-osc-MDT0000"}}, 25, false},
+		{"lustre_osc_timeouts_total", "Total number of RPCs to the target which timed out", counter, []labelPair{{"component", "client"}, {"target", "lustrefs-OST0001-osc-MDT0000"}}, 21, false},
+		{"lustre_osc_reconnects_total", "Total number of reconnections of the import to the target, a rising rate on many clients points to a server or network problem", counter, []labelPair{{"component", "client"}, {"target", "lustrefs-OST0001-osc-ffff88105db50000"}}, 0, false},
+		{"lustre_osc_timeouts_total", "Total number of RPCs to the target which timed out", counter, []labelPair{{"component", "client"}, {"target", "lustrefs-OST0001-osc-ffff88105db50000"}}, 0, false},
+		{"lustre_osc_reconnects_total", "Total number of reconnections of the import to the target, a rising rate on many clients points to a server or network problem", counter, []labelPair{{"component", "client"}, {"target", "lustrefs-OST0002-osc-MDT0000"}}, 22, false},
+		{"lustre_osc_timeouts_total", "Total number of RPCs to the target which timed out", counter, []labelPair{{"component", "client"}, {"target", "lustrefs-OST0002-osc-MDT0000"}}, 21, false},
+		{"lustre_osc_reconnects_total", "Total number of reconnections of the import to the target, a rising rate on many clients points to a server or network problem", counter, []labelPair{{"component", "client"}, {"target", "lustrefs-OST0002-osc-ffff88105db50000"}}, 0, false},
+		{"lustre_osc_timeouts_total", "Total number of RPCs to the target which timed out", counter, []labelPair{{"component", "client"}, {"target", "lustrefs-OST0002-osc-ffff88105db50000"}}, 0, false},
+		{"lustre_osc_reconnects_total", "Total number of reconnections of the import to the target, a rising rate on many clients points to a server or network problem", counter, []labelPair{{"component", "client"}, {"target", "lustrefs-OST0003-osc-MDT0000"}}, 23, false},
+		{"lustre_osc_timeouts_total", "Total number of RPCs to the target which timed out", counter, []labelPair{{"component", "client"}, {"target", "lustrefs-OST0003-osc-MDT0000"}}, 21, false},
+		{"lustre_osc_reconnects_total", "Total number of reconnections of the import to the target, a rising rate on many clients points to a server or network problem", counter, []labelPair{{"component", "client"}, {"target", "lustrefs-OST0003-osc-ffff88105db50000"}}, 3, false},
+		{"lustre_osc_timeouts_total", "Total number of RPCs to the target which timed out", counter, []labelPair{{"component", "client"}, {"target", "lustrefs-OST0003-osc-ffff88105db50000"}}, 2, false},
+		{"lustre_osc_reconnects_total", "Total number of reconnections of the import to the target, a rising rate on many clients points to a server or network problem", counter, []labelPair{{"component", "client"}, {"target", "lustrefs-OST0004-osc-MDT0000"}}, 23, false},
+		{"lustre_osc_timeouts_total", "Total number of RPCs to the target which timed out", counter, []labelPair{{"component", "client"}, {"target", "lustrefs-OST0004-osc-MDT0000"}}, 22, false},
+		{"lustre_osc_reconnects_total", "Total number of reconnections of the import to the target, a rising rate on many clients points to a server or network problem", counter, []labelPair{{"component", "client"}, {"target", "lustrefs-OST0004-osc-ffff88105db50000"}}, 0, false},
+		{"lustre_osc_timeouts_total", "Total number of RPCs to the target which timed out", counter, []labelPair{{"component", "client"}, {"target", "lustrefs-OST0004-osc-ffff88105db50000"}}, 0, false},
+		{"lustre_osc_reconnects_total", "Total number of reconnections of the import to the target, a rising rate on many clients points to a server or network problem", counter, []labelPair{{"component", "client"}, {"target", "lustrefs-OST0005-osc-MDT0000"}}, 23, false},
+		{"lustre_osc_timeouts_total", "Total number of RPCs to the target which timed out", counter, []labelPair{{"component", "client"}, {"target", "lustrefs-OST0005-osc-MDT0000"}}, 21, false},
+		{"lustre_osc_reconnects_total", "Total number of reconnections of the import to the target, a rising rate on many clients points to a server or network problem", counter, []labelPair{{"component", "client"}, {"target", "lustrefs-OST0005-osc-ffff88105db50000"}}, 0, false},
+		{"lustre_osc_timeouts_total", "Total number of RPCs to the target which timed out", counter, []labelPair{{"component", "client"}, {"target", "lustrefs-OST0005-osc-ffff88105db50000"}}, 0, false},
+		{"lustre_osc_reconnects_total", "Total number of reconnections of the import to the target, a rising rate on many clients points to a server or network problem", counter, []labelPair{{"component", "client"}, {"target", "lustrefs-OST0006-osc-MDT0000"}}, 23, false},
+		{"lustre_osc_timeouts_total", "Total number of RPCs to the target which timed out", counter, []labelPair{{"component", "client"}, {"target", "lustrefs-OST0006-osc-MDT0000"}}, 22, false},
+		{"lustre_osc_reconnects_total", "Total number of reconnections of the import to the target, a rising rate on many clients points to a server or network problem", counter, []labelPair{{"component", "client"}, {"target", "lustrefs-OST0006-osc-ffff88105db50000"}}, 0, false},
+		{"lustre_osc_timeouts_total", "Total number of RPCs to the target which timed out", counter, []labelPair{{"component", "client"}, {"target", "lustrefs-OST0006-osc-ffff88105db50000"}}, 0, false},
+		{"lustre_osc_pending_pages", "Number of dirty pages of the client waiting to be written back to the OST (pending write pages of rpc_stats)", gauge, []labelPair{{"component", "client"}, {"target", "lustrefs-OST0000-osc-ffff88105db50000"}}, 1244, false},
+		{"lustre_osc_writeback_queue_depth", "Number of write RPCs of the client to the OST in flight, the writeback queue depth (write RPCs in flight of rpc_stats)", gauge, []labelPair{{"component", "client"}, {"target", "lustrefs-OST0000-osc-ffff88105db50000"}}, 6, false},
+		{"lustre_osc_pending_pages", "Number of dirty pages of the client waiting to be written back to the OST (pending write pages of rpc_stats)", gauge, []labelPair{{"component", "client"}, {"target", "lustrefs-OST0001-osc-ffff88105db50000"}}, 0, false},
+		{"lustre_osc_writeback_queue_depth", "Number of write RPCs of the client to the OST in flight, the writeback queue depth (write RPCs in flight of rpc_stats)", gauge, []labelPair{{"component", "client"}, {"target", "lustrefs-OST0001-osc-ffff88105db50000"}}, 0, false},
+		{"lustre_osc_pending_pages", "Number of dirty pages of the client waiting to be written back to the OST (pending write pages of rpc_stats)", gauge, []labelPair{{"component", "client"}, {"target", "lustrefs-OST0002-osc-ffff88105db50000"}}, 0, false},
+		{"lustre_osc_writeback_queue_depth", "Number of write RPCs of the client to the OST in flight, the writeback queue depth (write RPCs in flight of rpc_stats)", gauge, []labelPair{{"component", "client"}, {"target", "lustrefs-OST0002-osc-ffff88105db50000"}}, 0, false},
+		{"lustre_osc_pending_pages", "Number of dirty pages of the client waiting to be written back to the OST (pending write pages of rpc_stats)", gauge, []labelPair{{"component", "client"}, {"target", "lustrefs-OST0003-osc-ffff88105db50000"}}, 0, false},
+		{"lustre_osc_writeback_queue_depth", "Number of write RPCs of the client to the OST in flight, the writeback queue depth (write RPCs in flight of rpc_stats)", gauge, []labelPair{{"component", "client"}, {"target", "lustrefs-OST0003-osc-ffff88105db50000"}}, 0, false},
+		{"lustre_osc_pending_pages", "Number of dirty pages of the client waiting to be written back to the OST (pending write pages of rpc_stats)", gauge, []labelPair{{"component", "client"}, {"target", "lustrefs-OST0004-osc-ffff88105db50000"}}, 0, false},
+		{"lustre_osc_writeback_queue_depth", "Number of write RPCs of the client to the OST in flight, the writeback queue depth (write RPCs in flight of rpc_stats)", gauge, []labelPair{{"component", "client"}, {"target", "lustrefs-OST0004-osc-ffff88105db50000"}}, 0, false},
+		{"lustre_osc_pending_pages", "Number of dirty pages of the client waiting to be written back to the OST (pending write pages of rpc_stats)", gauge, []labelPair{{"component", "client"}, {"target", "lustrefs-OST0005-osc-ffff88105db50000"}}, 0, false},
+		{"lustre_osc_writeback_queue_depth", "Number of write RPCs of the client to the OST in flight, the writeback queue depth (write RPCs in flight of rpc_stats)", gauge, []labelPair{{"component", "client"}, {"target", "lustrefs-OST0005-osc-ffff88105db50000"}}, 0, false},
+		{"lustre_osc_pending_pages", "Number of dirty pages of the client waiting to be written back to the OST (pending write pages of rpc_stats)", gauge, []labelPair{{"component", "client"}, {"target", "lustrefs-OST0006-osc-ffff88105db50000"}}, 0, false},
+		{"lustre_osc_writeback_queue_depth", "Number of write RPCs of the client to the OST in flight, the writeback queue depth (write RPCs in flight of rpc_stats)", gauge, []labelPair{{"component", "client"}, {"target", "lustrefs-OST0006-osc-ffff88105db50000"}}, 0, false},
+		{"lustre_mdc_reconnects_total", "Total number of reconnections of the import to the target, a rising rate on many clients points to a server or network problem", counter, []labelPair{{"component", "client"}, {"target", "lustrefs-MDT0000-mdc-ffff88105db50000"}}, 2, false},
+		{"lustre_mdc_timeouts_total", "Total number of RPCs to the target which timed out", counter, []labelPair{{"component", "client"}, {"target", "lustrefs-MDT0000-mdc-ffff88105db50000"}}, 1, false},
 
 		// Generic Metrics
 		{"lustre_cache_miss_total", "Total number of cache misses.", counter, []labelPair{{"component", "generic"}, {"target", "sptlrpc"}}, 0, false},
@@ -1705,6 +2007,7 @@ var	expectedMetrics = []promType{
 		{"lustre_shrinks_total", "Total number of shrinks.", counter, []labelPair{{"component", "generic"}, {"target", "sptlrpc"}}, 0, false},
 		{"lustre_free_page_low", "Lowest number of free pages reached.", gauge, []labelPair{{"component", "generic"}, {"target", "sptlrpc"}}, 0, false},
 		{"lustre_out_of_memory_request_total", "Total number of out of memory requests.", 0, []labelPair{{"component", "generic"}, {"target", "sptlrpc"}}, 0, false},
+		{"lustre_mgc_import_state", "Current state of the import to the MGS (1 for the reported state), anything but FULL or IDLE for long means configuration updates are not received", gauge, []labelPair{{"component", "generic"}, {"state", "FULL"}, {"target", "MGC172.20.20.1@o2ib"}}, 1, false},
 
 		// LNET Metrics
 		{"lustre_console_max_delay_centiseconds", "Minimum time in centiseconds before the console logs a message", gauge, []labelPair{{"component", "lnet"}, {"target", "lnet"}}, 60000, false},
@@ -1718,6 +2021,24 @@ var	expectedMetrics = []promType{
 		{"lustre_send_count_total", "Total number of messages that have been sent", counter, []labelPair{{"component", "lnet"}, {"target", "lnet"}}, 1.01719323e+08, false},
 		{"lustre_allocated", "Number of messages currently allocated", gauge, []labelPair{{"component", "lnet"}, {"target", "lnet"}}, 0, false},
 		{"lustre_catastrophe_enabled", "Returns 1 if currently in catastrophe mode", gauge, []labelPair{{"component", "lnet"}, {"target", "lnet"}}, 0, false},
+		{"lustre_lnet_selftest_latency_microseconds", "Average round trip latency in microseconds to the peer measured by the running LNET selftest session", gauge, []labelPair{{"component", "lnet"}, {"peer", "172.20.20.2@o2ib"}}, 152.5, false},
+		{"lustre_lnet_selftest_errors_total", "Number of RPC errors to the peer in the running LNET selftest session", counter, []labelPair{{"component", "lnet"}, {"peer", "172.20.20.2@o2ib"}}, 0, false},
+		{"lustre_lnet_selftest_latency_microseconds", "Average round trip latency in microseconds to the peer measured by the running LNET selftest session", gauge, []labelPair{{"component", "lnet"}, {"peer", "172.20.20.3@o2ib"}}, 198, false},
+		{"lustre_lnet_selftest_errors_total", "Number of RPC errors to the peer in the running LNET selftest session", counter, []labelPair{{"component", "lnet"}, {"peer", "172.20.20.3@o2ib"}}, 3, false},
+		{"lustre_lnet_peer_refcount", "Number of references held on the LNET peer", gauge, []labelPair{{"component", "lnet"}, {"nid", "0@lo"}}, 1, false},
+		{"lustre_lnet_peer_tx_credits", "Number of send credits currently available to the LNET peer, negative when messages are queued", gauge, []labelPair{{"component", "lnet"}, {"nid", "0@lo"}}, 0, false},
+		{"lustre_lnet_peer_min_tx_credits", "Lowest number of send credits available to the LNET peer since the module was loaded", gauge, []labelPair{{"component", "lnet"}, {"nid", "0@lo"}}, 0, false},
+		{"lustre_lnet_peer_queued_bytes", "Number of bytes queued for sending to the LNET peer", gauge, []labelPair{{"component", "lnet"}, {"nid", "0@lo"}}, 0, false},
+		{"lustre_lnet_peer_refcount", "Number of references held on the LNET peer", gauge, []labelPair{{"component", "lnet"}, {"nid", "172.20.20.2@o2ib"}}, 1, false},
+		{"lustre_lnet_peer_up", "Returns 1 if the LNET peer is up, 0 if it is down", gauge, []labelPair{{"component", "lnet"}, {"nid", "172.20.20.2@o2ib"}}, 1, false},
+		{"lustre_lnet_peer_tx_credits", "Number of send credits currently available to the LNET peer, negative when messages are queued", gauge, []labelPair{{"component", "lnet"}, {"nid", "172.20.20.2@o2ib"}}, 8, false},
+		{"lustre_lnet_peer_min_tx_credits", "Lowest number of send credits available to the LNET peer since the module was loaded", gauge, []labelPair{{"component", "lnet"}, {"nid", "172.20.20.2@o2ib"}}, 6, false},
+		{"lustre_lnet_peer_queued_bytes", "Number of bytes queued for sending to the LNET peer", gauge, []labelPair{{"component", "lnet"}, {"nid", "172.20.20.2@o2ib"}}, 0, false},
+		{"lustre_lnet_peer_refcount", "Number of references held on the LNET peer", gauge, []labelPair{{"component", "lnet"}, {"nid", "172.20.20.3@o2ib"}}, 2, false},
+		{"lustre_lnet_peer_up", "Returns 1 if the LNET peer is up, 0 if it is down", gauge, []labelPair{{"component", "lnet"}, {"nid", "172.20.20.3@o2ib"}}, 0, false},
+		{"lustre_lnet_peer_tx_credits", "Number of send credits currently available to the LNET peer, negative when messages are queued", gauge, []labelPair{{"component", "lnet"}, {"nid", "172.20.20.3@o2ib"}}, -2, false},
+		{"lustre_lnet_peer_min_tx_credits", "Lowest number of send credits available to the LNET peer since the module was loaded", gauge, []labelPair{{"component", "lnet"}, {"nid", "172.20.20.3@o2ib"}}, -4, false},
+		{"lustre_lnet_peer_queued_bytes", "Number of bytes queued for sending to the LNET peer", gauge, []labelPair{{"component", "lnet"}, {"nid", "172.20.20.3@o2ib"}}, 4096, false},
 		{"lustre_errors_total", "Total number of errors", counter, []labelPair{{"component", "lnet"}, {"target", "lnet"}}, 0, false},
 		{"lustre_lnet_memory_used_bytes", "Number of bytes allocated by LNET", gauge, []labelPair{{"component", "lnet"}, {"target", "lnet"}}, 3.6109496e+07, false},
 		{"lustre_send_bytes_total", "Total number of bytes sent", counter, []labelPair{{"component", "lnet"}, {"target", "lnet"}}, 2.1201322992e+10, false},
@@ -1745,7 +2066,7 @@ func TestCollector(t *testing.T) {
 	sources.SysLocation = "sys"
 
 	// These following metrics should be filtered out as they are specific to the deployment and will always change
-	blacklistedMetrics := []string{"go_", "http_", "process_", "lustre_exporter_", "promhttp_"}
+	blacklistedMetrics := []string{"go_", "http_", "process_", "lustre_exporter_", "lustre_parse_", "lustre_target_metrics_completeness", "lustre_collector_empty", "lustre_collector_files_read_total", "promhttp_"}
 
 	for i, metric := range expectedMetrics {
 		newLabels, err := sortByKey(metric.Labels)
@@ -1857,3 +2178,475 @@ func TestCollector(t *testing.T) {
 	sources.ProcLocation = "/proc"
 	sources.SysLocation = "/sys"
 }
+
+func TestH2C(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(prometheus.NewRegistry(), promhttp.HandlerOpts{}))
+	server := httptest.NewServer(withH2C(mux))
+	defer server.Close()
+
+	client := &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLS: func(network, addr string, cfg *tls.Config) (net.Conn, error) {
+			return net.Dial(network, addr)
+		},
+	}}
+	resp, err := client.Get(server.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Retrieved an unexpected status code. Expected: %d, Got: %d", http.StatusOK, resp.StatusCode)
+	}
+	if resp.ProtoMajor != 2 {
+		t.Fatalf("Retrieved an unexpected protocol. Expected: HTTP/2, Got: %s", resp.Proto)
+	}
+}
+
+func TestExposedSamples(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	g := exposedSamplesGauge()
+	reg.MustRegister(g)
+	vec := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "test_gauge", Help: "test"}, []string{"target"})
+	vec.WithLabelValues("OST0000").Set(1)
+	vec.WithLabelValues("OST0001").Set(2)
+	summary := prometheus.NewSummary(prometheus.SummaryOpts{Name: "test_summary", Help: "test", Objectives: map[float64]float64{0.5: 0.05, 0.9: 0.01}})
+	summary.Observe(1)
+	reg.MustRegister(vec, summary)
+	server := httptest.NewServer(promhttp.HandlerFor(samplesGatherer{reg, g}, promhttp.HandlerOpts{}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the gauge itself, the 2 series of the vector and the 2 quantiles, _sum
+	// and _count of the summary
+	expected := 7
+	var samples int
+	for _, line := range strings.Split(string(body), "\n") {
+		if line != "" && !strings.HasPrefix(line, "#") {
+			samples++
+		}
+	}
+	if samples != expected {
+		t.Fatalf("Retrieved an unexpected number of samples in the response. Expected: %d, Got: %d", expected, samples)
+	}
+	metricFamilies, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range metricFamilies {
+		if family.GetName() != "lustre_exporter_samples_exposed" {
+			continue
+		}
+		if value := family.Metric[0].GetGauge().GetValue(); int(value) != expected {
+			t.Fatalf("Retrieved an unexpected samples count. Expected: %d, Got: %f", expected, value)
+		}
+		return
+	}
+	t.Fatalf("lustre_exporter_samples_exposed not exposed")
+}
+
+func TestExposedBytes(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	g := exposedBytesGauge()
+	reg.MustRegister(g)
+	server := httptest.NewServer(withExposedBytes(promhttp.HandlerFor(reg, promhttp.HandlerOpts{}), g))
+	defer server.Close()
+
+	// the gauge counts the bytes written, which are gzipped when the client
+	// accepts it, the raw exposition is asked for to compare with its length
+	client := &http.Client{Transport: &http.Transport{DisableCompression: true}}
+
+	// the first response holds the gauge at 0, the second one its size
+	var lengths []int
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		lengths = append(lengths, len(body))
+	}
+
+	metricFamilies, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(metricFamilies) != 1 || metricFamilies[0].GetName() != "lustre_exporter_exposition_bytes" {
+		t.Fatalf("Retrieved unexpected metrics: %v", metricFamilies)
+	}
+	value := metricFamilies[0].Metric[0].GetGauge().GetValue()
+	if value == 0 || int(value) != lengths[1] {
+		t.Fatalf("Retrieved an unexpected exposition size. Expected: %d, Got: %f", lengths[1], value)
+	}
+	// the value rendered in the second response differs from the first one
+	// by a few digits at most
+	if diff := lengths[1] - lengths[0]; diff < 0 || diff > 20 {
+		t.Fatalf("Retrieved unexpected response lengths: %v", lengths)
+	}
+
+	failing := withExposedBytes(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "failed", http.StatusInternalServerError)
+	}), g)
+	failing.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/metrics", nil))
+	metricFamilies, err = reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := metricFamilies[0].Metric[0].GetGauge().GetValue(); got != value {
+		t.Fatalf("Retrieved an unexpected exposition size after a failed scrape. Expected: %f, Got: %f", value, got)
+	}
+}
+
+func TestLimitRequests(t *testing.T) {
+	const limit = 2
+
+	var started sync.WaitGroup
+	started.Add(limit)
+	release := make(chan struct{})
+	slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started.Done()
+		<-release
+		w.WriteHeader(http.StatusOK)
+	})
+	server := httptest.NewServer(limitRequests(slow, limit))
+	defer server.Close()
+
+	codes := make(chan int, limit)
+	for i := 0; i < limit; i++ {
+		go func() {
+			resp, err := http.Get(server.URL)
+			if err != nil {
+				codes <- 0
+				return
+			}
+			resp.Body.Close()
+			codes <- resp.StatusCode
+		}()
+	}
+	started.Wait()
+
+	// every slot is taken, so these must be rejected
+	for i := 0; i < 3; i++ {
+		resp, err := http.Get(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusServiceUnavailable {
+			t.Fatalf("Retrieved an unexpected status code. Expected: %d, Got: %d", http.StatusServiceUnavailable, resp.StatusCode)
+		}
+		if resp.Header.Get("Retry-After") == "" {
+			t.Fatal("Missing Retry-After header on a rejected request")
+		}
+	}
+
+	close(release)
+	for i := 0; i < limit; i++ {
+		if code := <-codes; code != http.StatusOK {
+			t.Fatalf("Retrieved an unexpected status code. Expected: %d, Got: %d", http.StatusOK, code)
+		}
+	}
+}
+
+func TestVersionLabel(t *testing.T) {
+	saved := sources.SysLocation
+	defer func() { sources.SysLocation = saved }()
+	sources.SysLocation = "tests/2.12/sys"
+
+	version, err := sources.LustreVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reg := prometheus.NewRegistry()
+	withVersionLabel(reg, version).MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: sources.Namespace,
+		Name:      "health_check",
+		Help:      "Current health status for the indicated instance",
+	}, func() float64 { return 1 }))
+
+	metricFamilies, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(metricFamilies) != 1 || len(metricFamilies[0].Metric) != 1 {
+		t.Fatalf("Retrieved an unexpected number of metrics: %v", metricFamilies)
+	}
+	labels := metricFamilies[0].Metric[0].Label
+	if len(labels) != 1 || *labels[0].Name != "lustre_version" {
+		t.Fatalf("Retrieved unexpected labels: %v", labels)
+	}
+	// tests/2.12/sys/fs/lustre/version holds "2.10.1"
+	if *labels[0].Value != "2.10" {
+		t.Fatalf("Retrieved an unexpected lustre_version. Expected: %s, Got: %s", "2.10", *labels[0].Value)
+	}
+}
+
+func TestCollectHandler(t *testing.T) {
+	savedProc, savedSys := sources.ProcLocation, sources.SysLocation
+	defer func() { sources.ProcLocation, sources.SysLocation = savedProc, savedSys }()
+	sources.ProcLocation = "proc"
+	sources.SysLocation = "sys"
+	toggleCollectors("OST")
+
+	enabledSources := []string{"procfs", "procsys", "sysfs"}
+	sourceList, err := loadSources(enabledSources)
+	if err != nil {
+		t.Fatal("Unable to load sources")
+	}
+	server := httptest.NewServer(collectHandler(sourceList))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Retrieved an unexpected status code. Expected: %d, Got: %d", http.StatusOK, resp.StatusCode)
+	}
+	var results []sources.CollectResult
+	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
+		t.Fatal(err)
+	}
+	var collectors []string
+	for _, res := range results {
+		collectors = append(collectors, res.Collector)
+	}
+	if !reflect.DeepEqual(collectors, enabledSources) {
+		t.Fatalf("Retrieved unexpected collectors. Expected: %v, Got: %v", enabledSources, collectors)
+	}
+}
+
+func TestMetricNamesHandler(t *testing.T) {
+	toggleCollectors("OST")
+
+	sourceList, err := loadSources([]string{"procfs"})
+	if err != nil {
+		t.Fatal("Unable to load sources")
+	}
+	server := httptest.NewServer(metricNamesHandler(sourceList))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Retrieved an unexpected status code. Expected: %d, Got: %d", http.StatusOK, resp.StatusCode)
+	}
+	var names []sources.MetricName
+	if err := json.NewDecoder(resp.Body).Decode(&names); err != nil {
+		t.Fatal(err)
+	}
+	expected := sources.MetricName{Name: "lustre_job_read_samples_total", Help: "Total number of reads that have been recorded.", Type: "counter", Labels: []string{"component", "jobid", "target"}}
+	for _, name := range names {
+		if name.Name == expected.Name {
+			if !reflect.DeepEqual(name, expected) {
+				t.Fatalf("Retrieved an unexpected metric name. Expected: %+v, Got: %+v", expected, name)
+			}
+			return
+		}
+	}
+	t.Fatalf("Metric %s not listed", expected.Name)
+}
+
+func TestStrictMode(t *testing.T) {
+	savedProc, savedShelfLife, savedVersion := sources.ProcLocation, sources.SHELF_LIFE, sources.CollectVersion
+	defer func() {
+		sources.ProcLocation, sources.SHELF_LIFE, sources.CollectVersion = savedProc, savedShelfLife, savedVersion
+		sources.Strict = false
+	}()
+	sources.CollectVersion = "v2"
+	sources.SHELF_LIFE = time.Duration(0)
+	toggleCollectors("OST")
+	defer func(generic string) { sources.GenericEnabled = generic }(sources.GenericEnabled)
+	sources.GenericEnabled = "core"
+
+	// a directory in place of the modules file makes the procfs collector
+	// fail, a broken target file would only lower its completeness
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "modules"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	sources.ProcLocation = dir
+	sourceList, err := loadSources([]string{"procfs"})
+	if err != nil {
+		t.Fatal("Unable to load sources")
+	}
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(LustreSource{sourceList: sourceList})
+
+	for _, strict := range []bool{true, false} {
+		sources.Strict = strict
+		server := httptest.NewServer(promhttp.HandlerFor(reg, metricsHandlerOpts(strict)))
+		resp, err := http.Get(server.URL)
+		if err != nil {
+			server.Close()
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		server.Close()
+
+		expected := http.StatusOK
+		if strict {
+			expected = http.StatusInternalServerError
+		}
+		if resp.StatusCode != expected {
+			t.Fatalf("Retrieved an unexpected status code in strict mode %t. Expected: %d, Got: %d", strict, expected, resp.StatusCode)
+		}
+	}
+}
+
+func TestScrapeIntervalGauge(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(scrapeIntervalGauge(30 * time.Second))
+
+	metricFamilies, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(metricFamilies) != 1 || len(metricFamilies[0].Metric) != 1 {
+		t.Fatalf("Retrieved an unexpected number of metrics: %v", metricFamilies)
+	}
+	if name := metricFamilies[0].GetName(); name != "lustre_exporter_expected_scrape_interval_seconds" {
+		t.Fatalf("Retrieved an unexpected metric name. Expected: %s, Got: %s", "lustre_exporter_expected_scrape_interval_seconds", name)
+	}
+	if value := metricFamilies[0].Metric[0].GetGauge().GetValue(); value != 30 {
+		t.Fatalf("Retrieved an unexpected scrape interval. Expected: %d, Got: %f", 30, value)
+	}
+}
+
+func TestStartTimeGauge(t *testing.T) {
+	testStart := time.Now()
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(startTimeGauge(startTime))
+
+	metricFamilies, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(metricFamilies) != 1 || len(metricFamilies[0].Metric) != 1 {
+		t.Fatalf("Retrieved an unexpected number of metrics: %v", metricFamilies)
+	}
+	if name := metricFamilies[0].GetName(); name != "lustre_exporter_start_time_seconds" {
+		t.Fatalf("Retrieved an unexpected metric name. Expected: %s, Got: %s", "lustre_exporter_start_time_seconds", name)
+	}
+	// set when the test binary started, just before this test
+	value := metricFamilies[0].Metric[0].GetGauge().GetValue()
+	if now := float64(testStart.Unix()); value > now+1 || value < now-3600 {
+		t.Fatalf("Retrieved an unexpected start time. Expected: within the hour before %f, Got: %f", now, value)
+	}
+}
+
+func TestServerRoleGauge(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(serverRoleGauge("combined"))
+
+	metricFamilies, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(metricFamilies) != 1 || len(metricFamilies[0].Metric) != 1 {
+		t.Fatalf("Retrieved an unexpected number of metrics: %v", metricFamilies)
+	}
+	if name := metricFamilies[0].GetName(); name != "lustre_server_role_info" {
+		t.Fatalf("Retrieved an unexpected metric name. Expected: %s, Got: %s", "lustre_server_role_info", name)
+	}
+	metric := metricFamilies[0].Metric[0]
+	if len(metric.Label) != 1 || metric.Label[0].GetName() != "role" || metric.Label[0].GetValue() != "combined" {
+		t.Fatalf("Retrieved an unexpected role label. Expected: %s, Got: %v", "role=combined", metric.Label)
+	}
+	if value := metric.GetGauge().GetValue(); value != 1 {
+		t.Fatalf("Retrieved an unexpected server role value. Expected: %d, Got: %f", 1, value)
+	}
+}
+
+func TestSummaryHandler(t *testing.T) {
+	server := httptest.NewServer(summaryHandler(sources.NewSummaryCollector(sources.Config{ProcLocation: "tests/2.12/proc", SysLocation: "tests/2.12/sys"})))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var parser expfmt.TextParser
+	metricFamilies, err := parser.TextToMetricFamilies(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"lustre_summary_targets", "lustre_summary_capacity_kilobytes", "lustre_summary_free_kilobytes", "lustre_summary_healthy"} {
+		if _, ok := metricFamilies[name]; !ok {
+			t.Fatalf("Expected %s in the summary", name)
+		}
+	}
+	// neither the default registry (go_*, process_*) nor the full set
+	for name := range metricFamilies {
+		if !strings.HasPrefix(name, "lustre_summary_") {
+			t.Fatalf("Retrieved an unexpected metric in the summary: %s", name)
+		}
+	}
+	if value := metricFamilies["lustre_summary_healthy"].Metric[0].GetGauge().GetValue(); value != 1 {
+		t.Fatalf("Retrieved an unexpected health. Expected: %d, Got: %f", 1, value)
+	}
+}
+
+// brokenCollector emits the given metrics as they are.
+type brokenCollector []prometheus.Metric
+
+func (c brokenCollector) Describe(ch chan<- *prometheus.Desc) {}
+
+func (c brokenCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, m := range c {
+		ch <- m
+	}
+}
+
+func TestSelfCheck(t *testing.T) {
+	byTarget := prometheus.NewDesc("lustre_self_check", "help", []string{"target"}, nil)
+	byComponent := prometheus.NewDesc("lustre_self_check", "help", []string{"component"}, nil)
+	badName := prometheus.NewDesc("lustre-self-check", "help", nil, nil)
+
+	for _, item := range []struct {
+		name      string
+		collector brokenCollector
+		fails     bool
+	}{
+		{"consistent", brokenCollector{
+			prometheus.MustNewConstMetric(byTarget, prometheus.GaugeValue, 1, "lustrefs-OST0000"),
+			prometheus.MustNewConstMetric(byTarget, prometheus.GaugeValue, 2, "lustrefs-OST0002"),
+		}, false},
+		{"inconsistent labels", brokenCollector{
+			prometheus.MustNewConstMetric(byTarget, prometheus.GaugeValue, 1, "lustrefs-OST0000"),
+			prometheus.MustNewConstMetric(byComponent, prometheus.GaugeValue, 1, "ost"),
+		}, true},
+		{"duplicate series", brokenCollector{
+			prometheus.MustNewConstMetric(byTarget, prometheus.GaugeValue, 1, "lustrefs-OST0000"),
+			prometheus.MustNewConstMetric(byTarget, prometheus.GaugeValue, 2, "lustrefs-OST0000"),
+		}, true},
+		{"invalid name", brokenCollector{
+			prometheus.NewInvalidMetric(badName, fmt.Errorf("invalid name")),
+		}, true},
+	} {
+		err := selfCheck(item.collector)
+		if (err != nil) != item.fails {
+			t.Fatalf("Retrieved an unexpected self check result for the %s collector. Expected failure: %t, Got: %v", item.name, item.fails, err)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+// Package remotewrite pushes gathered metrics to a Prometheus remote_write
+// endpoint, for nodes that can't be scraped from outside.
+package remotewrite
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/version"
+
+	"lustre_exporter/log"
+)
+
+// Config holds the settings of a remote_write client. The basic auth
+// password is read from PasswordFile, so it doesn't show in the command line
+// of the exporter.
+type Config struct {
+	URL          string
+	Username     string
+	PasswordFile string
+	Timeout      time.Duration
+}
+
+// Client sends WriteRequests to a single remote_write endpoint.
+type Client struct {
+	url          string
+	username     string
+	passwordFile string
+	client       *http.Client
+}
+
+// NewClient validates cfg and returns a client for it.
+func NewClient(cfg Config) (*Client, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid remote_write url %q: scheme must be http or https", cfg.URL)
+	}
+
+	if cfg.PasswordFile != "" {
+		if _, err := readPasswordFile(cfg.PasswordFile); err != nil {
+			return nil, err
+		}
+	}
+
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+
+	return &Client{
+		url:          cfg.URL,
+		username:     cfg.Username,
+		passwordFile: cfg.PasswordFile,
+		client:       &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// readPasswordFile returns the password stored in path, without the trailing
+// newline most editors add.
+func readPasswordFile(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("couldn't read the remote_write password file: %w", err)
+	}
+	return strings.TrimRight(string(content), "\r\n"), nil
+}
+
+// Push gathers g and sends the result to the endpoint in one request.
+func (c *Client) Push(ctx context.Context, g prometheus.Gatherer) error {
+	families, err := g.Gather()
+	if err != nil && len(families) == 0 {
+		return err
+	}
+	if err != nil {
+		// same as promhttp.ContinueOnError: push what we have
+		log.Warnf("remote_write: gathering finished with errors: %s", err)
+	}
+
+	series := toTimeSeries(families, time.Now().UnixNano()/int64(time.Millisecond))
+	if len(series) == 0 {
+		return nil
+	}
+
+	return c.send(ctx, snappyEncode(marshalWriteRequest(series)))
+}
+
+func (c *Client) send(ctx context.Context, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("User-Agent", "lustre_exporter/"+version.Version)
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	if c.username != "" {
+		// read on every push, a rotated password is picked up without restart
+		password := ""
+		if c.passwordFile != "" {
+			if password, err = readPasswordFile(c.passwordFile); err != nil {
+				return err
+			}
+		}
+		req.SetBasicAuth(c.username, password)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("remote_write to %s returned HTTP status %s: %s", c.url, resp.Status, bytes.TrimSpace(msg))
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	return nil
+}
+
+// Run pushes g every interval until ctx is cancelled. Failed pushes are logged
+// and retried on the next tick.
+func (c *Client) Run(ctx context.Context, g prometheus.Gatherer, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		begin := time.Now()
+		if err := c.Push(ctx, g); err != nil {
+			log.Errorf("ERROR: remote_write failed after %f seconds: %s", time.Since(begin).Seconds(), err)
+		} else {
+			log.Debugf("OK: remote_write succeeded after %f seconds", time.Since(begin).Seconds())
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,319 @@
+package remotewrite
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// snappyDecodeLiterals undoes snappyEncode following the snappy block format
+// (format_description.txt of google/snappy). It only understands literal
+// chunks, which is all the encoder produces.
+func snappyDecodeLiterals(src []byte) ([]byte, error) {
+	n, l := binary.Uvarint(src)
+	if l <= 0 {
+		return nil, fmt.Errorf("bad snappy header")
+	}
+	src = src[l:]
+	var dst []byte
+	for len(src) > 0 {
+		tag := src[0]
+		if tag&0x3 != 0 {
+			return nil, fmt.Errorf("unexpected snappy copy element %#x", tag)
+		}
+		var length int
+		switch tag >> 2 {
+		case 60:
+			length, src = int(src[1])+1, src[2:]
+		case 61:
+			length, src = int(src[1])|int(src[2])<<8+1, src[3:]
+		default:
+			length, src = int(tag>>2)+1, src[1:]
+		}
+		dst, src = append(dst, src[:length]...), src[length:]
+	}
+	if uint64(len(dst)) != n {
+		return nil, fmt.Errorf("snappy length mismatch: header %d, got %d", n, len(dst))
+	}
+	return dst, nil
+}
+
+// writeRequestDescriptor describes prompb.WriteRequest, with the field
+// numbers and types of prompb/remote.proto and prompb/types.proto, so the
+// requests are decoded by the protobuf runtime rather than by hand.
+func writeRequestDescriptor(t *testing.T) protoreflect.MessageDescriptor {
+	field := func(name string, number int32, label descriptorpb.FieldDescriptorProto_Label, typ descriptorpb.FieldDescriptorProto_Type, typeName string) *descriptorpb.FieldDescriptorProto {
+		f := &descriptorpb.FieldDescriptorProto{Name: proto.String(name), Number: proto.Int32(number), Label: label.Enum(), Type: typ.Enum()}
+		if typeName != "" {
+			f.TypeName = proto.String(typeName)
+		}
+		return f
+	}
+	const (
+		optional = descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+		repeated = descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+	)
+	file := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("remote.proto"),
+		Package: proto.String("prometheus"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{Name: proto.String("WriteRequest"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("timeseries", 1, repeated, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".prometheus.TimeSeries"),
+			}},
+			{Name: proto.String("TimeSeries"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("labels", 1, repeated, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".prometheus.Label"),
+				field("samples", 2, repeated, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".prometheus.Sample"),
+			}},
+			{Name: proto.String("Label"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("name", 1, optional, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+				field("value", 2, optional, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+			}},
+			{Name: proto.String("Sample"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("value", 1, optional, descriptorpb.FieldDescriptorProto_TYPE_DOUBLE, ""),
+				field("timestamp", 2, optional, descriptorpb.FieldDescriptorProto_TYPE_INT64, ""),
+			}},
+		},
+	}
+	fd, err := protodesc.NewFile(file, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fd.Messages().ByName("WriteRequest")
+}
+
+type decodedSeries struct {
+	labels    map[string]string
+	value     float64
+	timestamp int64
+}
+
+func decodeWriteRequest(md protoreflect.MessageDescriptor, b []byte) ([]decodedSeries, error) {
+	req := dynamicpb.NewMessage(md)
+	if err := (proto.UnmarshalOptions{DiscardUnknown: false}).Unmarshal(b, req); err != nil {
+		return nil, err
+	}
+	if len(req.GetUnknown()) != 0 {
+		return nil, fmt.Errorf("unknown fields in the WriteRequest")
+	}
+	var out []decodedSeries
+	series := req.Get(md.Fields().ByName("timeseries")).List()
+	for i := 0; i < series.Len(); i++ {
+		ts := series.Get(i).Message()
+		tsFields := ts.Descriptor().Fields()
+		s := decodedSeries{labels: map[string]string{}}
+		labels := ts.Get(tsFields.ByName("labels")).List()
+		for j := 0; j < labels.Len(); j++ {
+			l := labels.Get(j).Message()
+			lFields := l.Descriptor().Fields()
+			s.labels[l.Get(lFields.ByName("name")).String()] = l.Get(lFields.ByName("value")).String()
+		}
+		samples := ts.Get(tsFields.ByName("samples")).List()
+		if samples.Len() != 1 {
+			return nil, fmt.Errorf("series with %d samples", samples.Len())
+		}
+		smp := samples.Get(0).Message()
+		sFields := smp.Descriptor().Fields()
+		s.value = smp.Get(sFields.ByName("value")).Float()
+		s.timestamp = smp.Get(sFields.ByName("timestamp")).Int()
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+func strPtr(s string) *string                  { return &s }
+func floatPtr(f float64) *float64              { return &f }
+func uintPtr(u uint64) *uint64                 { return &u }
+func typePtr(t dto.MetricType) *dto.MetricType { return &t }
+
+func TestPush(t *testing.T) {
+	families := []*dto.MetricFamily{
+		{
+			Name: strPtr("lustre_read_bytes_total"),
+			Type: typePtr(dto.MetricType_COUNTER),
+			Metric: []*dto.Metric{{
+				Label:   []*dto.LabelPair{{Name: strPtr("target"), Value: strPtr("lustrefs-OST0000")}, {Name: strPtr("component"), Value: strPtr("ost")}},
+				Counter: &dto.Counter{Value: floatPtr(4096)},
+			}},
+		},
+		{
+			Name: strPtr("lustre_exporter_scrape_duration_seconds"),
+			Type: typePtr(dto.MetricType_SUMMARY),
+			Metric: []*dto.Metric{{
+				Summary: &dto.Summary{SampleCount: uintPtr(2), SampleSum: floatPtr(0.5), Quantile: []*dto.Quantile{{Quantile: floatPtr(0.5), Value: floatPtr(0.25)}}},
+			}},
+		},
+	}
+	gatherer := prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) { return families, nil })
+
+	md := writeRequestDescriptor(t)
+	var got []decodedSeries
+	var decodeErr error
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Encoding") != "snappy" || r.Header.Get("Content-Type") != "application/x-protobuf" {
+			decodeErr = fmt.Errorf("unexpected headers: %v", r.Header)
+		}
+		if user, pass, ok := r.BasicAuth(); !ok || user != "lustre" || pass != "secret" {
+			decodeErr = fmt.Errorf("missing or wrong basic auth")
+		}
+		body, _ := io.ReadAll(r.Body)
+		raw, err := snappyDecodeLiterals(body)
+		if err == nil {
+			got, err = decodeWriteRequest(md, raw)
+		}
+		if err != nil {
+			decodeErr = err
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	passwordFile := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(passwordFile, []byte("secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	client, err := NewClient(Config{URL: server.URL, Username: "lustre", PasswordFile: passwordFile, Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Push(context.Background(), gatherer); err != nil {
+		t.Fatal(err)
+	}
+	if decodeErr != nil {
+		t.Fatal(decodeErr)
+	}
+
+	// 1 counter + 1 quantile + _sum + _count
+	if len(got) != 4 {
+		t.Fatalf("Retrieved an unexpected number of series. Expected: %d, Got: %d", 4, len(got))
+	}
+	first := got[0]
+	if first.labels["__name__"] != "lustre_read_bytes_total" || first.labels["target"] != "lustrefs-OST0000" || first.labels["component"] != "ost" {
+		t.Fatalf("Retrieved unexpected labels: %v", first.labels)
+	}
+	if first.value != 4096 {
+		t.Fatalf("Retrieved an unexpected value. Expected: %v, Got: %v", 4096, first.value)
+	}
+	if first.timestamp <= 0 {
+		t.Fatalf("Retrieved an unexpected timestamp: %d", first.timestamp)
+	}
+	if got[1].labels["quantile"] != "0.5" || got[1].value != 0.25 {
+		t.Fatalf("Retrieved an unexpected quantile series: %+v", got[1])
+	}
+	if got[3].labels["__name__"] != "lustre_exporter_scrape_duration_seconds_count" || got[3].value != 2 {
+		t.Fatalf("Retrieved an unexpected count series: %+v", got[3])
+	}
+}
+
+func TestPushErrorStatus(t *testing.T) {
+	gatherer := prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		return []*dto.MetricFamily{{
+			Name:   strPtr("lustre_health_check"),
+			Type:   typePtr(dto.MetricType_GAUGE),
+			Metric: []*dto.Metric{{Gauge: &dto.Gauge{Value: floatPtr(1)}}},
+		}}, nil
+	})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "out of order sample", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(Config{URL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Push(context.Background(), gatherer); err == nil {
+		t.Fatal("Expected an error for a non-2xx response")
+	}
+}
+
+func TestNewClientInvalidURL(t *testing.T) {
+	if _, err := NewClient(Config{URL: "ftp://example.com/write"}); err == nil {
+		t.Fatal("Expected an error for a non-http url")
+	}
+}
+
+func TestNewClientMissingPasswordFile(t *testing.T) {
+	if _, err := NewClient(Config{URL: "http://example.com/write", Username: "lustre", PasswordFile: filepath.Join(t.TempDir(), "missing")}); err == nil {
+		t.Fatal("Expected an error for a missing password file")
+	}
+}
+
+func TestMarshalWriteRequestZeroValues(t *testing.T) {
+	// proto3 leaves the zero values out, the decoder must take the explicit
+	// ones the same
+	series := []timeSeries{{labels: []label{{name: "__name__", value: "lustre_degraded"}, {name: "target", value: ""}}, samples: []sample{{value: 0, timestamp: 0}}}}
+	got, err := decodeWriteRequest(writeRequestDescriptor(t), marshalWriteRequest(series))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].labels["__name__"] != "lustre_degraded" || got[0].value != 0 || got[0].timestamp != 0 {
+		t.Fatalf("Retrieved an unexpected series: %+v", got)
+	}
+}
+
+func TestToTimeSeriesInfBucket(t *testing.T) {
+	for _, bounds := range [][]float64{{1, 2}, {1, 2, math.Inf(1)}} {
+		h := &dto.Histogram{SampleCount: proto.Uint64(uint64(len(bounds))), SampleSum: proto.Float64(0)}
+		for i, bound := range bounds {
+			h.Bucket = append(h.Bucket, &dto.Bucket{CumulativeCount: proto.Uint64(uint64(i + 1)), UpperBound: proto.Float64(bound)})
+		}
+		families := []*dto.MetricFamily{{Name: proto.String("lustre_io_time_milliseconds"), Type: dto.MetricType_HISTOGRAM.Enum(), Metric: []*dto.Metric{{Histogram: h}}}}
+
+		infs := 0
+		for _, ts := range toTimeSeries(families, 1) {
+			for _, l := range ts.labels {
+				if l.name == "le" && l.value == "+Inf" {
+					infs++
+				}
+			}
+		}
+		if infs != 1 {
+			t.Fatalf("Retrieved an unexpected number of +Inf buckets for the bounds %v. Expected: %d, Got: %d", bounds, 1, infs)
+		}
+	}
+}
+
+func TestSnappyEncode(t *testing.T) {
+	// below 17 bytes the reference encoder (golang/snappy Encode) only emits
+	// a literal, its output is then the same as ours
+	for src, expected := range map[string][]byte{
+		"":                 {0x00},
+		"hello":            {0x05, 0x10, 'h', 'e', 'l', 'l', 'o'},
+		"lustrefs-OST0000": append([]byte{0x10, 0x3c}, "lustrefs-OST0000"...),
+	} {
+		if got := snappyEncode([]byte(src)); !bytes.Equal(got, expected) {
+			t.Fatalf("Retrieved an unexpected snappy block for %q. Expected: %x, Got: %x", src, expected, got)
+		}
+	}
+
+	// literals longer than 60 bytes use a 1 or 2 bytes length, longer
+	// inputs are split in 64KiB chunks
+	for _, n := range []int{60, 61, 256, 257, 1 << 16, 1<<16 + 1, 200000} {
+		src := bytes.Repeat([]byte{'x'}, n)
+		got, err := snappyDecodeLiterals(snappyEncode(src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, src) {
+			t.Fatalf("Retrieved an unexpected decoded block of %d bytes, Got: %d bytes", n, len(got))
+		}
+	}
+}
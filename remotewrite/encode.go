@@ -0,0 +1,172 @@
+package remotewrite
+
+import (
+	"encoding/binary"
+	"math"
+	"sort"
+	"strconv"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+// label, sample and timeSeries mirror the messages of the Prometheus
+// remote_write protocol (prompb.WriteRequest), so we don't need to pull in the
+// whole prometheus/prometheus module just to push a handful of series.
+type label struct {
+	name  string
+	value string
+}
+
+type sample struct {
+	value     float64
+	timestamp int64
+}
+
+type timeSeries struct {
+	labels  []label
+	samples []sample
+}
+
+// toTimeSeries flattens gathered metric families into remote_write series, one
+// sample per series, stamped with ts (milliseconds since epoch).
+// Summaries and histograms are expanded the same way the text format does it.
+func toTimeSeries(families []*dto.MetricFamily, ts int64) []timeSeries {
+	var out []timeSeries
+
+	add := func(name string, m *dto.Metric, value float64, extraName string, extraValue string) {
+		labels := make([]label, 0, len(m.GetLabel())+2)
+		labels = append(labels, label{name: "__name__", value: name})
+		for _, l := range m.GetLabel() {
+			labels = append(labels, label{name: l.GetName(), value: l.GetValue()})
+		}
+		if extraName != "" {
+			labels = append(labels, label{name: extraName, value: extraValue})
+		}
+		sort.Slice(labels, func(i, j int) bool { return labels[i].name < labels[j].name })
+
+		t := ts
+		if m.GetTimestampMs() != 0 {
+			t = m.GetTimestampMs()
+		}
+		out = append(out, timeSeries{labels: labels, samples: []sample{{value: value, timestamp: t}}})
+	}
+
+	for _, mf := range families {
+		name := mf.GetName()
+		for _, m := range mf.GetMetric() {
+			switch mf.GetType() {
+			case dto.MetricType_COUNTER:
+				add(name, m, m.GetCounter().GetValue(), "", "")
+			case dto.MetricType_GAUGE:
+				add(name, m, m.GetGauge().GetValue(), "", "")
+			case dto.MetricType_UNTYPED:
+				add(name, m, m.GetUntyped().GetValue(), "", "")
+			case dto.MetricType_SUMMARY:
+				s := m.GetSummary()
+				for _, q := range s.GetQuantile() {
+					add(name, m, q.GetValue(), "quantile", strconv.FormatFloat(q.GetQuantile(), 'g', -1, 64))
+				}
+				add(name+"_sum", m, s.GetSampleSum(), "", "")
+				add(name+"_count", m, float64(s.GetSampleCount()), "", "")
+			case dto.MetricType_HISTOGRAM:
+				h := m.GetHistogram()
+				buckets := h.GetBucket()
+				for _, b := range buckets {
+					add(name+"_bucket", m, float64(b.GetCumulativeCount()), "le", strconv.FormatFloat(b.GetUpperBound(), 'g', -1, 64))
+				}
+				// the +Inf bucket is implicit, unless the histogram has it
+				if len(buckets) == 0 || !math.IsInf(buckets[len(buckets)-1].GetUpperBound(), 1) {
+					add(name+"_bucket", m, float64(h.GetSampleCount()), "le", "+Inf")
+				}
+				add(name+"_sum", m, h.GetSampleSum(), "", "")
+				add(name+"_count", m, float64(h.GetSampleCount()), "", "")
+			}
+		}
+	}
+
+	return out
+}
+
+// protobuf wire types used by the WriteRequest messages
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+)
+
+func appendUvarint(b []byte, v uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], v)
+	return append(b, buf[:n]...)
+}
+
+func appendFixed64(b []byte, v uint64) []byte {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], v)
+	return append(b, buf[:]...)
+}
+
+func appendTag(b []byte, field int, wire int) []byte {
+	return appendUvarint(b, uint64(field<<3|wire))
+}
+
+func appendBytesField(b []byte, field int, data []byte) []byte {
+	b = appendTag(b, field, wireBytes)
+	b = appendUvarint(b, uint64(len(data)))
+	return append(b, data...)
+}
+
+// marshalWriteRequest encodes series as a prompb.WriteRequest:
+//
+//	WriteRequest { repeated TimeSeries timeseries = 1; }
+//	TimeSeries   { repeated Label labels = 1; repeated Sample samples = 2; }
+//	Label        { string name = 1; string value = 2; }
+//	Sample       { double value = 1; int64 timestamp = 2; }
+func marshalWriteRequest(series []timeSeries) []byte {
+	var out, ts, msg []byte
+	for _, s := range series {
+		ts = ts[:0]
+		for _, l := range s.labels {
+			msg = msg[:0]
+			msg = appendBytesField(msg, 1, []byte(l.name))
+			msg = appendBytesField(msg, 2, []byte(l.value))
+			ts = appendBytesField(ts, 1, msg)
+		}
+		for _, smp := range s.samples {
+			msg = msg[:0]
+			msg = appendTag(msg, 1, wireFixed64)
+			msg = appendFixed64(msg, math.Float64bits(smp.value))
+			msg = appendTag(msg, 2, wireVarint)
+			msg = appendUvarint(msg, uint64(smp.timestamp))
+			ts = appendBytesField(ts, 2, msg)
+		}
+		out = appendBytesField(out, 1, ts)
+	}
+	return out
+}
+
+// snappyEncode wraps src in a snappy block made only of literal chunks. That is
+// a valid (if uncompressed) snappy stream, which every remote_write receiver
+// accepts, and keeps us free of a compression dependency.
+func snappyEncode(src []byte) []byte {
+	const maxChunk = 1 << 16
+
+	dst := appendUvarint(make([]byte, 0, len(src)+len(src)/maxChunk*3+16), uint64(len(src)))
+	for len(src) > 0 {
+		n := len(src)
+		if n > maxChunk {
+			n = maxChunk
+		}
+		switch l := n - 1; {
+		case l < 60:
+			dst = append(dst, byte(l<<2))
+		case l < 1<<8:
+			dst = append(dst, 60<<2, byte(l))
+		default:
+			dst = append(dst, 61<<2, byte(l), byte(l>>8))
+		}
+		dst = append(dst, src[:n]...)
+		src = src[n:]
+	}
+	return dst
+}
@@ -0,0 +1,95 @@
+package sources
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const procAccessHelp string = "Whether the exporter can read the representative directory of the path class (proc: fs/lustre of the proc location, sys: fs/lustre of the sys location, debug: kernel/debug/lustre of the sys location): 1 for readable, 0 for permission denied. A class whose directory doesn't exist is not reported"
+
+// AccessCollector reports whether the exporter can read the proc, sys and
+// debug paths, debugfs usually being root-only. A non-root exporter silently
+// misses the metrics of the files it can't read, this tells why.
+type AccessCollector struct {
+	paths map[string]string
+	root  bool
+	// readDir checks the read access to a directory
+	readDir func(path string) error
+}
+
+// NewAccessCollector returns an AccessCollector checking the locations of
+// cfg.
+func NewAccessCollector(cfg Config) *AccessCollector {
+	return &AccessCollector{
+		paths: map[string]string{
+			"proc":  cfg.LustreProcPath(),
+			"sys":   cfg.LustreSysPath(),
+			"debug": cfg.LustreDebugPath(),
+		},
+		root:    os.Geteuid() == 0,
+		readDir: readDirAccess,
+	}
+}
+
+// readDirAccess opens path and reads one of its entries.
+func readDirAccess(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := f.Readdirnames(1); err != nil && err != io.EOF {
+		return err
+	}
+	return nil
+}
+
+// Check returns the access of every path class whose directory exists. Root
+// reads everything, the directories are then only checked for existence.
+func (c *AccessCollector) Check() map[string]bool {
+	out := map[string]bool{}
+	for class, path := range c.paths {
+		if c.root {
+			if _, err := os.Stat(path); err == nil {
+				out[class] = true
+			}
+			continue
+		}
+		err := c.readDir(path)
+		switch {
+		case err == nil:
+			out[class] = true
+		case errors.Is(err, fs.ErrPermission):
+			out[class] = false
+		}
+	}
+	return out
+}
+
+// Describe implements prometheus.Collector.
+func (c *AccessCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(c, ch)
+}
+
+// Collect implements prometheus.Collector.
+func (c *AccessCollector) Collect(ch chan<- prometheus.Metric) {
+	access := c.Check()
+	classes := make([]string, 0, len(access))
+	for class := range access {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+	desc := prometheus.NewDesc(prometheus.BuildFQName(Namespace, "exporter", "proc_access"), procAccessHelp, []string{"path_class"}, nil)
+	for _, class := range classes {
+		value := 0.0
+		if access[class] {
+			value = 1
+		}
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value, class)
+	}
+}
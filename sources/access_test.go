@@ -0,0 +1,42 @@
+package sources
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestAccessCollectorCheck(t *testing.T) {
+	dir := t.TempDir()
+	for _, path := range []string{"proc/fs/lustre", "sys/fs/lustre", "sys/kernel/debug/lustre"} {
+		if err := os.MkdirAll(filepath.Join(dir, path), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c := NewAccessCollector(Config{ProcLocation: filepath.Join(dir, "proc"), SysLocation: filepath.Join(dir, "sys")})
+	c.root = false
+	debug := filepath.Join(dir, "sys/kernel/debug/lustre")
+	c.readDir = func(path string) error {
+		if path == debug {
+			return &fs.PathError{Op: "open", Path: path, Err: fs.ErrPermission}
+		}
+		return readDirAccess(path)
+	}
+
+	expected := map[string]bool{"proc": true, "sys": true, "debug": false}
+	if access := c.Check(); !reflect.DeepEqual(access, expected) {
+		t.Fatalf("Retrieved an unexpected access. Expected: %v, Got: %v", expected, access)
+	}
+
+	// a missing directory is not an access problem
+	if err := os.RemoveAll(filepath.Join(dir, "sys/kernel")); err != nil {
+		t.Fatal(err)
+	}
+	c.readDir = readDirAccess
+	expected = map[string]bool{"proc": true, "sys": true}
+	if access := c.Check(); !reflect.DeepEqual(access, expected) {
+		t.Fatalf("Retrieved an unexpected access. Expected: %v, Got: %v", expected, access)
+	}
+}
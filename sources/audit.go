@@ -0,0 +1,118 @@
+package sources
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// AuditEntry is the raw value of one single value file (a tunable, or a
+// single number like kbytesfree) as written by --audit.output.
+type AuditEntry struct {
+	Component string `json:"component"`
+	Target    string `json:"target"`
+	Path      string `json:"path"`
+	Value     string `json:"value"`
+}
+
+// AuditTunables reads, in one pass, every single value file the procfs and
+// procsys sources know about, at the extended level whatever the collector
+// flags. Paths are relative to the lustre (or sys) directory, like the lctl
+// parameter names. Files which can't be read are left out.
+func AuditTunables(cfg Config) []AuditEntry {
+	var procfs lustreProcfsSource
+	procfs.basePath = cfg.LustreProcPath()
+	procfs.generateOSTMetricTemplates(extended)
+	procfs.generateMDTMetricTemplates(extended)
+	procfs.generateMGSMetricTemplates(extended)
+	procfs.generateMDSMetricTemplates(extended)
+	procfs.generateClientMetricTemplates(extended)
+	procfs.generateGenericMetricTemplates(extended)
+	procfs.generateLdlmMetricTemplates(extended)
+
+	var procsys lustreProcsysSource
+	procsys.basePath = filepath.Join(cfg.ProcLocation, "sys")
+	procsys.generateLNETTemplates(extended)
+
+	seen := map[string]bool{}
+	var entries []AuditEntry
+	audit := func(basePath string, metrics []lustreProcMetric, withTarget bool) {
+		for _, metric := range metrics {
+			paths, err := filepath.Glob(filepath.Join(basePath, metric.path, metric.filename))
+			if err != nil {
+				continue
+			}
+			for _, path := range paths {
+				if seen[path] {
+					continue
+				}
+				seen[path] = true
+				value, ok := readSingleValue(path)
+				if !ok {
+					continue
+				}
+				entry := AuditEntry{Component: metric.source, Value: value}
+				entry.Path, err = filepath.Rel(basePath, path)
+				if err != nil {
+					entry.Path = path
+				}
+				if withTarget {
+					if _, nodeName, err := parseFileElements(path, strings.Count(metric.filename, "/")); err == nil {
+						entry.Target = nodeName
+					}
+				}
+				entries = append(entries, entry)
+			}
+		}
+	}
+	audit(procfs.basePath, procfs.lustreProcMetrics, true)
+	audit(procsys.basePath, procsys.lustreProcMetrics, false)
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+	return entries
+}
+
+// readSingleValue returns the content of path if it is a single line.
+func readSingleValue(path string) (string, bool) {
+	content, err := readProcFile(path)
+	if err != nil {
+		return "", false
+	}
+	value := strings.TrimSpace(string(content))
+	if value == "" || strings.Contains(value, "\n") {
+		return "", false
+	}
+	return value, true
+}
+
+// WriteAudit writes entries to w as "json" (an array of objects) or "csv"
+// (with a component,target,path,value header).
+func WriteAudit(w io.Writer, format string, entries []AuditEntry) error {
+	switch format {
+	case "json":
+		if entries == nil {
+			entries = []AuditEntry{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	case "csv":
+		cw := csv.NewWriter(w)
+		if err := cw.Write([]string{"component", "target", "path", "value"}); err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if err := cw.Write([]string{entry.Component, entry.Target, entry.Path, entry.Value}); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	default:
+		return fmt.Errorf("unknown audit format %q, valid formats: [json, csv]", format)
+	}
+}
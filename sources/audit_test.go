@@ -0,0 +1,63 @@
+package sources
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestAuditTunables(t *testing.T) {
+	entries := AuditTunables(Config{ProcLocation: "../tests/2.12/proc"})
+
+	byPath := map[string]AuditEntry{}
+	for _, entry := range entries {
+		if _, ok := byPath[entry.Path]; ok {
+			t.Fatalf("Retrieved a duplicated audit entry: %v", entry)
+		}
+		byPath[entry.Path] = entry
+	}
+	for _, expected := range []AuditEntry{
+		{"ost", "lustrefs-OST0000", "obdfilter/lustrefs-OST0000/sync_journal", "0"},
+		{"client", "lustrefs-ffff88105db50000", "llite/lustrefs-ffff88105db50000/blocksize", "1048576"},
+		{"ost", "lustrefs-OST0000", "ldlm/namespaces/filter-lustrefs-OST0000_UUID/lock_timeouts", "7"},
+		{"lnet", "", "lnet/catastrophe", "0"},
+	} {
+		if got, ok := byPath[expected.Path]; !ok || got != expected {
+			t.Fatalf("Retrieved an unexpected audit entry for %s. Expected: %v, Got: %v", expected.Path, expected, got)
+		}
+	}
+	// multi line files are not tunables
+	if entry, ok := byPath["obdfilter/lustrefs-OST0000/stats"]; ok {
+		t.Fatalf("Retrieved an unexpected audit entry: %v", entry)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteAudit(&buf, "json", entries); err != nil {
+		t.Fatal(err)
+	}
+	var decoded []AuditEntry
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded) != len(entries) || decoded[0] != entries[0] {
+		t.Fatalf("Retrieved an unexpected JSON audit. Expected: %d entries, Got: %d", len(entries), len(decoded))
+	}
+
+	buf.Reset()
+	if err := WriteAudit(&buf, "csv", entries); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != len(entries)+1 || strings.Join(records[0], ",") != "component,target,path,value" {
+		t.Fatalf("Retrieved an unexpected CSV audit. Expected: %d records, Got: %d (%v)", len(entries)+1, len(records), records[0])
+	}
+
+	if err := WriteAudit(&buf, "yaml", entries); err == nil {
+		t.Fatal("Expected an unknown format to be rejected")
+	}
+}
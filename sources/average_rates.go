@@ -0,0 +1,53 @@
+package sources
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// EmitAverageRates enables lustre_op_avg_rate, the sample count of the
+// '[usec]' lines of the stats files divided by their elapsed time.
+var EmitAverageRates = false
+
+// the counters are only divided by the time since the last reset, recent
+// changes of the rate are flattened out the longer the stats run
+const opAvgRateHelp string = "Average number of operations per second since the stats of the target were started or last cleared (samples / elapsed_time). This is not a Prometheus rate, use rate() on lustre_stats_total when scrapes are frequent enough."
+
+type opRate struct {
+	operation string
+	rate      float64
+}
+
+// parseAverageRates divides the samples of the lines which carry latencies,
+// {name} {samples} 'samples' [usec] ..., by the age of the stats file. No
+// rate is returned when the file has no elapsed time.
+func parseAverageRates(content string) ([]opRate, error) {
+	elapsed, ok := statsResetAge(content)
+	if !ok || elapsed <= 0 {
+		return nil, nil
+	}
+	var out []opRate
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 || (fields[3] != "[usec]" && fields[3] != "[usecs]") {
+			continue
+		}
+		samples, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, opRate{operation: normalizeOperation(fields[0]), rate: samples / elapsed})
+	}
+	return out, nil
+}
+
+func averageRateMetrics(nodeType string, nodeName string, rates []opRate) []prometheus.Metric {
+	desc := newDesc(nodeType, "op_avg_rate", opAvgRateHelp, []string{"component", "target", "operation"})
+	out := make([]prometheus.Metric, 0, len(rates))
+	for _, r := range rates {
+		out = append(out, prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, r.rate, nodeType, nodeName, r.operation))
+	}
+	return out
+}
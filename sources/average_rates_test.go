@@ -0,0 +1,35 @@
+package sources
+
+import (
+	"os"
+	"testing"
+)
+
+func TestParseAverageRates(t *testing.T) {
+	content, err := os.ReadFile("../tests/2.12/proc/fs/lustre/obdfilter/lustrefs-OST0000/stats")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rates, err := parseAverageRates(string(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// sync: 12 samples, elapsed_time 176905.789180921
+	elapsed := 176905.789180921
+	expected := 12 / elapsed
+	if len(rates) != 1 || rates[0].operation != "sync" || rates[0].rate != expected {
+		t.Fatalf("Retrieved unexpected average rates. Expected: [{sync %g}], Got: %v", expected, rates)
+	}
+
+	// without elapsed_time nor start_time there is nothing to divide by
+	rates, err = parseAverageRates("snapshot_time             1510782606.789180921 secs.nsecs\nsync                      12 samples [usec] 3 1200 4800 2900000\n")
+	if err != nil || rates != nil {
+		t.Fatalf("Retrieved unexpected average rates. Expected: [], Got: %v (%v)", rates, err)
+	}
+
+	// snapshot_time - start_time, aliases normalized, [reqs] lines skipped
+	rates, err = parseAverageRates("snapshot_time 150 secs.nsecs\nstart_time 100 secs.nsecs\ngetinfo 25 samples [usecs] 1 2 3 4\nstatfs 40 samples [reqs]\n")
+	if err != nil || len(rates) != 1 || rates[0].operation != "get_info" || rates[0].rate != 0.5 {
+		t.Fatalf("Retrieved unexpected average rates. Expected: [{get_info 0.5}], Got: %v (%v)", rates, err)
+	}
+}
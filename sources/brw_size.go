@@ -0,0 +1,57 @@
+package sources
+
+import (
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	maxPagesPerRPCHelp    string = "Maximum number of pages the client puts in a single bulk RPC to the OST, negotiated with the OST brw_size on connect"
+	brwSizeConsistentHelp string = "Returns 1 if every OST of the filesystem on this node uses the same brw_size, 0 if some differ"
+)
+
+// brwSizes collects, per filesystem, the distinct brw_size in megabytes of the
+// local OSTs seen in a scrape. The max_pages_per_rpc of the client oscs is
+// reported on its own, it is a per client tunable only capped by brw_size.
+type brwSizes map[string]map[float64]bool
+
+// ostFsName returns the filesystem name of an OST or of one of its oscs, such
+// as 'lustrefs-OST0000' or 'lustrefs-OST0000-osc-ffff88105db50000'.
+func ostFsName(target string) string {
+	if i := strings.LastIndex(target, "-OST"); i > 0 {
+		return target[:i]
+	}
+	return target
+}
+
+func (b brwSizes) add(component string, target string, name string, value float64) {
+	if component != "ost" || name != "brw_size_megabytes" {
+		return
+	}
+	fs := ostFsName(target)
+	if b[fs] == nil {
+		b[fs] = map[float64]bool{}
+	}
+	b[fs][value] = true
+}
+
+func (b brwSizes) consistent(fs string) float64 {
+	if len(b[fs]) == 1 {
+		return 1
+	}
+	return 0
+}
+
+func (b brwSizes) metrics() []prometheus.Metric {
+	out := make([]prometheus.Metric, 0, len(b))
+	for fs := range b {
+		out = append(out, prometheus.MustNewConstMetric(
+			newDesc("ost", "brw_size_consistent", brwSizeConsistentHelp, []string{"fs"}),
+			prometheus.GaugeValue,
+			b.consistent(fs),
+			fs,
+		))
+	}
+	return out
+}
@@ -0,0 +1,37 @@
+package sources
+
+import "testing"
+
+func TestBrwSizeConsistent(t *testing.T) {
+	sizes := brwSizes{}
+	sizes.add("ost", "lustrefs-OST0000", "brw_size_megabytes", 4)
+	sizes.add("ost", "lustrefs-OST0002", "brw_size_megabytes", 4)
+	// the client RPC size is a tunable of its own, not compared
+	sizes.add("client", "lustrefs-OST0000-osc-ffff88105db50000", "max_pages_per_rpc", 1)
+	// scratch has one OST still at 1MB
+	sizes.add("ost", "scratch-OST0000", "brw_size_megabytes", 4)
+	sizes.add("ost", "scratch-OST0001", "brw_size_megabytes", 1)
+	// other metrics of the same files are ignored
+	sizes.add("ost", "scratch-OST0001", "read_bytes_total", 123)
+
+	for fs, expected := range map[string]float64{"lustrefs": 1, "scratch": 0} {
+		if consistent := sizes.consistent(fs); consistent != expected {
+			t.Fatalf("Retrieved an unexpected consistency for %s. Expected: %f, Got: %f (%v)", fs, expected, consistent, sizes[fs])
+		}
+	}
+	if len(sizes.metrics()) != 2 {
+		t.Fatalf("Retrieved an unexpected number of metrics. Expected: %d, Got: %d", 2, len(sizes.metrics()))
+	}
+}
+
+func TestOstFsName(t *testing.T) {
+	for target, expected := range map[string]string{
+		"lustrefs-OST0000":                      "lustrefs",
+		"lustrefs-OST0000-osc-ffff88105db50000": "lustrefs",
+		"my-fs-OST000a-osc-MDT0000":             "my-fs",
+	} {
+		if fs := ostFsName(target); fs != expected {
+			t.Fatalf("Retrieved an unexpected filesystem name for %s. Expected: %s, Got: %s", target, expected, fs)
+		}
+	}
+}
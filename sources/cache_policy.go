@@ -0,0 +1,39 @@
+package sources
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	readCacheEnable         string = "read_cache_enable"
+	writethroughCacheEnable string = "writethrough_cache_enable"
+
+	ostCachePolicyInfoHelp string = "Cache policy of the OST, always 1: the read_cache and writethrough labels tell whether the read cache (read_cache_enable) and the writethrough cache (writethrough_cache_enable) are enabled"
+)
+
+// cachePolicyState maps the content of a '*_cache_enable' file to the value
+// of the matching label of lustre_ost_cache_policy_info.
+func cachePolicyState(content string) (string, error) {
+	switch strings.TrimSpace(content) {
+	case "0":
+		return "disabled", nil
+	case "1":
+		return "enabled", nil
+	}
+	return "", fmt.Errorf("unexpected cache policy flag %q", strings.TrimSpace(content))
+}
+
+// cachePolicyLabels returns the read_cache and writethrough labels from the
+// content of the 'read_cache_enable' and 'writethrough_cache_enable' files.
+func cachePolicyLabels(readCache string, writethrough string) (string, string, error) {
+	readState, err := cachePolicyState(readCache)
+	if err != nil {
+		return "", "", err
+	}
+	writethroughState, err := cachePolicyState(writethrough)
+	if err != nil {
+		return "", "", err
+	}
+	return readState, writethroughState, nil
+}
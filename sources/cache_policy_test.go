@@ -0,0 +1,16 @@
+package sources
+
+import "testing"
+
+func TestCachePolicyLabels(t *testing.T) {
+	readCache, writethrough, err := cachePolicyLabels("1\n", "0\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if readCache != "enabled" || writethrough != "disabled" {
+		t.Fatalf("Retrieved unexpected cache policy labels. Expected: enabled/disabled, Got: %s/%s", readCache, writethrough)
+	}
+	if _, _, err := cachePolicyLabels("1\n", "on\n"); err == nil {
+		t.Fatal("Expected an unknown cache policy flag to be rejected")
+	}
+}
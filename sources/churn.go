@@ -0,0 +1,36 @@
+package sources
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const connectionChurnHelp string = "Total number of connect, reconnect and disconnect requests handled by the target, from its stats. A high rate with a stable number of exports means flapping clients"
+
+// churnOperations are the operations of a 'stats' file counted as connection
+// churn.
+var churnOperations = map[string]bool{"connect": true, "reconnect": true, "disconnect": true}
+
+// connectionChurn sums the connection requests of every target seen in a
+// scrape. Only the targets whose stats have at least one of the operations
+// are reported.
+type connectionChurn map[targetKey]float64
+
+func (c connectionChurn) add(component string, target string, name string, operation string, value float64) {
+	if name != "stats_total" || !churnOperations[operation] || target == "" {
+		return
+	}
+	c[targetKey{component, target}] += value
+}
+
+func (c connectionChurn) metrics() []prometheus.Metric {
+	var out []prometheus.Metric
+	for key, total := range c {
+		out = append(out, prometheus.MustNewConstMetric(
+			newDesc(key.component, "connection_churn_total", connectionChurnHelp, []string{"component", "target"}),
+			prometheus.CounterValue,
+			total,
+			key.component, key.target,
+		))
+	}
+	return out
+}
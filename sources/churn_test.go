@@ -0,0 +1,21 @@
+package sources
+
+import "testing"
+
+func TestConnectionChurn(t *testing.T) {
+	churn := connectionChurn{}
+	churn.add("ost", "lustrefs-OST0000", "stats_total", "connect", 4)
+	churn.add("ost", "lustrefs-OST0000", "stats_total", "disconnect", 3)
+	churn.add("ost", "lustrefs-OST0000", "stats_total", "reconnect", 2)
+	churn.add("ost", "lustrefs-OST0000", "stats_total", "ping", 141)
+	churn.add("ost", "lustrefs-OST0000", "read_bytes_total", "", 4096)
+	churn.add("ost", "lustrefs-OST0002", "stats_total", "statfs", 35)
+
+	key := targetKey{"ost", "lustrefs-OST0000"}
+	if churn[key] != 9 {
+		t.Fatalf("Retrieved an unexpected connection churn. Expected: %d, Got: %f", 9, churn[key])
+	}
+	if _, ok := churn[targetKey{"ost", "lustrefs-OST0002"}]; ok {
+		t.Fatalf("A target without connection requests was counted")
+	}
+}
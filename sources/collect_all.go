@@ -0,0 +1,88 @@
+package sources
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// CollectAll runs one collection of every registered source with the
+// current collector levels and locations, for the programs embedding the
+// exporter without serving HTTP. It returns the metrics of all the sources,
+// including the ones of a failed source, and the first error by source name.
+// It is independent of the HTTP path and of its shelf life, and safe to call
+// concurrently: every call creates its own sources, and the metrics derived
+// from the previous scrapes (frozen counters, ping stalls, job stats resets,
+// write rates, recovery counts, health state ages and unknown lines) are left
+// out, their state belongs to the HTTP path. The locations and levels are the
+// package level variables: set them before the first call and don't change
+// them while a collection runs.
+func CollectAll(ctx context.Context) ([]prometheus.Metric, error) {
+	cfg := NewConfig()
+	cfg.stateless = true
+	list := map[string]LustreSource{}
+	for _, name := range Registered() {
+		s, err := NewSource(name, cfg)
+		if err != nil {
+			return nil, err
+		}
+		list[name] = s
+	}
+	return collectAll(ctx, list)
+}
+
+type collectAllResult struct {
+	name    string
+	metrics []prometheus.Metric
+	err     error
+}
+
+func collectAll(ctx context.Context, list map[string]LustreSource) ([]prometheus.Metric, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	// buffered so the sources still running after a cancellation don't block
+	results := make(chan collectAllResult, len(list))
+	for name, s := range list {
+		go func(name string, s LustreSource) {
+			c := s.newCtx()
+			defer c.release()
+			res := collectAllResult{name: name, err: c.collect()}
+			ch := make(chan prometheus.Metric)
+			done := make(chan struct{})
+			go func() {
+				for m := range ch {
+					res.metrics = append(res.metrics, m)
+				}
+				close(done)
+			}()
+			c.update(ch)
+			close(ch)
+			<-done
+			results <- res
+		}(name, s)
+	}
+
+	out := make([]collectAllResult, 0, len(list))
+	for range list {
+		select {
+		case res := <-results:
+			out = append(out, res)
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].name < out[j].name })
+
+	var metrics []prometheus.Metric
+	var firstErr error
+	for _, res := range out {
+		metrics = append(metrics, res.metrics...)
+		if res.err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("source %s: %w", res.name, res.err)
+		}
+	}
+	return metrics, firstErr
+}
@@ -0,0 +1,142 @@
+package sources
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestCollectAll(t *testing.T) {
+	defer func(lnet string) { LnetEnabled = lnet }(LnetEnabled)
+	LnetEnabled = extended
+
+	dir := t.TempDir()
+	lnetDir := filepath.Join(dir, "sys", "lnet")
+	if err := os.MkdirAll(lnetDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"catastrophe", "fail_err", "lnet_memused"} {
+		if err := os.WriteFile(filepath.Join(lnetDir, name), []byte("0\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	list := map[string]LustreSource{"procsys": newLustreProcSysSource(Config{ProcLocation: dir})}
+
+	metrics, err := collectAll(context.Background(), list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(metrics) != 3 {
+		t.Fatalf("Retrieved an unexpected number of metrics. Expected: %d, Got: %d", 3, len(metrics))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := collectAll(ctx, list); err != context.Canceled {
+		t.Fatalf("Retrieved an unexpected error. Expected: %v, Got: %v", context.Canceled, err)
+	}
+}
+
+func TestCollectAllConcurrent(t *testing.T) {
+	defer func(proc, sys, ost string, frozen, ping int, rates bool) {
+		ProcLocation, SysLocation, OstEnabled = proc, sys, ost
+		FrozenThreshold, PingStallThreshold, ThroughputRates = frozen, ping, rates
+	}(ProcLocation, SysLocation, OstEnabled, FrozenThreshold, PingStallThreshold, ThroughputRates)
+	ProcLocation = "../tests/2.12/proc"
+	SysLocation = "../tests/2.12/sys"
+	OstEnabled = extended
+	FrozenThreshold = 1
+	PingStallThreshold = 1
+	ThroughputRates = true
+	defer func(frozen, ping *frozenDetector, rates *throughputTracker, resets *jobResetTracker) {
+		insFrozenDetector, insPingDetector, insWriteThroughput, insJobResets = frozen, ping, rates, resets
+	}(insFrozenDetector, insPingDetector, insWriteThroughput, insJobResets)
+	insFrozenDetector = &frozenDetector{targets: map[targetKey]*frozenState{}}
+	insPingDetector = &frozenDetector{targets: map[targetKey]*frozenState{}}
+	insWriteThroughput = &throughputTracker{last: map[targetKey]throughputSample{}}
+	insJobResets = &jobResetTracker{last: jobCounters{}, resets: map[targetKey]float64{}}
+
+	var wg sync.WaitGroup
+	counts := make([]int, 2)
+	errs := make([]error, 2)
+	for i := range counts {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			metrics, err := CollectAll(context.Background())
+			counts[i], errs[i] = len(metrics), err
+			for _, m := range metrics {
+				for _, name := range []string{"target_frozen", "ping_stalled", "write_bytes_rate", "jobstats_resets_total"} {
+					if strings.Contains(m.Desc().String(), name) {
+						errs[i] = fmt.Errorf("unexpected metric %s", m.Desc())
+					}
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("collection %d: %v", i, err)
+		}
+	}
+	if counts[0] == 0 || counts[0] != counts[1] {
+		t.Fatalf("Retrieved an unexpected number of metrics. Expected twice the same, Got: %v", counts)
+	}
+
+	// the state of the HTTP path is left as it was
+	if len(insFrozenDetector.targets) != 0 || len(insPingDetector.targets) != 0 || len(insWriteThroughput.last) != 0 || len(insJobResets.last) != 0 {
+		t.Fatal("CollectAll advanced the detectors of the HTTP path")
+	}
+}
+
+// An agent embedding the exporter sets the locations and levels once, then
+// collects on its own schedule, here the degraded state of the OSTs of the
+// 2.12 test data.
+func ExampleCollectAll() {
+	defer func(proc, sys, ost, lnet string) {
+		ProcLocation, SysLocation, OstEnabled, LnetEnabled = proc, sys, ost, lnet
+	}(ProcLocation, SysLocation, OstEnabled, LnetEnabled)
+	ProcLocation = "../tests/2.12/proc"
+	SysLocation = "../tests/2.12/sys"
+	OstEnabled = extended
+	LnetEnabled = core
+
+	metrics, err := CollectAll(context.Background())
+	if err != nil {
+		// the metrics of the other sources are still returned
+		fmt.Println("collection failed:", err)
+	}
+	var degraded []string
+	for _, m := range metrics {
+		if !strings.Contains(m.Desc().String(), `fqName: "lustre_degraded"`) {
+			continue
+		}
+		var d dto.Metric
+		if err := m.Write(&d); err != nil {
+			fmt.Println(err)
+			return
+		}
+		labels := map[string]string{}
+		for _, l := range d.GetLabel() {
+			labels[l.GetName()] = l.GetValue()
+		}
+		if labels["component"] == "ost" {
+			degraded = append(degraded, fmt.Sprintf("%s %.0f", labels["target"], d.GetGauge().GetValue()))
+		}
+	}
+	sort.Strings(degraded)
+	fmt.Println(strings.Join(degraded, "\n"))
+	// Output:
+	// lustrefs-OST0000 0
+	// lustrefs-OST0002 0
+	// lustrefs-OST0004 0
+	// lustrefs-OST0006 0
+}
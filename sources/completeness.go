@@ -0,0 +1,57 @@
+package sources
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const targetCompletenessHelp string = "Fraction of the target's files which were read and parsed successfully in the scrape, below 1 means some of its metrics are missing"
+
+// targetFiles records, per target, whether each of its files could be read
+// and parsed during a scrape. A file read by several templates only counts as
+// successful if all of them succeeded.
+type targetFiles map[targetKey]map[string]bool
+
+func (t targetFiles) observe(component string, target string, path string, ok bool) {
+	key := targetKey{component, target}
+	files, found := t[key]
+	if !found {
+		files = map[string]bool{}
+		t[key] = files
+	}
+	if prev, seen := files[path]; seen {
+		ok = ok && prev
+	}
+	files[path] = ok
+}
+
+func (t targetFiles) completeness(key targetKey) float64 {
+	files := t[key]
+	if len(files) == 0 {
+		return 0
+	}
+	read := 0
+	for _, ok := range files {
+		if ok {
+			read++
+		}
+	}
+	return float64(read) / float64(len(files))
+}
+
+func (t targetFiles) metrics() []prometheus.Metric {
+	out := make([]prometheus.Metric, 0, len(t))
+	for key := range t {
+		out = append(out, prometheus.MustNewConstMetric(
+			prometheus.NewDesc(
+				prometheus.BuildFQName(Namespace, "", "target_metrics_completeness"),
+				targetCompletenessHelp,
+				[]string{"component", "target"},
+				nil,
+			),
+			prometheus.GaugeValue,
+			t.completeness(key),
+			key.component, key.target,
+		))
+	}
+	return out
+}
@@ -0,0 +1,54 @@
+package sources
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTargetCompleteness(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "obdfilter", "lustrefs-OST0000")
+	if err := os.MkdirAll(target, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		"num_exports": "12\n",
+		"tot_dirty":   "not a number\n",
+	} {
+		if err := os.WriteFile(filepath.Join(target, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := &lustreProcfsSource{basePath: dir}
+	s.generateOSTMetricTemplates(core)
+
+	ctx := insProcfsV2.newCtx(s)
+	defer ctx.release()
+	// the partial read is reported by the completeness, not as a failure of
+	// the collector
+	if err := ctx.collect(); err != nil {
+		t.Fatalf("Retrieved an unexpected error for a partial read: %s", err)
+	}
+
+	completeness := ctx.files.completeness(targetKey{"ost", "lustrefs-OST0000"})
+	if completeness != 0.5 {
+		t.Fatalf("Retrieved an unexpected completeness. Expected: %f, Got: %f", 0.5, completeness)
+	}
+}
+
+func TestTargetFilesObserve(t *testing.T) {
+	files := targetFiles{}
+	key := targetKey{"mdt", "lustrefs-MDT0000"}
+
+	// the same file read by two templates, one of them failing
+	files.observe(key.component, key.target, "/proc/fs/lustre/mdt/lustrefs-MDT0000/md_stats", true)
+	files.observe(key.component, key.target, "/proc/fs/lustre/mdt/lustrefs-MDT0000/md_stats", false)
+	files.observe(key.component, key.target, "/proc/fs/lustre/mdt/lustrefs-MDT0000/num_exports", true)
+	files.observe(key.component, key.target, "/proc/fs/lustre/mdt/lustrefs-MDT0000/num_exports", true)
+
+	if completeness := files.completeness(key); completeness != 0.5 {
+		t.Fatalf("Retrieved an unexpected completeness. Expected: %f, Got: %f", 0.5, completeness)
+	}
+}
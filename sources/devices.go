@@ -0,0 +1,57 @@
+package sources
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SkipInactiveTargets drops the metrics of targets which are not active on
+// this node, e.g. the standby targets of a failover pair.
+var SkipInactiveTargets = false
+
+// deviceStates maps a device name to its status ("UP", "ST", "IN", "AT", ...)
+// as listed by the 'devices' file (the one behind 'lctl dl').
+type deviceStates map[string]string
+
+// parseDevices reads lines like "  3 UP obdfilter lustrefs-OST0000 lustrefs-OST0000_UUID 7".
+func parseDevices(content string) deviceStates {
+	states := deviceStates{}
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 || !isDigits(fields[0]) {
+			continue
+		}
+		states[fields[3]] = fields[1]
+	}
+	return states
+}
+
+// readDeviceStates returns the states of the first readable 'devices' file of
+// paths, or nil if none could be read.
+func readDeviceStates(paths ...string) deviceStates {
+	for _, path := range paths {
+		content, err := os.ReadFile(filepath.Clean(path))
+		if err != nil {
+			continue
+		}
+		if states := parseDevices(string(content)); len(states) > 0 {
+			return states
+		}
+	}
+	return nil
+}
+
+// inactive tells if the target should be skipped: it is listed but not UP, or
+// it is an OST/MDT which is not set up on this node at all. Without a device
+// list nothing is skipped.
+func (d deviceStates) inactive(component string, target string) bool {
+	if d == nil {
+		return false
+	}
+	status, ok := d[target]
+	if !ok {
+		return component == "ost" || component == "mdt"
+	}
+	return status != "UP"
+}
@@ -0,0 +1,85 @@
+package sources
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestSkipInactiveTargets(t *testing.T) {
+	dir := t.TempDir()
+	for _, target := range []string{"lustrefs-OST0000", "lustrefs-OST0001", "lustrefs-OST0002"} {
+		path := filepath.Join(dir, "obdfilter", target)
+		if err := os.MkdirAll(path, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(path, "num_exports"), []byte("12\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// OST0001 is stopping, OST0002 is mounted on the failover partner
+	devices := `  0 UP osd-ldiskfs lustrefs-OST0000-osd lustrefs-OST0000-osd_UUID 4
+  1 UP obdfilter lustrefs-OST0000 lustrefs-OST0000_UUID 8
+  2 ST obdfilter lustrefs-OST0001 lustrefs-OST0001_UUID 2
+`
+	if err := os.WriteFile(filepath.Join(dir, "devices"), []byte(devices), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	defer func(prev bool) { SkipInactiveTargets = prev }(SkipInactiveTargets)
+	for _, skip := range []bool{false, true} {
+		SkipInactiveTargets = skip
+
+		s := &lustreProcfsSource{basePath: dir}
+		s.generateOSTMetricTemplates(core)
+		ctx := insProcfsV2.newCtx(s)
+		if err := ctx.collect(); err != nil {
+			t.Fatal(err)
+		}
+		ctx.release()
+
+		expected := map[string]bool{"lustrefs-OST0000": true, "lustrefs-OST0001": !skip, "lustrefs-OST0002": !skip}
+		for target, collected := range expected {
+			if _, ok := ctx.files[targetKey{"ost", target}]; ok != collected {
+				t.Fatalf("Retrieved an unexpected state for %s with skip %t. Expected collected: %t, Got: %t", target, skip, collected, ok)
+			}
+		}
+
+		// the v1 collecting logic skips the same targets
+		ch := make(chan prometheus.Metric, 100)
+		if err := s.Update(ch); err != nil {
+			t.Fatal(err)
+		}
+		close(ch)
+		targets := map[string]bool{}
+		for m := range ch {
+			var d dto.Metric
+			if err := m.Write(&d); err != nil {
+				t.Fatal(err)
+			}
+			for _, l := range d.GetLabel() {
+				if l.GetName() == "target" {
+					targets[l.GetValue()] = true
+				}
+			}
+		}
+		for target, collected := range expected {
+			if targets[target] != collected {
+				t.Fatalf("Retrieved an unexpected v1 state for %s with skip %t. Expected collected: %t, Got: %t", target, skip, collected, targets[target])
+			}
+		}
+	}
+}
+
+func TestDeviceStatesWithoutList(t *testing.T) {
+	var devices deviceStates
+	if devices.inactive("ost", "lustrefs-OST0000") {
+		t.Fatal("Expected no target to be skipped without a device list")
+	}
+	if parseDevices("").inactive("client", "lustrefs-ffff88105db50000") {
+		t.Fatal("Expected unlisted client devices to be kept")
+	}
+}
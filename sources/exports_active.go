@@ -0,0 +1,28 @@
+package sources
+
+import (
+	"os"
+	"strings"
+)
+
+const (
+	exportsDir        string = "exports"
+	exportsActiveHelp string = "Number of clients currently connected to the target, from its 'exports' directory (exports_total is the cumulative count)"
+)
+
+// exportsActive returns the number of client exports of an 'exports'
+// directory: one sub-directory per client NID. Pseudo-files like 'clear' and
+// entries without a NID are not counted.
+func exportsActive(path string) (float64, error) {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return 0, err
+	}
+	var count float64
+	for _, entry := range entries {
+		if entry.IsDir() && strings.Contains(entry.Name(), "@") {
+			count++
+		}
+	}
+	return count, nil
+}
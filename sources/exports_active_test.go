@@ -0,0 +1,18 @@
+package sources
+
+import "testing"
+
+func TestExportsActive(t *testing.T) {
+	// the fixture has 3 client NIDs and the 'clear' pseudo-file
+	count, err := exportsActive("../tests/2.12/proc/fs/lustre/obdfilter/lustrefs-OST0000/exports")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 3 {
+		t.Fatalf("Retrieved an unexpected number of active exports. Expected: 3, Got: %f", count)
+	}
+
+	if _, err := exportsActive("../tests/2.12/proc/fs/lustre/obdfilter/lustrefs-OST0000/nonexistent"); err == nil {
+		t.Fatal("Expected a missing exports directory to fail")
+	}
+}
@@ -0,0 +1,134 @@
+package sources
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"lustre_exporter/log"
+)
+
+const extraParamHelp string = "Value of the Lustre parameter %s, exported through --collector.extra-params"
+
+type extraParam struct {
+	pattern   string
+	promName  string
+	valueType prometheus.ValueType
+}
+
+// ExtraParams are the single value parameters exported on top of the built-in
+// metrics, see ApplyExtraParams.
+var ExtraParams []extraParam
+
+var extraParamNameRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// ApplyExtraParams parses the 'glob=metric_name[:gauge|counter]' specs of
+// --collector.extra-params. The glob is an 'lctl get_param' pattern such as
+// 'osc.*.max_dirty_mb', or a path relative to fs/lustre when it contains a
+// '/'. Nothing is changed if a spec is invalid.
+func ApplyExtraParams(specs []string) error {
+	var params []extraParam
+	for _, spec := range specs {
+		idx := strings.Index(spec, "=")
+		if idx < 1 {
+			return fmt.Errorf("invalid extra param %q, expected glob=metric_name[:gauge|counter]", spec)
+		}
+		param := extraParam{pattern: strings.TrimSpace(spec[:idx]), valueType: prometheus.GaugeValue}
+		name := strings.TrimSpace(spec[idx+1:])
+		if i := strings.LastIndex(name, ":"); i >= 0 {
+			switch name[i+1:] {
+			case "gauge":
+			case "counter":
+				param.valueType = prometheus.CounterValue
+			default:
+				return fmt.Errorf("invalid type %q of extra param %q, valid types: gauge, counter", name[i+1:], spec)
+			}
+			name = name[:i]
+		}
+		if !extraParamNameRegexp.MatchString(name) {
+			return fmt.Errorf("invalid metric name %q of extra param %q", name, spec)
+		}
+		if !strings.Contains(param.pattern, "/") {
+			param.pattern = strings.Replace(param.pattern, ".", "/", -1)
+		}
+		if _, err := filepath.Match(param.pattern, ""); err != nil {
+			return fmt.Errorf("invalid glob of extra param %q: %s", spec, err)
+		}
+		param.promName = name
+		params = append(params, param)
+	}
+	ExtraParams = params
+	return nil
+}
+
+type extraParamValue struct {
+	param  extraParam
+	target string
+	value  float64
+}
+
+// extraParamValues reads the ExtraParams below each of roots, in order, a
+// parameter found below several roots (sys and proc) is only reported once.
+// Files which don't hold a single number are skipped. The target is the
+// directory of the file, empty for the parameters at the top of the root.
+func extraParamValues(roots []string, glob func(string) ([]string, error), readFile func(string) ([]byte, error)) ([]extraParamValue, error) {
+	var out []extraParamValue
+	for _, param := range ExtraParams {
+		seen := map[string]bool{}
+		for _, root := range roots {
+			paths, err := glob(filepath.Join(root, param.pattern))
+			if err != nil {
+				return nil, err
+			}
+			for _, path := range paths {
+				rel, err := filepath.Rel(root, path)
+				if err != nil || seen[rel] {
+					continue
+				}
+				content, err := readFile(path)
+				if err != nil {
+					log.Debugf("skipping extra param %s: %s", path, err)
+					continue
+				}
+				value, err := strconv.ParseFloat(strings.TrimSpace(string(content)), 64)
+				if err != nil {
+					log.Debugf("skipping extra param %s: not a single number", path)
+					continue
+				}
+				seen[rel] = true
+				target := ""
+				if filepath.Dir(rel) != "." {
+					_, target, _ = parseFileElements(path, 0)
+				}
+				out = append(out, extraParamValue{param: param, target: target, value: value})
+			}
+		}
+	}
+	return out, nil
+}
+
+func extraParamMetrics(roots []string, glob func(string) ([]string, error), readFile func(string) ([]byte, error)) ([]prometheus.Metric, error) {
+	values, err := extraParamValues(roots, glob, readFile)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]prometheus.Metric, 0, len(values))
+	for _, v := range values {
+		out = append(out, prometheus.MustNewConstMetric(
+			prometheus.NewDesc(
+				prometheus.BuildFQName(Namespace, "", v.param.promName),
+				fmt.Sprintf(extraParamHelp, v.param.pattern),
+				[]string{"target"},
+				nil,
+			),
+			v.param.valueType,
+			v.value,
+			sanitizeLabels([]string{v.target})...,
+		))
+	}
+	return out, nil
+}
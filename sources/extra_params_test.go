@@ -0,0 +1,65 @@
+package sources
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestExtraParams(t *testing.T) {
+	defer func(prev []extraParam) { ExtraParams = prev }(ExtraParams)
+
+	err := ApplyExtraParams([]string{
+		"osc.*.max_dirty_mb=osc_max_dirty_mb",
+		"obdfilter.*.precreate_batch = ost_precreate_batch:counter",
+		"timeout=obd_timeout_seconds",
+		"obdfilter/*/fstype=ost_fstype", // not a number, skipped
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := []string{"../tests/2.12/sys/fs/lustre", "../tests/2.12/proc/fs/lustre"}
+	values, err := extraParamValues(roots, filepath.Glob, os.ReadFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counts := map[string]int{}
+	for _, v := range values {
+		counts[v.param.promName]++
+		switch v.param.promName {
+		case "osc_max_dirty_mb":
+			if v.value != 32 || v.param.valueType != prometheus.GaugeValue || v.target == "" {
+				t.Fatalf("Retrieved an unexpected osc_max_dirty_mb for %s: %+v", v.target, v)
+			}
+		case "ost_precreate_batch":
+			if v.value != 128 || v.param.valueType != prometheus.CounterValue || v.target == "" {
+				t.Fatalf("Retrieved an unexpected ost_precreate_batch for %s: %+v", v.target, v)
+			}
+		case "obd_timeout_seconds":
+			if v.target != "" {
+				t.Fatalf("Retrieved an unexpected target for a top level param. Expected: %q, Got: %q", "", v.target)
+			}
+		}
+	}
+	expected := map[string]int{"osc_max_dirty_mb": 7, "ost_precreate_batch": 4, "obd_timeout_seconds": 1}
+	for name, count := range expected {
+		if counts[name] != count {
+			t.Fatalf("Retrieved an unexpected number of %s. Expected: %d, Got: %d", name, count, counts[name])
+		}
+	}
+	if len(counts) != len(expected) {
+		t.Fatalf("Retrieved unexpected params: %v", counts)
+	}
+
+	for _, spec := range []string{"osc.*.max_dirty_mb", "=name", "osc.*.max_dirty_mb=max-dirty", "osc.*.max_dirty_mb=name:histogram", "osc.[.max_dirty_mb=name"} {
+		if err := ApplyExtraParams([]string{spec}); err == nil {
+			t.Fatalf("Expected an error for the extra param %q", spec)
+		}
+	}
+	if len(ExtraParams) != 4 {
+		t.Fatal("Extra params were changed by an invalid spec")
+	}
+}
@@ -1,24 +1,160 @@
 package sources
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"sync"
+	"sync/atomic"
 
 	"github.com/gammazero/workerpool"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"lustre_exporter/log"
+)
+
+// MaxFileBytes is the size above which a file is skipped instead of parsed,
+// so a glob matching a debug dump can't exhaust the memory. 0 means no limit.
+var MaxFileBytes int64 = 256 << 20
+
+// binaryProbeBytes is how much of the beginning of a file is checked for NUL
+// bytes, text proc files never have any.
+const binaryProbeBytes = 512
+
+const skippedFilesHelp string = "Total number of files which were not parsed because they are larger than --collector.max-file-bytes (too_large) or look binary (binary)"
+
+var errFileSkipped = errors.New("file skipped")
+
+// PathAllow holds the absolute path globs set by ApplyPathAllow. When not
+// empty, a file is only read if its resolved path, or one of its parent
+// directories, matches one of them.
+var PathAllow []string
+
+var (
+	skippedTooLarge uint64
+	skippedBinary   uint64
 )
 
+// readProcFile reads path like os.ReadFile, unless it is larger than
+// MaxFileBytes or binary, in which case it is logged, counted and an error
+// wrapping errFileSkipped is returned.
+func readProcFile(path string) ([]byte, error) {
+	if !pathAllowed(path) {
+		log.Debugf("skipping %s: not allowed by --collector.path-allow", path)
+		return nil, fmt.Errorf("%w: %s is not allowed", errFileSkipped, path)
+	}
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	// proc files report a size of 0, the only way to know is to read them
+	var r io.Reader = f
+	if MaxFileBytes > 0 {
+		r = io.LimitReader(f, MaxFileBytes+1)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if MaxFileBytes > 0 && int64(len(data)) > MaxFileBytes {
+		atomic.AddUint64(&skippedTooLarge, 1)
+		log.Warnf("skipping %s: larger than %d bytes", path, MaxFileBytes)
+		return nil, fmt.Errorf("%w: %s is larger than %d bytes", errFileSkipped, path, MaxFileBytes)
+	}
+	probe := data
+	if len(probe) > binaryProbeBytes {
+		probe = probe[:binaryProbeBytes]
+	}
+	if bytes.IndexByte(probe, 0) >= 0 {
+		atomic.AddUint64(&skippedBinary, 1)
+		log.Warnf("skipping %s: binary content", path)
+		return nil, fmt.Errorf("%w: %s has binary content", errFileSkipped, path)
+	}
+	return data, nil
+}
+
+// ApplyPathAllow validates the path globs of --collector.path-allow and sets
+// PathAllow. Relative globs are made absolute.
+func ApplyPathAllow(patterns []string) error {
+	allow := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		if pattern == "" {
+			continue
+		}
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("%q: %w", pattern, err)
+		}
+		abs, err := filepath.Abs(pattern)
+		if err != nil {
+			return fmt.Errorf("%q: %w", pattern, err)
+		}
+		allow = append(allow, abs)
+	}
+	PathAllow = allow
+	return nil
+}
+
+// pathAllowed reports whether path may be read according to PathAllow.
+// Symlinks are resolved first, so a link can't lead outside of the allowed
+// subtrees.
+func pathAllowed(path string) bool {
+	if len(PathAllow) == 0 {
+		return true
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		// missing files fail on open anyway
+		resolved = path
+	}
+	resolved, err = filepath.Abs(resolved)
+	if err != nil {
+		return false
+	}
+	for dir := resolved; ; dir = filepath.Dir(dir) {
+		for _, pattern := range PathAllow {
+			if ok, _ := filepath.Match(pattern, dir); ok {
+				return true
+			}
+		}
+		if parent := filepath.Dir(dir); parent == dir {
+			return false
+		}
+	}
+}
+
+func skippedFilesMetrics() []prometheus.Metric {
+	desc := prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "", "skipped_files_total"),
+		skippedFilesHelp,
+		[]string{"reason"},
+		nil,
+	)
+	return []prometheus.Metric{
+		prometheus.MustNewConstMetric(desc, prometheus.CounterValue, float64(atomic.LoadUint64(&skippedTooLarge)), "too_large"),
+		prometheus.MustNewConstMetric(desc, prometheus.CounterValue, float64(atomic.LoadUint64(&skippedBinary)), "binary"),
+	}
+}
+
 type fileReader struct {
 	files              map[string][]byte
+	skipped            map[string]error
 	pathGlobs          map[string][]string
 	pool               *workerpool.WorkerPool
 	mu                 sync.Locker
 	wg                 *sync.WaitGroup
+	// number of files successfully read
+	reads              uint64
 }
 
 func newFileReader() *fileReader{
 	fr := &fileReader{
 		files        : map[string][]byte{},
+		skipped      : map[string]error{},
 		pathGlobs    : map[string][]string{},
 		mu           : &sync.Mutex{},
 		wg           : &sync.WaitGroup{},
@@ -38,11 +174,16 @@ func (fr *fileReader)readFileAsync(path string) {
 
 	fr.wg.Add(1)
 	fn := func() {
-		data, err :=  os.ReadFile(path)
+		data, err :=  fr.read(path)
 		if err == nil {
 			fr.mu.Lock()
 			defer fr.mu.Unlock()
 			fr.files[path] = data
+		} else if errors.Is(err, errFileSkipped) {
+			// remembered so readFile doesn't read and count it again
+			fr.mu.Lock()
+			defer fr.mu.Unlock()
+			fr.skipped[path] = err
 		}
 
 		fr.wg.Done()
@@ -50,6 +191,20 @@ func (fr *fileReader)readFileAsync(path string) {
 	fr.pool.Submit(fn)
 }
 
+// read reads path with readProcFile and counts it when it succeeds.
+func (fr *fileReader)read(path string) ([]byte, error) {
+	data, err := readProcFile(path)
+	if err == nil {
+		atomic.AddUint64(&fr.reads, 1)
+	}
+	return data, err
+}
+
+// filesRead returns the number of files fr read.
+func (fr *fileReader)filesRead() uint64 {
+	return atomic.LoadUint64(&fr.reads)
+}
+
 func (fr *fileReader)wait(release ...bool) {
 	fr.wg.Wait()
 
@@ -69,9 +224,15 @@ func (fr *fileReader)readFile(path string) ([]byte, error) {
 	if ok && data != nil{
 		return data, nil
 	}
+	if err, ok := fr.skipped[path]; ok {
+		return nil, err
+	}
 
-	data, err := os.ReadFile(path)
+	data, err := fr.read(path)
 	if err != nil {
+		if errors.Is(err, errFileSkipped) {
+			fr.skipped[path] = err
+		}
 		return data, err
 	}
 
@@ -85,7 +246,7 @@ func (fr *fileReader)glob(path string, read...bool) (paths []string, err error)
 
 	paths, ok := fr.pathGlobs[path]
 	if !ok {
-		paths, err = filepath.Glob(path)
+		paths, err = globPaths(path)
 		if err != nil {
 			return nil, err
 		}
@@ -93,18 +254,31 @@ func (fr *fileReader)glob(path string, read...bool) (paths []string, err error)
 	}
 
 	if len(read) > 0 && read[0] {
-		for _, path := range paths {
-			if _, ok := fr.files[path]; !ok {
-				fr.files[path] = nil
-				fr.readFileAsync(path)
-			}
-		}
+		fr.prefetchLocked(paths)
 	}
 
 	return paths, nil
 }
 
+// prefetch starts reading the paths not read yet in the background.
+func (fr *fileReader)prefetch(paths []string) {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+
+	fr.prefetchLocked(paths)
+}
+
+func (fr *fileReader)prefetchLocked(paths []string) {
+	for _, path := range paths {
+		if _, ok := fr.files[path]; !ok {
+			fr.files[path] = nil
+			fr.readFileAsync(path)
+		}
+	}
+}
+
 func (fr *fileReader)release(){
 	fr.files = nil
+	fr.skipped = nil
 	fr.pathGlobs = nil
 }
\ No newline at end of file
@@ -0,0 +1,102 @@
+package sources
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+func TestReadProcFileSkipsOversized(t *testing.T) {
+	defer func(prev int64) { MaxFileBytes = prev }(MaxFileBytes)
+	MaxFileBytes = 1024
+
+	oversized := "../tests/2.12/proc/fs/lustre/obdfilter/lustrefs-OST0000/brw_stats"
+	before := atomic.LoadUint64(&skippedTooLarge)
+	if _, err := readProcFile(oversized); !errors.Is(err, errFileSkipped) {
+		t.Fatalf("Expected %s to be skipped, Got: %v", oversized, err)
+	}
+	if n := atomic.LoadUint64(&skippedTooLarge) - before; n != 1 {
+		t.Fatalf("Retrieved an unexpected number of skipped files. Expected: %d, Got: %d", 1, n)
+	}
+
+	// the v2 reader remembers the skip instead of reading the file again
+	fr := newFileReader()
+	defer fr.release()
+	if _, err := fr.glob(oversized, true); err != nil {
+		t.Fatal(err)
+	}
+	fr.wait(true)
+	for i := 0; i < 2; i++ {
+		if _, err := fr.readFile(oversized); !errors.Is(err, errFileSkipped) {
+			t.Fatalf("Expected %s to be skipped, Got: %v", oversized, err)
+		}
+	}
+	if n := atomic.LoadUint64(&skippedTooLarge) - before; n != 2 {
+		t.Fatalf("Retrieved an unexpected number of skipped files. Expected: %d, Got: %d", 2, n)
+	}
+
+	if data, err := readProcFile("../tests/2.12/proc/fs/lustre/obdfilter/lustrefs-OST0000/brw_size"); err != nil || len(data) == 0 {
+		t.Fatalf("Expected a small file to be read, Got: %q (%v)", data, err)
+	}
+
+	MaxFileBytes = 0
+	if _, err := readProcFile(oversized); err != nil {
+		t.Fatalf("Expected no limit with MaxFileBytes = 0, Got: %v", err)
+	}
+}
+
+func TestReadProcFileSkipsBinary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dump")
+	if err := os.WriteFile(path, []byte("LUSTRE\x00\x01\x02"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	before := atomic.LoadUint64(&skippedBinary)
+	if _, err := readProcFile(path); !errors.Is(err, errFileSkipped) {
+		t.Fatalf("Expected %s to be skipped, Got: %v", path, err)
+	}
+	if n := atomic.LoadUint64(&skippedBinary) - before; n != 1 {
+		t.Fatalf("Retrieved an unexpected number of skipped files. Expected: %d, Got: %d", 1, n)
+	}
+}
+
+func TestReadProcFilePathAllow(t *testing.T) {
+	defer func(prev []string) { PathAllow = prev }(PathAllow)
+	base := "../tests/2.12/proc/fs/lustre/"
+	if err := ApplyPathAllow([]string{base + "obdfilter/*"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if data, err := readProcFile(base + "obdfilter/lustrefs-OST0000/brw_size"); err != nil || len(data) == 0 {
+		t.Fatalf("Expected a file of the allowed subtree to be read, Got: %q (%v)", data, err)
+	}
+	for _, path := range []string{
+		base + "mdt/lustrefs-MDT0000/recovery_status",
+		base + "llite/lustrefs-ffff88105db50000/blocksize",
+		// a link out of the allowed subtree (to osd-zfs) is not followed
+		base + "obdfilter/lustrefs-OST0000/brw_stats",
+	} {
+		if _, err := readProcFile(path); !errors.Is(err, errFileSkipped) {
+			t.Fatalf("Expected %s to be skipped, Got: %v", path, err)
+		}
+	}
+
+	// the v2 reader skips them as well
+	fr := newFileReader()
+	defer fr.release()
+	if _, err := fr.glob(base+"mdt/*/recovery_status", true); err != nil {
+		t.Fatal(err)
+	}
+	fr.wait(true)
+	if _, err := fr.readFile(base + "mdt/lustrefs-MDT0000/recovery_status"); !errors.Is(err, errFileSkipped) {
+		t.Fatalf("Expected the MDT recovery_status to be skipped, Got: %v", err)
+	}
+
+	if err := ApplyPathAllow([]string{"[oops"}); err == nil {
+		t.Fatal("Expected an invalid glob to be rejected")
+	}
+	if err := ApplyPathAllow(nil); err != nil || !pathAllowed(base+"llite/lustrefs-ffff88105db50000/blocksize") {
+		t.Fatalf("Expected every path to be allowed without globs, Got: %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+package sources
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const filesReadHelp string = "Total number of proc and sys files read by the collector, its file read footprint (v2 only)"
+
+// filesReadCounter is implemented by the collector contexts reading through a
+// fileReader, it returns how many files the context read.
+type filesReadCounter interface {
+	filesRead() uint64
+}
+
+// filesReadTotals holds the number of files read by every collector since
+// the exporter started.
+type filesReadTotals struct {
+	mu     sync.Mutex
+	totals map[string]uint64
+}
+
+var insFilesRead = &filesReadTotals{
+	totals: map[string]uint64{},
+}
+
+func (f *filesReadTotals) add(collector string, n uint64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.totals[collector] += n
+}
+
+func (f *filesReadTotals) metrics() []prometheus.Metric {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	collectors := make([]string, 0, len(f.totals))
+	for collector := range f.totals {
+		collectors = append(collectors, collector)
+	}
+	sort.Strings(collectors)
+	out := make([]prometheus.Metric, 0, len(collectors))
+	for _, collector := range collectors {
+		out = append(out, prometheus.MustNewConstMetric(
+			prometheus.NewDesc(
+				prometheus.BuildFQName(Namespace, "", "collector_files_read_total"),
+				filesReadHelp,
+				[]string{"collector"},
+				nil,
+			),
+			prometheus.CounterValue,
+			float64(f.totals[collector]),
+			collector,
+		))
+	}
+	return out
+}
@@ -0,0 +1,167 @@
+package sources
+
+import (
+	"encoding/binary"
+	"hash"
+	"hash/fnv"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// FrozenThreshold is the number of consecutive scrapes a target's stats must
+// stay byte-for-byte identical, while other targets are moving, before it is
+// reported as frozen. 0 disables the detection.
+var FrozenThreshold = 0
+
+// PingStallThreshold is the number of consecutive scrapes the ping counter of
+// a target must stay the same, while the ones of other targets advance,
+// before it is reported as stalled. 0 disables the detection.
+var PingStallThreshold = 0
+
+// ScrapeInterval is the interval the exporter is expected to be scraped at,
+// 0 when unknown. When set, the frozen and stalled detectors wait for
+// threshold * ScrapeInterval without change instead of counting scrapes, so
+// extra scrapes (several Prometheus, manual curls) don't shorten the delay.
+var ScrapeInterval time.Duration
+
+const (
+	targetFrozenHelp      string = "Returns 1 if the target's stats counters have not changed for --collector.frozen-threshold scrapes while other targets did"
+	targetPingStalledHelp string = "Returns 1 if the target's ping counter has not advanced for --collector.ping-stall-threshold scrapes while the ones of other targets did, which can indicate a wedged export"
+)
+
+type targetKey struct {
+	component string
+	target    string
+}
+
+type frozenState struct {
+	hash      uint64
+	unchanged int
+	changed   time.Time
+}
+
+// frozenDetector keeps the per-target hash history across scrapes.
+type frozenDetector struct {
+	mu      sync.Mutex
+	targets map[targetKey]*frozenState
+}
+
+var insFrozenDetector = &frozenDetector{
+	targets: map[targetKey]*frozenState{},
+}
+
+// insPingDetector runs the same state machine on the ping counters only.
+var insPingDetector = &frozenDetector{
+	targets: map[targetKey]*frozenState{},
+}
+
+// observe records the hashes of one scrape and returns, for every target of
+// that scrape, whether it is considered frozen.
+func (d *frozenDetector) observe(hashes map[targetKey]uint64, threshold int) map[targetKey]bool {
+	return d.observeAt(hashes, threshold, time.Now())
+}
+
+func (d *frozenDetector) observeAt(hashes map[targetKey]uint64, threshold int, now time.Time) map[targetKey]bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	active := false
+	for key, sum := range hashes {
+		state, ok := d.targets[key]
+		if !ok {
+			d.targets[key] = &frozenState{hash: sum, changed: now}
+			continue
+		}
+		if state.hash == sum {
+			state.unchanged++
+		} else {
+			state.hash = sum
+			state.unchanged = 0
+			state.changed = now
+			active = true
+		}
+	}
+
+	// forget targets which went away (unmounted, failed over, ...)
+	for key := range d.targets {
+		if _, ok := hashes[key]; !ok {
+			delete(d.targets, key)
+		}
+	}
+
+	out := make(map[targetKey]bool, len(hashes))
+	for key := range hashes {
+		out[key] = active && d.targets[key].stale(threshold, now)
+	}
+	return out
+}
+
+func (state *frozenState) stale(threshold int, now time.Time) bool {
+	if ScrapeInterval > 0 {
+		return now.Sub(state.changed) >= time.Duration(threshold)*ScrapeInterval
+	}
+	return state.unchanged >= threshold
+}
+
+// targetHasher accumulates the stats values of every target seen in a scrape.
+type targetHasher map[targetKey]hash.Hash64
+
+func (th targetHasher) add(component string, target string, name string, labelValues []string, value float64) {
+	h, ok := th[targetKey{component, target}]
+	if !ok {
+		h = fnv.New64a()
+		th[targetKey{component, target}] = h
+	}
+	h.Write([]byte(name))
+	for _, v := range labelValues {
+		h.Write([]byte(v))
+	}
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], math.Float64bits(value))
+	h.Write(buf[:])
+}
+
+func (th targetHasher) sums() map[targetKey]uint64 {
+	out := make(map[targetKey]uint64, len(th))
+	for key, h := range th {
+		out[key] = h.Sum64()
+	}
+	return out
+}
+
+func frozenMetric(key targetKey, frozen bool) prometheus.Metric {
+	value := 0.0
+	if frozen {
+		value = 1
+	}
+	return prometheus.MustNewConstMetric(
+		newDesc(key.component, "target_frozen", targetFrozenHelp, []string{"component", "target"}),
+		prometheus.GaugeValue,
+		value,
+		key.component, key.target,
+	)
+}
+
+// pingValues keeps the ping counter of every target seen in a scrape, as the
+// bits of the value so it can be fed to a frozenDetector.
+type pingValues map[targetKey]uint64
+
+func (p pingValues) add(component string, target string, value float64) {
+	p[targetKey{component, target}] = math.Float64bits(value)
+}
+
+func pingStalledMetric(key targetKey, stalled bool) prometheus.Metric {
+	value := 0.0
+	if stalled {
+		value = 1
+	}
+	return prometheus.MustNewConstMetric(
+		newDesc(key.component, "target_ping_stalled", targetPingStalledHelp, []string{"component", "target"}),
+		prometheus.GaugeValue,
+		value,
+		key.component, key.target,
+	)
+}
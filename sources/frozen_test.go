@@ -0,0 +1,87 @@
+package sources
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFrozenDetector(t *testing.T) {
+	d := &frozenDetector{targets: map[targetKey]*frozenState{}}
+	ost0 := targetKey{"ost", "lustrefs-OST0000"}
+	ost1 := targetKey{"ost", "lustrefs-OST0001"}
+	threshold := 2
+
+	scrape := func(v0 float64, v1 float64) map[targetKey]bool {
+		th := targetHasher{}
+		th.add(ost0.component, ost0.target, "stats_total", []string{"ping"}, v0)
+		th.add(ost1.component, ost1.target, "stats_total", []string{"ping"}, v1)
+		return d.observe(th.sums(), threshold)
+	}
+
+	// OST0000 stays identical while OST0001 keeps moving
+	expected := []bool{false, false, true, true, true}
+	for i, want := range expected {
+		frozen := scrape(10, float64(i))
+		if frozen[ost0] != want {
+			t.Fatalf("scrape %d: unexpected frozen state for %s. Expected: %v, Got: %v", i, ost0.target, want, frozen[ost0])
+		}
+		if frozen[ost1] {
+			t.Fatalf("scrape %d: %s is changing but reported as frozen", i, ost1.target)
+		}
+	}
+
+	// an idle cluster is not a frozen one
+	if frozen := scrape(10, 4); frozen[ost0] || frozen[ost1] {
+		t.Fatalf("Targets reported as frozen on an idle cluster: %v", frozen)
+	}
+
+	// a change resets the history
+	if frozen := scrape(11, 5); frozen[ost0] {
+		t.Fatalf("%s reported as frozen right after its stats changed", ost0.target)
+	}
+}
+
+func TestPingStalled(t *testing.T) {
+	d := &frozenDetector{targets: map[targetKey]*frozenState{}}
+	mdt0 := targetKey{"mdt", "lustrefs-MDT0000"}
+	ost0 := targetKey{"ost", "lustrefs-OST0000"}
+	threshold := 3
+
+	// the MDT keeps being pinged, the OST ping counter is stuck at 141
+	for i := 0; i < 5; i++ {
+		pings := pingValues{}
+		pings.add(mdt0.component, mdt0.target, float64(645+i))
+		pings.add(ost0.component, ost0.target, 141)
+
+		stalled := d.observe(pings, threshold)
+		if want := i >= threshold; stalled[ost0] != want {
+			t.Fatalf("scrape %d: unexpected stalled state for %s. Expected: %v, Got: %v", i, ost0.target, want, stalled[ost0])
+		}
+		if stalled[mdt0] {
+			t.Fatalf("scrape %d: %s is pinged but reported as stalled", i, mdt0.target)
+		}
+	}
+}
+
+func TestFrozenDetectorScrapeInterval(t *testing.T) {
+	defer func(prev time.Duration) { ScrapeInterval = prev }(ScrapeInterval)
+	ScrapeInterval = 30 * time.Second
+
+	d := &frozenDetector{targets: map[targetKey]*frozenState{}}
+	ost0 := targetKey{"ost", "lustrefs-OST0000"}
+	ost1 := targetKey{"ost", "lustrefs-OST0001"}
+	threshold := 2
+	start := time.Unix(1510782606, 0)
+
+	// two Prometheus scraping every 30s: 4 scrapes per minute, OST0000 must
+	// only be frozen once it has not changed for 2 * 30s
+	for i, want := range []bool{false, false, false, false, true, true} {
+		pings := pingValues{}
+		pings.add(ost0.component, ost0.target, 10)
+		pings.add(ost1.component, ost1.target, float64(i))
+		frozen := d.observeAt(pings, threshold, start.Add(time.Duration(i)*15*time.Second))
+		if frozen[ost0] != want {
+			t.Fatalf("scrape %d: unexpected frozen state for %s. Expected: %v, Got: %v", i, ost0.target, want, frozen[ost0])
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package sources
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// GrantExhaustionThreshold is the ratio of the space granted to the clients
+// (tot_granted) to the space available on the OST above which the grant is
+// reported as exhausted.
+var GrantExhaustionThreshold = 0.95
+
+const grantExhaustedHelp string = "Binary indicator as to whether the space granted to the clients (tot_granted) reached --collector.grant-exhaustion-threshold of the space available on the OST (kbytesavail) - 1 when the clients are about to be throttled to synchronous writes"
+
+type ostGrant struct {
+	granted    float64
+	available  float64
+	hasGranted bool
+	hasAvail   bool
+}
+
+// ostGrants collects the granted and available space of every local OST seen
+// in a scrape. The available space is read under both osd-* and obdfilter,
+// the smallest value is kept.
+type ostGrants map[string]*ostGrant
+
+func (o ostGrants) add(component string, target string, name string, value float64) {
+	if component != "ost" || (name != "exports_granted_total" && name != "available_kilobytes") {
+		return
+	}
+	grant, ok := o[target]
+	if !ok {
+		grant = &ostGrant{}
+		o[target] = grant
+	}
+	if name == "exports_granted_total" {
+		grant.granted = value
+		grant.hasGranted = true
+	} else if !grant.hasAvail || value < grant.available {
+		grant.available = value
+		grant.hasAvail = true
+	}
+}
+
+// grantExhausted returns whether granted bytes reached threshold of the
+// available kilobytes. Lustre grants no more than the space left, so this is
+// the limit tot_granted approaches.
+func grantExhausted(granted float64, availableKilobytes float64, threshold float64) bool {
+	limit := availableKilobytes * 1024
+	if limit <= 0 {
+		return granted > 0
+	}
+	return granted/limit >= threshold
+}
+
+func (o ostGrants) metrics() []prometheus.Metric {
+	var out []prometheus.Metric
+	for target, grant := range o {
+		if !grant.hasGranted || !grant.hasAvail {
+			continue
+		}
+		exhausted := 0.0
+		if grantExhausted(grant.granted, grant.available, GrantExhaustionThreshold) {
+			exhausted = 1
+		}
+		out = append(out, prometheus.MustNewConstMetric(
+			newDesc("ost", "grant_exhausted", grantExhaustedHelp, []string{"component", "target"}),
+			prometheus.GaugeValue,
+			exhausted,
+			"ost",
+			target,
+		))
+	}
+	return out
+}
@@ -0,0 +1,36 @@
+package sources
+
+import (
+	"testing"
+)
+
+func TestGrantExhausted(t *testing.T) {
+	for _, tc := range []struct {
+		granted   float64
+		available float64
+		expected  bool
+	}{
+		// 1000 KiB available, the threshold is at 950 KiB granted
+		{950 * 1024, 1000, true},
+		{949.9 * 1024, 1000, false},
+		{2000 * 1024, 1000, true},
+		{0, 1000, false},
+		// a full OST can't grant anything more
+		{4096, 0, true},
+		{0, 0, false},
+	} {
+		if got := grantExhausted(tc.granted, tc.available, 0.95); got != tc.expected {
+			t.Fatalf("Retrieved an unexpected grant exhaustion for %f granted bytes of %f available kilobytes. Expected: %t, Got: %t", tc.granted, tc.available, tc.expected, got)
+		}
+	}
+
+	grants := ostGrants{}
+	grants.add("ost", "lustrefs-OST0000", "exports_granted_total", 950*1024)
+	grants.add("ost", "lustrefs-OST0000", "available_kilobytes", 1200)
+	// obdfilter reports less than the osd, the smallest one counts
+	grants.add("ost", "lustrefs-OST0000", "available_kilobytes", 1000)
+	grants.add("mdt", "lustrefs-MDT0000", "available_kilobytes", 1)
+	if len(grants) != 1 || grants["lustrefs-OST0000"].available != 1000 {
+		t.Fatalf("Retrieved unexpected grants. Expected: lustrefs-OST0000 with 1000 available, Got: %v", grants)
+	}
+}
@@ -0,0 +1,61 @@
+package sources
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// HealthStateAge enables lustre_health_state_age_seconds, for how long the
+// health_check and degraded signals have held their current value.
+var HealthStateAge = false
+
+const healthStateAgeHelp string = "Number of seconds the health signal (health_check, degraded) of the target has held its current value. Tracked by the exporter, a value held since before it started counts from its first scrape"
+
+type heldState struct {
+	value float64
+	since time.Time
+}
+
+// stateAgeTracker remembers when every signal was first seen with its
+// current value.
+type stateAgeTracker struct {
+	mu     sync.Mutex
+	states map[string]*heldState
+}
+
+var insHealthStateAge = &stateAgeTracker{
+	states: map[string]*heldState{},
+}
+
+// observe records the value of a signal and returns the number of seconds it
+// has held it. A new signal or a change of value starts again from 0.
+func (t *stateAgeTracker) observe(key string, value float64, now time.Time) float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state, ok := t.states[key]
+	if !ok || state.value != value {
+		state = &heldState{value: value, since: now}
+		t.states[key] = state
+	}
+	return now.Sub(state.since).Seconds()
+}
+
+// stateAgeMetricFunc returns a metric function emitting, instead of the value
+// of the signal, the number of seconds the signal of these labels has held
+// it.
+func stateAgeMetricFunc(metricFunc prometheusType, tracker *stateAgeTracker) prometheusType {
+	return func(labels []string, labelValues []string, name string, helpText string, value float64) prometheus.Metric {
+		age := tracker.observe(strings.Join(labelValues, "\x00"), value, time.Now())
+		return metricFunc(labels, labelValues, name, helpText, age)
+	}
+}
+
+// healthStateAgeTemplate is the template of the age of the binary signal
+// read from filename.
+func healthStateAgeTemplate(filename string, metricFunc prometheusType) lustreHelpStruct {
+	return lustreHelpStruct{filename, "health_state_age_seconds", healthStateAgeHelp, stateAgeMetricFunc(metricFunc, insHealthStateAge), false, core}
+}
@@ -0,0 +1,46 @@
+package sources
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestHealthStateAge(t *testing.T) {
+	tracker := &stateAgeTracker{states: map[string]*heldState{}}
+	start := time.Unix(1700000000, 0)
+
+	// degraded steady at 0 across three scrapes, then degraded
+	for i, tc := range []struct {
+		value    float64
+		at       time.Duration
+		expected float64
+	}{
+		{0, 0, 0},
+		{0, 30 * time.Second, 30},
+		{0, 90 * time.Second, 90},
+		{1, 120 * time.Second, 0},
+		{1, 2*time.Hour + 120*time.Second, 7200},
+	} {
+		age := tracker.observe("ost\x00lustrefs-OST0000", tc.value, start.Add(tc.at))
+		if age != tc.expected {
+			t.Fatalf("Retrieved an unexpected age at scrape %d. Expected: %f, Got: %f", i, tc.expected, age)
+		}
+	}
+
+	// the other targets have their own state
+	if age := tracker.observe("ost\x00lustrefs-OST0002", 0, start.Add(3*time.Hour)); age != 0 {
+		t.Fatalf("Retrieved an unexpected age for a new target. Expected: 0, Got: %f", age)
+	}
+
+	var got float64
+	capture := func(labels []string, labelValues []string, name string, helpText string, value float64) prometheus.Metric {
+		got = value
+		return nil
+	}
+	stateAgeMetricFunc(capture, tracker)([]string{"component", "target"}, []string{"health", "lustre"}, "health_state_age_seconds", healthStateAgeHelp, 1)
+	if got != 0 {
+		t.Fatalf("Retrieved an unexpected age for a first scrape. Expected: 0, Got: %f", got)
+	}
+}
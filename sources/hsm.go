@@ -0,0 +1,96 @@
+package sources
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	hsmActions        string = "hsm/actions"
+	hsmActiveRequests string = "hsm/active_requests"
+
+	hsmActiveRequestsName  string = "hsm_active_requests"
+	hsmWaitingRequestsName string = "hsm_waiting_requests"
+	hsmRequestsName        string = "hsm_requests"
+
+	hsmActiveRequestsHelp  string = "Number of HSM requests of the coordinator currently handled by a copytool (hsm/active_requests)"
+	hsmWaitingRequestsHelp string = "Number of HSM requests of the coordinator waiting for a copytool, a growing value means archiving is falling behind (hsm/actions)"
+	hsmRequestsHelp        string = "Number of HSM requests in the coordinator action list by action (archive, restore, remove, cancel) and status (hsm/actions)"
+)
+
+func hsmTemplates(s *lustreProcfsSource) []lustreHelpStruct {
+	return []lustreHelpStruct{
+		{hsmActiveRequests, hsmActiveRequestsName, hsmActiveRequestsHelp, s.gaugeMetric, false, core},
+		{hsmActions, hsmWaitingRequestsName, hsmWaitingRequestsHelp, s.gaugeMetric, false, core},
+		{hsmActions, hsmRequestsName, hsmRequestsHelp, s.gaugeMetric, false, core},
+	}
+}
+
+// hsmCount is a value of an HSM metric, action and status are only set for
+// hsm_requests.
+type hsmCount struct {
+	action string
+	status string
+	value  float64
+}
+
+// parseHsmRequest returns the lowercased action and status of a line of the
+// 'actions' or 'active_requests' listing:
+//
+//	lrh=[type=10680000 len=136 idx=1/3] fid=[0x200000400:0x1:0x0] ... action=ARCHIVE archive#=1 flags=0x0 ... status=WAITING data=[]
+func parseHsmRequest(line string) (action string, status string, err error) {
+	for _, field := range strings.Fields(line) {
+		switch {
+		case strings.HasPrefix(field, "action="):
+			action = strings.ToLower(strings.TrimPrefix(field, "action="))
+		case strings.HasPrefix(field, "status="):
+			status = strings.ToLower(strings.TrimPrefix(field, "status="))
+		}
+	}
+	if action == "" {
+		return "", "", fmt.Errorf("HSM request without an action: %q", line)
+	}
+	return action, status, nil
+}
+
+// hsmValues returns the values of the HSM metric promName from the content of
+// its file.
+func hsmValues(promName string, content string) ([]hsmCount, error) {
+	var requests int
+	counts := map[[2]string]float64{}
+	for _, line := range strings.Split(content, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		action, status, err := parseHsmRequest(line)
+		if err != nil {
+			return nil, err
+		}
+		switch promName {
+		case hsmActiveRequestsName:
+			requests++
+		case hsmWaitingRequestsName:
+			if status == "waiting" {
+				requests++
+			}
+		default:
+			counts[[2]string{action, status}]++
+		}
+	}
+	if promName != hsmRequestsName {
+		return []hsmCount{{value: float64(requests)}}, nil
+	}
+
+	out := make([]hsmCount, 0, len(counts))
+	for key, value := range counts {
+		out = append(out, hsmCount{action: key[0], status: key[1], value: value})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].action != out[j].action {
+			return out[i].action < out[j].action
+		}
+		return out[i].status < out[j].status
+	})
+	return out, nil
+}
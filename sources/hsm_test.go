@@ -0,0 +1,48 @@
+package sources
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestHsm(t *testing.T) {
+	s := &lustreProcfsSource{}
+	got := map[string][]hsmCount{}
+	for _, template := range hsmTemplates(s) {
+		path := filepath.Join("../tests/2.12/proc/fs/lustre/mdt/lustrefs-MDT0000", template.filename)
+		err := s.parseHsm("mdt", path, strings.Count(template.filename, "/"), template.helpText, template.promName, func(nodeType string, nodeName string, action string, status string, name string, helpText string, value float64) {
+			if nodeName != "lustrefs-MDT0000" {
+				t.Fatalf("Retrieved an unexpected target. Expected: %s, Got: %s", "lustrefs-MDT0000", nodeName)
+			}
+			got[name] = append(got[name], hsmCount{action: action, status: status, value: value})
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected := map[string][]hsmCount{
+		hsmActiveRequestsName:  {{value: 2}},
+		hsmWaitingRequestsName: {{value: 2}},
+		hsmRequestsName: {
+			{action: "archive", status: "failed", value: 1},
+			{action: "archive", status: "started", value: 1},
+			{action: "archive", status: "waiting", value: 2},
+			{action: "remove", status: "succeed", value: 1},
+			{action: "restore", status: "started", value: 1},
+		},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Retrieved unexpected HSM metrics. Expected: %v, Got: %v", expected, got)
+	}
+
+	// an idle coordinator has empty listings
+	counts, err := hsmValues(hsmWaitingRequestsName, "")
+	if err != nil || len(counts) != 1 || counts[0].value != 0 {
+		t.Fatalf("Retrieved unexpected HSM metrics for an empty listing: %v (%v)", counts, err)
+	}
+	if _, err := hsmValues(hsmRequestsName, "fid=[0x200000400:0x1:0x0] status=WAITING\n"); err == nil {
+		t.Fatal("Expected an HSM request without an action to be rejected")
+	}
+}
@@ -0,0 +1,77 @@
+package sources
+
+import (
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// IOTimeHistogram enables the histogram form of the brw_stats "I/O time"
+// section, next to the per-bucket io_time_milliseconds_total counters.
+var IOTimeHistogram = false
+
+// Lustre only keeps the number of I/Os per time bucket, not the time spent,
+// so the _sum of the histogram is unknown.
+const ioTimeHistogramHelp string = "Distribution of the service time of the disk I/Os in milliseconds, from the brw_stats 'I/O time' section since the stats were last cleared. The sum is not recorded by Lustre and is reported as NaN."
+
+type ioTimeHistogram struct {
+	operation string
+	count     uint64
+	buckets   map[float64]uint64
+}
+
+// ioTimeHistograms turns the rows of the brw_stats "I/O time" block into one
+// histogram per operation. The rows count the I/Os of each bucket, whose
+// label is its upper bound in milliseconds (1K being 1024 ms), the histogram
+// buckets are cumulative.
+func ioTimeHistograms(block string) ([]ioTimeHistogram, error) {
+	rows, err := splitBRWStats(block)
+	if err != nil {
+		return nil, err
+	}
+	counts := map[string]map[float64]uint64{}
+	var operations []string
+	for _, row := range rows {
+		bound, err := strconv.ParseFloat(convertToBytes(row.size), 64)
+		if err != nil {
+			return nil, err
+		}
+		value, err := strconv.ParseUint(row.value, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := counts[row.operation]; !ok {
+			counts[row.operation] = map[float64]uint64{}
+			operations = append(operations, row.operation)
+		}
+		counts[row.operation][bound] += value
+	}
+
+	out := make([]ioTimeHistogram, 0, len(operations))
+	for _, operation := range operations {
+		bounds := make([]float64, 0, len(counts[operation]))
+		for bound := range counts[operation] {
+			bounds = append(bounds, bound)
+		}
+		sort.Float64s(bounds)
+		h := ioTimeHistogram{operation: operation, buckets: make(map[float64]uint64, len(bounds))}
+		for _, bound := range bounds {
+			h.count += counts[operation][bound]
+			h.buckets[bound] = h.count
+		}
+		out = append(out, h)
+	}
+	return out, nil
+}
+
+func ioTimeHistogramMetric(nodeType string, nodeName string, h ioTimeHistogram) prometheus.Metric {
+	return prometheus.MustNewConstHistogram(
+		newDesc(nodeType, "io_time_milliseconds", ioTimeHistogramHelp, []string{"component", "target", "operation"}),
+		h.count,
+		math.NaN(),
+		h.buckets,
+		sanitizeLabels([]string{nodeType, nodeName, h.operation})...,
+	)
+}
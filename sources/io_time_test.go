@@ -0,0 +1,45 @@
+package sources
+
+import (
+	"os"
+	"testing"
+)
+
+func TestIOTimeHistograms(t *testing.T) {
+	content, err := os.ReadFile("../tests/io_time/proc/fs/lustre/obdfilter/lustrefs-OST0000/brw_stats")
+	if err != nil {
+		t.Fatal(err)
+	}
+	block := regexCaptureString("(?ms:^"+brwStatsMetricBlocks[ioTimeHelp]+".*?(\n\n|\\z))", string(content))
+	histograms, err := ioTimeHistograms(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]map[float64]uint64{
+		"read":  {1: 1, 2: 5, 4: 8, 8: 10},
+		"write": {1: 120, 2: 210, 4: 270, 8: 300},
+	}
+	if len(histograms) != len(expected) {
+		t.Fatalf("Retrieved an unexpected number of histograms. Expected: %d, Got: %d", len(expected), len(histograms))
+	}
+	for _, h := range histograms {
+		buckets := expected[h.operation]
+		if h.count != buckets[8] {
+			t.Fatalf("Retrieved an unexpected %s count. Expected: %d, Got: %d", h.operation, buckets[8], h.count)
+		}
+		for bound, value := range buckets {
+			if h.buckets[bound] != value {
+				t.Fatalf("Retrieved an unexpected %s bucket le=%g. Expected: %d, Got: %d", h.operation, bound, value, h.buckets[bound])
+			}
+		}
+	}
+
+	// bucket labels are times, 1K is 1024 ms
+	histograms, err = ioTimeHistograms("I/O time (1/1000s)     ios   % cum % |  ios         % cum %\n1K:\t\t 3 100 100   |    0   0   0\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if histograms[0].buckets[1024] != 3 {
+		t.Fatalf("Retrieved an unexpected bucket le=1024. Expected: %d, Got: %d", 3, histograms[0].buckets[1024])
+	}
+}
@@ -0,0 +1,85 @@
+package sources
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const jobStatsResetsHelp string = "Total number of jobids whose job_stats counters went backwards between two scrapes, usually a job purged by job_cleanup_interval and started again"
+
+type jobKey struct {
+	targetKey
+	jobid string
+}
+
+// jobCounters holds the job_stats counters of one scrape, per job and per
+// counter (metric name and remaining label values).
+type jobCounters map[jobKey]map[string]float64
+
+func (jc jobCounters) add(component string, target string, jobid string, name string, labelValues []string, value float64) {
+	key := jobKey{targetKey{component, target}, jobid}
+	counters, ok := jc[key]
+	if !ok {
+		counters = map[string]float64{}
+		jc[key] = counters
+	}
+	counters[name+"/"+strings.Join(labelValues, "/")] = value
+}
+
+// jobResetTracker remembers the counters of the previous scrape and the
+// number of resets seen per target since the exporter started.
+type jobResetTracker struct {
+	mu     sync.Mutex
+	last   jobCounters
+	resets map[targetKey]float64
+}
+
+var insJobResets = &jobResetTracker{
+	last:   jobCounters{},
+	resets: map[targetKey]float64{},
+}
+
+// observe counts the jobs of current which have at least one counter lower
+// than in the previous scrape, and returns the totals of the targets of
+// current, a target gone from the node is no longer reported. Jobs missing
+// from a scrape are forgotten, so a job purged and recreated between two
+// scrapes is only counted if it was seen on both sides.
+func (t *jobResetTracker) observe(current jobCounters) map[targetKey]float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	out := map[targetKey]float64{}
+	for key, counters := range current {
+		out[key.targetKey] = 0
+		if _, ok := t.resets[key.targetKey]; !ok {
+			t.resets[key.targetKey] = 0
+		}
+		prev, ok := t.last[key]
+		if !ok {
+			continue
+		}
+		for name, value := range counters {
+			if last, ok := prev[name]; ok && value < last {
+				t.resets[key.targetKey]++
+				break
+			}
+		}
+	}
+	t.last = current
+
+	for key := range out {
+		out[key] = t.resets[key]
+	}
+	return out
+}
+
+func jobStatsResetsMetric(key targetKey, total float64) prometheus.Metric {
+	return prometheus.MustNewConstMetric(
+		newDesc(key.component, "jobstats_resets_total", jobStatsResetsHelp, []string{"component", "target"}),
+		prometheus.CounterValue,
+		total,
+		key.component, key.target,
+	)
+}
@@ -0,0 +1,34 @@
+package sources
+
+import "testing"
+
+func TestJobStatsResets(t *testing.T) {
+	tracker := &jobResetTracker{last: jobCounters{}, resets: map[targetKey]float64{}}
+	key := targetKey{"ost", "lustrefs-OST0000"}
+
+	scrape := func(punch float64, readBytes float64) map[targetKey]float64 {
+		current := jobCounters{}
+		current.add(key.component, key.target, "dd.0", "job_stats_total", []string{"punch"}, punch)
+		current.add(key.component, key.target, "dd.0", "job_read_bytes_total", nil, readBytes)
+		current.add(key.component, key.target, "cp.0", "job_stats_total", []string{"punch"}, 7)
+		return tracker.observe(current)
+	}
+
+	if resets := scrape(10, 4096); resets[key] != 0 {
+		t.Fatalf("Retrieved an unexpected number of resets. Expected: %d, Got: %f", 0, resets[key])
+	}
+	if resets := scrape(12, 8192); resets[key] != 0 {
+		t.Fatalf("Retrieved an unexpected number of resets. Expected: %d, Got: %f", 0, resets[key])
+	}
+	// both counters of dd.0 went backwards, it is still one reset
+	if resets := scrape(1, 0); resets[key] != 1 {
+		t.Fatalf("Retrieved an unexpected number of resets. Expected: %d, Got: %f", 1, resets[key])
+	}
+	if resets := scrape(3, 4096); resets[key] != 1 {
+		t.Fatalf("Retrieved an unexpected number of resets. Expected: %d, Got: %f", 1, resets[key])
+	}
+	// a target without job_stats in the scrape is not reported
+	if resets := tracker.observe(jobCounters{}); len(resets) != 0 {
+		t.Fatalf("Retrieved unexpected resets: %v", resets)
+	}
+}
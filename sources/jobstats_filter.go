@@ -0,0 +1,62 @@
+package sources
+
+import (
+	"regexp"
+
+	"lustre_exporter/log"
+)
+
+var (
+	// JobstatsInclude, if set, limits the OST and MDT job_stats to the jobids
+	// it matches
+	JobstatsInclude *regexp.Regexp
+	// JobstatsExclude, if set, drops the OST and MDT job_stats of the jobids it
+	// matches
+	JobstatsExclude *regexp.Regexp
+	// JobstatsMaxJobs caps the number of jobids kept per job_stats file, the
+	// ones past the cap are dropped in file order. 0 means no limit.
+	JobstatsMaxJobs = 0
+)
+
+// jobstatsFilter applies the --collector.jobstats.* settings to the jobids of
+// one job_stats file, whichever component it belongs to, so OSTs and MDTs
+// share the same cardinality control.
+type jobstatsFilter struct {
+	include *regexp.Regexp
+	exclude *regexp.Regexp
+	max     int
+	kept    int
+	capped  int
+}
+
+func newJobstatsFilter() *jobstatsFilter {
+	return &jobstatsFilter{
+		include: JobstatsInclude,
+		exclude: JobstatsExclude,
+		max:     JobstatsMaxJobs,
+	}
+}
+
+// keep tells if the metrics of jobid are exported, counting it against the cap
+// when they are.
+func (f *jobstatsFilter) keep(jobid string) bool {
+	if f.include != nil && !f.include.MatchString(jobid) {
+		return false
+	}
+	if f.exclude != nil && f.exclude.MatchString(jobid) {
+		return false
+	}
+	if f.max > 0 && f.kept >= f.max {
+		f.capped++
+		return false
+	}
+	f.kept++
+	return true
+}
+
+// done logs the jobids dropped by the cap of the file at path.
+func (f *jobstatsFilter) done(path string) {
+	if f.capped > 0 {
+		log.Debugf("%s: dropped %d jobids above --collector.jobstats.max-jobs=%d", path, f.capped, f.max)
+	}
+}
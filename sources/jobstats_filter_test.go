@@ -0,0 +1,105 @@
+package sources
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestJobstatsFilterKeep(t *testing.T) {
+	f := &jobstatsFilter{exclude: regexp.MustCompile(`^kworker/`), max: 2}
+	var kept []string
+	for _, jobid := range []string{"dd.0", "kworker/14:1.0", "cat.0", "ls.0"} {
+		if f.keep(jobid) {
+			kept = append(kept, jobid)
+		}
+	}
+	expected := []string{"dd.0", "cat.0"}
+	if !reflect.DeepEqual(kept, expected) {
+		t.Fatalf("Retrieved unexpected jobids. Expected: %v, Got: %v", expected, kept)
+	}
+	if f.capped != 1 {
+		t.Fatalf("Retrieved an unexpected number of capped jobids. Expected: %d, Got: %d", 1, f.capped)
+	}
+}
+
+func TestJobstatsExcludeOSTAndMDT(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		filepath.Join(dir, "obdfilter", "lustrefs-OST0000", "job_stats"): `job_stats:
+- job_id:          kworker/14:1.0
+  snapshot_time:   1510782606
+  read_bytes:      { samples:           0, unit: bytes, min:       0, max:       0, sum:               0 }
+  write_bytes:     { samples:           0, unit: bytes, min:       0, max:       0, sum:               0 }
+  set_info:        { samples:         286, unit:  reqs }
+- job_id:          dd.0
+  snapshot_time:   1510782606
+  read_bytes:      { samples:           0, unit: bytes, min:       0, max:       0, sum:               0 }
+  write_bytes:     { samples:       64575, unit: bytes, min:    4096, max: 4194304, sum:    215147593728 }
+  punch:           { samples:           1, unit:  reqs }
+`,
+		filepath.Join(dir, "mdt", "lustrefs-MDT0000", "job_stats"): `job_stats:
+- job_id:          touch.0
+  snapshot_time:   1510782606
+  open:            { samples:           4, unit:  reqs }
+  close:           { samples:           4, unit:  reqs }
+- job_id:          kworker/3:2.0
+  snapshot_time:   1510782606
+  getattr:         { samples:          17, unit:  reqs }
+`,
+	}
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	defer func(prev *regexp.Regexp) { JobstatsExclude = prev }(JobstatsExclude)
+	JobstatsExclude = regexp.MustCompile(`^kworker/`)
+
+	s := &lustreProcfsSource{basePath: dir}
+	s.generateOSTMetricTemplates(core)
+	s.generateMDTMetricTemplates(core)
+	ctx := insProcfsV2.newCtx(s)
+	defer ctx.release()
+	if err := ctx.collect(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string][]string{
+		filepath.Join(dir, "obdfilter", "lustrefs-OST0000", "job_stats"): {"dd.0"},
+		filepath.Join(dir, "mdt", "lustrefs-MDT0000", "job_stats"):       {"touch.0"},
+	}
+	for path, jobids := range expected {
+		jss, ok := ctx.filesJobStats[path]
+		if !ok {
+			t.Fatalf("No job_stats parsed for %s", path)
+		}
+		var got []string
+		for _, js := range *jss {
+			got = append(got, js.jobid)
+		}
+		if !reflect.DeepEqual(got, jobids) {
+			t.Fatalf("Retrieved unexpected jobids for %s. Expected: %v, Got: %v", path, jobids, got)
+		}
+	}
+
+	// the v1 parser shares the same filter
+	metrics, err := parseJobStatsText(files[filepath.Join(dir, "mdt", "lustrefs-MDT0000", "job_stats")], "job_stats_total", jobStatsHelp, true, newJobstatsFilter())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(metrics) == 0 {
+		t.Fatal("Retrieved no metrics from the v1 parser")
+	}
+	for _, m := range metrics {
+		if m.jobID != "touch.0" {
+			t.Fatalf("Retrieved an unexpected jobid from the v1 parser. Expected: %s, Got: %s", "touch.0", m.jobID)
+		}
+	}
+}
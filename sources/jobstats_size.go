@@ -0,0 +1,25 @@
+package sources
+
+import "os"
+
+const (
+	jobStatsFileBytes     string = "jobstats_file_bytes"
+	jobStatsFileBytesHelp string = "Size in bytes of the job_stats file of the target, a steadily growing size means parsing it will take longer and longer"
+)
+
+// jobStatsFileSize returns the size of a job_stats file from stat(). proc
+// files report a size of 0, their content is then read with read to know.
+func jobStatsFileSize(path string, read func(string) ([]byte, error)) (float64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	if info.Size() > 0 {
+		return float64(info.Size()), nil
+	}
+	content, err := read(path)
+	if err != nil {
+		return 0, err
+	}
+	return float64(len(content)), nil
+}
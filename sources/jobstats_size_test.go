@@ -0,0 +1,38 @@
+package sources
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestJobStatsFileSize(t *testing.T) {
+	path := "../tests/2.12/proc/fs/lustre/obdfilter/lustrefs-OST0000/job_stats"
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	size, err := jobStatsFileSize(path, func(string) ([]byte, error) {
+		t.Fatal("Expected the file not to be read when stat() reports its size")
+		return nil, nil
+	})
+	if err != nil || size != float64(info.Size()) {
+		t.Fatalf("Retrieved an unexpected job_stats size. Expected: %d, Got: %f (%v)", info.Size(), size, err)
+	}
+
+	// like proc files, an empty stat() size falls back to the content
+	empty := filepath.Join(t.TempDir(), "job_stats")
+	if err := os.WriteFile(empty, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	size, err = jobStatsFileSize(empty, func(string) ([]byte, error) {
+		return []byte("job_stats:\n"), nil
+	})
+	if err != nil || size != 11 {
+		t.Fatalf("Retrieved an unexpected job_stats size. Expected: %d, Got: %f (%v)", 11, size, err)
+	}
+
+	if _, err := jobStatsFileSize(filepath.Join(t.TempDir(), "missing"), readProcFile); err == nil {
+		t.Fatal("Expected an error for a missing job_stats file")
+	}
+}
@@ -0,0 +1,103 @@
+package sources
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// LatencyStats enables the latency mean/stddev gauges computed from the
+// '[usec]' lines of the stats files.
+var LatencyStats = false
+
+const (
+	// Lustre only keeps count, min, max, sum and sum of squares per operation,
+	// true quantiles can't be derived from them.
+	latencyMeanHelp   string = "Mean latency of the operation in microseconds since the stats were last cleared (sum / samples). This is not a quantile."
+	latencyStddevHelp string = "Standard deviation of the latency of the operation in microseconds since the stats were last cleared, computed from sum and sumsq. This is not a quantile."
+)
+
+type opLatency struct {
+	operation string
+	mean      float64
+	stddev    float64
+}
+
+// latencyMeanStddev returns the mean and the sample standard deviation the
+// same way lprocfs does it.
+func latencyMeanStddev(samples float64, sum float64, sumsq float64) (float64, float64) {
+	if samples <= 0 {
+		return 0, 0
+	}
+	mean := sum / samples
+	if samples < 2 {
+		return mean, 0
+	}
+	variance := (sumsq - sum*sum/samples) / (samples - 1)
+	if variance < 0 {
+		// rounding noise on near constant latencies
+		variance = 0
+	}
+	return mean, math.Sqrt(variance)
+}
+
+// parseLatencyStats picks the lines of a stats file which carry latencies:
+// {name} {samples} 'samples' [usec] {min} {max} {sum} {sumsq}
+func parseLatencyStats(content string) ([]opLatency, error) {
+	var out []opLatency
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 8 || (fields[3] != "[usec]" && fields[3] != "[usecs]") {
+			continue
+		}
+		var nums [3]float64
+		for i, field := range []string{fields[1], fields[6], fields[7]} {
+			value, err := strconv.ParseFloat(field, 64)
+			if err != nil {
+				return nil, err
+			}
+			nums[i] = value
+		}
+		mean, stddev := latencyMeanStddev(nums[0], nums[1], nums[2])
+		out = append(out, opLatency{operation: normalizeOperation(fields[0]), mean: mean, stddev: stddev})
+	}
+	return out, nil
+}
+
+func latencyMetrics(nodeType string, nodeName string, latencies []opLatency) []prometheus.Metric {
+	labels := []string{"component", "target", "operation"}
+	out := make([]prometheus.Metric, 0, 2*len(latencies))
+	for _, l := range latencies {
+		out = append(out,
+			prometheus.MustNewConstMetric(
+				newDesc(nodeType, "op_latency_mean_microseconds", latencyMeanHelp, labels),
+				prometheus.GaugeValue, l.mean, nodeType, nodeName, l.operation,
+			),
+			prometheus.MustNewConstMetric(
+				newDesc(nodeType, "op_latency_stddev_microseconds", latencyStddevHelp, labels),
+				prometheus.GaugeValue, l.stddev, nodeType, nodeName, l.operation,
+			),
+		)
+	}
+	return out
+}
+
+// latencies returns the latency metrics of the stats file path of a nodeType
+// target, for the v1 collecting logic.
+func (s *lustreProcfsSource) latencies(nodeType string, path string, directoryDepth int) ([]prometheus.Metric, error) {
+	_, nodeName, err := parseFileElements(path, directoryDepth)
+	if err != nil {
+		return nil, err
+	}
+	content, err := readProcFile(path)
+	if err != nil {
+		return nil, err
+	}
+	latencies, err := parseLatencyStats(string(content))
+	if err != nil {
+		return nil, err
+	}
+	return latencyMetrics(nodeType, nodeName, latencies), nil
+}
@@ -0,0 +1,46 @@
+package sources
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLatencyMeanStddev(t *testing.T) {
+	// latencies 2, 4, 4, 4, 5, 5, 7, 9: sum 40, sumsq 232
+	mean, stddev := latencyMeanStddev(8, 40, 232)
+	if mean != 5 {
+		t.Fatalf("Retrieved an unexpected mean. Expected: %f, Got: %f", 5.0, mean)
+	}
+	if expected := math.Sqrt(32.0 / 7.0); math.Abs(stddev-expected) > 1e-9 {
+		t.Fatalf("Retrieved an unexpected stddev. Expected: %f, Got: %f", expected, stddev)
+	}
+
+	if mean, stddev = latencyMeanStddev(1, 42, 1764); mean != 42 || stddev != 0 {
+		t.Fatalf("Retrieved an unexpected mean/stddev for one sample: %f/%f", mean, stddev)
+	}
+	if mean, stddev = latencyMeanStddev(0, 0, 0); mean != 0 || stddev != 0 {
+		t.Fatalf("Retrieved an unexpected mean/stddev for no sample: %f/%f", mean, stddev)
+	}
+}
+
+func TestParseLatencyStats(t *testing.T) {
+	statsFile := `snapshot_time             1510782606.789180921 secs.nsecs
+read_bytes                4 samples [bytes] 4096 1048576 2105344
+read                      8 samples [usec] 2 9 40 232
+getinfo                   4 samples [usec] 321 602 1890 952394
+statfs                    35359 samples [reqs]
+`
+	latencies, err := parseLatencyStats(statsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(latencies) != 2 {
+		t.Fatalf("Retrieved an unexpected number of latencies. Expected: %d, Got: %d", 2, len(latencies))
+	}
+	if latencies[0].operation != "read" || latencies[0].mean != 5 {
+		t.Fatalf("Retrieved an unexpected latency: %+v", latencies[0])
+	}
+	if latencies[1].operation != "get_info" || latencies[1].mean != 472.5 {
+		t.Fatalf("Retrieved an unexpected latency: %+v", latencies[1])
+	}
+}
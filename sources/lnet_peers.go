@@ -0,0 +1,106 @@
+package sources
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	lnetPeers string = "peers"
+
+	lnetPeerRefcountHelp     string = "Number of references held on the LNET peer"
+	lnetPeerUpHelp           string = "Returns 1 if the LNET peer is up, 0 if it is down"
+	lnetPeerTxCreditsHelp    string = "Number of send credits currently available to the LNET peer, negative when messages are queued"
+	lnetPeerMinTxCreditsHelp string = "Lowest number of send credits available to the LNET peer since the module was loaded"
+	lnetPeerQueuedBytesHelp  string = "Number of bytes queued for sending to the LNET peer"
+)
+
+// lnetPeerColumns are the columns of the peers table each metric is read
+// from, a "min" column is named after the column it follows.
+var lnetPeerColumns = map[string]string{
+	"lnet_peer_refcount":       "refs",
+	"lnet_peer_up":             "state",
+	"lnet_peer_tx_credits":     "tx",
+	"lnet_peer_min_tx_credits": "min_tx",
+	"lnet_peer_queued_bytes":   "queue",
+}
+
+type lnetPeer struct {
+	nid    string
+	values map[string]string
+}
+
+// parseLnetPeers reads the peers table of '/proc/sys/lnet/peers', or
+// '/sys/kernel/debug/lnet/peers' on the kernels which moved it to debugfs:
+//
+//	nid                      refs state  last   max   rtr   min    tx   min queue
+//	172.20.20.2@o2ib            1    up    -1     8     8     8     8     6 0
+//
+// The columns are found from the header, whatever their spacing and order,
+// so a reformatted table doesn't yield values read from the wrong column.
+// Only the lines starting with a NID are peers.
+func parseLnetPeers(content string) ([]lnetPeer, error) {
+	var header []string
+	var peers []lnetPeer
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == "nid" {
+			header = lnetPeerHeader(fields)
+			continue
+		}
+		if !strings.Contains(fields[0], "@") {
+			continue
+		}
+		if header == nil {
+			return nil, fmt.Errorf("peer %s before the header of the peers table", fields[0])
+		}
+		if len(fields) != len(header) {
+			return nil, fmt.Errorf("peer %s has %d columns, the header has %d", fields[0], len(fields), len(header))
+		}
+		peer := lnetPeer{nid: fields[0], values: map[string]string{}}
+		for i, name := range header[1:] {
+			peer.values[name] = fields[i+1]
+		}
+		peers = append(peers, peer)
+	}
+	return peers, nil
+}
+
+func lnetPeerHeader(fields []string) []string {
+	header := make([]string, len(fields))
+	for i, name := range fields {
+		if name == "min" && i > 0 {
+			name = "min_" + fields[i-1]
+		}
+		header[i] = name
+	}
+	return header
+}
+
+// lnetPeerValue returns the value of promName for a peer, ok is false when
+// the table has no such column or the peer has no value, like the "NA" state
+// of the loopback NID.
+func lnetPeerValue(peer lnetPeer, promName string) (value float64, ok bool, err error) {
+	raw, ok := peer.values[lnetPeerColumns[promName]]
+	if !ok {
+		return 0, false, nil
+	}
+	if promName == "lnet_peer_up" {
+		switch raw {
+		case "up":
+			return 1, true, nil
+		case "down":
+			return 0, true, nil
+		}
+		return 0, false, nil
+	}
+	value, err = strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid %s of peer %s: %w", lnetPeerColumns[promName], peer.nid, err)
+	}
+	return value, true, nil
+}
@@ -0,0 +1,101 @@
+package sources
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestParseLnetPeers(t *testing.T) {
+	content, err := os.ReadFile("../tests/2.12/proc/sys/lnet/peers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	peers, err := parseLnetPeers(string(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(peers) != 3 {
+		t.Fatalf("Retrieved an unexpected number of peers. Expected: %d, Got: %d", 3, len(peers))
+	}
+
+	expected := []struct {
+		peer     int
+		promName string
+		value    float64
+		ok       bool
+	}{
+		{0, "lnet_peer_up", 0, false}, // "NA" for the loopback NID
+		{1, "lnet_peer_up", 1, true},
+		{2, "lnet_peer_up", 0, true},
+		{2, "lnet_peer_refcount", 2, true},
+		{2, "lnet_peer_tx_credits", -2, true},
+		{2, "lnet_peer_min_tx_credits", -4, true},
+		{2, "lnet_peer_queued_bytes", 4096, true},
+	}
+	for _, test := range expected {
+		value, ok, err := lnetPeerValue(peers[test.peer], test.promName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if value != test.value || ok != test.ok {
+			t.Fatalf("Retrieved an unexpected %s of %s. Expected: %f (%t), Got: %f (%t)", test.promName, peers[test.peer].nid, test.value, test.ok, value, ok)
+		}
+	}
+
+	// the columns follow the header, not their position
+	peers, err = parseLnetPeers("nid\tqueue refs\n172.20.20.2@o2ib\t512 3\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value, _, _ := lnetPeerValue(peers[0], "lnet_peer_queued_bytes"); value != 512 {
+		t.Fatalf("Retrieved an unexpected queued bytes. Expected: %d, Got: %f", 512, value)
+	}
+	if _, ok, _ := lnetPeerValue(peers[0], "lnet_peer_tx_credits"); ok {
+		t.Fatal("Expected no tx credits without a tx column")
+	}
+
+	if _, err := parseLnetPeers("172.20.20.2@o2ib 1 up -1 8 8 8 8 6 0\n"); err == nil {
+		t.Fatal("Expected an error for a peer without header")
+	}
+	if _, err := parseLnetPeers("nid refs state\n172.20.20.2@o2ib 1\n"); err == nil {
+		t.Fatal("Expected an error for a peer with missing columns")
+	}
+	peers, err = parseLnetPeers("nid refs\n172.20.20.2@o2ib n/a\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := lnetPeerValue(peers[0], "lnet_peer_refcount"); err == nil {
+		t.Fatal("Expected an error for a non numeric refcount")
+	}
+}
+
+func TestLnetPeersDebugfs(t *testing.T) {
+	defer func(lnet string) { LnetEnabled = lnet }(LnetEnabled)
+	LnetEnabled = extended
+
+	content, err := os.ReadFile("../tests/2.12/proc/sys/lnet/peers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sys := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(sys, "kernel/debug/lnet"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(sys, "kernel/debug/lnet", lnetPeers), content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// no 'peers' below the proc location, the debugfs one is read
+	ctx := newLustreProcSysSource(Config{ProcLocation: t.TempDir(), SysLocation: sys}).newCtx()
+	defer ctx.release()
+	if err := ctx.collect(); err != nil {
+		t.Fatal(err)
+	}
+	// 4 values for the loopback NID, which has no state, 5 for the others
+	if n := countMetrics(make(chan prometheus.Metric, 100), ctx.update); n != 14 {
+		t.Fatalf("Retrieved an unexpected number of peer metrics. Expected: %d, Got: %d", 14, n)
+	}
+}
@@ -0,0 +1,56 @@
+package sources
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	lnetSelftest string = "selftest"
+
+	lnetSelftestLatencyHelp string = "Average round trip latency in microseconds to the peer measured by the running LNET selftest session"
+	lnetSelftestErrorsHelp  string = "Number of RPC errors to the peer in the running LNET selftest session"
+)
+
+type selftestPeer struct {
+	nid     string
+	latency float64
+	errors  float64
+}
+
+// parseLnetSelftest reads the per peer results of the 'selftest' file, only
+// present while an LNET selftest session is running:
+//
+//	session: bulk_read
+//	peer                      latency_usec  errors
+//	192.168.10.11@o2ib        152.5         0
+//
+// The header lines are skipped, every line starting with a NID is a peer.
+func parseLnetSelftest(content string) ([]selftestPeer, error) {
+	var peers []selftestPeer
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 || !strings.Contains(fields[0], "@") {
+			continue
+		}
+		latency, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selftest latency of %s: %w", fields[0], err)
+		}
+		errors, err := strconv.ParseFloat(fields[2], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selftest errors of %s: %w", fields[0], err)
+		}
+		peers = append(peers, selftestPeer{nid: fields[0], latency: latency, errors: errors})
+	}
+	return peers, nil
+}
+
+// lnetSelftestValue returns the value of promName for a peer.
+func lnetSelftestValue(peer selftestPeer, helpText string) float64 {
+	if helpText == lnetSelftestErrorsHelp {
+		return peer.errors
+	}
+	return peer.latency
+}
@@ -0,0 +1,39 @@
+package sources
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestParseLnetSelftest(t *testing.T) {
+	content, err := os.ReadFile("../tests/2.12/proc/sys/lnet/selftest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	peers, err := parseLnetSelftest(string(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []selftestPeer{
+		{nid: "172.20.20.2@o2ib", latency: 152.5, errors: 0},
+		{nid: "172.20.20.3@o2ib", latency: 198, errors: 3},
+	}
+	if !reflect.DeepEqual(peers, expected) {
+		t.Fatalf("Retrieved unexpected selftest peers. Expected: %v, Got: %v", expected, peers)
+	}
+	if got := lnetSelftestValue(peers[1], lnetSelftestErrorsHelp); got != 3 {
+		t.Fatalf("Retrieved an unexpected selftest errors value. Expected: %d, Got: %f", 3, got)
+	}
+	if got := lnetSelftestValue(peers[1], lnetSelftestLatencyHelp); got != 198 {
+		t.Fatalf("Retrieved an unexpected selftest latency value. Expected: %d, Got: %f", 198, got)
+	}
+
+	// no session running
+	if peers, err := parseLnetSelftest(""); err != nil || peers != nil {
+		t.Fatalf("Retrieved unexpected selftest peers without session. Expected: [], Got: %v (%v)", peers, err)
+	}
+	if _, err := parseLnetSelftest("172.20.20.2@o2ib  n/a  0\n"); err == nil {
+		t.Fatal("Expected an error for a non numeric latency")
+	}
+}
@@ -0,0 +1,25 @@
+package sources
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	lodQosPrioFree    string = "qos_prio_free"
+	lodQosThresholdRR string = "qos_threshold_rr"
+
+	lodQosPrioFreeHelp    string = "Weight in percent given to the free space of the OSTs over their load by the QoS object allocator of the MDT"
+	lodQosThresholdRRHelp string = "Free space imbalance in percent between the OSTs above which the MDT switches from round-robin to QoS weighted object allocation"
+)
+
+// parseLodQosTunable returns the value of a 'lod/*/qos_*' percentage tunable,
+// e.g. '91%'.
+func parseLodQosTunable(content string) (float64, error) {
+	value, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(content), "%"), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid qos tunable %q: %w", strings.TrimSpace(content), err)
+	}
+	return value, nil
+}
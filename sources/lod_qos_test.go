@@ -0,0 +1,19 @@
+package sources
+
+import "testing"
+
+func TestParseLodQosTunable(t *testing.T) {
+	tests := map[string]float64{"91%\n": 91, "17%": 17, "0%\n": 0, "100": 100}
+	for content, expected := range tests {
+		value, err := parseLodQosTunable(content)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if value != expected {
+			t.Fatalf("Retrieved an unexpected qos tunable value. Expected: %f, Got: %f", expected, value)
+		}
+	}
+	if _, err := parseLodQosTunable("5 Sec\n"); err == nil {
+		t.Fatalf("Expected an error parsing a non percentage qos tunable")
+	}
+}
@@ -0,0 +1,74 @@
+package sources
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// EmitZeroOnMissing reports the expectedMetrics of a target as 0 when their
+// file is missing or can't be read, instead of leaving the series out. The
+// zero looks like a real value: a target which lost its file keeps reporting
+// 0 (e.g. no free space) rather than going stale.
+var EmitZeroOnMissing = false
+
+// expectedMetrics are the single value metrics every OST and MDT target is
+// expected to report.
+var expectedMetrics = map[string]bool{
+	"inodes_free":         true,
+	"inodes_maximum":      true,
+	"available_kilobytes": true,
+	"free_kilobytes":      true,
+	"capacity_kilobytes":  true,
+	"exports_total":       true,
+}
+
+// zeroOnMissing tells if the missing files of metric are reported as 0, only
+// the plain files directly in the target directory are.
+func zeroOnMissing(metric *lustreProcMetric) bool {
+	return EmitZeroOnMissing && expectedMetrics[metric.promName] && !strings.ContainsAny(metric.filename, "/*?[")
+}
+
+// missingExpectedFiles returns the file of metric of every target directory
+// matched by its path under one of the roots which is not in read under any
+// of them, i.e. which was not found or failed to be read.
+func missingExpectedFiles(roots []string, metric *lustreProcMetric, read map[string]bool) ([]string, error) {
+	var out []string
+	seen := map[string]bool{}
+	for _, root := range roots {
+		dirs, err := filepath.Glob(filepath.Join(root, metric.path))
+		if err != nil {
+			return nil, err
+		}
+		for _, dir := range dirs {
+			if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+				continue
+			}
+			rel, err := filepath.Rel(root, dir)
+			if err != nil || seen[rel] {
+				continue
+			}
+			seen[rel] = true
+			if !readUnder(roots, filepath.Join(rel, metric.filename), read) {
+				out = append(out, filepath.Join(dir, metric.filename))
+			}
+		}
+	}
+	return out, nil
+}
+
+func readUnder(roots []string, rel string, read map[string]bool) bool {
+	for _, root := range roots {
+		if read[filepath.Join(root, rel)] {
+			return true
+		}
+	}
+	return false
+}
+
+func missingZeroMetric(metric *lustreProcMetric, path string) prometheus.Metric {
+	_, nodeName, _ := parseFileElements(path, 0)
+	return metric.metricFunc([]string{"component", "target"}, []string{metric.source, nodeName}, metric.promName, metric.helpText, 0)
+}
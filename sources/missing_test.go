@@ -0,0 +1,89 @@
+package sources
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type recordedValue struct {
+	name   string
+	target string
+	value  float64
+}
+
+func TestEmitZeroOnMissing(t *testing.T) {
+	dir := t.TempDir()
+	for target, files := range map[string]map[string]string{
+		"lustrefs-OST0000": {"kbytesfree": "1024\n", "kbytesavail": "512\n"},
+		"lustrefs-OST0001": {"kbytesfree": "2048\n"},
+	} {
+		if err := os.MkdirAll(filepath.Join(dir, "obdfilter", target), 0755); err != nil {
+			t.Fatal(err)
+		}
+		for name, content := range files {
+			if err := os.WriteFile(filepath.Join(dir, "obdfilter", target, name), []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	var recorded []recordedValue
+	record := func(labels []string, values []string, name string, help string, value float64) prometheus.Metric {
+		recorded = append(recorded, recordedValue{name, values[1], value})
+		return nil
+	}
+	s := &lustreProcfsSource{basePath: dir}
+	s.lustreProcMetrics = []lustreProcMetric{
+		newLustreProcMetric("kbytesavail", "available_kilobytes", "ost", "obdfilter/*", "", false, record),
+		newLustreProcMetric("kbytesfree", "free_kilobytes", "ost", "obdfilter/*", "", false, record),
+	}
+
+	defer func(prev bool) { EmitZeroOnMissing = prev }(EmitZeroOnMissing)
+	for emit, expected := range map[bool][]recordedValue{
+		false: {
+			{"available_kilobytes", "lustrefs-OST0000", 512},
+			{"free_kilobytes", "lustrefs-OST0000", 1024},
+			{"free_kilobytes", "lustrefs-OST0001", 2048},
+		},
+		true: {
+			{"available_kilobytes", "lustrefs-OST0000", 512},
+			{"available_kilobytes", "lustrefs-OST0001", 0},
+			{"free_kilobytes", "lustrefs-OST0000", 1024},
+			{"free_kilobytes", "lustrefs-OST0001", 2048},
+		},
+	} {
+		EmitZeroOnMissing = emit
+
+		recorded = nil
+		ctx := insProcfsV2.newCtx(s)
+		if err := ctx.collect(); err != nil {
+			t.Fatal(err)
+		}
+		ctx.release()
+		if !reflect.DeepEqual(recorded, expected) {
+			t.Fatalf("Retrieved unexpected v2 values with emit %t. Expected: %v, Got: %v", emit, expected, recorded)
+		}
+
+		recorded = nil
+		ch := make(chan prometheus.Metric)
+		done := make(chan struct{})
+		go func() {
+			for range ch {
+			}
+			close(done)
+		}()
+		err := s.Update(ch)
+		close(ch)
+		<-done
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(recorded, expected) {
+			t.Fatalf("Retrieved unexpected v1 values with emit %t. Expected: %v, Got: %v", emit, expected, recorded)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package sources
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	moduleRefcountHelp string = "Number of references to the Lustre related kernel module, a nonzero value blocks unloading it (/proc/modules)"
+	moduleLoadedHelp   string = "Whether the Lustre related kernel module is loaded, always 1 (/proc/modules)"
+)
+
+// lustreModules are the kernel modules of Lustre and LNET reported from
+// /proc/modules.
+var lustreModules = map[string]bool{
+	"fid": true, "fld": true, "ko2iblnd": true, "ksocklnd": true, "kkfilnd": true,
+	"lfsck": true, "libcfs": true, "lmv": true, "lnet": true, "lod": true,
+	"lov": true, "lquota": true, "lustre": true, "mdc": true, "mdd": true,
+	"mdt": true, "mgc": true, "mgs": true, "obdclass": true, "ofd": true,
+	"osc": true, "osd_ldiskfs": true, "osd_zfs": true, "osp": true,
+	"ost": true, "ptlrpc": true, "ldiskfs": true,
+}
+
+type kernelModule struct {
+	name     string
+	refcount float64
+}
+
+// parseModules returns the Lustre modules of a /proc/modules listing:
+//
+//	lustre 1097728 2 - Live 0xffffffffc0f5c000 (OE)
+//
+// name, size, reference count, dependent modules, state and address.
+func parseModules(content string) ([]kernelModule, error) {
+	var out []kernelModule
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 || !lustreModules[fields[0]] {
+			continue
+		}
+		refcount, err := strconv.ParseFloat(fields[2], 64)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, kernelModule{name: fields[0], refcount: refcount})
+	}
+	return out, nil
+}
+
+// moduleMetrics returns lustre_module_refcount and lustre_module_loaded for
+// the Lustre modules of the /proc/modules file at path, none when the file
+// doesn't exist or is not allowed by --collector.path-allow.
+func moduleMetrics(path string, readFile func(string) ([]byte, error)) ([]prometheus.Metric, error) {
+	content, err := readFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) || errors.Is(err, errFileSkipped) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	modules, err := parseModules(string(content))
+	if err != nil {
+		return nil, err
+	}
+	refcountDesc := newDesc("generic", "module_refcount", moduleRefcountHelp, []string{"module"})
+	loadedDesc := newDesc("generic", "module_loaded", moduleLoadedHelp, []string{"module"})
+	out := make([]prometheus.Metric, 0, 2*len(modules))
+	for _, module := range modules {
+		out = append(out,
+			prometheus.MustNewConstMetric(refcountDesc, prometheus.GaugeValue, module.refcount, module.name),
+			prometheus.MustNewConstMetric(loadedDesc, prometheus.GaugeValue, 1, module.name),
+		)
+	}
+	return out, nil
+}
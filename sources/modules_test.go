@@ -0,0 +1,42 @@
+package sources
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestParseModules(t *testing.T) {
+	content, err := os.ReadFile("../tests/2.12/proc/modules")
+	if err != nil {
+		t.Fatal(err)
+	}
+	modules, err := parseModules(string(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]float64{}
+	for _, module := range modules {
+		got[module.name] = module.refcount
+	}
+	// zfs and xfs are not Lustre modules
+	if len(got) != 21 {
+		t.Fatalf("Retrieved an unexpected number of modules. Expected: %d, Got: %d (%v)", 21, len(got), got)
+	}
+	for name, refcount := range map[string]float64{"obdclass": 40, "ptlrpc": 21, "lnet": 8, "lustre": 2} {
+		if got[name] != refcount {
+			t.Fatalf("Retrieved an unexpected refcount for %s. Expected: %f, Got: %f", name, refcount, got[name])
+		}
+	}
+
+	metrics, err := moduleMetrics("../tests/2.12/proc/nonexistent", os.ReadFile)
+	if err != nil || metrics != nil {
+		t.Fatalf("Expected no module metrics without /proc/modules, Got: %v (%v)", metrics, err)
+	}
+	if _, err := parseModules("lustre 1097728 x - Live 0xffffffffc0dd6000\n"); err == nil {
+		t.Fatal("Expected an invalid refcount to be rejected")
+	}
+	if modules, err := parseModules(""); err != nil || !reflect.DeepEqual(modules, []kernelModule(nil)) {
+		t.Fatalf("Retrieved unexpected modules for an empty listing: %v (%v)", modules, err)
+	}
+}
@@ -0,0 +1,171 @@
+package sources
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"lustre_exporter/log"
+)
+
+// MetricName describes a metric family a source can emit.
+type MetricName struct {
+	Name   string   `json:"name"`
+	Help   string   `json:"help"`
+	Type   string   `json:"type"`
+	Labels []string `json:"labels"`
+}
+
+// templateDescriber is implemented by the sources built from metric
+// templates, it returns a sample of every template whatever the files of the
+// node.
+type templateDescriber interface {
+	describeTemplates() []prometheus.Metric
+}
+
+// describeTemplates builds one zero sample per template of metrics through
+// its own metricFunc, so the name, help and type are the ones of the real
+// samples. labels returns the label names the collect logic gives the
+// template, their values are only made unique.
+func describeTemplates(metrics []lustreProcMetric, labels func(metric *lustreProcMetric) []string) []prometheus.Metric {
+	out := make([]prometheus.Metric, 0, len(metrics))
+	for i := range metrics {
+		names := labels(&metrics[i])
+		values := make([]string, len(names))
+		for j := range values {
+			values[j] = strconv.Itoa(i)
+		}
+		out = append(out, metrics[i].metricFunc(names, values, metrics[i].promName, metrics[i].helpText, 0))
+	}
+	return out
+}
+
+// componentTargetLabels is the label set of the single value files.
+func componentTargetLabels(metric *lustreProcMetric) []string {
+	return []string{"component", "target"}
+}
+
+// templateSamples is an unchecked collector replaying the samples of the
+// templates.
+type templateSamples []prometheus.Metric
+
+func (t templateSamples) Describe(ch chan<- *prometheus.Desc) {}
+
+func (t templateSamples) Collect(ch chan<- prometheus.Metric) {
+	for _, m := range t {
+		ch <- m
+	}
+}
+
+// MetricNames lists the metric families the sources of list can emit with
+// the current collector levels, sorted by name, even the ones without any
+// file on this node. Only the families built from the metric templates are
+// listed, not the ones derived during the scrape.
+func MetricNames(list map[string]LustreSource) []MetricName {
+	var samples templateSamples
+	for _, c := range list {
+		if d, ok := c.(templateDescriber); ok {
+			samples = append(samples, d.describeTemplates()...)
+		}
+	}
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(samples)
+	families, err := reg.Gather()
+	if err != nil {
+		// the families are still gathered, the inconsistent samples are
+		// left out
+		log.Warnf("Inconsistent metric templates: %s", err)
+	}
+
+	out := make([]MetricName, 0, len(families))
+	for _, family := range families {
+		out = append(out, metricName(family))
+	}
+	return out
+}
+
+func metricName(family *dto.MetricFamily) MetricName {
+	name := MetricName{
+		Name:   family.GetName(),
+		Help:   family.GetHelp(),
+		Type:   strings.ToLower(family.GetType().String()),
+		Labels: []string{},
+	}
+	if metrics := family.GetMetric(); len(metrics) > 0 {
+		for _, label := range metrics[0].GetLabel() {
+			name.Labels = append(name.Labels, label.GetName())
+		}
+	}
+	return name
+}
+
+func (s *lustreProcfsSource) describeTemplates() []prometheus.Metric {
+	return describeTemplates(s.lustreProcMetrics, procfsTemplateLabels)
+}
+
+// procfsTemplateLabels returns the labels the procfs collect logic gives the
+// samples of metric, keep it in line with its switch on the filename.
+func procfsTemplateLabels(metric *lustreProcMetric) []string {
+	switch metric.filename {
+	case "brw_stats", "rpc_stats":
+		if _, ok := oscWritebackFields[metric.promName]; ok {
+			return []string{"component", "target"}
+		}
+		if metric.hasMultipleVals {
+			return []string{"component", "target", "operation", "size", "type"}
+		}
+		return []string{"component", "target", "operation", "size"}
+	case "job_stats":
+		if metric.promName == jobStatsFileBytes {
+			return []string{"component", "target"}
+		}
+		if metric.hasMultipleVals {
+			return []string{"component", "target", "jobid", "operation"}
+		}
+		return []string{"component", "target", "jobid"}
+	case ldlmPoolStats:
+		return []string{"namespace", "target"}
+	case lodStripeCount, lodStripeSize:
+		if metric.promName == clientLovStripeCount {
+			return []string{"component", "target"}
+		}
+		return []string{"fs"}
+	case lodPools:
+		return []string{"fs", "pool", "target"}
+	case exportLdlmStats:
+		return []string{"client_nid", "target"}
+	case mdtServiceStats:
+		return []string{"component", "target", "service"}
+	case qmtGlobalDt, qmtGlobalMd:
+		return []string{"fs", "type", "id"}
+	case renameStats:
+		return []string{"component", "target", "type", "size"}
+	case quotaAcct:
+		return []string{"component", "target", "type", "id"}
+	case readCacheEnable:
+		return []string{"component", "target", "read_cache", "writethrough"}
+	case stats, mdStats, encryptPagePools, unstableStats:
+		if metric.hasMultipleVals || metric.promName == mdtReintTotal || metric.promName == opErrorsTotal {
+			return []string{"component", "target", "operation"}
+		}
+	}
+	return componentTargetLabels(metric)
+}
+
+func (s *lustreProcsysSource) describeTemplates() []prometheus.Metric {
+	return describeTemplates(s.lustreProcMetrics, func(metric *lustreProcMetric) []string {
+		switch metric.filename {
+		case lnetSelftest:
+			return []string{"component", "peer"}
+		case lnetPeers:
+			return []string{"component", "nid"}
+		}
+		return componentTargetLabels(metric)
+	})
+}
+
+func (s *lustreSysSource) describeTemplates() []prometheus.Metric {
+	return describeTemplates(s.lustreProcMetrics, componentTargetLabels)
+}
@@ -0,0 +1,75 @@
+package sources
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NegativeCacheTTL, if set, is how long a directory found missing below
+// which a template globs is remembered, the glob being skipped meanwhile
+// (e.g. the llite directory on a pure OSS). A directory showing up later,
+// such as a client mounted after the start, is found once the TTL expired.
+// 0 disables the cache.
+var NegativeCacheTTL time.Duration
+
+// negativeCache remembers when the directories found missing were checked.
+type negativeCache struct {
+	mu      sync.Mutex
+	missing map[string]time.Time
+	glob    func(string) ([]string, error)
+}
+
+var insNegativeCache = &negativeCache{
+	missing: map[string]time.Time{},
+	glob:    filepath.Glob,
+}
+
+// globDir returns the deepest directory of pattern without glob meta
+// characters, the one which has to exist for the pattern to match.
+func globDir(pattern string) string {
+	dir := filepath.Dir(pattern)
+	for strings.ContainsAny(dir, `*?[\`) {
+		dir = filepath.Dir(dir)
+	}
+	return dir
+}
+
+// lookup globs pattern unless its directory was found missing less than ttl
+// ago. The directory is only checked when the glob matches nothing, so the
+// existing paths cost no more syscalls.
+func (c *negativeCache) lookup(pattern string, ttl time.Duration, now time.Time) ([]string, error) {
+	if ttl <= 0 {
+		return c.glob(pattern)
+	}
+	dir := globDir(pattern)
+
+	c.mu.Lock()
+	checked, ok := c.missing[dir]
+	if ok && now.Sub(checked) < ttl {
+		c.mu.Unlock()
+		return nil, nil
+	}
+	delete(c.missing, dir)
+	c.mu.Unlock()
+
+	paths, err := c.glob(pattern)
+	if err != nil || len(paths) > 0 {
+		return paths, err
+	}
+	if _, err := os.Stat(dir); errors.Is(err, fs.ErrNotExist) {
+		c.mu.Lock()
+		c.missing[dir] = now
+		c.mu.Unlock()
+	}
+	return nil, nil
+}
+
+// globPaths is filepath.Glob behind the negative cache.
+func globPaths(pattern string) ([]string, error) {
+	return insNegativeCache.lookup(pattern, NegativeCacheTTL, time.Now())
+}
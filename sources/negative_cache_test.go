@@ -0,0 +1,57 @@
+package sources
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNegativeCache(t *testing.T) {
+	base := t.TempDir()
+	globs := 0
+	cache := &negativeCache{
+		missing: map[string]time.Time{},
+		glob: func(pattern string) ([]string, error) {
+			globs++
+			return filepath.Glob(pattern)
+		},
+	}
+	pattern := filepath.Join(base, "llite", "*", "stats")
+	if dir := globDir(pattern); dir != filepath.Join(base, "llite") {
+		t.Fatalf("Retrieved an unexpected glob directory. Expected: %s, Got: %s", filepath.Join(base, "llite"), dir)
+	}
+	ttl := time.Minute
+	start := time.Unix(1700000000, 0)
+
+	// the first scrape finds llite missing
+	if paths, err := cache.lookup(pattern, ttl, start); err != nil || paths != nil {
+		t.Fatalf("Retrieved unexpected paths. Expected: none, Got: %v (%v)", paths, err)
+	}
+	// the client is mounted, but the glob is skipped within the TTL
+	if err := os.MkdirAll(filepath.Join(base, "llite", "lustrefs-ffff88105db50000"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(base, "llite", "lustrefs-ffff88105db50000", "stats"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if paths, _ := cache.lookup(pattern, ttl, start.Add(30*time.Second)); paths != nil || globs != 1 {
+		t.Fatalf("Expected the glob to be skipped within the TTL, Got: %d globs, %v", globs, paths)
+	}
+	// and retried after it
+	paths, err := cache.lookup(pattern, ttl, start.Add(ttl))
+	if err != nil || len(paths) != 1 || globs != 2 {
+		t.Fatalf("Expected the glob to be retried after the TTL, Got: %d globs, %v (%v)", globs, paths, err)
+	}
+
+	// an existing directory without any match is not cached
+	empty := filepath.Join(base, "llite", "*", "unknown")
+	for i := 0; i < 2; i++ {
+		if _, err := cache.lookup(empty, ttl, start.Add(ttl)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if globs != 4 {
+		t.Fatalf("Expected every glob below an existing directory to run. Expected: %d, Got: %d", 4, globs)
+	}
+}
@@ -0,0 +1,84 @@
+package sources
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// NidAggregateBits is the IPv4 prefix length the client NIDs of the export
+// collectors are grouped by in lustre_clients_by_subnet, 0 disables it.
+var NidAggregateBits = 0
+
+// nonIPSubnet is the bucket of the NIDs whose address is not an IPv4 address,
+// e.g. the GUID or node id of gni/ptl4 NIDs.
+const nonIPSubnet = "non-ip"
+
+const clientsBySubnetHelp string = "Number of client NIDs with an export on the target, grouped by the --collector.nid-aggregate subnet of their address, the NIDs which are not IPv4 addresses are counted under subnet=\"non-ip\""
+
+// ApplyNidAggregate parses the --collector.nid-aggregate prefix length, given
+// as '/24' or '24'. An empty value disables the aggregation.
+func ApplyNidAggregate(spec string) error {
+	spec = strings.TrimPrefix(strings.TrimSpace(spec), "/")
+	if spec == "" {
+		NidAggregateBits = 0
+		return nil
+	}
+	bits, err := strconv.Atoi(spec)
+	if err != nil || bits < 1 || bits > 32 {
+		return fmt.Errorf("invalid prefix length %q, expected /1 to /32", spec)
+	}
+	NidAggregateBits = bits
+	return nil
+}
+
+// nidSubnet returns the subnet, in CIDR notation, of the address part of a
+// NID like '10.0.0.12@o2ib1'.
+func nidSubnet(nid string, bits int) string {
+	addr := nid
+	if idx := strings.Index(nid, "@"); idx >= 0 {
+		addr = nid[:idx]
+	}
+	ip := net.ParseIP(addr).To4()
+	if ip == nil {
+		return nonIPSubnet
+	}
+	mask := net.CIDRMask(bits, 32)
+	return (&net.IPNet{IP: ip.Mask(mask), Mask: mask}).String()
+}
+
+type subnetKey struct {
+	subnet string
+	target string
+}
+
+// clientSubnets collects the distinct client NIDs of every target and subnet
+// seen in a scrape, a NID is read once per export file.
+type clientSubnets map[subnetKey]map[string]bool
+
+func (c clientSubnets) add(target string, nid string) {
+	if NidAggregateBits == 0 {
+		return
+	}
+	key := subnetKey{nidSubnet(nid, NidAggregateBits), target}
+	if _, ok := c[key]; !ok {
+		c[key] = map[string]bool{}
+	}
+	c[key][nid] = true
+}
+
+func (c clientSubnets) metrics() []prometheus.Metric {
+	out := make([]prometheus.Metric, 0, len(c))
+	for key, nids := range c {
+		out = append(out, prometheus.MustNewConstMetric(
+			newDesc("mdt", "clients_by_subnet", clientsBySubnetHelp, []string{"subnet", "target"}),
+			prometheus.GaugeValue,
+			float64(len(nids)),
+			key.subnet, key.target,
+		))
+	}
+	return out
+}
@@ -0,0 +1,51 @@
+package sources
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestApplyNidAggregate(t *testing.T) {
+	defer func(prev int) { NidAggregateBits = prev }(NidAggregateBits)
+	for spec, expected := range map[string]int{"/24": 24, "16": 16, "": 0} {
+		if err := ApplyNidAggregate(spec); err != nil {
+			t.Fatal(err)
+		}
+		if NidAggregateBits != expected {
+			t.Fatalf("Retrieved an unexpected prefix length for %q. Expected: %d, Got: %d", spec, expected, NidAggregateBits)
+		}
+	}
+	for _, spec := range []string{"/0", "/33", "/x"} {
+		if err := ApplyNidAggregate(spec); err == nil {
+			t.Fatalf("Expected an error for %q", spec)
+		}
+	}
+}
+
+func TestClientSubnets(t *testing.T) {
+	defer func(prev int) { NidAggregateBits = prev }(NidAggregateBits)
+	NidAggregateBits = 24
+
+	c := clientSubnets{}
+	for _, nid := range []string{"10.0.0.12@tcp", "10.0.0.13@tcp", "10.0.1.7@o2ib1", "10.0.0.12@tcp", "10.0.1.8@o2ib1", "10.0.1.9@o2ib1", "27@gni"} {
+		c.add("lustrefs-MDT0000", nid)
+	}
+	c.add("lustrefs-MDT0001", "10.0.0.12@tcp")
+
+	got := map[subnetKey]int{}
+	for key, nids := range c {
+		got[key] = len(nids)
+	}
+	expected := map[subnetKey]int{
+		{"10.0.0.0/24", "lustrefs-MDT0000"}: 2,
+		{"10.0.1.0/24", "lustrefs-MDT0000"}: 3,
+		{nonIPSubnet, "lustrefs-MDT0000"}:   1,
+		{"10.0.0.0/24", "lustrefs-MDT0001"}: 1,
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Retrieved unexpected client counts. Expected: %v, Got: %v", expected, got)
+	}
+	if n := len(c.metrics()); n != len(expected) {
+		t.Fatalf("Retrieved an unexpected number of metrics. Expected: %d, Got: %d", len(expected), n)
+	}
+}
@@ -0,0 +1,115 @@
+package sources
+
+import (
+	"strconv"
+	"strings"
+)
+
+// RawOperationNames disables the normalization of the 'operation' label, only
+// the canonical spellings are then recognized in stats files.
+var RawOperationNames = false
+
+// operationRename is an operation reported as raw instead of canonical by the
+// Lustre versions before 'before' (major.minor), by every version when before
+// is empty.
+type operationRename struct {
+	raw       string
+	canonical string
+	before    string
+}
+
+// operationRenames lists the known spellings an operation has across Lustre
+// versions and stats files. Note that 'truncate' (llite) and 'punch' (OST) are
+// distinct operations, not a rename.
+var operationRenames = []operationRename{
+	{"getinfo", "get_info", ""},
+	{"setinfo", "set_info", ""},
+}
+
+// operationAliases maps the spellings an operation has in the running Lustre
+// version to its canonical 'operation' label value, see
+// ApplyOperationVersion.
+var operationAliases = operationAliasesFor("")
+
+// operationSpellings is the reverse of operationAliases.
+var operationSpellings = operationSpellingsOf(operationAliases)
+
+// ApplyOperationVersion sets the aliases to the renames of the Lustre version
+// (major.minor, e.g. 2.12). With an unknown version "" only the renames of
+// every version are applied: a rename of another version may be an
+// operation of its own.
+func ApplyOperationVersion(version string) {
+	operationAliases = operationAliasesFor(version)
+	operationSpellings = operationSpellingsOf(operationAliases)
+}
+
+func operationAliasesFor(version string) map[string]string {
+	out := map[string]string{}
+	for _, rename := range operationRenames {
+		if rename.before == "" || (version != "" && versionBefore(version, rename.before)) {
+			out[rename.raw] = rename.canonical
+		}
+	}
+	return out
+}
+
+func operationSpellingsOf(aliases map[string]string) map[string][]string {
+	out := map[string][]string{}
+	for alias, op := range aliases {
+		out[op] = append(out[op], alias)
+	}
+	return out
+}
+
+// versionBefore returns whether the major.minor version is older than
+// before. Unparsable versions are never before anything.
+func versionBefore(version string, before string) bool {
+	parse := func(v string) (int, int, bool) {
+		parts := strings.SplitN(v, ".", 3)
+		if len(parts) < 2 {
+			return 0, 0, false
+		}
+		major, err := strconv.Atoi(parts[0])
+		if err != nil {
+			return 0, 0, false
+		}
+		minor, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return 0, 0, false
+		}
+		return major, minor, true
+	}
+	major, minor, ok := parse(version)
+	beforeMajor, beforeMinor, beforeOk := parse(before)
+	if !ok || !beforeOk {
+		return false
+	}
+	return major < beforeMajor || (major == beforeMajor && minor < beforeMinor)
+}
+
+// normalizeOperation returns the canonical name of a raw operation name.
+func normalizeOperation(op string) string {
+	if RawOperationNames {
+		return op
+	}
+	if canonical, ok := operationAliases[op]; ok {
+		return canonical
+	}
+	return op
+}
+
+// captureOperation returns the first line of text where the operation op,
+// under its canonical name or one of its aliases, is followed by suffix. The
+// name must start the line (indentation aside), so "setattr" does not match
+// "reint_setattr".
+func captureOperation(op string, suffix string, text string) string {
+	if match := regexCaptureString(`(?m)^[ \t]*`+op+suffix, text); match != "" || RawOperationNames {
+		return match
+	}
+	for _, alias := range operationSpellings[op] {
+		if match := regexCaptureString(`(?m)^[ \t]*`+alias+suffix, text); match != "" {
+			return match
+		}
+	}
+	return ""
+}
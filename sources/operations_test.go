@@ -0,0 +1,129 @@
+package sources
+
+import (
+	"testing"
+)
+
+func TestNormalizeOperation(t *testing.T) {
+	for raw, expected := range map[string]string{
+		"getinfo":  "get_info",
+		"setinfo":  "set_info",
+		"get_info": "get_info",
+		"set_info": "set_info",
+		"getattr":  "getattr",
+	} {
+		if op := normalizeOperation(raw); op != expected {
+			t.Fatalf("Retrieved an unexpected operation for %s. Expected: %s, Got: %s", raw, expected, op)
+		}
+	}
+
+	RawOperationNames = true
+	defer func() { RawOperationNames = false }()
+	if op := normalizeOperation("getinfo"); op != "getinfo" {
+		t.Fatalf("Retrieved an unexpected raw operation. Expected: %s, Got: %s", "getinfo", op)
+	}
+}
+
+func TestOperationAliasesInStats(t *testing.T) {
+	statsFile := `snapshot_time             1510950459.787901292 secs.nsecs
+getinfo                   7 samples [reqs]
+connect                   3 samples [reqs]
+`
+	metricList, err := getStatsOperationMetrics(statsFile, "stats_total", statsHelp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]float64{}
+	for _, m := range metricList {
+		got[m.extraLabelValue] = m.value
+	}
+	if got["get_info"] != 7 || got["connect"] != 3 || len(got) != 2 {
+		t.Fatalf("Retrieved unexpected operations: %v", got)
+	}
+
+	jobBlock := `- job_id:          29
+  snapshot_time:   1493326943
+  setinfo:         { samples:           9, unit:  reqs }`
+	jobList, err := getJobStatsOperationMetrics(jobBlock, "29", "job_stats_total", jobStatsHelp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jobList) != 1 || jobList[0].extraLabelValue != "set_info" || jobList[0].value != 9 {
+		t.Fatalf("Retrieved unexpected job operations: %v", jobList)
+	}
+
+	var js jobState
+	if err := js.parsingFromText(jobBlock); err != nil {
+		t.Fatal(err)
+	}
+	if js.vals[20] != 9 {
+		t.Fatalf("Retrieved an unexpected set_info count. Expected: %d, Got: %d", 9, js.vals[20])
+	}
+
+	// the escape hatch only recognizes the canonical spellings
+	RawOperationNames = true
+	defer func() { RawOperationNames = false }()
+	metricList, err = getStatsOperationMetrics(statsFile, "stats_total", statsHelp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(metricList) != 1 || metricList[0].extraLabelValue != "connect" {
+		t.Fatalf("Retrieved unexpected operations with raw names: %v", metricList)
+	}
+}
+
+func TestOperationRenamesByVersion(t *testing.T) {
+	// simulates an operation renamed from 'old_op' to 'new_op' in 2.10
+	defer func(renames []operationRename) {
+		operationRenames = renames
+		ApplyOperationVersion("")
+	}(operationRenames)
+	operationRenames = append(operationRenames, operationRename{"old_op", "new_op", "2.10"})
+
+	for _, test := range []struct {
+		version string
+		raw     string
+	}{
+		{"2.7", "old_op"},
+		{"2.12", "new_op"},
+	} {
+		ApplyOperationVersion(test.version)
+		if op := normalizeOperation(test.raw); op != "new_op" {
+			t.Fatalf("Retrieved an unexpected operation for %s on Lustre %s. Expected: %s, Got: %s", test.raw, test.version, "new_op", op)
+		}
+		if match := captureOperation("new_op", " .*", test.raw+" 5 samples [reqs]\n"); match == "" {
+			t.Fatalf("Expected %s to be found as new_op on Lustre %s", test.raw, test.version)
+		}
+		if op := normalizeOperation("getinfo"); op != "get_info" {
+			t.Fatalf("Retrieved an unexpected operation on Lustre %s. Expected: %s, Got: %s", test.version, "get_info", op)
+		}
+	}
+
+	// the old name may be an operation of its own in later versions, and the
+	// version is unknown when it couldn't be detected
+	for _, version := range []string{"2.12", ""} {
+		ApplyOperationVersion(version)
+		if op := normalizeOperation("old_op"); op != "old_op" {
+			t.Fatalf("Retrieved an unexpected operation on Lustre %q. Expected: %s, Got: %s", version, "old_op", op)
+		}
+	}
+}
+
+func TestVersionBefore(t *testing.T) {
+	for _, test := range []struct {
+		version  string
+		before   string
+		expected bool
+	}{
+		{"2.7", "2.10", true},
+		{"2.9", "2.10", true},
+		{"2.10", "2.10", false},
+		{"2.15", "2.10", false},
+		{"1.8", "2.10", true},
+		{"garbage", "2.10", false},
+	} {
+		if got := versionBefore(test.version, test.before); got != test.expected {
+			t.Fatalf("Retrieved an unexpected comparison of %s and %s. Expected: %t, Got: %t", test.version, test.before, test.expected, got)
+		}
+	}
+}
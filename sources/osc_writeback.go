@@ -0,0 +1,49 @@
+package sources
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	oscPendingPages        string = "osc_pending_pages"
+	oscWritebackQueueDepth string = "osc_writeback_queue_depth"
+
+	oscPendingPagesHelp        string = "Number of dirty pages of the client waiting to be written back to the OST (pending write pages of rpc_stats)"
+	oscWritebackQueueDepthHelp string = "Number of write RPCs of the client to the OST in flight, the writeback queue depth (write RPCs in flight of rpc_stats)"
+)
+
+// oscWritebackFields maps the writeback metrics to their line in the header
+// of the osc 'rpc_stats' file:
+//
+//	snapshot_time:         1510950459.796835534 (secs.nsecs)
+//	read RPCs in flight:  0
+//	write RPCs in flight: 6
+//	pending write pages:  1244
+//	pending read pages:   0
+var oscWritebackFields = map[string]string{
+	oscPendingPages:        "pending write pages",
+	oscWritebackQueueDepth: "write RPCs in flight",
+}
+
+// rpcStatsHeaderValue returns the value of the header line field of an
+// 'rpc_stats' file, false if the file has no such line.
+func rpcStatsHeaderValue(content string, field string) (float64, bool, error) {
+	for _, line := range strings.Split(content, "\n") {
+		key, value, found := strings.Cut(line, ":")
+		if !found || strings.TrimSpace(key) != field {
+			continue
+		}
+		fields := strings.Fields(value)
+		if len(fields) == 0 {
+			return 0, false, fmt.Errorf("no value for rpc_stats field '%s'", field)
+		}
+		number, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			return 0, false, err
+		}
+		return number, true, nil
+	}
+	return 0, false, nil
+}
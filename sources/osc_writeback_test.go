@@ -0,0 +1,27 @@
+package sources
+
+import (
+	"os"
+	"testing"
+)
+
+func TestRPCStatsHeaderValue(t *testing.T) {
+	content, err := os.ReadFile("../tests/2.12/proc/fs/lustre/osc/lustrefs-OST0000-osc-ffff88105db50000/rpc_stats")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for promName, expected := range map[string]float64{
+		oscPendingPages:        1244,
+		oscWritebackQueueDepth: 6,
+	} {
+		value, ok, err := rpcStatsHeaderValue(string(content), oscWritebackFields[promName])
+		if err != nil || !ok || value != expected {
+			t.Fatalf("Retrieved an unexpected %s. Expected: %f, Got: %f (found: %t, %v)", promName, expected, value, ok, err)
+		}
+	}
+
+	// the histogram lines are not header fields
+	if _, ok, err := rpcStatsHeaderValue("pages per rpc         rpcs   % cum % |       rpcs   % cum %\n", "pages per rpc"); ok || err != nil {
+		t.Fatalf("Expected no header value, Got: %t (%v)", ok, err)
+	}
+}
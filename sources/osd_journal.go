@@ -0,0 +1,51 @@
+package sources
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	osdJournalStats string = "journal_stats"
+
+	osdJournalInflightHelp string = "Number of journal handles (transactions) of the ldiskfs backend of the OST in flight"
+	osdJournalWaitHelp     string = "Total time in milliseconds spent by the OST waiting to start a journal handle, journal pressure of the ldiskfs backend"
+	osdIOWaitHelp          string = "Total time in milliseconds spent by the OST waiting for the ldiskfs backend to complete I/Os"
+)
+
+// osdJournalFields maps the metrics of the 'journal_stats' file of the
+// osd-ldiskfs devices to their field.
+var osdJournalFields = map[string]string{
+	"osd_journal_inflight":                "inflight",
+	"osd_journal_wait_milliseconds_total": "journal_wait_ms",
+	"osd_io_wait_milliseconds_total":      "io_wait_ms",
+}
+
+// osdJournalValue returns the value of promName from the content of a
+// 'journal_stats' file:
+//
+//	snapshot_time:         1700000000.123456789 secs.nsecs
+//	inflight:              3
+//	journal_wait_ms:       182734
+//	io_wait_ms:            904512
+//
+// ok is false when the file has no such field.
+func osdJournalValue(promName string, content string) (value float64, ok bool, err error) {
+	field, known := osdJournalFields[promName]
+	if !known {
+		return 0, false, fmt.Errorf("unknown journal_stats metric %s", promName)
+	}
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || strings.TrimSuffix(fields[0], ":") != field {
+			continue
+		}
+		value, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			return 0, false, err
+		}
+		return value, true, nil
+	}
+	return 0, false, nil
+}
@@ -0,0 +1,52 @@
+package sources
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestOsdJournalStats(t *testing.T) {
+	var s lustreProcfsSource
+	s.basePath = "../tests/ldiskfs/proc/fs/lustre"
+	s.generateOSTMetricTemplates(extended)
+
+	got := map[string]float64{}
+	for _, metric := range s.lustreProcMetrics {
+		if metric.filename != osdJournalStats {
+			continue
+		}
+		paths, err := filepath.Glob(filepath.Join(s.basePath, metric.path, metric.filename))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, path := range paths {
+			err := s.parseOsdJournalStats(metric.source, path, 0, metric.helpText, metric.promName, func(nodeType string, nodeName string, name string, helpText string, value float64) {
+				if nodeType != "ost" || nodeName != "lustrefs-OST0000" {
+					t.Fatalf("Retrieved an unexpected target. Expected: %s, Got: %s/%s", "ost/lustrefs-OST0000", nodeType, nodeName)
+				}
+				got[name] = value
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	expected := map[string]float64{
+		"osd_journal_inflight":                3,
+		"osd_journal_wait_milliseconds_total": 182734,
+		"osd_io_wait_milliseconds_total":      904512,
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("Retrieved an unexpected number of journal metrics. Expected: %d, Got: %v", len(expected), got)
+	}
+	for name, value := range expected {
+		if got[name] != value {
+			t.Fatalf("Retrieved an unexpected value for %s. Expected: %f, Got: %f", name, value, got[name])
+		}
+	}
+
+	// the fields are optional
+	if _, ok, err := osdJournalValue("osd_journal_inflight", "journal_wait_ms: 12\n"); ok || err != nil {
+		t.Fatalf("Expected a missing field to be skipped, Got: %t (%v)", ok, err)
+	}
+}
@@ -0,0 +1,79 @@
+package sources
+
+import (
+	"math"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const ostSpaceImbalanceHelp string = "Spread of the free space of the OSTs of the filesystem seen by this node, (max_free - min_free) / max_free, 0 when all OSTs have the same free space and close to 1 when some are full while others are empty"
+
+type ostSpace struct {
+	free     float64
+	capacity float64
+	hasFree  bool
+}
+
+// ostSpaces collects the free space and capacity of every local OST seen in a
+// scrape. The same OST is read under both osd-* and obdfilter, the smallest
+// free value is kept so the result does not depend on the read order.
+type ostSpaces map[string]*ostSpace
+
+func (o ostSpaces) add(component string, target string, name string, value float64) {
+	if component != "ost" || (name != "free_kilobytes" && name != "capacity_kilobytes") {
+		return
+	}
+	space, ok := o[target]
+	if !ok {
+		space = &ostSpace{}
+		o[target] = space
+	}
+	if name == "capacity_kilobytes" {
+		space.capacity = math.Max(space.capacity, value)
+	} else if !space.hasFree || value < space.free {
+		space.free = value
+		space.hasFree = true
+	}
+}
+
+// imbalance returns (max_free - min_free) / max_free per filesystem, the OSTs
+// reporting no capacity are skipped.
+func (o ostSpaces) imbalance() map[string]float64 {
+	minFree := map[string]float64{}
+	maxFree := map[string]float64{}
+	for target, space := range o {
+		if space.capacity <= 0 || !space.hasFree {
+			continue
+		}
+		fs := ostFsName(target)
+		if free, ok := minFree[fs]; !ok || space.free < free {
+			minFree[fs] = space.free
+		}
+		if free, ok := maxFree[fs]; !ok || space.free > free {
+			maxFree[fs] = space.free
+		}
+	}
+	out := make(map[string]float64, len(maxFree))
+	for fs, max := range maxFree {
+		if max <= 0 {
+			// every OST is full, they are balanced
+			out[fs] = 0
+			continue
+		}
+		out[fs] = (max - minFree[fs]) / max
+	}
+	return out
+}
+
+func (o ostSpaces) metrics() []prometheus.Metric {
+	var out []prometheus.Metric
+	for fs, ratio := range o.imbalance() {
+		out = append(out, prometheus.MustNewConstMetric(
+			newDesc("ost", "ost_space_imbalance_ratio", ostSpaceImbalanceHelp, []string{"fs"}),
+			prometheus.GaugeValue,
+			ratio,
+			fs,
+		))
+	}
+	return out
+}
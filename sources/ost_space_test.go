@@ -0,0 +1,42 @@
+package sources
+
+import (
+	"testing"
+)
+
+func TestOstSpaceImbalance(t *testing.T) {
+	spaces := ostSpaces{}
+	for _, ost := range []struct {
+		target   string
+		free     float64
+		capacity float64
+	}{
+		{"scratch-OST0000", 900, 1000},
+		{"scratch-OST0001", 100, 1000},
+		{"scratch-OST0002", 500, 1000},
+		{"scratch-OST0003", 0, 0}, // not set up, skipped
+		{"home-OST0000", 400, 1000},
+		{"home-OST0001", 400, 1000},
+	} {
+		spaces.add("ost", ost.target, "free_kilobytes", ost.free)
+		spaces.add("ost", ost.target, "capacity_kilobytes", ost.capacity)
+	}
+	// the same OST read again under obdfilter, and an MDT which is ignored
+	spaces.add("ost", "scratch-OST0000", "free_kilobytes", 899)
+	spaces.add("mdt", "scratch-MDT0000", "free_kilobytes", 1)
+	spaces.add("mdt", "scratch-MDT0000", "capacity_kilobytes", 1000)
+
+	expected := map[string]float64{
+		"scratch": (899.0 - 100) / 899,
+		"home":    0,
+	}
+	got := spaces.imbalance()
+	if len(got) != len(expected) {
+		t.Fatalf("Retrieved an unexpected number of filesystems. Expected: %d, Got: %d", len(expected), len(got))
+	}
+	for fs, value := range expected {
+		if got[fs] != value {
+			t.Fatalf("Retrieved an unexpected imbalance for %s. Expected: %f, Got: %f", fs, value, got[fs])
+		}
+	}
+}
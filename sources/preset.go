@@ -0,0 +1,92 @@
+package sources
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var (
+	// JobStatsEnabled collects the job_stats files of the OSTs and MDTs, one
+	// series per jobid.
+	JobStatsEnabled = true
+	// BrwStatsEnabled collects the brw_stats histograms of the OSTs and MDTs.
+	BrwStatsEnabled = true
+)
+
+// presetToggles maps the names of the collectors and sub-toggles a preset sets
+// to the flag controlling them, for the ones set explicitly to be left alone.
+var presetToggles = map[string]*bool{
+	"jobstats":  &JobStatsEnabled,
+	"brw-stats": &BrwStatsEnabled,
+}
+
+type preset struct {
+	levels  map[string]string // collectors not listed are disabled
+	toggles map[string]bool
+}
+
+// presets are the curated minimal sets of --preset: the core level of the
+// collectors of the role (capacity, inodes, health, basic stats) without the
+// job_stats and brw_stats of the targets.
+var presets = map[string]preset{
+	"oss-lite": {
+		levels:  map[string]string{"ost": core, "generic": core, "health": core},
+		toggles: map[string]bool{"jobstats": false, "brw-stats": false},
+	},
+	"mds-lite": {
+		levels:  map[string]string{"mdt": core, "mds": core, "mgs": core, "generic": core, "health": core},
+		toggles: map[string]bool{"jobstats": false, "brw-stats": false},
+	},
+	"client-lite": {
+		levels:  map[string]string{"client": core, "generic": core, "health": core},
+		toggles: map[string]bool{"jobstats": false, "brw-stats": false},
+	},
+}
+
+// ApplyPreset sets the collector levels and sub-toggles of the named preset.
+// The ones in explicit, named as in --collector.components (plus "jobstats"
+// and "brw-stats"), were given on the command line and keep their value.
+func ApplyPreset(name string, explicit map[string]bool) error {
+	p, ok := presets[name]
+	if !ok {
+		valid := make([]string, 0, len(presets))
+		for n := range presets {
+			valid = append(valid, n)
+		}
+		sort.Strings(valid)
+		return fmt.Errorf("unknown preset %q, valid presets: %s", name, strings.Join(valid, ", "))
+	}
+	for component, level := range componentFlags {
+		if explicit[component] || component == "oss" {
+			continue
+		}
+		if l, ok := p.levels[component]; ok {
+			*level = l
+		} else {
+			*level = disabled
+		}
+	}
+	for toggle, value := range p.toggles {
+		if !explicit[toggle] {
+			*presetToggles[toggle] = value
+		}
+	}
+	return nil
+}
+
+// dropDisabledFiles removes the templates of the job_stats and brw_stats
+// files when they are turned off.
+func dropDisabledFiles(metrics []lustreProcMetric) []lustreProcMetric {
+	if JobStatsEnabled && BrwStatsEnabled {
+		return metrics
+	}
+	kept := metrics[:0]
+	for _, metric := range metrics {
+		if (!JobStatsEnabled && metric.filename == "job_stats") || (!BrwStatsEnabled && metric.filename == "brw_stats") {
+			continue
+		}
+		kept = append(kept, metric)
+	}
+	return kept
+}
@@ -0,0 +1,72 @@
+package sources
+
+import (
+	"testing"
+)
+
+func TestApplyPreset(t *testing.T) {
+	all := []*string{&OstEnabled, &MdtEnabled, &MgsEnabled, &MdsEnabled, &ClientEnabled, &GenericEnabled, &LnetEnabled, &LdlmEnabled, &HealthStatusEnabled}
+	saved := make([]string, len(all))
+	for i, level := range all {
+		saved[i] = *level
+		*level = extended
+	}
+	defer func() {
+		for i, level := range all {
+			*level = saved[i]
+		}
+		JobStatsEnabled = true
+		BrwStatsEnabled = true
+	}()
+
+	// --collector.lnet=extended given with the preset wins over it
+	if err := ApplyPreset("oss-lite", map[string]bool{"lnet": true}); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[*string]string{
+		&OstEnabled:          core,
+		&MdtEnabled:          disabled,
+		&MgsEnabled:          disabled,
+		&MdsEnabled:          disabled,
+		&ClientEnabled:       disabled,
+		&GenericEnabled:      core,
+		&LnetEnabled:         extended,
+		&LdlmEnabled:         disabled,
+		&HealthStatusEnabled: core,
+	}
+	for level, want := range expected {
+		if *level != want {
+			t.Fatalf("Retrieved an unexpected collector level. Expected: %s, Got: %s", want, *level)
+		}
+	}
+	if JobStatsEnabled || BrwStatsEnabled {
+		t.Fatalf("Expected oss-lite to disable the job_stats and brw_stats, Got: %t, %t", JobStatsEnabled, BrwStatsEnabled)
+	}
+
+	source := newLustreSource(Config{ProcLocation: "../tests/2.12/proc"}).(*lustreProcfsSource)
+	families := map[string]bool{}
+	for _, metric := range source.lustreProcMetrics {
+		if metric.filename == "job_stats" || metric.filename == "brw_stats" {
+			t.Fatalf("Retrieved an unexpected %s template %s with oss-lite", metric.filename, metric.promName)
+		}
+		families[metric.promName] = true
+	}
+	for _, name := range []string{"capacity_kilobytes", "free_kilobytes", "inodes_free", "inodes_maximum", "degraded", "read_bytes_total", "write_bytes_total"} {
+		if !families[name] {
+			t.Fatalf("Expected oss-lite to collect %s", name)
+		}
+	}
+
+	// an explicit --collector.jobstats keeps the job_stats
+	JobStatsEnabled = true
+	if err := ApplyPreset("oss-lite", map[string]bool{"jobstats": true}); err != nil {
+		t.Fatal(err)
+	}
+	if !JobStatsEnabled {
+		t.Fatal("Expected an explicit --collector.jobstats to override the preset")
+	}
+
+	if err := ApplyPreset("oss-heavy", nil); err == nil {
+		t.Fatal("Expected an error for an unknown preset")
+	}
+}
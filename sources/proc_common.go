@@ -19,6 +19,8 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync/atomic"
+	"unicode"
 
 	"github.com/prometheus/client_golang/prometheus"
 )
@@ -34,6 +36,152 @@ var (
 	jobidRegexPattern = regexp.MustCompile(`job_id:\s*(.*[0-9]+|[0-9_]+)`)
 )
 
+// integerMetrics flags the metric families whose values are inherently
+// integers (counts of inodes, objects, pages, bytes, operations, ...).
+// Note that the text exposition format may still render large values in
+// exponent form, consumers should always parse the values as floats.
+var integerMetrics = map[string]bool{
+	"available_kilobytes":            true,
+	"blocksize_bytes":                true,
+	"cache_access_total":             true,
+	"cache_miss_total":               true,
+	"capacity_kilobytes":             true,
+	"client_ldlm_lock_count":         true,
+	"client_ldlm_lru_size":           true,
+	"client_lmv_mdt_count":           true,
+	"client_lov_stripe_count":        true,
+	"default_ea_size_bytes":          true,
+	"discontiguous_blocks_total":     true,
+	"discontiguous_pages_total":      true,
+	"disk_io":                        true,
+	"disk_io_total":                  true,
+	"drop_bytes_total":               true,
+	"drop_count_total":               true,
+	"errors_total":                   true,
+	"exports_active":                 true,
+	"exports_dirty_total":            true,
+	"exports_granted_total":          true,
+	"exports_pending_total":          true,
+	"exports_total":                  true,
+	"free_kilobytes":                 true,
+	"free_page_low":                  true,
+	"free_pages":                     true,
+	"grant_precreate_capacity_bytes": true,
+	"grows_failure_total":            true,
+	"grows_total":                    true,
+	"inodes_free":                    true,
+	"inodes_maximum":                 true,
+	"job_read_bytes_total":           true,
+	"job_read_maximum_size_bytes":    true,
+	"job_read_minimum_size_bytes":    true,
+	"job_read_samples_total":         true,
+	"job_stats_total":                true,
+	"job_write_bytes_total":          true,
+	"job_write_maximum_size_bytes":   true,
+	"job_write_minimum_size_bytes":   true,
+	"job_write_samples_total":        true,
+	"lnet_memory_used_bytes":         true,
+	"lock_cancel_total":              true,
+	"lock_contended_total":           true,
+	"lnet_selftest_errors_total":     true,
+	"lock_count_total":               true,
+	"lock_timeout_total":             true,
+	"locks_grant_total":              true,
+	"locks_granted":                  true,
+	"maximum_ea_size_bytes":          true,
+	"maximum_pages":                  true,
+	"maximum_pages_reached_total":    true,
+	"maximum_pools":                  true,
+	"operation_errors_total":         true,
+	"osc_pending_pages":              true,
+	"osc_writeback_queue_depth":      true,
+	"out_of_memory_request_total":    true,
+	"pages_in_pools":                 true,
+	"pages_per_bulk_rw_total":        true,
+	"pages_per_pool":                 true,
+	"pages_per_rpc_total":            true,
+	"physical_pages":                 true,
+	"qmt_global_limit_inodes":        true,
+	"qmt_global_limit_kilobytes":     true,
+	"qmt_global_used_inodes":         true,
+	"qmt_global_used_kilobytes":      true,
+	"read_bytes_total":               true,
+	"read_maximum_size_bytes":        true,
+	"read_minimum_size_bytes":        true,
+	"read_samples_total":             true,
+	"readcache_max_filesize_bytes":   true,
+	"receive_bytes_total":            true,
+	"receive_count_total":            true,
+	"recalc_freed_total":             true,
+	"route_bytes_total":              true,
+	"route_count_total":              true,
+	"rpcs_in_flight":                 true,
+	"send_bytes_total":               true,
+	"send_count_total":               true,
+	"shrink_freed_total":             true,
+	"shrink_requests_total":          true,
+	"shrinks_total":                  true,
+	"stats_total":                    true,
+	"write_bytes_total":              true,
+	"write_maximum_size_bytes":       true,
+	"write_minimum_size_bytes":       true,
+	"write_samples_total":            true,
+}
+
+func isIntegerMetric(promName string) bool {
+	return integerMetrics[promName]
+}
+
+// SanitizeLabels strips control characters and surrounding whitespace from
+// the label values before they are emitted, e.g. from corrupted jobids.
+var SanitizeLabels = false
+
+const labelsSanitizedHelp string = "Total number of label values which had control characters or surrounding whitespace removed"
+
+var labelsSanitized uint64
+
+// sanitizeLabels returns labelValues cleaned up if SanitizeLabels is set. The
+// slice is copied before any change since callers may reuse it.
+func sanitizeLabels(labelValues []string) []string {
+	if !SanitizeLabels {
+		return labelValues
+	}
+	var out []string
+	for i, value := range labelValues {
+		clean := strings.TrimSpace(strings.Map(func(r rune) rune {
+			if unicode.IsControl(r) {
+				return -1
+			}
+			return r
+		}, value))
+		if clean == value {
+			continue
+		}
+		if out == nil {
+			out = append([]string(nil), labelValues...)
+		}
+		out[i] = clean
+		atomic.AddUint64(&labelsSanitized, 1)
+	}
+	if out == nil {
+		return labelValues
+	}
+	return out
+}
+
+func labelsSanitizedMetric() prometheus.Metric {
+	return prometheus.MustNewConstMetric(
+		prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "", "labels_sanitized_total"),
+			labelsSanitizedHelp,
+			nil,
+			nil,
+		),
+		prometheus.CounterValue,
+		float64(atomic.LoadUint64(&labelsSanitized)),
+	)
+}
+
 type prometheusType func([]string, []string, string, string, float64) prometheus.Metric
 
 type lustreProcMetric struct {
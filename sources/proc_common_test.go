@@ -17,6 +17,7 @@ import (
 	"errors"
 	"reflect"
 	"strings"
+	"sync/atomic"
 	"testing"
 )
 
@@ -160,3 +161,58 @@ func TestConvertToBytes(t *testing.T) {
 		}
 	}
 }
+
+func TestIntegerMetrics(t *testing.T) {
+	for _, name := range []string{"inodes_free", "inodes_maximum", "capacity_kilobytes", "exports_total", "stats_total", "job_stats_total"} {
+		if !isIntegerMetric(name) {
+			t.Fatalf("Metric %s is not flagged as integer-semantic", name)
+		}
+	}
+	for _, name := range []string{"lock_grant_rate", "recovery_time_soft_seconds", "health_check"} {
+		if isIntegerMetric(name) {
+			t.Fatalf("Metric %s is unexpectedly flagged as integer-semantic", name)
+		}
+	}
+
+	// every flagged family has to be a metric we actually export
+	var s lustreProcfsSource
+	for _, generate := range []func(string){s.generateOSTMetricTemplates, s.generateMDTMetricTemplates, s.generateMGSMetricTemplates, s.generateMDSMetricTemplates, s.generateClientMetricTemplates, s.generateGenericMetricTemplates} {
+		generate(extended)
+	}
+	var sys lustreProcsysSource
+	sys.generateLNETTemplates(extended)
+	known := map[string]bool{}
+	for _, m := range append(s.lustreProcMetrics, sys.lustreProcMetrics...) {
+		known[m.promName] = true
+	}
+	for name := range integerMetrics {
+		if !known[name] {
+			t.Fatalf("Metric %s is flagged as integer-semantic but is not exported", name)
+		}
+	}
+}
+
+func TestSanitizeLabels(t *testing.T) {
+	defer func(prev bool) { SanitizeLabels = prev }(SanitizeLabels)
+
+	labelValues := []string{"ost", "lustrefs-OST0000", "dd\x07.0\n"}
+
+	SanitizeLabels = false
+	if got := sanitizeLabels(labelValues); !reflect.DeepEqual(got, labelValues) {
+		t.Fatalf("Label values changed while sanitizing is disabled: %q", got)
+	}
+
+	SanitizeLabels = true
+	before := atomic.LoadUint64(&labelsSanitized)
+	got := sanitizeLabels(labelValues)
+	expected := []string{"ost", "lustrefs-OST0000", "dd.0"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Retrieved unexpected label values. Expected: %q, Got: %q", expected, got)
+	}
+	if labelValues[2] != "dd\x07.0\n" {
+		t.Fatalf("The label values of the caller were modified: %q", labelValues)
+	}
+	if n := atomic.LoadUint64(&labelsSanitized) - before; n != 1 {
+		t.Fatalf("Retrieved an unexpected number of sanitized labels. Expected: %d, Got: %d", 1, n)
+	}
+}
@@ -14,11 +14,14 @@
 package sources
 
 import (
-	"os"
+	"fmt"
+	"math"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 )
@@ -37,11 +40,12 @@ const (
 	statsHelp        string = "Number of operations the filesystem has performed."
 
 	// Help text dedicated to the 'brw_stats' file
-	pagesPerBlockRWHelp    string = "Total number of pages per block RPC."
-	discontiguousPagesHelp string = "Total number of logical discontinuities per RPC."
-	ioTimeHelp             string = "Total time in milliseconds the filesystem has spent processing various object sizes."
-	diskIOSizeHelp         string = "Total number of operations the filesystem has performed for the given size."
-	diskIOsInFlightHelp    string = "Current number of I/O operations that are processing during the snapshot."
+	pagesPerBlockRWHelp     string = "Total number of pages per block RPC."
+	discontiguousPagesHelp  string = "Total number of logical discontinuities per RPC."
+	discontiguousBlocksHelp string = "Total number of on-disk discontinuities per RPC, the disk level fragmentation of the target."
+	ioTimeHelp              string = "Total time in milliseconds the filesystem has spent processing various object sizes."
+	diskIOSizeHelp          string = "Total number of operations the filesystem has performed for the given size."
+	diskIOsInFlightHelp     string = "Current number of I/O operations that are processing during the snapshot."
 
 	// Help text dedicated to the 'rpc_stats' file
 	pagesPerRPCHelp  string = "Total number of pages per RPC."
@@ -65,9 +69,99 @@ const (
 	maxWaitQueueDepthHelp string = "Maximum waitqueue length."
 	outOfMemHelp          string = "Total number of out of memory requests."
 
+	// Help text dedicated to the 'unstable_stats' file of llite
+	unstablePagesHelp string = "Number of pages sent to the OSTs but not yet committed to stable storage, pinned in client memory until then."
+
+	// Help text dedicated to the 'pool/stats' file of ldlm namespaces
+	ldlmPoolGrantRateHelp  string = "Mean number of locks granted per second by the pool over its recalculations."
+	ldlmPoolCancelRateHelp string = "Mean number of locks cancelled per second by the pool over its recalculations."
+	ldlmPoolGrantSpeedHelp string = "Mean grant speed of the pool (grant rate less cancel rate), positive values mean the lock count is growing."
+
+	// Help text dedicated to the read-ahead tunables of llite
+	clientMaxReadAheadHelp        string = "Maximum number of megabytes the client reads ahead, across all files (max_read_ahead_mb)"
+	clientMaxReadAheadPerFileHelp string = "Maximum number of megabytes the client reads ahead for a single file (max_read_ahead_per_file_mb)"
+	clientMaxReadAheadWholeHelp   string = "Maximum size in megabytes of a file the client reads in its entirety (max_read_ahead_whole_mb)"
+
+	// Help text dedicated to the client side ldlm namespaces
+	clientLdlmLruSizeHelp   string = "Maximum number of locks the client keeps in the LRU of the ldlm namespace, 0 means the size is set dynamically (lru_size)"
+	clientLdlmLockCountHelp string = "Number of locks the client currently holds in the ldlm namespace (lock_count)"
+
+	// Help text dedicated to the client side lov and lmv devices
+	clientLovStripeCountHelp string = "Default stripe count of the files created by the client, as seen by its lov device (stripecount)"
+	clientLmvMdtCountHelp    string = "Number of MDTs the lmv device of the client spreads the metadata over (numobd)"
+
+	// Help text dedicated to the read cache tunables of obdfilter
+	readcacheMaxFilesizeHelp string = "Maximum size in bytes of the files the OST keeps in its read cache, larger files are read without caching (readcache_max_filesize)"
+
+	// Help text dedicated to the 'lock_timeouts' file of ldlm namespaces
+	ldlmBlockingTimeoutsHelp string = "Total number of locks of the namespace which timed out waiting for the lock callback of a client, a growing value on a server target usually points at one unresponsive client."
+
+	// Help text dedicated to the 'osp' devices on the MDS
+	ospPreallocGapHelp string = "Number of objects precreated on the OST and not used yet by the MDS (prealloc_last_id - prealloc_next_id + 1), 0 means creates will stall"
+
+	// Help text dedicated to the global indexes of the quota master
+	qmtGlobalUsedKilobytesHelp  string = "Space in kilobytes granted by the quota master to the ID over the whole filesystem, the global quota usage (granted)"
+	qmtGlobalLimitKilobytesHelp string = "Global hard block quota limit of the ID in kilobytes, 0 means no limit (hard)"
+	qmtGlobalUsedInodesHelp     string = "Inodes granted by the quota master to the ID over the whole filesystem, the global quota usage (granted)"
+	qmtGlobalLimitInodesHelp    string = "Global hard inode quota limit of the ID, 0 means no limit (hard)"
+
+	// Help text dedicated to the FID sequence controller/server on the MDS
+	seqAllocatedHelp string = "First FID sequence of the space not handed out yet by the sequence controller/server, the space ends at 0xffffffffffffffff on the controller"
+	seqWidthHelp     string = "Number of sequences (controller) or FIDs per sequence (server) handed out at a time"
+
+	// Help text dedicated to the 'stats' files of the MDT services
+	mdtReqQdepthHelp string = "Average number of requests waiting in the queue of the MDT service when a request arrives, since the stats were last cleared (sum / samples)"
+	mdtReqActiveHelp string = "Average number of requests being handled by the MDT service when a request arrives, since the stats were last cleared (sum / samples)"
+
+	// Help text dedicated to the 'reint_*' lines of the MDT 'md_stats' file
+	mdtReintHelp string = "Number of modifying (reintegration) requests of the given type the MDT has handled."
+
+	// Help text dedicated to the '<operation>_errors' lines of the 'stats' and 'md_stats' files
+	opErrorsHelp string = "Number of operations of the given type which failed, only reported when the stats file has an error counter for it."
+
+	// Help text dedicated to the optional fields of the 'recovery_status' files
+	recoveryStaleLocksHelp   string = "Total number of stale locks cancelled during the recovery of the target, only reported when recovery_status has the field"
+	recoveryStaleClientsHelp string = "Number of stale clients of the recovery of the target, only reported when recovery_status has the field"
+
+	// Help text dedicated to the 'exports/*/ldlm_stats' files of the MDT
+	mdtExportLockRPCDifferenceHelp string = "Number of ldlm_enqueue less ldlm_cancel requests the client sent to the target, from the ldlm_stats of the export. Not the number of locks held: a cancel request can carry several locks and the locks revoked by the server are not counted."
+
+	// Help text dedicated to the default striping of the MDT 'lod' devices
+	defaultStripeCountHelp string = "Default number of OSTs a new file is striped over, -1 means all OSTs."
+	defaultStripeSizeHelp  string = "Default stripe size of new files in bytes."
+	ostPoolMemberHelp      string = "Returns 1 for every OST which is a member of the OST pool, as listed by the MDT 'lod' devices."
+
+	// Help text dedicated to the 'import' file of the mgc, osc and mdc devices
+	mgcImportStateHelp   string = "Current state of the import to the MGS (1 for the reported state), anything but FULL or IDLE for long means configuration updates are not received"
+	mgcLastPingHelp      string = "Number of seconds since the last reply (pings included) was received from the MGS, only reported when the import tracks idleness"
+	importReconnectsHelp string = "Total number of reconnections of the import to the target, a rising rate on many clients points to a server or network problem"
+	importTimeoutsHelp   string = "Total number of RPCs to the target which timed out"
+
+	// Help text dedicated to the server-level sums of the OST byte counters
+	ossReadBytesHelp  string = "The total number of bytes that have been read from all OSTs of this OSS."
+	ossWriteBytesHelp string = "The total number of bytes that have been written to all OSTs of this OSS."
+
 	//repeated strings replaced by constants
-	mdStats          string = "md_stats"
-	encryptPagePools string = "encrypt_page_pools"
+	mdStats           string = "md_stats"
+	encryptPagePools  string = "encrypt_page_pools"
+	unstableStats     string = "unstable_stats"
+	ldlmPoolStats     string = "pool/stats"
+	ospPreallocLastID string = "prealloc_last_id"
+	ospPreallocNextID string = "prealloc_next_id"
+	exportLdlmStats   string = "ldlm_stats"
+	lodStripeCount    string = "stripecount"
+	lodStripeSize     string = "stripesize"
+	seqSpace          string = "space"
+	importFile        string = "import"
+	mdtServiceStats   string = "mdt*/stats"
+	lodPools          string = "pools/*"
+	mdtReintTotal     string = "mdt_reint_total"
+	opErrorsTotal     string = "operation_errors_total"
+	recoveryStatus    string = "recovery_status"
+	reintPrefix       string = "reint_"
+	opErrorsSuffix    string = "_errors"
+
+	clientLovStripeCount string = "client_lov_stripe_count"
 )
 
 var (
@@ -83,6 +177,30 @@ var (
 	ClientEnabled string
 	// GenericEnabled specifies whether to collect Generic metrics
 	GenericEnabled string
+	// LdlmEnabled specifies whether to collect LDLM metrics
+	LdlmEnabled string
+	// MdtExportStats specifies whether to collect the per-client metrics of
+	// the MDT exports, which creates one series per client and target
+	MdtExportStats bool
+	// ExportNidAllow, if set, limits the per-client metrics to the NIDs it matches
+	ExportNidAllow *regexp.Regexp
+	// ExportNidDeny, if set, drops the per-client metrics of the NIDs it matches
+	ExportNidDeny *regexp.Regexp
+	// ServiceStatsEnabled specifies whether to collect the request queue
+	// metrics of the MDT services (mds/MDS/mdt*/stats)
+	ServiceStatsEnabled bool
+	// RecoveryEnabled specifies whether to collect the stale locks and
+	// clients and the recovery count of the OST and MDT recovery_status files
+	RecoveryEnabled bool
+	// HsmEnabled specifies whether to collect the HSM coordinator queue of the
+	// MDTs (hsm/actions and hsm/active_requests)
+	HsmEnabled bool
+	// PtlrpcEnabled specifies whether to collect the RPC error counters of the
+	// client imports (osc and mdc import files)
+	PtlrpcEnabled bool
+	// DropZeroJobStats drops the OST job_stats blocks without any read or
+	// write sample (v2 only)
+	DropZeroJobStats bool
 )
 
 type lustreJobsMetric struct {
@@ -102,12 +220,16 @@ type multistatParsingStruct struct {
 }
 
 func init() {
-	Factories["procfs"] = newLustreSource
+	Register("procfs", func(cfg Config) Collector { return newLustreSource(cfg) })
 }
 
 type lustreProcfsSource struct {
 	lustreProcMetrics []lustreProcMetric
+	cfg               Config
 	basePath          string
+	sysBasePaths      []string
+	modulesPath       string
+	uuids             *targetUUIDs
 }
 
 func (s *lustreProcfsSource) generateOSTMetricTemplates(filter string) {
@@ -116,6 +238,7 @@ func (s *lustreProcfsSource) generateOSTMetricTemplates(filter string) {
 			{"blocksize", "blocksize_bytes", "Filesystem block size in bytes", s.gaugeMetric, false, core},
 			{"brw_stats", "pages_per_bulk_rw_total", pagesPerBlockRWHelp, s.counterMetric, false, extended},
 			{"brw_stats", "discontiguous_pages_total", discontiguousPagesHelp, s.counterMetric, false, extended},
+			{"brw_stats", "discontiguous_blocks_total", discontiguousBlocksHelp, s.counterMetric, false, extended},
 			{"brw_stats", "disk_io", diskIOsInFlightHelp, s.gaugeMetric, false, core},
 			{"brw_stats", "io_time_milliseconds_total", ioTimeHelp, s.counterMetric, false, core},
 			{"brw_stats", "disk_io_total", diskIOSizeHelp, s.counterMetric, false, core},
@@ -125,11 +248,17 @@ func (s *lustreProcfsSource) generateOSTMetricTemplates(filter string) {
 			{"kbytesfree", "free_kilobytes", "Number of kilobytes allocated to the pool", s.gaugeMetric, false, core},
 			{"kbytestotal", "capacity_kilobytes", "Capacity of the pool in kilobytes", s.gaugeMetric, false, core},
 		},
+		"osd-ldiskfs/*OST*": {
+			{osdJournalStats, "osd_journal_inflight", osdJournalInflightHelp, s.gaugeMetric, false, extended},
+			{osdJournalStats, "osd_journal_wait_milliseconds_total", osdJournalWaitHelp, s.counterMetric, false, extended},
+			{osdJournalStats, "osd_io_wait_milliseconds_total", osdIOWaitHelp, s.counterMetric, false, extended},
+		},
 		"obdfilter/*": {
 			{"blocksize", "blocksize_bytes", "Filesystem block size in bytes", s.gaugeMetric, false, core},
 			{"brw_size", "brw_size_megabytes", "Block read/write size in megabytes", s.gaugeMetric, false, extended},
 			{"brw_stats", "pages_per_bulk_rw_total", pagesPerBlockRWHelp, s.counterMetric, false, extended},
 			{"brw_stats", "discontiguous_pages_total", discontiguousPagesHelp, s.counterMetric, false, extended},
+			{"brw_stats", "discontiguous_blocks_total", discontiguousBlocksHelp, s.counterMetric, false, extended},
 			{"brw_stats", "disk_io", diskIOsInFlightHelp, s.gaugeMetric, false, core},
 			{"brw_stats", "io_time_milliseconds_total", ioTimeHelp, s.counterMetric, false, core},
 			{"brw_stats", "disk_io_total", diskIOSizeHelp, s.counterMetric, false, core},
@@ -148,12 +277,16 @@ func (s *lustreProcfsSource) generateOSTMetricTemplates(filter string) {
 			{"job_stats", "job_write_maximum_size_bytes", writeMaximumHelp, s.gaugeMetric, false, extended},
 			{"job_stats", "job_write_bytes_total", writeTotalHelp, s.counterMetric, false, core},
 			{"job_stats", "job_stats_total", jobStatsHelp, s.counterMetric, true, core},
+			{"job_stats", jobStatsFileBytes, jobStatsFileBytesHelp, s.gaugeMetric, false, core},
 			{"kbytesavail", "available_kilobytes", "Number of kilobytes readily available in the pool", s.gaugeMetric, false, core},
 			{"kbytesfree", "free_kilobytes", "Number of kilobytes allocated to the pool", s.gaugeMetric, false, core},
 			{"kbytestotal", "capacity_kilobytes", "Capacity of the pool in kilobytes", s.gaugeMetric, false, core},
 			{"lfsck_speed_limit", "lfsck_speed_limit", "Maximum operations per second LFSCK (Lustre filesystem verification) can run", s.gaugeMetric, false, extended},
+			{exportsDir, "exports_active", exportsActiveHelp, s.gaugeMetric, false, core},
 			{"num_exports", "exports_total", "Total number of times the pool has been exported", s.counterMetric, false, core},
 			{"precreate_batch", "precreate_batch", "Maximum number of objects that can be included in a single transaction", s.gaugeMetric, false, extended},
+			{readCacheEnable, "ost_cache_policy_info", ostCachePolicyInfoHelp, s.gaugeMetric, false, core},
+			{"readcache_max_filesize", "readcache_max_filesize_bytes", readcacheMaxFilesizeHelp, s.gaugeMetric, false, core},
 			{"recovery_time_hard", "recovery_time_hard_seconds", "Maximum timeout 'recover_time_soft' can increment to for a single server", s.gaugeMetric, false, extended},
 			{"recovery_time_soft", "recovery_time_soft_seconds", "Duration in seconds for a client to attempt to reconnect after a crash (automatically incremented if servers are still in an error state)", s.gaugeMetric, false, extended},
 			{"soft_sync_limit", "soft_sync_limit", "Number of RPCs necessary before triggering a sync", s.gaugeMetric, false, extended},
@@ -166,6 +299,7 @@ func (s *lustreProcfsSource) generateOSTMetricTemplates(filter string) {
 			{"stats", "write_maximum_size_bytes", writeMaximumHelp, s.gaugeMetric, false, extended},
 			{"stats", "write_bytes_total", writeTotalHelp, s.counterMetric, false, core},
 			{"stats", "stats_total", statsHelp, s.counterMetric, true, core},
+			{"stats", opErrorsTotal, opErrorsHelp, s.counterMetric, true, extended},
 			{"sync_journal", "sync_journal_enabled", "Binary indicator as to whether or not the journal is set for asynchronous commits", s.gaugeMetric, false, extended},
 			{"tot_dirty", "exports_dirty_total", "Total number of exports that have been marked dirty", s.counterMetric, false, core},
 			{"tot_granted", "exports_granted_total", "Total number of exports that have been marked granted", s.counterMetric, false, core},
@@ -189,6 +323,16 @@ func (s *lustreProcfsSource) generateOSTMetricTemplates(filter string) {
 			{"pool/slv", "server_lock_volume", "Current value for server lock volume (SLV)", s.gaugeMetric, false, extended},
 		},
 	}
+	metricMap["obdfilter/*"] = append(metricMap["obdfilter/*"], recoveryStatusTemplates(s)...)
+	if RecoveryEnabled {
+		metricMap["obdfilter/*"] = append(metricMap["obdfilter/*"], recoveryTemplates(s)...)
+	}
+	if QuotaEnabled {
+		metricMap["osd-*/*OST*"] = append(metricMap["osd-*/*OST*"], quotaTemplates(s)...)
+	}
+	if HealthStateAge && !s.cfg.stateless {
+		metricMap["obdfilter/*"] = append(metricMap["obdfilter/*"], healthStateAgeTemplate("degraded", s.gaugeMetric))
+	}
 	for path := range metricMap {
 		for _, item := range metricMap[path] {
 			if filter == extended || item.priorityLevel == core {
@@ -211,9 +355,41 @@ func (s *lustreProcfsSource) generateMDTMetricTemplates(filter string) {
 		},
 		"mdt/*": {
 			{mdStats, "stats_total", statsHelp, s.counterMetric, true, core},
+			{exportsDir, "exports_active", exportsActiveHelp, s.gaugeMetric, false, core},
 			{"num_exports", "exports_total", "Total number of times the pool has been exported", s.counterMetric, false, core},
 			{"job_stats", "job_stats_total", jobStatsHelp, s.counterMetric, true, core},
+			{"job_stats", jobStatsFileBytes, jobStatsFileBytesHelp, s.gaugeMetric, false, core},
+			{mdStats, mdtReintTotal, mdtReintHelp, s.counterMetric, true, extended},
+			{mdStats, opErrorsTotal, opErrorsHelp, s.counterMetric, true, extended},
+			{renameStats, "mdt_rename_samples_total", mdtRenameSamplesHelp, s.counterMetric, false, extended},
+		},
+		"qmt/*": {
+			{qmtGlobalDt, qmtGlobalUsedKilobytes, qmtGlobalUsedKilobytesHelp, s.gaugeMetric, false, core},
+			{qmtGlobalDt, qmtGlobalLimitKilobytes, qmtGlobalLimitKilobytesHelp, s.gaugeMetric, false, core},
+			{qmtGlobalMd, qmtGlobalUsedInodes, qmtGlobalUsedInodesHelp, s.gaugeMetric, false, core},
+			{qmtGlobalMd, qmtGlobalLimitInodes, qmtGlobalLimitInodesHelp, s.gaugeMetric, false, core},
 		},
+		"lod/*": {
+			{lodStripeCount, "default_stripe_count", defaultStripeCountHelp, s.gaugeMetric, false, core},
+			{lodStripeSize, "default_stripe_size_bytes", defaultStripeSizeHelp, s.gaugeMetric, false, core},
+			{lodPools, "ost_pool_member", ostPoolMemberHelp, s.gaugeMetric, false, core},
+		},
+	}
+	if MdtExportStats {
+		metricMap["mdt/*/exports/*"] = []lustreHelpStruct{
+			{exportLdlmStats, "mdt_export_lock_rpc_difference", mdtExportLockRPCDifferenceHelp, s.gaugeMetric, false, core},
+		}
+	}
+	metricMap["mdt/*"] = append(metricMap["mdt/*"], recoveryStatusTemplates(s)...)
+	metricMap["mdt/*"] = append(metricMap["mdt/*"], lustreHelpStruct{recoveryStatus, "recovery_evicted_clients", recoveryEvictedClientsHelp, s.gaugeMetric, false, extended})
+	if RecoveryEnabled {
+		metricMap["mdt/*"] = append(metricMap["mdt/*"], recoveryTemplates(s)...)
+	}
+	if QuotaEnabled {
+		metricMap["osd-*/*-MDT*"] = append(metricMap["osd-*/*-MDT*"], quotaTemplates(s)...)
+	}
+	if HsmEnabled {
+		metricMap["mdt/*"] = append(metricMap["mdt/*"], hsmTemplates(s)...)
 	}
 	for path := range metricMap {
 		for _, item := range metricMap[path] {
@@ -247,7 +423,33 @@ func (s *lustreProcfsSource) generateMGSMetricTemplates(filter string) {
 }
 
 func (s *lustreProcfsSource) generateMDSMetricTemplates(filter string) {
-	metricMap := map[string][]lustreHelpStruct{}
+	metricMap := map[string][]lustreHelpStruct{
+		"osp/*": {
+			{ospPreallocLastID, "osp_prealloc_gap", ospPreallocGapHelp, s.gaugeMetric, false, core},
+			{"prealloc_reserved", "osp_prealloc_reserved", "Number of precreated objects reserved for creates in progress", s.gaugeMetric, false, extended},
+			{"sync_in_flight", "osp_sync_in_flight", "Number of OST object destroy/setattr RPCs currently in flight", s.gaugeMetric, false, core},
+			{"sync_in_progress", "osp_sync_in_progress", "Number of OST object destroy/setattr changes currently being processed", s.gaugeMetric, false, extended},
+			{"sync_changes", "osp_sync_changes", "Number of OST object destroy/setattr changes waiting to be synced", s.gaugeMetric, false, core},
+		},
+		"seq/ctl-*": {
+			{seqSpace, "seq_allocated", seqAllocatedHelp, s.gaugeMetric, false, extended},
+			{"width", "seq_width", seqWidthHelp, s.gaugeMetric, false, extended},
+		},
+		"seq/srv-*": {
+			{seqSpace, "seq_allocated", seqAllocatedHelp, s.gaugeMetric, false, extended},
+			{"width", "seq_width", seqWidthHelp, s.gaugeMetric, false, extended},
+		},
+		"lod/*": {
+			{lodQosPrioFree, "lod_qos_prio_free", lodQosPrioFreeHelp, s.gaugeMetric, false, extended},
+			{lodQosThresholdRR, "lod_qos_threshold_rr", lodQosThresholdRRHelp, s.gaugeMetric, false, extended},
+		},
+	}
+	if ServiceStatsEnabled {
+		metricMap["mds/MDS"] = []lustreHelpStruct{
+			{mdtServiceStats, "mdt_req_qdepth", mdtReqQdepthHelp, s.gaugeMetric, false, core},
+			{mdtServiceStats, "mdt_req_active", mdtReqActiveHelp, s.gaugeMetric, false, core},
+		}
+	}
 	for path := range metricMap {
 		for _, item := range metricMap[path] {
 			if filter == extended || item.priorityLevel == core {
@@ -271,9 +473,9 @@ func (s *lustreProcfsSource) generateClientMetricTemplates(filter string) {
 			{"kbytestotal", "capacity_kilobytes", "Capacity of the pool in kilobytes", s.gaugeMetric, false, core},
 			{"lazystatfs", "lazystatfs_enabled", "Returns '1' if lazystatfs (a non-blocking alternative to statfs) is enabled for the client", s.gaugeMetric, false, extended},
 			{"max_easize", "maximum_ea_size_bytes", "Maximum Extended Attribute (EA) size in bytes", s.gaugeMetric, false, extended},
-			{"max_read_ahead_mb", "maximum_read_ahead_megabytes", "Maximum number of megabytes to read ahead", s.gaugeMetric, false, extended},
-			{"max_read_ahead_per_file_mb", "maximum_read_ahead_per_file_megabytes", "Maximum number of megabytes per file to read ahead", s.gaugeMetric, false, extended},
-			{"max_read_ahead_whole_mb", "maximum_read_ahead_whole_megabytes", "Maximum file size in megabytes for a file to be read in its entirety", s.gaugeMetric, false, extended},
+			{"max_read_ahead_mb", "client_max_read_ahead_mb", clientMaxReadAheadHelp, s.gaugeMetric, false, core},
+			{"max_read_ahead_per_file_mb", "client_max_read_ahead_per_file_mb", clientMaxReadAheadPerFileHelp, s.gaugeMetric, false, core},
+			{"max_read_ahead_whole_mb", "client_max_read_ahead_whole_mb", clientMaxReadAheadWholeHelp, s.gaugeMetric, false, core},
 			{"statahead_agl", "statahead_agl_enabled", "Returns '1' if the Asynchronous Glimpse Lock (AGL) for statahead is enabled", s.gaugeMetric, false, extended},
 			{"statahead_max", "statahead_maximum", "Maximum window size for statahead", s.gaugeMetric, false, extended},
 			{"stats", "read_samples_total", readSamplesHelp, s.counterMetric, false, core},
@@ -285,17 +487,45 @@ func (s *lustreProcfsSource) generateClientMetricTemplates(filter string) {
 			{"stats", "write_maximum_size_bytes", writeMaximumHelp, s.gaugeMetric, false, extended},
 			{"stats", "write_bytes_total", writeTotalHelp, s.counterMetric, false, core},
 			{"stats", "stats_total", statsHelp, s.counterMetric, true, core},
+			{unstableStats, "client_unstable_pages", unstablePagesHelp, s.gaugeMetric, false, extended},
 			{"xattr_cache", "xattr_cache_enabled", "Returns '1' if extended attribute cache is enabled", s.gaugeMetric, false, extended},
 		},
+		// the client namespaces are named after the server target and the
+		// mount (super block address), the MDT side osc ones end in -MDTxxxx
+		"ldlm/namespaces/*-mdc-ffff*": {
+			{"lru_size", "client_ldlm_lru_size", clientLdlmLruSizeHelp, s.gaugeMetric, false, extended},
+			{"lock_count", "client_ldlm_lock_count", clientLdlmLockCountHelp, s.gaugeMetric, false, extended},
+		},
+		"lov/*-clilov-*": {
+			{lodStripeCount, clientLovStripeCount, clientLovStripeCountHelp, s.gaugeMetric, false, core},
+		},
+		"lmv/*-clilmv-*": {
+			{"numobd", "client_lmv_mdt_count", clientLmvMdtCountHelp, s.gaugeMetric, false, core},
+		},
+		"ldlm/namespaces/*-osc-ffff*": {
+			{"lru_size", "client_ldlm_lru_size", clientLdlmLruSizeHelp, s.gaugeMetric, false, extended},
+			{"lock_count", "client_ldlm_lock_count", clientLdlmLockCountHelp, s.gaugeMetric, false, extended},
+		},
 		"mdc/*": {
+			{importFile, "mdc_reconnects_total", importReconnectsHelp, s.counterMetric, false, core},
+			{importFile, "mdc_timeouts_total", importTimeoutsHelp, s.counterMetric, false, core},
 			{"rpc_stats", "rpcs_in_flight", rpcsInFlightHelp, s.gaugeMetric, true, core},
 		},
 		"osc/*": {
+			{importFile, "osc_reconnects_total", importReconnectsHelp, s.counterMetric, false, core},
+			{importFile, "osc_timeouts_total", importTimeoutsHelp, s.counterMetric, false, core},
+			{"max_pages_per_rpc", "max_pages_per_rpc", maxPagesPerRPCHelp, s.gaugeMetric, false, extended},
 			{"rpc_stats", "pages_per_rpc_total", pagesPerRPCHelp, s.counterMetric, false, core},
 			{"rpc_stats", "rpcs_in_flight", rpcsInFlightHelp, s.gaugeMetric, true, core},
 			{"rpc_stats", "rpcs_offset", offsetHelp, s.gaugeMetric, false, core},
+			{"rpc_stats", oscPendingPages, oscPendingPagesHelp, s.gaugeMetric, false, extended},
+			{"rpc_stats", oscWritebackQueueDepth, oscWritebackQueueDepthHelp, s.gaugeMetric, false, extended},
 		},
 	}
+	if PtlrpcEnabled {
+		metricMap["mdc/*"] = append(metricMap["mdc/*"], ptlrpcTemplates(s)...)
+		metricMap["osc/*"] = append(metricMap["osc/*"], ptlrpcTemplates(s)...)
+	}
 	for path := range metricMap {
 		for _, item := range metricMap[path] {
 			if filter == extended || item.priorityLevel == core {
@@ -308,6 +538,10 @@ func (s *lustreProcfsSource) generateClientMetricTemplates(filter string) {
 
 func (s *lustreProcfsSource) generateGenericMetricTemplates(filter string) {
 	metricMap := map[string][]lustreHelpStruct{
+		"mgc/*": {
+			{importFile, "mgc_import_state", mgcImportStateHelp, s.gaugeMetric, false, core},
+			{importFile, "mgc_last_ping_seconds", mgcLastPingHelp, s.gaugeMetric, false, core},
+		},
 		"sptlrpc": {
 			{"encrypt_page_pools", "physical_pages", physicalPagesHelp, s.gaugeMetric, false, extended},
 			{"encrypt_page_pools", "pages_per_pool", pagesPerPoolHelp, s.gaugeMetric, false, extended},
@@ -336,9 +570,30 @@ func (s *lustreProcfsSource) generateGenericMetricTemplates(filter string) {
 	}
 }
 
-func newLustreSource() LustreSource {
+func (s *lustreProcfsSource) generateLdlmMetricTemplates(filter string) {
+	metricMap := map[string][]lustreHelpStruct{
+		"ldlm/namespaces/*": {
+			{ldlmPoolStats, "ldlm_pool_grant_rate", ldlmPoolGrantRateHelp, s.gaugeMetric, false, core},
+			{ldlmPoolStats, "ldlm_pool_cancel_rate", ldlmPoolCancelRateHelp, s.gaugeMetric, false, core},
+			{ldlmPoolStats, "ldlm_pool_grant_speed", ldlmPoolGrantSpeedHelp, s.gaugeMetric, false, core},
+			{"lock_timeouts", "ldlm_blocking_timeouts_total", ldlmBlockingTimeoutsHelp, s.counterMetric, false, core},
+		},
+	}
+	for path := range metricMap {
+		for _, item := range metricMap[path] {
+			if filter == extended || item.priorityLevel == core {
+				newMetric := newLustreProcMetric(item.filename, item.promName, "ldlm", path, item.helpText, item.hasMultipleVals, item.metricFunc)
+				s.lustreProcMetrics = append(s.lustreProcMetrics, newMetric)
+			}
+		}
+	}
+}
+
+func newLustreSource(cfg Config) LustreSource {
 	var l lustreProcfsSource
-	l.basePath = filepath.Join(ProcLocation, "fs/lustre")
+	l.cfg = cfg
+	l.basePath = cfg.LustreProcPath()
+	l.sysBasePaths = sysfsBasePaths(cfg)
 	//control which node metrics you pull via flags
 	if OstEnabled != disabled {
 		l.generateOSTMetricTemplates(OstEnabled)
@@ -357,7 +612,20 @@ func newLustreSource() LustreSource {
 	}
 	if GenericEnabled != disabled {
 		l.generateGenericMetricTemplates(GenericEnabled)
+		l.modulesPath = filepath.Join(cfg.ProcLocation, "modules")
 	}
+	if LdlmEnabled != disabled {
+		l.generateLdlmMetricTemplates(LdlmEnabled)
+	}
+	// the templates come from maps, order them so the first of the series
+	// both an osd and an obdfilter template emit is always the same one
+	sort.SliceStable(l.lustreProcMetrics, func(i, j int) bool {
+		return l.lustreProcMetrics[i].path < l.lustreProcMetrics[j].path
+	})
+	l.lustreProcMetrics = dropDisabledFiles(l.lustreProcMetrics)
+	l.lustreProcMetrics = appendUnitConversions(l.lustreProcMetrics)
+	l.appendUUIDLabels()
+	applySubsystems(l.lustreProcMetrics)
 	return &l
 }
 
@@ -365,31 +633,95 @@ func (s *lustreProcfsSource) Update(ch chan<- prometheus.Metric) (err error) {
 	var metricType string
 	var directoryDepth int
 
+	ossTotals := ossBytesTotals{}
+	writeBytes := writeBytesTotals{}
+	churn := connectionChurn{}
+	stripeSeen := fsSeen{}
+	subnets := clientSubnets{}
+	quotas := quotaTables{}
+	recoveries := recoveryFiles{}
+	jobs := jobCounters{}
+	sizes := brwSizes{}
+	spaces := ostSpaces{}
+	latencyFiles := map[string]bool{}
+	if s.uuids != nil {
+		s.uuids.reset()
+	}
+
+	var devices deviceStates
+	if SkipInactiveTargets {
+		// 'devices' moved to debugfs in Lustre 2.11
+		devices = readDeviceStates(filepath.Join(s.basePath, "devices"), filepath.Join(s.cfg.LustreDebugPath(), "devices"))
+	}
+
 	for _, metric := range s.lustreProcMetrics {
 		directoryDepth = strings.Count(metric.filename, "/")
-		paths, err := filepath.Glob(filepath.Join(s.basePath, metric.path, metric.filename))
+		paths, err := s.metricGlobs(&metric, globPaths)
 		if err != nil {
 			return err
 		}
-		if paths == nil {
+		var read map[string]bool
+		if zeroOnMissing(&metric) {
+			read = map[string]bool{}
+		}
+		if paths == nil && read == nil {
 			continue
 		}
 		for _, path := range paths {
+			if devices != nil || len(TargetGlobs) > 0 {
+				if _, nodeName, e := parseFileElements(path, directoryDepth); e == nil && (devices.inactive(metric.source, nodeName) || !targetSelected(metric.source, nodeName)) {
+					if read != nil {
+						read[path] = true
+					}
+					continue
+				}
+			}
 			metricType = single
 			switch metric.filename {
 			case "brw_stats", "rpc_stats":
+				if _, ok := oscWritebackFields[metric.promName]; ok {
+					err = s.parseOscWriteback(metric.source, path, directoryDepth, metric.helpText, metric.promName, func(nodeType string, nodeName string, name string, helpText string, value float64) {
+						ch <- metric.metricFunc([]string{"component", "target"}, []string{nodeType, nodeName}, name, helpText, value)
+					})
+					if err != nil {
+						return err
+					}
+					break
+				}
+				histogramHandler := func(m prometheus.Metric) {
+					ch <- m
+				}
+				if osdDuplicate(&metric, s.lustreProcMetrics) {
+					histogramHandler = nil
+				}
 				err = s.parseBRWStats(metric.source, "stats", path, directoryDepth, metric.helpText, metric.promName, metric.hasMultipleVals, func(nodeType string, brwOperation string, brwSize string, nodeName string, name string, helpText string, value float64, extraLabel string, extraLabelValue string) {
 					if extraLabelValue == "" {
 						ch <- metric.metricFunc([]string{"component", "target", "operation", "size"}, []string{nodeType, nodeName, brwOperation, brwSize}, name, helpText, value)
 					} else {
 						ch <- metric.metricFunc([]string{"component", "target", "operation", "size", extraLabel}, []string{nodeType, nodeName, brwOperation, brwSize, extraLabelValue}, name, helpText, value)
 					}
-				})
+				}, histogramHandler)
 				if err != nil {
 					return err
 				}
 			case "job_stats":
+				if metric.promName == jobStatsFileBytes {
+					err = s.parseJobStatsSize(metric.source, path, directoryDepth, metric.helpText, metric.promName, func(nodeType string, nodeName string, name string, helpText string, value float64) {
+						ch <- metric.metricFunc([]string{"component", "target"}, []string{nodeType, nodeName}, name, helpText, value)
+					})
+					if err != nil {
+						return err
+					}
+					break
+				}
 				err = s.parseJobStats(metric.source, "job_stats", path, directoryDepth, metric.helpText, metric.promName, metric.hasMultipleVals, func(nodeType string, jobid string, nodeName string, name string, helpText string, value float64, extraLabel string, extraLabelValue string) {
+					if strings.HasSuffix(name, "_total") {
+						var labelValues []string
+						if extraLabelValue != "" {
+							labelValues = []string{extraLabelValue}
+						}
+						jobs.add(nodeType, nodeName, jobid, name, labelValues, value)
+					}
 					if extraLabelValue == "" {
 						ch <- metric.metricFunc([]string{"component", "target", "jobid"}, []string{nodeType, nodeName, jobid}, name, helpText, value)
 					} else {
@@ -399,6 +731,143 @@ func (s *lustreProcfsSource) Update(ch chan<- prometheus.Metric) (err error) {
 				if err != nil {
 					return err
 				}
+			case ldlmPoolStats:
+				err = s.parseLdlmPoolStats(path, directoryDepth, metric.helpText, metric.promName, func(namespace string, nodeName string, name string, helpText string, value float64) {
+					ch <- metric.metricFunc([]string{"namespace", "target"}, []string{namespace, nodeName}, name, helpText, value)
+				})
+				if err != nil {
+					return err
+				}
+			case lodStripeCount, lodStripeSize:
+				if metric.promName == clientLovStripeCount {
+					err = s.parseFile(metric.source, single, path, directoryDepth, metric.helpText, metric.promName, metric.hasMultipleVals, func(nodeType string, nodeName string, name string, helpText string, value float64, extraLabel string, extraLabelValue string) {
+						ch <- metric.metricFunc([]string{"component", "target"}, []string{nodeType, nodeName}, name, helpText, value)
+					})
+					if err != nil {
+						return err
+					}
+					break
+				}
+				err = s.parseDefaultStripe(path, directoryDepth, metric.helpText, metric.promName, stripeSeen, func(fs string, name string, helpText string, value float64) {
+					ch <- metric.metricFunc([]string{"fs"}, []string{fs}, name, helpText, value)
+				})
+				if err != nil {
+					return err
+				}
+			case lodPools:
+				err = s.parseOstPool(path, directoryDepth, metric.helpText, metric.promName, stripeSeen, func(fs string, pool string, target string, name string, helpText string, value float64) {
+					ch <- metric.metricFunc([]string{"fs", "pool", "target"}, []string{fs, pool, target}, name, helpText, value)
+				})
+				if err != nil {
+					return err
+				}
+			case lodQosPrioFree, lodQosThresholdRR:
+				err = s.parseLodQos(metric.source, path, directoryDepth, metric.helpText, metric.promName, func(nodeType string, nodeName string, name string, helpText string, value float64) {
+					ch <- metric.metricFunc([]string{"component", "target"}, []string{nodeType, nodeName}, name, helpText, value)
+				})
+				if err != nil {
+					return err
+				}
+			case seqSpace:
+				err = s.parseSeqSpace(metric.source, path, directoryDepth, metric.helpText, metric.promName, func(nodeType string, nodeName string, name string, helpText string, value float64) {
+					ch <- metric.metricFunc([]string{"component", "target"}, []string{nodeType, nodeName}, name, helpText, value)
+				})
+				if err != nil {
+					return err
+				}
+			case importFile:
+				err = s.parseImport(metric.source, path, directoryDepth, metric.helpText, metric.promName, func(nodeType string, nodeName string, state string, name string, helpText string, value float64) {
+					if state != "" {
+						ch <- metric.metricFunc([]string{"component", "target", "state"}, []string{nodeType, nodeName, state}, name, helpText, value)
+					} else {
+						ch <- metric.metricFunc([]string{"component", "target"}, []string{nodeType, nodeName}, name, helpText, value)
+					}
+				})
+				if err != nil {
+					return err
+				}
+			case exportLdlmStats:
+				err = s.parseExportLockRPCDifference(path, metric.helpText, metric.promName, func(nid string, nodeName string, name string, helpText string, value float64) {
+					subnets.add(nodeName, nid)
+					ch <- metric.metricFunc([]string{"client_nid", "target"}, []string{nid, nodeName}, name, helpText, value)
+				})
+				if err != nil {
+					return err
+				}
+			case mdtServiceStats:
+				err = s.parseServiceStats(metric.source, path, directoryDepth, metric.helpText, metric.promName, func(nodeType string, nodeName string, service string, name string, helpText string, value float64) {
+					ch <- metric.metricFunc([]string{"component", "target", "service"}, []string{nodeType, nodeName, service}, name, helpText, value)
+				})
+				if err != nil {
+					return err
+				}
+			case recoveryStatus:
+				err = s.parseRecoveryStatus(metric.source, path, directoryDepth, metric.helpText, metric.promName, recoveries, func(nodeType string, nodeName string, name string, helpText string, value float64) {
+					ch <- metric.metricFunc([]string{"component", "target"}, []string{nodeType, nodeName}, name, helpText, value)
+				})
+				if err != nil {
+					return err
+				}
+			case quotaAcct:
+				err = s.parseQuota(metric.source, path, directoryDepth, metric.helpText, metric.promName, quotas, func(nodeType string, nodeName string, quotaType string, id string, name string, helpText string, value float64) {
+					ch <- metric.metricFunc([]string{"component", "target", "type", "id"}, []string{nodeType, nodeName, quotaType, id}, name, helpText, value)
+				})
+				if err != nil {
+					return err
+				}
+			case renameStats:
+				err = s.parseRenameStats(metric.source, path, directoryDepth, metric.helpText, metric.promName, func(nodeType string, nodeName string, section string, size string, name string, helpText string, value float64) {
+					ch <- metric.metricFunc([]string{"component", "target", "type", "size"}, []string{nodeType, nodeName, section, size}, name, helpText, value)
+				})
+				if err != nil {
+					return err
+				}
+			case ospPreallocLastID:
+				err = s.parseOspPreallocGap(metric.source, path, directoryDepth, metric.helpText, metric.promName, func(nodeType string, nodeName string, name string, helpText string, value float64) {
+					ch <- metric.metricFunc([]string{"component", "target"}, []string{nodeType, nodeName}, name, helpText, value)
+				})
+				if err != nil {
+					return err
+				}
+			case osdJournalStats:
+				err = s.parseOsdJournalStats(metric.source, path, directoryDepth, metric.helpText, metric.promName, func(nodeType string, nodeName string, name string, helpText string, value float64) {
+					ch <- metric.metricFunc([]string{"component", "target"}, []string{nodeType, nodeName}, name, helpText, value)
+				})
+				if err != nil {
+					return err
+				}
+			case hsmActions, hsmActiveRequests:
+				err = s.parseHsm(metric.source, path, directoryDepth, metric.helpText, metric.promName, func(nodeType string, nodeName string, action string, status string, name string, helpText string, value float64) {
+					if action == "" {
+						ch <- metric.metricFunc([]string{"component", "target"}, []string{nodeType, nodeName}, name, helpText, value)
+					} else {
+						ch <- metric.metricFunc([]string{"component", "target", "action", "status"}, []string{nodeType, nodeName, action, status}, name, helpText, value)
+					}
+				})
+				if err != nil {
+					return err
+				}
+			case qmtGlobalDt, qmtGlobalMd:
+				err = s.parseQmtGlobal(path, metric.helpText, metric.promName, func(fs string, quotaType string, id string, name string, helpText string, value float64) {
+					ch <- metric.metricFunc([]string{"fs", "type", "id"}, []string{fs, quotaType, id}, name, helpText, value)
+				})
+				if err != nil {
+					return err
+				}
+			case exportsDir:
+				err = s.parseExportsActive(metric.source, path, directoryDepth, metric.helpText, metric.promName, func(nodeType string, nodeName string, name string, helpText string, value float64) {
+					ch <- metric.metricFunc([]string{"component", "target"}, []string{nodeType, nodeName}, name, helpText, value)
+				})
+				if err != nil {
+					return err
+				}
+			case readCacheEnable:
+				err = s.parseCachePolicy(metric.source, path, directoryDepth, metric.helpText, metric.promName, func(nodeType string, nodeName string, readCache string, writethrough string, name string, helpText string, value float64) {
+					ch <- metric.metricFunc([]string{"component", "target", "read_cache", "writethrough"}, []string{nodeType, nodeName, readCache, writethrough}, name, helpText, value)
+				})
+				if err != nil {
+					return err
+				}
 			default:
 				if metric.filename == stats {
 					metricType = stats
@@ -406,23 +875,141 @@ func (s *lustreProcfsSource) Update(ch chan<- prometheus.Metric) (err error) {
 					metricType = mdStats
 				} else if metric.filename == encryptPagePools {
 					metricType = encryptPagePools
+				} else if metric.filename == unstableStats {
+					metricType = unstableStats
 				}
 				err = s.parseFile(metric.source, metricType, path, directoryDepth, metric.helpText, metric.promName, metric.hasMultipleVals, func(nodeType string, nodeName string, name string, helpText string, value float64, extraLabel string, extraLabelValue string) {
+					ossTotals.add(nodeType, name, value)
+					writeBytes.add(nodeType, nodeName, name, value)
+					churn.add(nodeType, nodeName, name, extraLabelValue, value)
+					if metric.filename == "brw_size" {
+						sizes.add(nodeType, nodeName, name, value)
+					}
+					if metric.filename == "kbytesfree" || metric.filename == "kbytestotal" {
+						spaces.add(nodeType, nodeName, name, value)
+					}
 					if extraLabelValue == "" {
 						ch <- metric.metricFunc([]string{"component", "target"}, []string{nodeType, nodeName}, name, helpText, value)
 					} else {
 						ch <- metric.metricFunc([]string{"component", "target", extraLabel}, []string{nodeType, nodeName, extraLabelValue}, name, helpText, value)
 					}
 				})
-				if err != nil {
+				if err != nil && read == nil {
 					return err
 				}
+				if err == nil && LatencyStats && metric.filename == stats && !latencyFiles[path] {
+					latencyFiles[path] = true
+					latencies, err := s.latencies(metric.source, path, directoryDepth)
+					if err != nil {
+						return err
+					}
+					for _, m := range latencies {
+						ch <- m
+					}
+				}
+			}
+			if read != nil && err == nil {
+				read[path] = true
+			}
+		}
+		if read != nil {
+			missing, err := missingExpectedFiles(s.roots(), &metric, read)
+			if err != nil {
+				return err
+			}
+			for _, path := range missing {
+				ch <- missingZeroMetric(&metric, path)
 			}
 		}
 	}
+	if len(writeBytes) > 0 && !s.cfg.stateless {
+		for key, rate := range insWriteThroughput.observe(writeBytes, time.Now()) {
+			ch <- writeBytesRateMetric(key, rate)
+		}
+	}
+	if len(jobs) > 0 && !s.cfg.stateless {
+		for key, total := range insJobResets.observe(jobs) {
+			ch <- jobStatsResetsMetric(key, total)
+		}
+	}
+	for _, m := range ossTotals.metrics(s) {
+		ch <- m
+	}
+	for _, m := range sizes.metrics() {
+		ch <- m
+	}
+	for _, m := range spaces.metrics() {
+		ch <- m
+	}
+	for _, m := range churn.metrics() {
+		ch <- m
+	}
+	for _, m := range subnets.metrics() {
+		ch <- m
+	}
+	if s.uuids != nil {
+		for _, m := range s.uuids.metrics() {
+			ch <- m
+		}
+	}
+	if s.modulesPath != "" {
+		modules, err := moduleMetrics(s.modulesPath, readProcFile)
+		if err != nil {
+			return err
+		}
+		for _, m := range modules {
+			ch <- m
+		}
+	}
+	if len(ExtraParams) > 0 {
+		extra, err := extraParamMetrics(s.extraParamRoots(), filepath.Glob, readProcFile)
+		if err != nil {
+			return err
+		}
+		for _, m := range extra {
+			ch <- m
+		}
+	}
 	return nil
 }
 
+// extraParamRoots are the directories the extra params are looked up in,
+// sysfs first like lctl does.
+func (s *lustreProcfsSource) extraParamRoots() []string {
+	return []string{s.cfg.LustreSysPath(), s.basePath}
+}
+
+// ossBytesTotals sums the per-OST read/write byte counters of one scrape into
+// server-level totals. Only the local obdfilter devices are read, so every OST
+// seen belongs to this OSS.
+type ossBytesTotals map[string]float64
+
+var ossBytesTotalNames = map[string]struct {
+	promName string
+	helpText string
+}{
+	"read_bytes_total":  {"oss_read_bytes_total", ossReadBytesHelp},
+	"write_bytes_total": {"oss_write_bytes_total", ossWriteBytesHelp},
+}
+
+func (t ossBytesTotals) add(nodeType string, name string, value float64) {
+	if nodeType != "ost" {
+		return
+	}
+	if _, ok := ossBytesTotalNames[name]; ok {
+		t[name] += value
+	}
+}
+
+func (t ossBytesTotals) metrics(s *lustreProcfsSource) []prometheus.Metric {
+	var out []prometheus.Metric
+	for name, total := range t {
+		oss := ossBytesTotalNames[name]
+		out = append(out, s.counterMetric(nil, nil, subsystemName("ost", oss.promName), oss.helpText, total))
+	}
+	return out
+}
+
 func getStatsOperationMetrics(statsFile string, promName string, helpText string) (metricList []lustreStatsMetric, err error) {
 	operationSlice := []multistatParsingStruct{
 		{pattern: "open", index: 1},
@@ -443,10 +1030,12 @@ func getStatsOperationMetrics(statsFile string, promName string, helpText string
 		{pattern: "get_info", index: 1},
 		{pattern: "set_info_async", index: 1},
 		{pattern: "connect", index: 1},
+		{pattern: "reconnect", index: 1},
+		{pattern: "disconnect", index: 1},
 		{pattern: "ping", index: 1},
 	}
 	for _, operation := range operationSlice {
-		opStat := regexCaptureString(operation.pattern+" .*", statsFile)
+		opStat := captureOperation(operation.pattern, " .*", statsFile)
 		if len(opStat) < 1 {
 			continue
 		}
@@ -471,6 +1060,78 @@ func getStatsOperationMetrics(statsFile string, promName string, helpText string
 	return metricList, nil
 }
 
+// parseReintStats returns the sample count of every 'reint_*' line of an
+// 'md_stats' file, keyed by the operation without the prefix.
+func parseReintStats(statsFile string) (map[string]float64, error) {
+	out := map[string]float64{}
+	for _, line := range strings.Split(statsFile, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || !strings.HasPrefix(fields[0], reintPrefix) {
+			continue
+		}
+		value, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			return nil, err
+		}
+		out[strings.TrimPrefix(fields[0], reintPrefix)] = value
+	}
+	return out, nil
+}
+
+func getReintMetrics(statsFile string, promName string, helpText string) (metricList []lustreStatsMetric, err error) {
+	reints, err := parseReintStats(statsFile)
+	if err != nil {
+		return nil, err
+	}
+	for operation, value := range reints {
+		l := lustreStatsMetric{
+			title:           promName,
+			help:            helpText,
+			value:           value,
+			extraLabel:      "operation",
+			extraLabelValue: operation,
+		}
+		metricList = append(metricList, l)
+	}
+	return metricList, nil
+}
+
+// parseOperationErrors returns the sample count of every '<operation>_errors'
+// line of a 'stats' or 'md_stats' file, keyed by the operation.
+func parseOperationErrors(statsFile string) (map[string]float64, error) {
+	out := map[string]float64{}
+	for _, line := range strings.Split(statsFile, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || len(fields[0]) <= len(opErrorsSuffix) || !strings.HasSuffix(fields[0], opErrorsSuffix) {
+			continue
+		}
+		value, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			return nil, err
+		}
+		out[normalizeOperation(strings.TrimSuffix(fields[0], opErrorsSuffix))] = value
+	}
+	return out, nil
+}
+
+func getOperationErrorMetrics(statsFile string, promName string, helpText string) (metricList []lustreStatsMetric, err error) {
+	errs, err := parseOperationErrors(statsFile)
+	if err != nil {
+		return nil, err
+	}
+	for operation, value := range errs {
+		l := lustreStatsMetric{
+			title:           promName,
+			help:            helpText,
+			value:           value,
+			extraLabel:      "operation",
+			extraLabelValue: operation,
+		}
+		metricList = append(metricList, l)
+	}
+	return metricList, nil
+}
+
 func getStatsIOMetrics(statsFile string, promName string, helpText string) (metricList []lustreStatsMetric, err error) {
 	// bytesSplit is in the following format:
 	// bytesString: {name} {number of samples} 'samples' [{units}] {minimum} {maximum} {sum}
@@ -499,6 +1160,7 @@ func getStatsIOMetrics(statsFile string, promName string, helpText string) (metr
 		lowFreeMarkHelp:       {pattern: "low free mark: .*", index: 3},
 		maxWaitQueueDepthHelp: {pattern: "max waitqueue depth: .*", index: 3},
 		outOfMemHelp:          {pattern: "out of mem: .*", index: 3},
+		unstablePagesHelp:     {pattern: "unstable_pages: .*", index: 1},
 	}
 	pattern := bytesMap[helpText].pattern
 	bytesString := regexCaptureString(pattern, statsFile)
@@ -556,13 +1218,17 @@ func splitBRWStats(statBlock string) (metricList []lustreBRWMetric, err error) {
 }
 
 func parseStatsFile(helpText string, promName string, path string, hasMultipleVals bool) (metricList []lustreStatsMetric, err error) {
-	statsFileBytes, err := os.ReadFile(filepath.Clean(path))
+	statsFileBytes, err := readProcFile(path)
 	if err != nil {
 		return nil, err
 	}
 	statsFile := string(statsFileBytes[:])
 	var statsList []lustreStatsMetric
-	if hasMultipleVals {
+	if promName == mdtReintTotal {
+		statsList, err = getReintMetrics(statsFile, promName, helpText)
+	} else if promName == opErrorsTotal {
+		statsList, err = getOperationErrorMetrics(statsFile, promName, helpText)
+	} else if hasMultipleVals {
 		statsList, err = getStatsOperationMetrics(statsFile, promName, helpText)
 	} else {
 		statsList, err = getStatsIOMetrics(statsFile, promName, helpText)
@@ -652,7 +1318,7 @@ func getJobStatsOperationMetrics(jobBlock string, jobID string, promName string,
 		{index: 0, pattern: "quotactl"},
 	}
 	for _, operation := range operationSlice {
-		opStat := regexCaptureString(operation.pattern+": .*", jobBlock)
+		opStat := captureOperation(operation.pattern, ": .*", jobBlock)
 		opNumbers := regexCaptureStrings("[0-9]*\\.[0-9]+|[0-9]+", opStat)
 		if len(opNumbers) < 1 {
 			continue
@@ -674,7 +1340,7 @@ func getJobStatsOperationMetrics(jobBlock string, jobID string, promName string,
 	return metricList, err
 }
 
-func parseJobStatsText(jobStats string, promName string, helpText string, hasMultipleVals bool) (metricList []lustreJobsMetric, err error) {
+func parseJobStatsText(jobStats string, promName string, helpText string, hasMultipleVals bool, filter *jobstatsFilter) (metricList []lustreJobsMetric, err error) {
 	jobs := regexCaptureStrings("(?ms:job_id:.*?$.*?(-|\\z))", jobStats)
 	if len(jobs) < 1 {
 		return nil, nil
@@ -685,6 +1351,9 @@ func parseJobStatsText(jobStats string, promName string, helpText string, hasMul
 		if err != nil {
 			return nil, err
 		}
+		if !filter.keep(jobID) {
+			continue
+		}
 		if hasMultipleVals {
 			jobList, err = getJobStatsOperationMetrics(job, jobID, promName, helpText)
 		} else {
@@ -705,17 +1374,19 @@ func (s *lustreProcfsSource) parseJobStats(nodeType string, metricType string, p
 	if err != nil {
 		return err
 	}
-	jobStatsBytes, err := os.ReadFile(filepath.Clean(path))
+	jobStatsBytes, err := readProcFile(path)
 	if err != nil {
 		return err
 	}
 
 	jobStatsFile := string(jobStatsBytes[:])
 
-	metricList, err := parseJobStatsText(jobStatsFile, promName, helpText, hasMultipleVals)
+	filter := newJobstatsFilter()
+	metricList, err := parseJobStatsText(jobStatsFile, promName, helpText, hasMultipleVals, filter)
 	if err != nil {
 		return err
 	}
+	filter.done(path)
 
 	for _, item := range metricList {
 		handler(nodeType, item.jobID, nodeName, item.lustreStatsMetric.title, item.lustreStatsMetric.help, item.lustreStatsMetric.value, item.lustreStatsMetric.extraLabel, item.lustreStatsMetric.extraLabelValue)
@@ -723,22 +1394,36 @@ func (s *lustreProcfsSource) parseJobStats(nodeType string, metricType string, p
 	return nil
 }
 
-func (s *lustreProcfsSource) parseBRWStats(nodeType string, metricType string, path string, directoryDepth int, helpText string, promName string, hasMultipleVals bool, handler func(string, string, string, string, string, string, float64, string, string)) (err error) {
+func (s *lustreProcfsSource) parseJobStatsSize(nodeType string, path string, directoryDepth int, helpText string, promName string, handler func(string, string, string, string, float64)) (err error) {
+	_, nodeName, err := parseFileElements(path, directoryDepth)
+	if err != nil {
+		return err
+	}
+	size, err := jobStatsFileSize(path, readProcFile)
+	if err != nil {
+		return err
+	}
+	handler(nodeType, nodeName, promName, helpText, size)
+	return nil
+}
+
+func (s *lustreProcfsSource) parseBRWStats(nodeType string, metricType string, path string, directoryDepth int, helpText string, promName string, hasMultipleVals bool, handler func(string, string, string, string, string, string, float64, string, string), histogramHandler func(prometheus.Metric)) (err error) {
 	_, nodeName, err := parseFileElements(path, directoryDepth)
 	if err != nil {
 		return err
 	}
 	metricBlocks := map[string]string{
-		pagesPerBlockRWHelp:    "pages per bulk r/w",
-		discontiguousPagesHelp: "discontiguous pages",
-		diskIOsInFlightHelp:    "disk I/Os in flight",
-		ioTimeHelp:             "I/O time",
-		diskIOSizeHelp:         "disk I/O size",
-		pagesPerRPCHelp:        "pages per rpc",
-		rpcsInFlightHelp:       "rpcs in flight",
-		offsetHelp:             "offset",
+		pagesPerBlockRWHelp:     "pages per bulk r/w",
+		discontiguousPagesHelp:  "discontiguous pages",
+		discontiguousBlocksHelp: "discontiguous blocks",
+		diskIOsInFlightHelp:     "disk I/Os in flight",
+		ioTimeHelp:              "I/O time",
+		diskIOSizeHelp:          "disk I/O size",
+		pagesPerRPCHelp:         "pages per rpc",
+		rpcsInFlightHelp:        "rpcs in flight",
+		offsetHelp:              "offset",
 	}
-	statsFileBytes, err := os.ReadFile(filepath.Clean(path))
+	statsFileBytes, err := readProcFile(path)
 	if err != nil {
 		return err
 	}
@@ -748,6 +1433,15 @@ func (s *lustreProcfsSource) parseBRWStats(nodeType string, metricType string, p
 	if err != nil {
 		return err
 	}
+	if IOTimeHistogram && helpText == ioTimeHelp && histogramHandler != nil {
+		histograms, err := ioTimeHistograms(block)
+		if err != nil {
+			return err
+		}
+		for _, h := range histograms {
+			histogramHandler(ioTimeHistogramMetric(nodeType, nodeName, h))
+		}
+	}
 	extraLabel := ""
 	extraLabelValue := ""
 	if hasMultipleVals {
@@ -765,6 +1459,626 @@ func (s *lustreProcfsSource) parseBRWStats(nodeType string, metricType string, p
 	return nil
 }
 
+// ldlmPoolStatsFields maps the pool metrics to their field in 'pool/stats',
+// the grant speed is the grant rate less the cancel rate.
+var ldlmPoolStatsFields = map[string]string{
+	"ldlm_pool_grant_rate":  "grant_rate",
+	"ldlm_pool_cancel_rate": "cancel_rate",
+}
+
+// parseLdlmPoolStatsText returns the mean of the samples of every field of an
+// ldlm 'pool/stats' file, fields without sample excluded:
+//
+//	snapshot_time             1510782606.785647043 secs.nsecs
+//	grant_rate                16165 samples [locks/s] 0 32 33 1025
+//	cancel_rate               16165 samples [locks/s] 0 32 32 1024
+func parseLdlmPoolStatsText(content string) map[string]float64 {
+	values := map[string]float64{}
+	for _, line := range strings.Split(content, "\n") {
+		// name count 'samples' [unit] min max sum sumsq
+		fields := strings.Fields(line)
+		if len(fields) < 7 || fields[2] != "samples" {
+			continue
+		}
+		count, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil || count == 0 {
+			continue
+		}
+		sum, err := strconv.ParseFloat(fields[6], 64)
+		if err != nil {
+			continue
+		}
+		values[fields[0]] = sum / count
+	}
+	return values
+}
+
+// ldlmPoolValue returns the value of the pool metric promName.
+func ldlmPoolValue(promName string, values map[string]float64) (float64, bool) {
+	if promName == "ldlm_pool_grant_speed" {
+		grant, grantOk := values["grant_rate"]
+		cancel, cancelOk := values["cancel_rate"]
+		return grant - cancel, grantOk && cancelOk
+	}
+	value, ok := values[ldlmPoolStatsFields[promName]]
+	return value, ok
+}
+
+func (s *lustreProcfsSource) parseLdlmPoolStats(path string, directoryDepth int, helpText string, promName string, handler func(string, string, string, string, float64)) (err error) {
+	_, nodeName, err := parseFileElements(path, directoryDepth)
+	if err != nil {
+		return err
+	}
+	content, err := readProcFile(path)
+	if err != nil {
+		return err
+	}
+	value, ok := ldlmPoolValue(promName, parseLdlmPoolStatsText(string(content)))
+	if !ok {
+		return nil
+	}
+	pathElements := strings.Split(path, "/")
+	handler(pathElements[len(pathElements)-3], nodeName, promName, helpText, value)
+	return nil
+}
+
+// serviceStatNames maps the service metrics to their line in the stats file.
+var serviceStatNames = map[string]string{
+	"mdt_req_qdepth": "req_qdepth",
+	"mdt_req_active": "req_active",
+}
+
+// serviceStatMean returns sum / samples of a line of a service 'stats' file:
+// {name} {samples} 'samples' [{unit}] {min} {max} {sum} {sumsq}
+func serviceStatMean(content string, name string) (float64, bool, error) {
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 7 || fields[0] != name {
+			continue
+		}
+		samples, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			return 0, false, err
+		}
+		sum, err := strconv.ParseFloat(fields[6], 64)
+		if err != nil {
+			return 0, false, err
+		}
+		if samples == 0 {
+			return 0, true, nil
+		}
+		return sum / samples, true, nil
+	}
+	return 0, false, nil
+}
+
+func (s *lustreProcfsSource) parseServiceStats(nodeType string, path string, directoryDepth int, helpText string, promName string, handler func(string, string, string, string, string, float64)) (err error) {
+	_, nodeName, err := parseFileElements(path, directoryDepth)
+	if err != nil {
+		return err
+	}
+	content, err := readProcFile(path)
+	if err != nil {
+		return err
+	}
+	value, found, err := serviceStatMean(string(content), serviceStatNames[promName])
+	if err != nil || !found {
+		return err
+	}
+	// every service directory (mdt, mdt_readpage, mdt_out, ...) is its own
+	// series, a saturated DNE or readdir service must not be averaged away
+	handler(nodeType, nodeName, filepath.Base(filepath.Dir(path)), promName, helpText, value)
+	return nil
+}
+
+// recoveryTemplates are the optional recovery details collected with
+// RecoveryEnabled.
+func recoveryTemplates(s *lustreProcfsSource) []lustreHelpStruct {
+	templates := []lustreHelpStruct{
+		{recoveryStatus, "recovery_stale_locks_total", recoveryStaleLocksHelp, s.counterMetric, false, core},
+		{recoveryStatus, "recovery_stale_clients", recoveryStaleClientsHelp, s.gaugeMetric, false, core},
+	}
+	if !s.cfg.stateless {
+		templates = append(templates, lustreHelpStruct{recoveryStatus, "recovery_count_total", recoveryCountHelp, s.counterMetric, false, core})
+	}
+	return templates
+}
+
+// recoveryStatusFields maps the recovery metrics to their optional field in
+// the recovery_status file.
+var recoveryStatusFields = map[string]string{
+	"recovery_stale_locks_total":      "stale_locks",
+	"recovery_stale_clients":          "stale_clients",
+	"recovery_evicted_clients":        "evicted_clients",
+	"recovery_time_remaining_seconds": "time_remaining",
+}
+
+// parseRecoveryStatus returns the single number fields of a recovery_status
+// file, the other ones ('status: COMPLETE', 'completed_clients: 1/1', ...)
+// are left out.
+func parseRecoveryStatus(content string) map[string]float64 {
+	values := map[string]float64{}
+	for _, line := range strings.Split(content, "\n") {
+		idx := strings.Index(line, ":")
+		if idx < 1 {
+			continue
+		}
+		value, err := strconv.ParseFloat(strings.TrimSpace(line[idx+1:]), 64)
+		if err != nil {
+			continue
+		}
+		values[strings.TrimSpace(line[:idx])] = value
+	}
+	return values
+}
+
+func (s *lustreProcfsSource) parseRecoveryStatus(nodeType string, path string, directoryDepth int, helpText string, promName string, recoveries recoveryFiles, handler func(string, string, string, string, float64)) (err error) {
+	_, nodeName, err := parseFileElements(path, directoryDepth)
+	if err != nil {
+		return err
+	}
+	content, err := recoveries.read(path, readProcFile)
+	if err != nil {
+		return err
+	}
+	value, ok := recoveryStatusValue(targetKey{nodeType, nodeName}, promName, content)
+	if !ok {
+		return nil
+	}
+	handler(nodeType, nodeName, promName, helpText, value)
+	return nil
+}
+
+// ospPreallocGap returns prealloc_last_id - prealloc_next_id of an osp device.
+func ospPreallocGap(lastID string, nextID string) (float64, error) {
+	last, err := strconv.ParseFloat(strings.TrimSpace(lastID), 64)
+	if err != nil {
+		return 0, err
+	}
+	next, err := strconv.ParseFloat(strings.TrimSpace(nextID), 64)
+	if err != nil {
+		return 0, err
+	}
+	// next_id points to the next object to hand out, so last_id == next_id - 1
+	// means nothing is left.
+	gap := last - next + 1
+	if gap < 0 {
+		gap = 0
+	}
+	return gap, nil
+}
+
+func (s *lustreProcfsSource) parseOscWriteback(nodeType string, path string, directoryDepth int, helpText string, promName string, handler func(string, string, string, string, float64)) (err error) {
+	_, nodeName, err := parseFileElements(path, directoryDepth)
+	if err != nil {
+		return err
+	}
+	content, err := readProcFile(path)
+	if err != nil {
+		return err
+	}
+	value, ok, err := rpcStatsHeaderValue(string(content), oscWritebackFields[promName])
+	if err != nil || !ok {
+		return err
+	}
+	handler(nodeType, nodeName, promName, helpText, value)
+	return nil
+}
+
+func (s *lustreProcfsSource) parseOsdJournalStats(nodeType string, path string, directoryDepth int, helpText string, promName string, handler func(string, string, string, string, float64)) (err error) {
+	_, nodeName, err := parseFileElements(path, directoryDepth)
+	if err != nil {
+		return err
+	}
+	content, err := readProcFile(path)
+	if err != nil {
+		return err
+	}
+	value, ok, err := osdJournalValue(promName, string(content))
+	if err != nil || !ok {
+		return err
+	}
+	handler(nodeType, nodeName, promName, helpText, value)
+	return nil
+}
+
+func (s *lustreProcfsSource) parseHsm(nodeType string, path string, directoryDepth int, helpText string, promName string, handler func(string, string, string, string, string, string, float64)) (err error) {
+	_, nodeName, err := parseFileElements(path, directoryDepth)
+	if err != nil {
+		return err
+	}
+	content, err := readProcFile(path)
+	if err != nil {
+		return err
+	}
+	counts, err := hsmValues(promName, string(content))
+	if err != nil {
+		return err
+	}
+	for _, count := range counts {
+		handler(nodeType, nodeName, count.action, count.status, promName, helpText, count.value)
+	}
+	return nil
+}
+
+func (s *lustreProcfsSource) parseQmtGlobal(path string, helpText string, promName string, handler func(string, string, string, string, string, float64)) (err error) {
+	fs, quotaType, err := qmtElements(path)
+	if err != nil {
+		return err
+	}
+	content, err := readProcFile(path)
+	if err != nil {
+		return err
+	}
+	entries, err := parseQmtGlobalIndex(string(content))
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		handler(fs, quotaType, entry.id, promName, helpText, qmtGlobalValue(promName, entry))
+	}
+	return nil
+}
+
+func (s *lustreProcfsSource) parseExportsActive(nodeType string, path string, directoryDepth int, helpText string, promName string, handler func(string, string, string, string, float64)) (err error) {
+	_, nodeName, err := parseFileElements(path, directoryDepth)
+	if err != nil {
+		return err
+	}
+	count, err := exportsActive(path)
+	if err != nil {
+		return err
+	}
+	handler(nodeType, nodeName, promName, helpText, count)
+	return nil
+}
+
+func (s *lustreProcfsSource) parseCachePolicy(nodeType string, path string, directoryDepth int, helpText string, promName string, handler func(string, string, string, string, string, string, float64)) (err error) {
+	_, nodeName, err := parseFileElements(path, directoryDepth)
+	if err != nil {
+		return err
+	}
+	readCache, err := readProcFile(path)
+	if err != nil {
+		return err
+	}
+	writethrough, err := readProcFile(filepath.Join(filepath.Dir(path), writethroughCacheEnable))
+	if err != nil {
+		return err
+	}
+	readState, writethroughState, err := cachePolicyLabels(string(readCache), string(writethrough))
+	if err != nil {
+		return err
+	}
+	handler(nodeType, nodeName, readState, writethroughState, promName, helpText, 1)
+	return nil
+}
+
+func (s *lustreProcfsSource) parseOspPreallocGap(nodeType string, path string, directoryDepth int, helpText string, promName string, handler func(string, string, string, string, float64)) (err error) {
+	_, nodeName, err := parseFileElements(path, directoryDepth)
+	if err != nil {
+		return err
+	}
+	lastID, err := readProcFile(path)
+	if err != nil {
+		return err
+	}
+	nextID, err := readProcFile(filepath.Join(filepath.Dir(path), ospPreallocNextID))
+	if err != nil {
+		return err
+	}
+	gap, err := ospPreallocGap(string(lastID), string(nextID))
+	if err != nil {
+		return err
+	}
+	handler(nodeType, nodeName, promName, helpText, gap)
+	return nil
+}
+
+var seqSpacePattern = regexp.MustCompile(`\[\s*(0x[0-9a-fA-F]+)\s*-\s*(0x[0-9a-fA-F]+)\s*\]`)
+
+// seqSpaceStart returns the lower bound of a sequence range like
+// '[0x240000400 - 0xffffffffffffffff]:0:mdt'. Older versions print the range
+// without the ':<index>:<type>' suffix.
+func seqSpaceStart(content string) (float64, error) {
+	match := seqSpacePattern.FindStringSubmatch(content)
+	if match == nil {
+		return 0, fmt.Errorf("unable to parse sequence range from %q", strings.TrimSpace(content))
+	}
+	start, err := strconv.ParseUint(match[1], 0, 64)
+	if err != nil {
+		return 0, err
+	}
+	return float64(start), nil
+}
+
+func (s *lustreProcfsSource) parseSeqSpace(nodeType string, path string, directoryDepth int, helpText string, promName string, handler func(string, string, string, string, float64)) (err error) {
+	_, nodeName, err := parseFileElements(path, directoryDepth)
+	if err != nil {
+		return err
+	}
+	content, err := readProcFile(path)
+	if err != nil {
+		return err
+	}
+	start, err := seqSpaceStart(string(content))
+	if err != nil {
+		return err
+	}
+	handler(nodeType, nodeName, promName, helpText, start)
+	return nil
+}
+
+func (s *lustreProcfsSource) parseRenameStats(nodeType string, path string, directoryDepth int, helpText string, promName string, handler func(string, string, string, string, string, string, float64)) (err error) {
+	_, nodeName, err := parseFileElements(path, directoryDepth)
+	if err != nil {
+		return err
+	}
+	content, err := readProcFile(path)
+	if err != nil {
+		return err
+	}
+	buckets, err := parseRenameStats(string(content))
+	if err != nil {
+		return err
+	}
+	for _, bucket := range buckets {
+		handler(nodeType, nodeName, bucket.section, bucket.size, promName, helpText, bucket.samples)
+	}
+	return nil
+}
+
+func (s *lustreProcfsSource) parseLodQos(nodeType string, path string, directoryDepth int, helpText string, promName string, handler func(string, string, string, string, float64)) (err error) {
+	_, nodeName, err := parseFileElements(path, directoryDepth)
+	if err != nil {
+		return err
+	}
+	content, err := readProcFile(path)
+	if err != nil {
+		return err
+	}
+	value, err := parseLodQosTunable(string(content))
+	if err != nil {
+		return err
+	}
+	handler(nodeType, nodeName, promName, helpText, value)
+	return nil
+}
+
+type importInfo struct {
+	state string
+	// numeric fields found in the file, by key
+	values map[string]float64
+}
+
+// importValueKeys lists the numeric fields of the 'import' file we export.
+var importValueKeys = map[string]bool{"idle": true, "connection_attempts": true, "timeouts": true, "resend": true, "out_of_mem": true}
+
+// parseImportInfo reads the YAML-like 'import' file of an mgc/mdc/osc/osp
+// device. The 'idle' line (seconds since the last reply) is only printed for
+// imports with an idle timeout.
+func parseImportInfo(content string) (importInfo, error) {
+	info := importInfo{values: map[string]float64{}}
+	for _, line := range strings.Split(content, "\n") {
+		key, value, found := strings.Cut(strings.TrimSpace(line), ":")
+		if !found {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch {
+		case key == "state":
+			if info.state == "" {
+				info.state = value
+			}
+		case importValueKeys[key]:
+			fields := strings.Fields(value)
+			if len(fields) == 0 {
+				return info, fmt.Errorf("no value for import field '%s'", key)
+			}
+			number, err := strconv.ParseFloat(fields[0], 64)
+			if err != nil {
+				return info, err
+			}
+			info.values[key] = number
+		}
+	}
+	if info.state == "" {
+		return info, fmt.Errorf("no import state found")
+	}
+	return info, nil
+}
+
+// value returns the value of a numeric import metric, false if the file does
+// not carry it.
+func (info importInfo) value(promName string) (float64, bool) {
+	switch promName {
+	case "mgc_last_ping_seconds":
+		value, ok := info.values["idle"]
+		return value, ok
+	case "osc_reconnects_total", "mdc_reconnects_total":
+		// the first connection is counted as an attempt as well
+		value, ok := info.values["connection_attempts"]
+		return math.Max(value-1, 0), ok
+	case "osc_timeouts_total", "mdc_timeouts_total":
+		value, ok := info.values["timeouts"]
+		return value, ok
+	case "ptlrpc_resend_total", "ptlrpc_timeout_total", "ptlrpc_out_of_mem_total":
+		value, ok := info.values[ptlrpcImportFields[promName]]
+		return value, ok
+	}
+	return 0, false
+}
+
+func (s *lustreProcfsSource) parseImport(nodeType string, path string, directoryDepth int, helpText string, promName string, handler func(string, string, string, string, string, float64)) (err error) {
+	_, nodeName, err := parseFileElements(path, directoryDepth)
+	if err != nil {
+		return err
+	}
+	content, err := readProcFile(path)
+	if err != nil {
+		return err
+	}
+	info, err := parseImportInfo(string(content))
+	if err != nil {
+		return err
+	}
+	if promName == "mgc_import_state" {
+		handler(nodeType, nodeName, info.state, promName, helpText, 1)
+	} else if value, ok := info.value(promName); ok {
+		handler(nodeType, nodeName, "", promName, helpText, value)
+	}
+	return nil
+}
+
+// lodFsName returns the filesystem name of a 'lod' device such as
+// 'lustrefs-MDT0000-mdtlod'.
+func lodFsName(nodeName string) string {
+	if i := strings.LastIndex(nodeName, "-MDT"); i > 0 {
+		return nodeName[:i]
+	}
+	return nodeName
+}
+
+// fsSeen remembers which per-filesystem metrics have already been emitted in
+// a scrape, since every MDT of a filesystem has its own 'lod' device carrying
+// the same defaults.
+type fsSeen map[string]bool
+
+func (f fsSeen) first(promName string, fs string) bool {
+	key := promName + "/" + fs
+	if f[key] {
+		return false
+	}
+	f[key] = true
+	return true
+}
+
+func (s *lustreProcfsSource) parseDefaultStripe(path string, directoryDepth int, helpText string, promName string, seen fsSeen, handler func(string, string, string, float64)) (err error) {
+	_, nodeName, err := parseFileElements(path, directoryDepth)
+	if err != nil {
+		return err
+	}
+	fs := lodFsName(nodeName)
+	if !seen.first(promName, fs) {
+		return nil
+	}
+	content, err := readProcFile(path)
+	if err != nil {
+		return err
+	}
+	value, err := strconv.ParseFloat(strings.TrimSpace(string(content)), 64)
+	if err != nil {
+		return err
+	}
+	handler(fs, promName, helpText, value)
+	return nil
+}
+
+// parseOstPool returns the OSTs listed in a 'lod/*/pools/<pool>' file, one
+// 'lustrefs-OST0000_UUID' per line.
+func parseOstPool(content string) []string {
+	var targets []string
+	for _, line := range strings.Split(content, "\n") {
+		target := strings.TrimSpace(line)
+		if target == "" {
+			continue
+		}
+		targets = append(targets, strings.TrimSuffix(target, "_UUID"))
+	}
+	return targets
+}
+
+func (s *lustreProcfsSource) parseOstPool(path string, directoryDepth int, helpText string, promName string, seen fsSeen, handler func(string, string, string, string, string, float64)) (err error) {
+	pool, nodeName, err := parseFileElements(path, directoryDepth)
+	if err != nil {
+		return err
+	}
+	fs := lodFsName(nodeName)
+	if !seen.first(promName+"/"+pool, fs) {
+		return nil
+	}
+	content, err := readProcFile(path)
+	if err != nil {
+		return err
+	}
+	for _, target := range parseOstPool(string(content)) {
+		handler(fs, pool, target, promName, helpText, 1)
+	}
+	return nil
+}
+
+// exportElements returns the client NID and the target of an
+// '<target>/exports/<nid>/<file>' path. ok is false for anything which is not
+// a client export (pseudo-files like 'clear', entries without a NID) and for
+// NIDs filtered out by ExportNidAllow/ExportNidDeny.
+func exportElements(path string) (nid string, target string, ok bool) {
+	pathElements := strings.Split(path, "/")
+	if len(pathElements) < 4 || pathElements[len(pathElements)-3] != "exports" {
+		return "", "", false
+	}
+	nid = pathElements[len(pathElements)-2]
+	target = pathElements[len(pathElements)-4]
+	if !strings.Contains(nid, "@") {
+		return "", "", false
+	}
+	return nid, target, exportNidAllowed(nid)
+}
+
+func exportNidAllowed(nid string) bool {
+	if ExportNidAllow != nil && !ExportNidAllow.MatchString(nid) {
+		return false
+	}
+	if ExportNidDeny != nil && ExportNidDeny.MatchString(nid) {
+		return false
+	}
+	return true
+}
+
+// exportLockRPCDifference returns the ldlm_enqueue less the ldlm_cancel
+// requests of the 'ldlm_stats' file of an export. The file only counts the
+// requests, there is no per export lock count to read.
+func exportLockRPCDifference(content string) (float64, error) {
+	var enqueued, cancelled float64
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		var target *float64
+		switch fields[0] {
+		case "ldlm_enqueue":
+			target = &enqueued
+		case "ldlm_cancel":
+			target = &cancelled
+		default:
+			continue
+		}
+		value, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			return 0, err
+		}
+		*target = value
+	}
+	return enqueued - cancelled, nil
+}
+
+func (s *lustreProcfsSource) parseExportLockRPCDifference(path string, helpText string, promName string, handler func(string, string, string, string, float64)) (err error) {
+	nid, nodeName, ok := exportElements(path)
+	if !ok {
+		return nil
+	}
+	content, err := readProcFile(path)
+	if err != nil {
+		return err
+	}
+	difference, err := exportLockRPCDifference(string(content))
+	if err != nil {
+		return err
+	}
+	handler(nid, nodeName, promName, helpText, difference)
+	return nil
+}
+
 func (s *lustreProcfsSource) parseFile(nodeType string, metricType string, path string, directoryDepth int, helpText string, promName string, hasMultipleVals bool, handler func(string, string, string, string, float64, string, string)) (err error) {
 	_, nodeName, err := parseFileElements(path, directoryDepth)
 	if err != nil {
@@ -772,7 +2086,7 @@ func (s *lustreProcfsSource) parseFile(nodeType string, metricType string, path
 	}
 	switch metricType {
 	case single:
-		value, err := os.ReadFile(filepath.Clean(path))
+		value, err := readProcFile(path)
 		if err != nil {
 			return err
 		}
@@ -781,7 +2095,7 @@ func (s *lustreProcfsSource) parseFile(nodeType string, metricType string, path
 			return err
 		}
 		handler(nodeType, nodeName, promName, helpText, convertedValue, "", "")
-	case stats, mdStats, encryptPagePools:
+	case stats, mdStats, encryptPagePools, unstableStats:
 		metricList, err := parseStatsFile(helpText, promName, path, hasMultipleVals)
 		if err != nil {
 			return err
@@ -796,43 +2110,28 @@ func (s *lustreProcfsSource) parseFile(nodeType string, metricType string, path
 
 func (s *lustreProcfsSource) counterMetric(labels []string, labelValues []string, name string, helpText string, value float64) prometheus.Metric {
 	return prometheus.MustNewConstMetric(
-		prometheus.NewDesc(
-			prometheus.BuildFQName(Namespace, "", name),
-			helpText,
-			labels,
-			nil,
-		),
+		newDesc("", name, helpText, labels),
 		prometheus.CounterValue,
 		value,
-		labelValues...,
+		sanitizeLabels(labelValues)...,
 	)
 }
 
 func (s *lustreProcfsSource) gaugeMetric(labels []string, labelValues []string, name string, helpText string, value float64) prometheus.Metric {
 	return prometheus.MustNewConstMetric(
-		prometheus.NewDesc(
-			prometheus.BuildFQName(Namespace, "", name),
-			helpText,
-			labels,
-			nil,
-		),
+		newDesc("", name, helpText, labels),
 		prometheus.GaugeValue,
 		value,
-		labelValues...,
+		sanitizeLabels(labelValues)...,
 	)
 }
 
 func (s *lustreProcfsSource) untypedMetric(labels []string, labelValues []string, name string, helpText string, value float64) prometheus.Metric {
 	return prometheus.MustNewConstMetric(
-		prometheus.NewDesc(
-			prometheus.BuildFQName(Namespace, "", name),
-			helpText,
-			labels,
-			nil,
-		),
+		newDesc("", name, helpText, labels),
 		prometheus.UntypedValue,
 		value,
-		labelValues...,
+		sanitizeLabels(labelValues)...,
 	)
 }
 
@@ -14,7 +14,17 @@
 package sources
 
 import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
 	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 )
 
 func TestGetJobNum(t *testing.T) {
@@ -140,3 +150,697 @@ func TestGetJobStats(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestParseLdlmPoolStats(t *testing.T) {
+	testPoolStats := `snapshot_time             1510782606.785647043 secs.nsecs
+granted                   16165 samples [locks] 1 1 16165 16165
+grant                     33 samples [locks] 1 1 33 33
+cancel                    32 samples [locks] 1 1 32 32
+grant_rate                4 samples [locks/s] 0 32 48 1025
+cancel_rate               4 samples [locks/s] 0 32 80 1024
+grant_plan                16165 samples [locks/s] 32207 3864795 524555363 31718968780341
+recalc_freed              0 samples [locks] 0 0 0 0
+`
+	expected := map[string]float64{
+		"ldlm_pool_grant_rate":  12,
+		"ldlm_pool_cancel_rate": 20,
+		"ldlm_pool_grant_speed": -8,
+	}
+
+	values := parseLdlmPoolStatsText(testPoolStats)
+	for promName, want := range expected {
+		got, ok := ldlmPoolValue(promName, values)
+		if !ok {
+			t.Fatalf("Value %s was not found", promName)
+		}
+		if got != want {
+			t.Fatalf("Retrieved an unexpected value for %s. Expected: %f, Got: %f", promName, want, got)
+		}
+	}
+	if _, ok := values["recalc_freed"]; ok {
+		t.Fatal("Expected no value for a field without sample")
+	}
+	if _, ok := values["snapshot_time"]; ok {
+		t.Fatal("Expected no value for the snapshot time")
+	}
+	if _, ok := ldlmPoolValue("ldlm_pool_grant_speed", map[string]float64{"grant_rate": 1}); ok {
+		t.Fatal("Expected no grant speed without a cancel rate")
+	}
+}
+
+func TestOspPreallocGap(t *testing.T) {
+	gap, err := ospPreallocGap("97\n", "67\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gap != 31 {
+		t.Fatalf("Retrieved an unexpected gap. Expected: %d, Got: %f", 31, gap)
+	}
+
+	// everything precreated has been handed out
+	gap, err = ospPreallocGap("97", "98")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gap != 0 {
+		t.Fatalf("Retrieved an unexpected gap. Expected: %d, Got: %f", 0, gap)
+	}
+
+	if _, err = ospPreallocGap("97", "n/a"); err == nil {
+		t.Fatal("Expected an error for an invalid prealloc_next_id")
+	}
+}
+
+func TestOssBytesTotals(t *testing.T) {
+	ostStats := []string{
+		`snapshot_time             1510950459.787901292 secs.nsecs
+read_bytes                1000 samples [bytes] 4096 1048576 3000000
+write_bytes               2000 samples [bytes] 4096 4194304 5000000
+`,
+		`snapshot_time             1510950459.787901292 secs.nsecs
+read_bytes                10 samples [bytes] 4096 1048576 40000
+write_bytes               20 samples [bytes] 4096 4194304 60000
+`,
+	}
+
+	totals := ossBytesTotals{}
+	for _, statsFile := range ostStats {
+		for _, item := range []struct{ promName, helpText string }{
+			{"read_bytes_total", readTotalHelp},
+			{"write_bytes_total", writeTotalHelp},
+		} {
+			metricList, err := getStatsIOMetrics(statsFile, item.promName, item.helpText)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, m := range metricList {
+				totals.add("ost", m.title, m.value)
+			}
+		}
+	}
+	// client side counters of the same name must not be summed
+	totals.add("client", "read_bytes_total", 123)
+
+	if totals["read_bytes_total"] != 3040000 {
+		t.Fatalf("Retrieved an unexpected read total. Expected: %d, Got: %f", 3040000, totals["read_bytes_total"])
+	}
+	if totals["write_bytes_total"] != 5060000 {
+		t.Fatalf("Retrieved an unexpected write total. Expected: %d, Got: %f", 5060000, totals["write_bytes_total"])
+	}
+}
+
+func TestOstReadBytes(t *testing.T) {
+	content, err := os.ReadFile("../tests/2.12/proc/fs/lustre/obdfilter/lustrefs-OST0000/stats")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, item := range []struct {
+		promName string
+		helpText string
+		expected float64
+	}{
+		// the total comes from the sum column, not the sample count
+		{"read_bytes_total", readTotalHelp, 4826395774976},
+		{"read_samples_total", readSamplesHelp, 1193211},
+	} {
+		metricList, err := getStatsIOMetrics(string(content), item.promName, item.helpText)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(metricList) != 1 || metricList[0].value != item.expected {
+			t.Fatalf("Retrieved an unexpected %s. Expected: %f, Got: %v", item.promName, item.expected, metricList)
+		}
+	}
+}
+
+func TestMdtExportLockRPCDifference(t *testing.T) {
+	paths, err := filepath.Glob("../tests/2.12/proc/fs/lustre/mdt/*/exports/*/" + exportLdlmStats)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 2 {
+		t.Fatalf("Retrieved an unexpected number of exports. Expected: %d, Got: %d", 2, len(paths))
+	}
+
+	expected := map[string]float64{"10.0.0.11@o2ib": 3926, "10.0.0.12@o2ib": 2}
+	for _, path := range paths {
+		nid, target, ok := exportElements(path)
+		if !ok {
+			t.Fatalf("Export %s was unexpectedly skipped", path)
+		}
+		if target != "lustrefs-MDT0000" {
+			t.Fatalf("Retrieved an unexpected target. Expected: %s, Got: %s", "lustrefs-MDT0000", target)
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		difference, err := exportLockRPCDifference(string(content))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if difference != expected[nid] {
+			t.Fatalf("Retrieved an unexpected lock RPC difference for %s. Expected: %f, Got: %f", nid, expected[nid], difference)
+		}
+	}
+
+	// pseudo-files of the exports directory are not clients
+	if _, _, ok := exportElements("/proc/fs/lustre/mdt/lustrefs-MDT0000/exports/clear"); ok {
+		t.Fatal("The 'clear' pseudo-file was not skipped")
+	}
+
+	ExportNidDeny = regexp.MustCompile(`^10\.0\.0\.12@`)
+	defer func() { ExportNidDeny = nil }()
+	if _, _, ok := exportElements(paths[1]); ok {
+		t.Fatal("A denied NID was not skipped")
+	}
+	if _, _, ok := exportElements(paths[0]); !ok {
+		t.Fatal("An allowed NID was unexpectedly skipped")
+	}
+}
+
+func TestDefaultStripe(t *testing.T) {
+	if fs := lodFsName("lustrefs-MDT0000-mdtlod"); fs != "lustrefs" {
+		t.Fatalf("Retrieved an unexpected filesystem name. Expected: %s, Got: %s", "lustrefs", fs)
+	}
+	if fs := lodFsName("scratch-fs-MDT0001-mdtlod"); fs != "scratch-fs" {
+		t.Fatalf("Retrieved an unexpected filesystem name. Expected: %s, Got: %s", "scratch-fs", fs)
+	}
+
+	seen := fsSeen{}
+	if !seen.first("default_stripe_count", "lustrefs") {
+		t.Fatal("First MDT of a filesystem was skipped")
+	}
+	if seen.first("default_stripe_count", "lustrefs") {
+		t.Fatal("Second MDT of a filesystem was not skipped")
+	}
+	if !seen.first("default_stripe_size_bytes", "lustrefs") {
+		t.Fatal("Another metric of the same filesystem was skipped")
+	}
+
+	var s lustreProcfsSource
+	got := map[string]float64{}
+	for _, item := range []struct{ filename, promName string }{
+		{lodStripeCount, "default_stripe_count"},
+		{lodStripeSize, "default_stripe_size_bytes"},
+	} {
+		paths, err := filepath.Glob("../tests/2.12/proc/fs/lustre/lod/*/" + item.filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, path := range paths {
+			err = s.parseDefaultStripe(path, 0, "", item.promName, fsSeen{}, func(fs string, name string, helpText string, value float64) {
+				got[name+"/"+fs] = value
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if got["default_stripe_count/lustrefs"] != 1 {
+		t.Fatalf("Retrieved an unexpected stripe count. Expected: %d, Got: %f", 1, got["default_stripe_count/lustrefs"])
+	}
+	if got["default_stripe_size_bytes/lustrefs"] != 1048576 {
+		t.Fatalf("Retrieved an unexpected stripe size. Expected: %d, Got: %f", 1048576, got["default_stripe_size_bytes/lustrefs"])
+	}
+}
+
+func TestUnstableStats(t *testing.T) {
+	metricList, err := parseStatsFile(unstablePagesHelp, "client_unstable_pages", "../tests/2.12/proc/fs/lustre/llite/lustrefs-ffff88105db50000/"+unstableStats, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(metricList) != 1 {
+		t.Fatalf("Retrieved an unexpected number of metrics. Expected: %d, Got: %d", 1, len(metricList))
+	}
+	if metricList[0].value != 2816 {
+		t.Fatalf("Retrieved an unexpected unstable page count. Expected: %d, Got: %f", 2816, metricList[0].value)
+	}
+}
+
+func TestSeqSpaceStart(t *testing.T) {
+	for content, expected := range map[string]float64{
+		"[0x240000400 - 0xffffffffffffffff]:0:mdt\n": 9663677440,
+		"[0x2000013a0 - 0x240000400]:0:mdt":          8589939616,
+		"[0x0 - 0x0]:6:mdt":                          0,
+		"[0x200000400-0x240000400]":                  8589935616,
+	} {
+		start, err := seqSpaceStart(content)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if start != expected {
+			t.Fatalf("Retrieved an unexpected sequence start for %q. Expected: %f, Got: %f", content, expected, start)
+		}
+	}
+
+	if _, err := seqSpaceStart("<none>\n"); err == nil {
+		t.Fatal("Expected an error for a missing sequence range")
+	}
+}
+
+func TestParseImportInfo(t *testing.T) {
+	content := `import:
+    name: MGC172.20.20.1@o2ib
+    target: MGS
+    state: FULL
+    connect_data:
+       flags: 0x2000011005002020
+       target_version: 2.10.1.0
+    connection:
+       current_connection: 172.20.20.1@o2ib
+       idle: 14 sec
+`
+	info, err := parseImportInfo(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.state != "FULL" {
+		t.Fatalf("Retrieved an unexpected import state. Expected: %s, Got: %s", "FULL", info.state)
+	}
+	if idle, ok := info.value("mgc_last_ping_seconds"); !ok || idle != 14 {
+		t.Fatalf("Retrieved an unexpected idle time. Expected: %d, Got: %f (%t)", 14, idle, ok)
+	}
+
+	if _, err := parseImportInfo("import:\n    name: MGC172.20.20.1@o2ib\n"); err == nil {
+		t.Fatal("Expected an error for an import without state")
+	}
+}
+
+func TestServiceStatMean(t *testing.T) {
+	content, err := os.ReadFile("../tests/2.12/proc/fs/lustre/mds/MDS/mdt/stats")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, expected := range map[string]float64{
+		"req_qdepth": 234.0 / 57267,
+		"req_active": 119847.0 / 57267,
+	} {
+		value, found, err := serviceStatMean(string(content), name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !found || value != expected {
+			t.Fatalf("Retrieved an unexpected %s. Expected: %f, Got: %f (found: %t)", name, expected, value, found)
+		}
+	}
+
+	if _, found, _ := serviceStatMean(string(content), "req_history"); found {
+		t.Fatal("Expected a missing line not to be found")
+	}
+}
+
+func TestServiceStatsPerService(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("../tests/2.12/proc/fs/lustre/mds/MDS", mdtServiceStats))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &lustreProcfsSource{}
+	got := map[string]float64{}
+	for _, path := range paths {
+		err := s.parseServiceStats("mds", path, strings.Count(mdtServiceStats, "/"), mdtReqQdepthHelp, "mdt_req_qdepth", func(nodeType string, nodeName string, service string, name string, helpText string, value float64) {
+			if _, ok := got[service]; ok {
+				t.Fatalf("Retrieved the service %s twice", service)
+			}
+			got[service] = value
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected := map[string]float64{
+		"mdt":          234.0 / 57267,
+		"mdt_fld":      0,
+		"mdt_out":      6.0 / 42,
+		"mdt_readpage": 0,
+		"mdt_seqm":     0,
+		"mdt_seqs":     0,
+		"mdt_setattr":  1.0 / 8,
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Retrieved unexpected per-service values. Expected: %v, Got: %v", expected, got)
+	}
+}
+
+func TestOstPool(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("../tests/2.12/proc/fs/lustre/lod/*", lodPools))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &lustreProcfsSource{}
+	seen := fsSeen{}
+	got := map[string][]string{}
+	for _, path := range paths {
+		err := s.parseOstPool(path, strings.Count(lodPools, "/"), ostPoolMemberHelp, "ost_pool_member", seen, func(fs string, pool string, target string, name string, helpText string, value float64) {
+			if fs != "lustrefs" || value != 1 {
+				t.Fatalf("Retrieved an unexpected member. Expected: fs lustrefs = 1, Got: fs %s = %f", fs, value)
+			}
+			got[pool] = append(got[pool], target)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected := map[string][]string{
+		"archive": {"lustrefs-OST0002", "lustrefs-OST0003", "lustrefs-OST0004", "lustrefs-OST0005", "lustrefs-OST0006"},
+		"flash":   {"lustrefs-OST0000", "lustrefs-OST0001", "lustrefs-OST0002"},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Retrieved unexpected pools. Expected: %v, Got: %v", expected, got)
+	}
+}
+
+func TestLdlmBlockingTimeouts(t *testing.T) {
+	got := map[string]float64{}
+	record := func(labels []string, values []string, name string, help string, value float64) prometheus.Metric {
+		if name == "ldlm_blocking_timeouts_total" {
+			got[values[1]] = value
+		}
+		return nil
+	}
+	s := &lustreProcfsSource{basePath: "../tests/2.12/proc/fs/lustre"}
+	s.generateLdlmMetricTemplates(core)
+	for i := range s.lustreProcMetrics {
+		s.lustreProcMetrics[i].metricFunc = record
+	}
+	ctx := insProcfsV2.newCtx(s)
+	defer ctx.release()
+	if err := ctx.collect(); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]float64{
+		"lustrefs-OST0000":     7,
+		"lustrefs-OST0002":     0,
+		"lustrefs-OST0004":     0,
+		"lustrefs-OST0006":     0,
+		"mdt-lustrefs-MDT0000": 0,
+		"MGS":                  0,
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Retrieved unexpected blocking timeouts. Expected: %v, Got: %v", expected, got)
+	}
+}
+
+func TestDiscontiguousBlocks(t *testing.T) {
+	s := &lustreProcfsSource{}
+	got := map[string]float64{}
+	err := s.parseBRWStats("ost", "stats", "../tests/2.12/proc/fs/lustre/osd-zfs/lustrefs-OST0000/brw_stats", 0, discontiguousBlocksHelp, "discontiguous_blocks_total", false, func(nodeType string, brwOperation string, brwSize string, nodeName string, name string, helpText string, value float64, extraLabel string, extraLabelValue string) {
+		got[brwOperation+"/"+brwSize] = value
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]float64{
+		"read/0": 20, "read/1": 3, "read/2": 0, "read/3": 0,
+		"write/0": 3810, "write/1": 412, "write/2": 97, "write/3": 21,
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Retrieved unexpected discontiguous blocks. Expected: %v, Got: %v", expected, got)
+	}
+}
+
+func TestImportReconnects(t *testing.T) {
+	paths, err := filepath.Glob("../tests/2.12/proc/fs/lustre/[om][sd]c/*/" + importFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatal("No import file found in the fixtures")
+	}
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		info, err := parseImportInfo(string(content))
+		if err != nil {
+			t.Fatalf("Failed to parse %s: %s", path, err)
+		}
+		if !strings.HasSuffix(filepath.Dir(path), "lustrefs-OST0003-osc-ffff88105db50000") {
+			continue
+		}
+		if reconnects, ok := info.value("osc_reconnects_total"); !ok || reconnects != 3 {
+			t.Fatalf("Retrieved an unexpected reconnect count. Expected: %d, Got: %f (%t)", 3, reconnects, ok)
+		}
+		if timeouts, ok := info.value("osc_timeouts_total"); !ok || timeouts != 2 {
+			t.Fatalf("Retrieved an unexpected timeout count. Expected: %d, Got: %f (%t)", 2, timeouts, ok)
+		}
+	}
+}
+
+func TestReintStats(t *testing.T) {
+	content, err := os.ReadFile("../tests/2.12/proc/fs/lustre/mdt/lustrefs-MDT0000/md_stats")
+	if err != nil {
+		t.Fatal(err)
+	}
+	reints, err := parseReintStats(string(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]float64{"setattr": 57, "create": 3, "open": 10, "unlink": 2}
+	if len(reints) != len(expected) {
+		t.Fatalf("Retrieved an unexpected number of reint operations. Expected: %d, Got: %d", len(expected), len(reints))
+	}
+	for op, value := range expected {
+		if reints[op] != value {
+			t.Fatalf("Retrieved an unexpected reint_%s. Expected: %f, Got: %f", op, value, reints[op])
+		}
+	}
+
+	// the plain operations must not pick up the reint lines
+	text := "reint_create              3 samples [reqs]\ncreate                    5 samples [reqs]\n"
+	if got := captureOperation("create", " .*", text); !strings.HasPrefix(got, "create ") || !strings.Contains(got, " 5 ") {
+		t.Fatalf("Retrieved an unexpected create line. Expected: %q, Got: %q", "create                    5 samples [reqs]", got)
+	}
+	if got := captureOperation("unlink", " .*", text); got != "" {
+		t.Fatalf("Retrieved an unexpected unlink line. Expected: %q, Got: %q", "", got)
+	}
+}
+
+func TestOperationErrors(t *testing.T) {
+	for path, expected := range map[string]map[string]float64{
+		"../tests/2.12/proc/fs/lustre/mdt/lustrefs-MDT0000/md_stats":    {"setattr": 4},
+		"../tests/2.12/proc/fs/lustre/obdfilter/lustrefs-OST0000/stats": {"create": 1},
+	} {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		errs, err := parseOperationErrors(string(content))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(errs, expected) {
+			t.Fatalf("Retrieved unexpected operation errors in %s. Expected: %v, Got: %v", path, expected, errs)
+		}
+
+		// the error counter is not taken for the operation count
+		for op := range expected {
+			if got := captureOperation(op, " .*", string(content)); strings.Contains(got, "_errors") {
+				t.Fatalf("Retrieved an unexpected %s line. Got: %q", op, got)
+			}
+		}
+	}
+
+	if _, err := parseOperationErrors("create_errors             x samples [reqs]\n"); err == nil {
+		t.Fatal("Expected an error for a non numeric error count")
+	}
+}
+
+func TestRecoveryStatus(t *testing.T) {
+	content, err := os.ReadFile("../tests/2.12/proc/fs/lustre/mdt/lustrefs-MDT0000/recovery_status")
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := parseRecoveryStatus(string(content))
+	for promName, expected := range map[string]float64{
+		"recovery_stale_locks_total": 37,
+		"recovery_stale_clients":     1,
+		"recovery_evicted_clients":   1,
+	} {
+		value, ok := values[recoveryStatusFields[promName]]
+		if !ok || value != expected {
+			t.Fatalf("Retrieved an unexpected %s. Expected: %f, Got: %f (found: %t)", promName, expected, value, ok)
+		}
+	}
+	if _, ok := values["connected_clients"]; ok {
+		t.Fatal("Expected the 'n/m' fields to be left out")
+	}
+
+	// a completed recovery does not report the stale counts
+	content, err = os.ReadFile("../tests/2.12/proc/fs/lustre/obdfilter/lustrefs-OST0000/recovery_status")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := parseRecoveryStatus(string(content))["stale_locks"]; ok {
+		t.Fatal("Expected stale_locks to be missing from a completed recovery")
+	}
+}
+
+func TestRecoveryFiles(t *testing.T) {
+	path := "../tests/2.12/proc/fs/lustre/mdt/lustrefs-MDT0000/recovery_status"
+	reads := 0
+	read := func(path string) ([]byte, error) {
+		reads++
+		return os.ReadFile(path)
+	}
+
+	// the recovery templates of a target share one read
+	recoveries := recoveryFiles{}
+	for i := 0; i < 7; i++ {
+		content, err := recoveries.read(path, read)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status, _ := recoveryStatusState(content); status != recoveryStatusRecovering {
+			t.Fatalf("Retrieved an unexpected status. Expected: %s, Got: %s", recoveryStatusRecovering, status)
+		}
+	}
+	if reads != 1 {
+		t.Fatalf("Retrieved an unexpected number of reads. Expected: %d, Got: %d", 1, reads)
+	}
+}
+
+func TestClientLdlmNamespaces(t *testing.T) {
+	var s lustreProcfsSource
+	s.basePath = "../tests/2.12/proc/fs/lustre"
+	s.generateClientMetricTemplates(extended)
+
+	targets := map[string]bool{}
+	for _, metric := range s.lustreProcMetrics {
+		if metric.promName != "client_ldlm_lru_size" {
+			continue
+		}
+		paths, err := filepath.Glob(filepath.Join(s.basePath, metric.path, metric.filename))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, path := range paths {
+			_, nodeName, err := parseFileElements(path, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			targets[nodeName] = true
+		}
+	}
+	// the osc namespaces of the MDT (lustrefs-OST0000-osc-MDT0000) are not
+	// client ones
+	if len(targets) != 8 || !targets["lustrefs-MDT0000-mdc-ffff88105db50000"] || !targets["lustrefs-OST0006-osc-ffff88105db50000"] {
+		t.Fatalf("Retrieved unexpected client ldlm namespaces. Expected: %d, Got: %v", 8, targets)
+	}
+}
+
+func TestClientLovLmv(t *testing.T) {
+	var s lustreProcfsSource
+	s.basePath = "../tests/2.12/proc/fs/lustre"
+	s.generateClientMetricTemplates(core)
+
+	got := map[string]string{}
+	for _, metric := range s.lustreProcMetrics {
+		if metric.promName != clientLovStripeCount && metric.promName != "client_lmv_mdt_count" {
+			continue
+		}
+		paths, err := filepath.Glob(filepath.Join(s.basePath, metric.path, metric.filename))
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the lov of the MDT (lustrefs-MDT0000-mdtlov) is not a client one
+		if len(paths) != 1 {
+			t.Fatalf("Retrieved an unexpected number of %s files. Expected: %d, Got: %v", metric.promName, 1, paths)
+		}
+		err = s.parseFile(metric.source, single, paths[0], 0, metric.helpText, metric.promName, false, func(nodeType string, nodeName string, name string, helpText string, value float64, extraLabel string, extraLabelValue string) {
+			got[name] = fmt.Sprintf("%s/%s=%g", nodeType, nodeName, value)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected := map[string]string{
+		clientLovStripeCount:   "client/lustrefs-clilov-ffff88105db50000=1",
+		"client_lmv_mdt_count": "client/lustrefs-clilmv-ffff88105db50000=1",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Retrieved unexpected client lov/lmv metrics. Expected: %v, Got: %v", expected, got)
+	}
+}
+
+func TestLdlmPoolStatsCollect(t *testing.T) {
+	defer func(levels []string) {
+		OstEnabled, MdtEnabled, MgsEnabled, MdsEnabled, ClientEnabled, GenericEnabled, LdlmEnabled = levels[0], levels[1], levels[2], levels[3], levels[4], levels[5], levels[6]
+	}([]string{OstEnabled, MdtEnabled, MgsEnabled, MdsEnabled, ClientEnabled, GenericEnabled, LdlmEnabled})
+	OstEnabled, MdtEnabled, MgsEnabled, MdsEnabled, ClientEnabled, GenericEnabled = disabled, disabled, disabled, disabled, disabled, disabled
+	LdlmEnabled = core
+
+	s := newLustreSource(Config{ProcLocation: "../tests/2.12/proc"}).(*lustreProcfsSource)
+	ctx := s.newCtx()
+	defer ctx.release()
+	if err := ctx.collect(); err != nil {
+		t.Fatal(err)
+	}
+	ch := make(chan prometheus.Metric, 1000)
+	ctx.update(ch)
+	close(ch)
+
+	// grant_rate 16165 samples [locks/s] 0 32 33 1025
+	// cancel_rate 16165 samples [locks/s] 0 32 32 1024
+	expected := map[string]float64{
+		"lustre_ldlm_pool_grant_rate":  33.0 / 16165,
+		"lustre_ldlm_pool_cancel_rate": 32.0 / 16165,
+		"lustre_ldlm_pool_grant_speed": 33.0/16165 - 32.0/16165,
+	}
+	got := map[string]float64{}
+	for m := range ch {
+		var d dto.Metric
+		if err := m.Write(&d); err != nil {
+			t.Fatal(err)
+		}
+		for _, l := range d.GetLabel() {
+			if l.GetName() == "namespace" && l.GetValue() == "filter-lustrefs-OST0000_UUID" {
+				got[fqNameRE.FindStringSubmatch(m.Desc().String())[1]] = d.GetGauge().GetValue()
+			}
+		}
+	}
+	for name, want := range expected {
+		if value, ok := got[name]; !ok || math.Abs(value-want) > 1e-9 {
+			t.Fatalf("Retrieved an unexpected value for %s. Expected: %g, Got: %g (found: %t)", name, want, value, ok)
+		}
+	}
+}
+
+func TestUpdateDerivedMetrics(t *testing.T) {
+	defer func(ost string, latency bool) { OstEnabled, LatencyStats = ost, latency }(OstEnabled, LatencyStats)
+	OstEnabled = extended
+	LatencyStats = true
+
+	names := func(collect func(ch chan<- prometheus.Metric) error) map[string]bool {
+		ch := make(chan prometheus.Metric, 100000)
+		if err := collect(ch); err != nil {
+			t.Fatal(err)
+		}
+		close(ch)
+		out := map[string]bool{}
+		for m := range ch {
+			out[fqNameRE.FindStringSubmatch(m.Desc().String())[1]] = true
+		}
+		return out
+	}
+	s := newLustreSource(Config{ProcLocation: "../tests/2.12/proc"}).(*lustreProcfsSource)
+	v2 := names(func(ch chan<- prometheus.Metric) error {
+		ctx := s.newCtx()
+		defer ctx.release()
+		if err := ctx.collect(); err != nil {
+			return err
+		}
+		ctx.update(ch)
+		return nil
+	})
+	v1 := names(s.Update)
+
+	// the metrics derived from several files of the scrape are emitted by
+	// both collecting logics
+	for _, name := range []string{"lustre_op_latency_mean_microseconds", "lustre_op_latency_stddev_microseconds", "lustre_jobstats_resets_total", "lustre_brw_size_consistent", "lustre_ost_space_imbalance_ratio"} {
+		if !v2[name] || !v1[name] {
+			t.Fatalf("Retrieved an unexpected %s. Expected in v1 and v2, Got: v1 %t, v2 %t", name, v1[name], v2[name])
+		}
+	}
+}
@@ -1,11 +1,13 @@
 package sources
 
 import (
+	"errors"
 	"fmt"
 	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 	"unsafe"
 
 	"lustre_exporter/log"
@@ -22,6 +24,23 @@ type procfsV2Ctx struct {
   s                  *lustreProcfsSource
 	fr                 *fileReader
 	filesJobStats      map[string]*[]jobState
+	hasher             targetHasher
+	unknownLines       *scrapeUnknownLines
+	ossTotals          ossBytesTotals
+	stripeSeen         fsSeen
+	files              targetFiles
+	latencyFiles       map[string]bool
+	rateFiles          map[string]bool
+	resetFiles         map[string]bool
+	jobCounters        jobCounters
+	brwSizes           brwSizes
+	ostSpaces          ostSpaces
+	grants             ostGrants
+	writeBytes         writeBytesTotals
+	churn              connectionChurn
+	subnets            clientSubnets
+	pings              pingValues
+	quotas             quotaTables
 	metrics_           []prometheus.Metric
 }
 
@@ -32,6 +51,23 @@ func (v2 *procfsV2)newCtx(s  *lustreProcfsSource) *procfsV2Ctx{
 	  s            : s,
 		fr           : newFileReader(),
 		filesJobStats: map[string]*[]jobState{},
+		hasher       : targetHasher{},
+		unknownLines : newScrapeUnknownLines(),
+		ossTotals    : ossBytesTotals{},
+		stripeSeen   : fsSeen{},
+		files        : targetFiles{},
+		latencyFiles : map[string]bool{},
+		rateFiles    : map[string]bool{},
+		resetFiles   : map[string]bool{},
+		jobCounters  : jobCounters{},
+		brwSizes     : brwSizes{},
+		ostSpaces    : ostSpaces{},
+		grants       : ostGrants{},
+		writeBytes   : writeBytesTotals{},
+		churn        : connectionChurn{},
+		subnets      : clientSubnets{},
+		pings        : pingValues{},
+		quotas       : quotaTables{},
 	}
 }
 
@@ -51,12 +87,17 @@ func (ctx *procfsV2Ctx)release()  {
 	ctx.fr.release()
 }
 
+func (ctx *procfsV2Ctx)filesRead() uint64 {
+	return ctx.fr.filesRead()
+}
+
 func (ctx *procfsV2Ctx)prepareFiles() (err error) {
 	for _, metric := range ctx.s.lustreProcMetrics {
-		_, err := ctx.fr.glob(filepath.Join(ctx.s.basePath, metric.path, metric.filename), true)
+		paths, err := ctx.s.metricGlobs(&metric, func(path string) ([]string, error) { return ctx.fr.glob(path) })
 		if err != nil {
 			return err
 		}
+		ctx.fr.prefetch(paths)
 	}
 	ctx.fr.wait(true)
 
@@ -70,31 +111,118 @@ func (ctx *procfsV2Ctx)collect() error {
 	s := ctx.s
 
 	ctx.prepareFiles()
+	if s.uuids != nil {
+		s.uuids.reset()
+	}
+
+	// a file which fails to parse doesn't stop nor fail the scrape, the other
+	// files are still collected and the failure is reported by the
+	// completeness of its target. Only the node wide modules and extra params
+	// return their error.
+	var firstErr error
+
+	var devices deviceStates
+	if SkipInactiveTargets {
+		// 'devices' moved to debugfs in Lustre 2.11
+		devices = readDeviceStates(filepath.Join(s.basePath, "devices"), filepath.Join(s.cfg.LustreDebugPath(), "devices"))
+	}
 
 	for _, metric := range s.lustreProcMetrics {
 		directoryDepth = strings.Count(metric.filename, "/")
-		paths, err := ctx.fr.glob(filepath.Join(s.basePath, metric.path, metric.filename))
+		paths, err := s.metricGlobs(&metric, func(path string) ([]string, error) { return ctx.fr.glob(path) })
 		if err != nil {
 			return err
 		}
-		if paths == nil {
+		var read map[string]bool
+		if zeroOnMissing(&metric) {
+			read = map[string]bool{}
+		}
+		if paths == nil && read == nil {
 			continue
 		}
 		for _, path := range paths {
+			if devices != nil || len(TargetGlobs) > 0 {
+				if _, nodeName, e := parseFileElements(path, directoryDepth); e == nil && (devices.inactive(metric.source, nodeName) || !targetSelected(metric.source, nodeName)) {
+					if read != nil {
+						read[path] = true
+					}
+					continue
+				}
+			}
 			metricType = single
 			switch metric.filename {
 			case "brw_stats", "rpc_stats":
+				if _, ok := oscWritebackFields[metric.promName]; ok {
+					basicLables := []string{"component", "target"}
+					err = ctx.parseOscWriteback(metric.source, path, directoryDepth, &metric, basicLables)
+					break
+				}
 			  basicLables := []string{"component", "target", "operation", "size"}
 				err = ctx.parseBRWStats(metric.source, "stats", path, directoryDepth, &metric, basicLables)
-				if err != nil {
-					return err
-				}
 			case "job_stats":
+				if metric.promName == jobStatsFileBytes {
+					basicLables := []string{"component", "target"}
+					err = ctx.parseJobStatsSize(metric.source, path, directoryDepth, &metric, basicLables)
+					break
+				}
 				basicLables := []string{"component", "target", "jobid"}
 				err = ctx.parseJobStats(metric.source, "job_stats", path, directoryDepth, &metric, basicLables)
-				if err != nil {
-					return err
+			case ldlmPoolStats:
+				basicLables := []string{"namespace", "target"}
+				err = ctx.parseLdlmPoolStats(path, directoryDepth, &metric, basicLables)
+			case lodStripeCount, lodStripeSize:
+				if metric.promName == clientLovStripeCount {
+					basicLables := []string{"component", "target"}
+					err = ctx.parseFile(metric.source, single, path, directoryDepth, &metric, basicLables)
+					break
 				}
+				basicLables := []string{"fs"}
+				err = ctx.parseDefaultStripe(path, directoryDepth, &metric, basicLables)
+			case lodPools:
+				basicLables := []string{"fs", "pool", "target"}
+				err = ctx.parseOstPool(path, directoryDepth, &metric, basicLables)
+			case lodQosPrioFree, lodQosThresholdRR:
+				basicLables := []string{"component", "target"}
+				err = ctx.parseLodQos(metric.source, path, directoryDepth, &metric, basicLables)
+			case seqSpace:
+				basicLables := []string{"component", "target"}
+				err = ctx.parseSeqSpace(metric.source, path, directoryDepth, &metric, basicLables)
+			case importFile:
+				basicLables := []string{"component", "target"}
+				err = ctx.parseImport(metric.source, path, directoryDepth, &metric, basicLables)
+			case exportLdlmStats:
+				basicLables := []string{"client_nid", "target"}
+				err = ctx.parseExportLockRPCDifference(path, &metric, basicLables)
+			case mdtServiceStats:
+				basicLables := []string{"component", "target", "service"}
+				err = ctx.parseServiceStats(metric.source, path, directoryDepth, &metric, basicLables)
+			case recoveryStatus:
+				basicLables := []string{"component", "target"}
+				err = ctx.parseRecoveryStatus(metric.source, path, directoryDepth, &metric, basicLables)
+			case quotaAcct:
+				basicLables := []string{"component", "target", "type", "id"}
+				err = ctx.parseQuota(metric.source, path, directoryDepth, &metric, basicLables)
+			case renameStats:
+				basicLables := []string{"component", "target", "type", "size"}
+				err = ctx.parseRenameStats(metric.source, path, directoryDepth, &metric, basicLables)
+			case ospPreallocLastID:
+				basicLables := []string{"component", "target"}
+				err = ctx.parseOspPreallocGap(metric.source, path, directoryDepth, &metric, basicLables)
+			case osdJournalStats:
+				basicLables := []string{"component", "target"}
+				err = ctx.parseOsdJournalStats(metric.source, path, directoryDepth, &metric, basicLables)
+			case hsmActions, hsmActiveRequests:
+				basicLables := []string{"component", "target"}
+				err = ctx.parseHsm(metric.source, path, directoryDepth, &metric, basicLables)
+			case qmtGlobalDt, qmtGlobalMd:
+				basicLables := []string{"fs", "type", "id"}
+				err = ctx.parseQmtGlobal(path, &metric, basicLables)
+			case exportsDir:
+				basicLables := []string{"component", "target"}
+				err = ctx.parseExportsActive(metric.source, path, directoryDepth, &metric, basicLables)
+			case readCacheEnable:
+				basicLables := []string{"component", "target", "read_cache", "writethrough"}
+				err = ctx.parseCachePolicy(metric.source, path, directoryDepth, &metric, basicLables)
 			default:
 				if metric.filename == stats {
 					metricType = stats
@@ -102,28 +230,104 @@ func (ctx *procfsV2Ctx)collect() error {
 					metricType = mdStats
 				} else if metric.filename == encryptPagePools {
 					metricType = encryptPagePools
+				} else if metric.filename == unstableStats {
+					metricType = unstableStats
 				}
 				basicLables := []string{"component", "target"}
 				err = ctx.parseFile(metric.source, metricType, path, directoryDepth, &metric, basicLables)
-				if err != nil {
-					return err
-				}
+			}
+			if _, nodeName, e := parseFileElements(path, directoryDepth); e == nil {
+				ctx.files.observe(metric.source, nodeName, path, err == nil)
+			}
+			if read != nil && err == nil {
+				read[path] = true
+			}
+			// skipped files are already logged and counted
+			if err != nil && !errors.Is(err, errFileSkipped) {
+				log.Warnf("parsing %s failed: %s", path, err)
+			}
+		}
+		if read != nil {
+			missing, err := missingExpectedFiles(s.roots(), &metric, read)
+			if err != nil {
+				return err
+			}
+			for _, path := range missing {
+				ctx.metrics_ = append(ctx.metrics_, missingZeroMetric(&metric, path))
 			}
 		}
 	}
 
-	return nil
+	if FrozenThreshold > 0 && !s.cfg.stateless {
+		for key, frozen := range insFrozenDetector.observe(ctx.hasher.sums(), FrozenThreshold) {
+			ctx.metrics_ = append(ctx.metrics_, frozenMetric(key, frozen))
+		}
+	}
+
+	if len(ctx.jobCounters) > 0 && !s.cfg.stateless {
+		for key, total := range insJobResets.observe(ctx.jobCounters) {
+			ctx.metrics_ = append(ctx.metrics_, jobStatsResetsMetric(key, total))
+		}
+	}
+
+	if PingStallThreshold > 0 && !s.cfg.stateless {
+		for key, stalled := range insPingDetector.observe(ctx.pings, PingStallThreshold) {
+			ctx.metrics_ = append(ctx.metrics_, pingStalledMetric(key, stalled))
+		}
+	}
+
+	if len(ctx.writeBytes) > 0 && !s.cfg.stateless {
+		for key, rate := range insWriteThroughput.observe(ctx.writeBytes, time.Now()) {
+			ctx.metrics_ = append(ctx.metrics_, writeBytesRateMetric(key, rate))
+		}
+	}
+
+	ctx.metrics_ = append(ctx.metrics_, ctx.ossTotals.metrics(s)...)
+	ctx.metrics_ = append(ctx.metrics_, ctx.brwSizes.metrics()...)
+	ctx.metrics_ = append(ctx.metrics_, ctx.ostSpaces.metrics()...)
+	ctx.metrics_ = append(ctx.metrics_, ctx.grants.metrics()...)
+	ctx.metrics_ = append(ctx.metrics_, ctx.churn.metrics()...)
+	ctx.metrics_ = append(ctx.metrics_, ctx.subnets.metrics()...)
+	if s.uuids != nil {
+		ctx.metrics_ = append(ctx.metrics_, s.uuids.metrics()...)
+	}
+
+	if !s.cfg.stateless {
+		ctx.metrics_ = append(ctx.metrics_, ctx.unknownLines.metrics()...)
+	}
+
+	if s.modulesPath != "" {
+		modules, err := moduleMetrics(s.modulesPath, ctx.fr.readFile)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		ctx.metrics_ = append(ctx.metrics_, modules...)
+	}
+
+	if len(ExtraParams) > 0 {
+		glob := func(path string) ([]string, error) { return ctx.fr.glob(path) }
+		extra, err := extraParamMetrics(s.extraParamRoots(), glob, ctx.fr.readFile)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		ctx.metrics_ = append(ctx.metrics_, extra...)
+	}
+
+	ctx.metrics_ = append(ctx.metrics_, ctx.files.metrics()...)
+
+	return firstErr
 }
 
 var	brwStatsMetricBlocks = map[string]string{
-		pagesPerBlockRWHelp:    "pages per bulk r/w",
-		discontiguousPagesHelp: "discontiguous pages",
-		diskIOsInFlightHelp:    "disk I/Os in flight",
-		ioTimeHelp:             "I/O time",
-		diskIOSizeHelp:         "disk I/O size",
-		pagesPerRPCHelp:        "pages per rpc",
-		rpcsInFlightHelp:       "rpcs in flight",
-		offsetHelp:             "offset",
+		pagesPerBlockRWHelp:     "pages per bulk r/w",
+		discontiguousPagesHelp:  "discontiguous pages",
+		discontiguousBlocksHelp: "discontiguous blocks",
+		diskIOsInFlightHelp:     "disk I/Os in flight",
+		ioTimeHelp:              "I/O time",
+		diskIOSizeHelp:          "disk I/O size",
+		pagesPerRPCHelp:         "pages per rpc",
+		rpcsInFlightHelp:        "rpcs in flight",
+		offsetHelp:              "offset",
 	}
 
 func (ctx *procfsV2Ctx) parseBRWStats(nodeType string, metricType string, path string, directoryDepth int, metric *lustreProcMetric, basicLables []string) (err error) {
@@ -137,6 +341,7 @@ func (ctx *procfsV2Ctx) parseBRWStats(nodeType string, metricType string, path s
 		return err
 	}
 	statsFile := string(statsFileBytes[:])
+	ctx.unknownLines.add(nodeType, path, statsFile, countUnknownHistogramLines)
 	block := regexCaptureString("(?ms:^"+brwStatsMetricBlocks[metric.helpText]+".*?(\n\n|\\z))", statsFile)
 
 	extraLabel := ""
@@ -152,6 +357,16 @@ func (ctx *procfsV2Ctx) parseBRWStats(nodeType string, metricType string, path s
 		return err
 	}
 
+	if IOTimeHistogram && metric.helpText == ioTimeHelp && !osdDuplicate(metric, ctx.s.lustreProcMetrics) {
+		histograms, err := ioTimeHistograms(block)
+		if err != nil {
+			return err
+		}
+		for _, h := range histograms {
+			ctx.metrics_ = append(ctx.metrics_, ioTimeHistogramMetric(nodeType, nodeName, h))
+		}
+	}
+
 	return nil
 }
 
@@ -193,6 +408,338 @@ func (ctx *procfsV2Ctx)splitBRWStats(nodeType string, nodeName string, statBlock
 	return nil
 }
 
+func (ctx *procfsV2Ctx) parseLdlmPoolStats(path string, directoryDepth int, metric *lustreProcMetric, basicLables []string) (err error) {
+	_, nodeName, err := parseFileElements(path, directoryDepth)
+	if err != nil {
+		return err
+	}
+	content, err := ctx.fr.readFile(path)
+	if err != nil {
+		return err
+	}
+	value, ok := ldlmPoolValue(metric.promName, parseLdlmPoolStatsText(string(content)))
+	if !ok {
+		return nil
+	}
+	pathElements := strings.Split(path, "/")
+	ctx.appendMetrics(metric, basicLables, []string{pathElements[len(pathElements)-3], nodeName}, value, "", "")
+	return nil
+}
+
+func (ctx *procfsV2Ctx) parseQuota(nodeType string, path string, directoryDepth int, metric *lustreProcMetric, basicLables []string) (err error) {
+	_, nodeName, err := parseFileElements(path, directoryDepth)
+	if err != nil {
+		return err
+	}
+	quotaType, entries, err := ctx.quotas.read(path, ctx.fr.readFile)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if value, ok := quotaValue(metric.promName, nodeType, entry); ok {
+			ctx.appendMetrics(metric, basicLables, []string{nodeType, nodeName, quotaType, entry.id}, value, "", "")
+		}
+	}
+	return nil
+}
+
+func (ctx *procfsV2Ctx) parseRecoveryStatus(nodeType string, path string, directoryDepth int, metric *lustreProcMetric, basicLables []string) (err error) {
+	_, nodeName, err := parseFileElements(path, directoryDepth)
+	if err != nil {
+		return err
+	}
+	content, err := ctx.fr.readFile(path)
+	if err != nil {
+		return err
+	}
+	value, ok := recoveryStatusValue(targetKey{nodeType, nodeName}, metric.promName, string(content))
+	if !ok {
+		return nil
+	}
+	ctx.appendMetrics(metric, basicLables, []string{nodeType, nodeName}, value, "", "")
+	return nil
+}
+
+func (ctx *procfsV2Ctx) parseServiceStats(nodeType string, path string, directoryDepth int, metric *lustreProcMetric, basicLables []string) (err error) {
+	_, nodeName, err := parseFileElements(path, directoryDepth)
+	if err != nil {
+		return err
+	}
+	content, err := ctx.fr.readFile(path)
+	if err != nil {
+		return err
+	}
+	value, found, err := serviceStatMean(string(content), serviceStatNames[metric.promName])
+	if err != nil || !found {
+		return err
+	}
+	ctx.appendMetrics(metric, basicLables, []string{nodeType, nodeName, filepath.Base(filepath.Dir(path))}, value, "", "")
+	return nil
+}
+
+func (ctx *procfsV2Ctx) parseOscWriteback(nodeType string, path string, directoryDepth int, metric *lustreProcMetric, basicLables []string) (err error) {
+	_, nodeName, err := parseFileElements(path, directoryDepth)
+	if err != nil {
+		return err
+	}
+	content, err := ctx.fr.readFile(path)
+	if err != nil {
+		return err
+	}
+	value, ok, err := rpcStatsHeaderValue(string(content), oscWritebackFields[metric.promName])
+	if err != nil || !ok {
+		return err
+	}
+	ctx.appendMetrics(metric, basicLables, []string{nodeType, nodeName}, value, "", "")
+	return nil
+}
+
+func (ctx *procfsV2Ctx) parseOsdJournalStats(nodeType string, path string, directoryDepth int, metric *lustreProcMetric, basicLables []string) (err error) {
+	_, nodeName, err := parseFileElements(path, directoryDepth)
+	if err != nil {
+		return err
+	}
+	content, err := ctx.fr.readFile(path)
+	if err != nil {
+		return err
+	}
+	value, ok, err := osdJournalValue(metric.promName, string(content))
+	if err != nil || !ok {
+		return err
+	}
+	ctx.appendMetrics(metric, basicLables, []string{nodeType, nodeName}, value, "", "")
+	return nil
+}
+
+func (ctx *procfsV2Ctx) parseHsm(nodeType string, path string, directoryDepth int, metric *lustreProcMetric, basicLables []string) (err error) {
+	_, nodeName, err := parseFileElements(path, directoryDepth)
+	if err != nil {
+		return err
+	}
+	content, err := ctx.fr.readFile(path)
+	if err != nil {
+		return err
+	}
+	counts, err := hsmValues(metric.promName, string(content))
+	if err != nil {
+		return err
+	}
+	for _, count := range counts {
+		if count.action == "" {
+			ctx.appendMetrics(metric, basicLables, []string{nodeType, nodeName}, count.value, "", "")
+		} else {
+			ctx.appendMetrics(metric, append(basicLables, "action", "status"), []string{nodeType, nodeName, count.action, count.status}, count.value, "", "")
+		}
+	}
+	return nil
+}
+
+func (ctx *procfsV2Ctx) parseQmtGlobal(path string, metric *lustreProcMetric, basicLables []string) (err error) {
+	fs, quotaType, err := qmtElements(path)
+	if err != nil {
+		return err
+	}
+	content, err := ctx.fr.readFile(path)
+	if err != nil {
+		return err
+	}
+	entries, err := parseQmtGlobalIndex(string(content))
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		ctx.appendMetrics(metric, basicLables, []string{fs, quotaType, entry.id}, qmtGlobalValue(metric.promName, entry), "", "")
+	}
+	return nil
+}
+
+func (ctx *procfsV2Ctx) parseExportsActive(nodeType string, path string, directoryDepth int, metric *lustreProcMetric, basicLables []string) (err error) {
+	_, nodeName, err := parseFileElements(path, directoryDepth)
+	if err != nil {
+		return err
+	}
+	count, err := exportsActive(path)
+	if err != nil {
+		return err
+	}
+	ctx.appendMetrics(metric, basicLables, []string{nodeType, nodeName}, count, "", "")
+	return nil
+}
+
+func (ctx *procfsV2Ctx) parseCachePolicy(nodeType string, path string, directoryDepth int, metric *lustreProcMetric, basicLables []string) (err error) {
+	_, nodeName, err := parseFileElements(path, directoryDepth)
+	if err != nil {
+		return err
+	}
+	readCache, err := ctx.fr.readFile(path)
+	if err != nil {
+		return err
+	}
+	writethrough, err := ctx.fr.readFile(filepath.Join(filepath.Dir(path), writethroughCacheEnable))
+	if err != nil {
+		return err
+	}
+	readState, writethroughState, err := cachePolicyLabels(string(readCache), string(writethrough))
+	if err != nil {
+		return err
+	}
+	ctx.appendMetrics(metric, basicLables, []string{nodeType, nodeName, readState, writethroughState}, 1, "", "")
+	return nil
+}
+
+func (ctx *procfsV2Ctx) parseOspPreallocGap(nodeType string, path string, directoryDepth int, metric *lustreProcMetric, basicLables []string) (err error) {
+	_, nodeName, err := parseFileElements(path, directoryDepth)
+	if err != nil {
+		return err
+	}
+	lastID, err := ctx.fr.readFile(path)
+	if err != nil {
+		return err
+	}
+	nextID, err := ctx.fr.readFile(filepath.Join(filepath.Dir(path), ospPreallocNextID))
+	if err != nil {
+		return err
+	}
+	gap, err := ospPreallocGap(string(lastID), string(nextID))
+	if err != nil {
+		return err
+	}
+	ctx.appendMetrics(metric, basicLables, []string{nodeType, nodeName}, gap, "", "")
+	return nil
+}
+
+func (ctx *procfsV2Ctx) parseSeqSpace(nodeType string, path string, directoryDepth int, metric *lustreProcMetric, basicLables []string) (err error) {
+	_, nodeName, err := parseFileElements(path, directoryDepth)
+	if err != nil {
+		return err
+	}
+	content, err := ctx.fr.readFile(path)
+	if err != nil {
+		return err
+	}
+	start, err := seqSpaceStart(string(content))
+	if err != nil {
+		return err
+	}
+	ctx.appendMetrics(metric, basicLables, []string{nodeType, nodeName}, start, "", "")
+	return nil
+}
+
+func (ctx *procfsV2Ctx) parseRenameStats(nodeType string, path string, directoryDepth int, metric *lustreProcMetric, basicLables []string) (err error) {
+	_, nodeName, err := parseFileElements(path, directoryDepth)
+	if err != nil {
+		return err
+	}
+	content, err := ctx.fr.readFile(path)
+	if err != nil {
+		return err
+	}
+	buckets, err := parseRenameStats(string(content))
+	if err != nil {
+		return err
+	}
+	for _, bucket := range buckets {
+		ctx.appendMetrics(metric, basicLables, []string{nodeType, nodeName, bucket.section, bucket.size}, bucket.samples, "", "")
+	}
+	return nil
+}
+
+func (ctx *procfsV2Ctx) parseLodQos(nodeType string, path string, directoryDepth int, metric *lustreProcMetric, basicLables []string) (err error) {
+	_, nodeName, err := parseFileElements(path, directoryDepth)
+	if err != nil {
+		return err
+	}
+	content, err := ctx.fr.readFile(path)
+	if err != nil {
+		return err
+	}
+	value, err := parseLodQosTunable(string(content))
+	if err != nil {
+		return err
+	}
+	ctx.appendMetrics(metric, basicLables, []string{nodeType, nodeName}, value, "", "")
+	return nil
+}
+
+func (ctx *procfsV2Ctx) parseImport(nodeType string, path string, directoryDepth int, metric *lustreProcMetric, basicLables []string) (err error) {
+	_, nodeName, err := parseFileElements(path, directoryDepth)
+	if err != nil {
+		return err
+	}
+	content, err := ctx.fr.readFile(path)
+	if err != nil {
+		return err
+	}
+	info, err := parseImportInfo(string(content))
+	if err != nil {
+		return err
+	}
+	if metric.promName == "mgc_import_state" {
+		ctx.appendMetrics(metric, basicLables, []string{nodeType, nodeName}, 1, "state", info.state)
+	} else if value, ok := info.value(metric.promName); ok {
+		ctx.appendMetrics(metric, basicLables, []string{nodeType, nodeName}, value, "", "")
+	}
+	return nil
+}
+
+func (ctx *procfsV2Ctx) parseDefaultStripe(path string, directoryDepth int, metric *lustreProcMetric, basicLables []string) (err error) {
+	_, nodeName, err := parseFileElements(path, directoryDepth)
+	if err != nil {
+		return err
+	}
+	fs := lodFsName(nodeName)
+	if !ctx.stripeSeen.first(metric.promName, fs) {
+		return nil
+	}
+	content, err := ctx.fr.readFile(path)
+	if err != nil {
+		return err
+	}
+	value, err := strconv.ParseFloat(strings.TrimSpace(string(content)), 64)
+	if err != nil {
+		return err
+	}
+	ctx.appendMetrics(metric, basicLables, []string{fs}, value, "", "")
+	return nil
+}
+
+func (ctx *procfsV2Ctx) parseOstPool(path string, directoryDepth int, metric *lustreProcMetric, basicLables []string) (err error) {
+	pool, nodeName, err := parseFileElements(path, directoryDepth)
+	if err != nil {
+		return err
+	}
+	fs := lodFsName(nodeName)
+	if !ctx.stripeSeen.first(metric.promName+"/"+pool, fs) {
+		return nil
+	}
+	content, err := ctx.fr.readFile(path)
+	if err != nil {
+		return err
+	}
+	for _, target := range parseOstPool(string(content)) {
+		ctx.appendMetrics(metric, basicLables, []string{fs, pool, target}, 1, "", "")
+	}
+	return nil
+}
+
+func (ctx *procfsV2Ctx) parseExportLockRPCDifference(path string, metric *lustreProcMetric, basicLables []string) (err error) {
+	nid, nodeName, ok := exportElements(path)
+	if !ok {
+		return nil
+	}
+	content, err := ctx.fr.readFile(path)
+	if err != nil {
+		return err
+	}
+	difference, err := exportLockRPCDifference(string(content))
+	if err != nil {
+		return err
+	}
+	ctx.subnets.add(nodeName, nid)
+	ctx.appendMetrics(metric, basicLables, []string{nid, nodeName}, difference, "", "")
+	return nil
+}
+
 func (ctx *procfsV2Ctx) parseFile(nodeType string, metricType string, path string, directoryDepth int, metric *lustreProcMetric, basicLables []string) (err error) {
 	_, nodeName, err := parseFileElements(path, directoryDepth)
 	if err != nil {
@@ -209,7 +756,7 @@ func (ctx *procfsV2Ctx) parseFile(nodeType string, metricType string, path strin
 			return err
 		}
 		ctx.appendMetrics(metric, basicLables, []string{nodeType, nodeName}, convertedValue, "", "")
-	case stats, mdStats, encryptPagePools:
+	case stats, mdStats, encryptPagePools, unstableStats:
 		metricList, err := ctx.parseStatsFile(path, nodeType, nodeName, metric, basicLables)
 		if err != nil {
 			return err
@@ -228,8 +775,37 @@ func (ctx *procfsV2Ctx)parseStatsFile(path string, nodeType string, nodeName str
 		return nil, err
 	}
 	statsFile := string(statsFileBytes[:])
+	if metric.filename == stats || metric.filename == mdStats {
+		ctx.unknownLines.add(nodeType, path, statsFile, countUnknownStatsLines)
+	}
+	if (metric.filename == stats || metric.filename == mdStats) && !ctx.resetFiles[path] {
+		ctx.resetFiles[path] = true
+		if age, ok := statsResetAge(statsFile); ok {
+			ctx.metrics_ = append(ctx.metrics_, statsResetMetric(nodeType, nodeName, age))
+		}
+	}
+	if LatencyStats && metric.filename == stats && !ctx.latencyFiles[path] {
+		ctx.latencyFiles[path] = true
+		latencies, err := parseLatencyStats(statsFile)
+		if err != nil {
+			return nil, err
+		}
+		ctx.metrics_ = append(ctx.metrics_, latencyMetrics(nodeType, nodeName, latencies)...)
+	}
+	if EmitAverageRates && (metric.filename == stats || metric.filename == mdStats) && !ctx.rateFiles[path] {
+		ctx.rateFiles[path] = true
+		rates, err := parseAverageRates(statsFile)
+		if err != nil {
+			return nil, err
+		}
+		ctx.metrics_ = append(ctx.metrics_, averageRateMetrics(nodeType, nodeName, rates)...)
+	}
 	var statsList []lustreStatsMetric
-	if metric.hasMultipleVals {
+	if metric.promName == mdtReintTotal {
+		err = ctx.getReintMetrics(statsFile, nodeType, nodeName, metric, basicLables)
+	} else if metric.promName == opErrorsTotal {
+		err = ctx.getOperationErrorMetrics(statsFile, nodeType, nodeName, metric, basicLables)
+	} else if metric.hasMultipleVals {
 		err = ctx.getStatsOperationMetrics(statsFile, nodeType, nodeName, metric, basicLables)
 	} else {
 		err = ctx.getStatsIOMetrics(statsFile, nodeType, nodeName, metric, basicLables)
@@ -263,6 +839,8 @@ var	operationSlice = []multistatParsingStruct{
 		{pattern: "get_info",         index: 1},
 		{pattern: "set_info_async",   index: 1},
 		{pattern: "connect",          index: 1},
+		{pattern: "reconnect",        index: 1},
+		{pattern: "disconnect",       index: 1},
 		{pattern: "ping",             index: 1},
 	}
 
@@ -285,7 +863,7 @@ func (ctx *procfsV2Ctx)getStatsOperationMetrics(statsFile string, nodeType strin
 	// return nil
 
 	for _, operation := range operationSlice {
-		opStat := regexCaptureString(operation.pattern+" .*", statsFile)
+		opStat := captureOperation(operation.pattern, " .*", statsFile)
 		if len(opStat) < 1 {
 			continue
 		}
@@ -300,6 +878,28 @@ func (ctx *procfsV2Ctx)getStatsOperationMetrics(statsFile string, nodeType strin
 	return nil
 }
 
+func (ctx *procfsV2Ctx)getReintMetrics(statsFile string, nodeType string, nodeName string, metric *lustreProcMetric, basicLables []string) (err error) {
+	reints, err := parseReintStats(statsFile)
+	if err != nil {
+		return err
+	}
+	for operation, value := range reints {
+		ctx.appendMetrics(metric, basicLables, []string{nodeType, nodeName}, value, "operation", operation)
+	}
+	return nil
+}
+
+func (ctx *procfsV2Ctx) getOperationErrorMetrics(statsFile string, nodeType string, nodeName string, metric *lustreProcMetric, basicLables []string) (err error) {
+	errs, err := parseOperationErrors(statsFile)
+	if err != nil {
+		return err
+	}
+	for operation, value := range errs {
+		ctx.appendMetrics(metric, basicLables, []string{nodeType, nodeName}, value, "operation", operation)
+	}
+	return nil
+}
+
 var bytesMap = map[string]multistatParsingStruct{
 		readSamplesHelp:       {pattern: "read_bytes .*",           index: 1},
 		readMinimumHelp:       {pattern: "read_bytes .*",           index: 4},
@@ -324,6 +924,7 @@ var bytesMap = map[string]multistatParsingStruct{
 		lowFreeMarkHelp:       {pattern: "low free mark: .*",       index: 3},
 		maxWaitQueueDepthHelp: {pattern: "max waitqueue depth: .*", index: 3},
 		outOfMemHelp:          {pattern: "out of mem: .*",          index: 3},
+		unstablePagesHelp:     {pattern: "unstable_pages: .*",      index: 1},
 	}
 
 func (ctx *procfsV2Ctx)getStatsIOMetrics(statsFile string, nodeType string, nodeName string, metric *lustreProcMetric, basicLables []string) (err error) {
@@ -348,6 +949,19 @@ func (ctx *procfsV2Ctx)getStatsIOMetrics(statsFile string, nodeType string, node
 	return nil
 }
 
+func (ctx *procfsV2Ctx) parseJobStatsSize(nodeType string, path string, directoryDepth int, metric *lustreProcMetric, basicLables []string) (err error) {
+	_, nodeName, err := parseFileElements(path, directoryDepth)
+	if err != nil {
+		return err
+	}
+	size, err := jobStatsFileSize(path, ctx.fr.readFile)
+	if err != nil {
+		return err
+	}
+	ctx.appendMetrics(metric, basicLables, []string{nodeType, nodeName}, size, "", "")
+	return nil
+}
+
 func (ctx *procfsV2Ctx) parseJobStats(nodeType string, metricType string, path string, directoryDepth int, metric *lustreProcMetric, basicLables []string) (err error) {
 	_, nodeName, err := parseFileElements(path, directoryDepth)
 	if err != nil {
@@ -367,6 +981,31 @@ func (ctx *procfsV2Ctx)appendMetrics(metric *lustreProcMetric, basicLables []str
 		basicLables = append(basicLables, extraLable)
 		lableVals   = append(lableVals, extraLableVal)
 	}
+	if FrozenThreshold > 0 && (metric.filename == stats || metric.filename == mdStats) {
+		ctx.hasher.add(lableVals[0], lableVals[1], metric.promName, lableVals[2:], val)
+	}
+	if metric.filename == "job_stats" && strings.HasSuffix(metric.promName, "_total") {
+		ctx.jobCounters.add(lableVals[0], lableVals[1], lableVals[2], metric.promName, lableVals[3:], val)
+	}
+	if metric.filename == "brw_size" {
+		ctx.brwSizes.add(lableVals[0], lableVals[1], metric.promName, val)
+	}
+	if metric.filename == "kbytesfree" || metric.filename == "kbytestotal" {
+		ctx.ostSpaces.add(lableVals[0], lableVals[1], metric.promName, val)
+	}
+	if metric.filename == "tot_granted" || metric.filename == "kbytesavail" {
+		ctx.grants.add(lableVals[0], lableVals[1], metric.promName, val)
+	}
+	if PingStallThreshold > 0 && metric.filename == stats && len(lableVals) > 2 && lableVals[2] == "ping" {
+		ctx.pings.add(lableVals[0], lableVals[1], val)
+	}
+	if metric.filename == stats {
+		ctx.ossTotals.add(lableVals[0], metric.promName, val)
+		ctx.writeBytes.add(lableVals[0], lableVals[1], metric.promName, val)
+		if len(lableVals) > 2 {
+			ctx.churn.add(lableVals[0], lableVals[1], metric.promName, lableVals[2], val)
+		}
+	}
 	ctx.metrics_ = append(ctx.metrics_, metric.metricFunc(basicLables, lableVals, metric.promName, metric.helpText, val) )
 }
 
@@ -425,6 +1064,11 @@ func (js *jobState)__init2(){
 	*js = jobStateInitVal
 }
 
+// noIO tells if the job has no read nor write sample, missing lines count as 0
+func (js *jobState)noIO() bool {
+	return js.readbytes[0] <= 0 && js.writebytes[0] <= 0
+}
+
 func (js *jobState)parsingFromText(content string)error{
 	js.__init2()
 
@@ -449,7 +1093,7 @@ func (js *jobState)parsingFromText(content string)error{
 
 		var cnt int
 		key := strings.TrimSpace(line[:idx])
-		switch key {
+		switch normalizeOperation(key) {
 			case "read_bytes"     : cnt, err = parsingNums(&js.readbytes , line); if cnt <= 3 { err = fmt.Errorf("invalid data for %s", key) }
 			case "write_bytes"    : cnt, err = parsingNums(&js.writebytes, line); if cnt <= 3 { err = fmt.Errorf("invalid data for %s", key) }
 			case "open"           : js.vals[ 0], err= parsingInt64(line)
@@ -532,14 +1176,22 @@ func (ctx *procfsV2Ctx)parseJobStatsText(path string, nodeType string, nodeName
 		jobs := splits[1:]
 
 		var js jobState
+		filter := newJobstatsFilter()
 		for _, job := range jobs {
 			err = js.parsingFromText(job)
 			if err != nil {
 				log.Warnf("parsing jobstat failed: %s", err)
 				continue
 			}
+			if DropZeroJobStats && nodeType == "ost" && js.noIO() {
+				continue
+			}
+			if !filter.keep(js.jobid) {
+				continue
+			}
 			*jobsStats = append(*jobsStats, js)
 		}
+		filter.done(path)
 		ctx.filesJobStats[path] = jobsStats
 	}
 
@@ -2,6 +2,9 @@ package sources
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
 
 	// "github.com/alecthomas/assert"
@@ -77,4 +80,56 @@ func TestParsingJobStats(t *testing.T){
 // 	assert.Equal(t, int64(-1), js.crossdir_rename)
 
 // 	sPool.recycleJobState(js)
-// }
\ No newline at end of file
+// }
+func TestDropZeroJobStats(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "obdfilter", "lustrefs-OST0000")
+	if err := os.MkdirAll(target, 0755); err != nil {
+		t.Fatal(err)
+	}
+	jobStats := `job_stats:
+- job_id:          idle.0
+  snapshot_time:   1510782606
+  read_bytes:      { samples:           0, unit: bytes, min:       0, max:       0, sum:               0 }
+  write_bytes:     { samples:           0, unit: bytes, min:       0, max:       0, sum:               0 }
+  punch:           { samples:           0, unit:  reqs }
+- job_id:          dd.0
+  snapshot_time:   1510782606
+  read_bytes:      { samples:           0, unit: bytes, min:       0, max:       0, sum:               0 }
+  write_bytes:     { samples:       64575, unit: bytes, min:    4096, max: 4194304, sum:    215147593728 }
+  punch:           { samples:           1, unit:  reqs }
+- job_id:          cat.0
+  snapshot_time:   1510782606
+  read_bytes:      { samples:          12, unit: bytes, min:    4096, max:   65536, sum:          401408 }
+  write_bytes:     { samples:           0, unit: bytes, min:       0, max:       0, sum:               0 }
+  punch:           { samples:           0, unit:  reqs }
+`
+	path := filepath.Join(target, "job_stats")
+	if err := os.WriteFile(path, []byte(jobStats), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	defer func(prev bool) { DropZeroJobStats = prev }(DropZeroJobStats)
+	for drop, expected := range map[bool][]string{
+		false: {"idle.0", "dd.0", "cat.0"},
+		true:  {"dd.0", "cat.0"},
+	} {
+		DropZeroJobStats = drop
+
+		s := &lustreProcfsSource{basePath: dir}
+		s.generateOSTMetricTemplates(core)
+		ctx := insProcfsV2.newCtx(s)
+		if err := ctx.collect(); err != nil {
+			t.Fatal(err)
+		}
+		var jobids []string
+		for _, js := range *ctx.filesJobStats[path] {
+			jobids = append(jobids, js.jobid)
+		}
+		ctx.release()
+
+		if !reflect.DeepEqual(jobids, expected) {
+			t.Fatalf("Retrieved unexpected jobids with drop %t. Expected: %v, Got: %v", drop, expected, jobids)
+		}
+	}
+}
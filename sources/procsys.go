@@ -14,7 +14,6 @@
 package sources
 
 import (
-	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -44,12 +43,13 @@ const (
 var LnetEnabled string
 
 func init() {
-	Factories["procsys"] = newLustreProcSysSource
+	Register("procsys", func(cfg Config) Collector { return newLustreProcSysSource(cfg) })
 }
 
 type lustreProcsysSource struct {
 	lustreProcMetrics []lustreProcMetric
 	basePath          string
+	debugPath         string
 }
 
 func (s *lustreProcsysSource) generateLNETTemplates(filter string) {
@@ -76,6 +76,13 @@ func (s *lustreProcsysSource) generateLNETTemplates(filter string) {
 			{"stats", "receive_bytes_total", lnetReceiveLengthHelp, s.counterMetric, false, core},
 			{"stats", "route_bytes_total", lnetRouteLengthHelp, s.counterMetric, false, core},
 			{"stats", "drop_bytes_total", lnetDropLengthHelp, s.counterMetric, false, core},
+			{lnetSelftest, "lnet_selftest_latency_microseconds", lnetSelftestLatencyHelp, s.gaugeMetric, true, extended},
+			{lnetSelftest, "lnet_selftest_errors_total", lnetSelftestErrorsHelp, s.counterMetric, true, extended},
+			{lnetPeers, "lnet_peer_refcount", lnetPeerRefcountHelp, s.gaugeMetric, true, extended},
+			{lnetPeers, "lnet_peer_up", lnetPeerUpHelp, s.gaugeMetric, true, extended},
+			{lnetPeers, "lnet_peer_tx_credits", lnetPeerTxCreditsHelp, s.gaugeMetric, true, extended},
+			{lnetPeers, "lnet_peer_min_tx_credits", lnetPeerMinTxCreditsHelp, s.gaugeMetric, true, extended},
+			{lnetPeers, "lnet_peer_queued_bytes", lnetPeerQueuedBytesHelp, s.gaugeMetric, true, extended},
 			{"watchdog_ratelimit", "watchdog_ratelimit_enabled", "Returns 1 if the watchdog rate limiter is enabled", s.gaugeMetric, false, extended},
 		},
 	}
@@ -89,12 +96,18 @@ func (s *lustreProcsysSource) generateLNETTemplates(filter string) {
 	}
 }
 
-func newLustreProcSysSource() LustreSource {
+func newLustreProcSysSource(cfg Config) LustreSource {
 	var l lustreProcsysSource
-	l.basePath = filepath.Join(ProcLocation, "sys")
+	l.basePath = filepath.Join(cfg.ProcLocation, "sys")
+	if cfg.SysLocation != "" {
+		// 'peers' moved to debugfs in the recent kernels
+		l.debugPath = filepath.Join(cfg.SysLocation, "kernel/debug/lnet")
+	}
 	if LnetEnabled != disabled {
 		l.generateLNETTemplates(LnetEnabled)
 	}
+	l.lustreProcMetrics = appendUnitConversions(l.lustreProcMetrics)
+	applySubsystems(l.lustreProcMetrics)
 	return &l
 }
 
@@ -102,14 +115,54 @@ func (s *lustreProcsysSource) Update(ch chan<- prometheus.Metric) (err error) {
 	var metricType string
 
 	for _, metric := range s.lustreProcMetrics {
-		paths, err := filepath.Glob(filepath.Join(s.basePath, metric.path, metric.filename))
+		paths, err := globPaths(filepath.Join(s.basePath, metric.path, metric.filename))
 		if err != nil {
 			return err
 		}
+		if paths == nil && metric.filename == lnetPeers && s.debugPath != "" {
+			paths, err = globPaths(filepath.Join(s.debugPath, metric.filename))
+			if err != nil {
+				return err
+			}
+		}
 		if paths == nil {
 			continue
 		}
 		for _, path := range paths {
+			if metric.filename == lnetPeers {
+				content, err := readProcFile(path)
+				if err != nil {
+					return err
+				}
+				peers, err := parseLnetPeers(string(content))
+				if err != nil {
+					return err
+				}
+				for _, peer := range peers {
+					value, ok, err := lnetPeerValue(peer, metric.promName)
+					if err != nil {
+						return err
+					}
+					if ok {
+						ch <- metric.metricFunc([]string{"component", "nid"}, []string{metric.source, peer.nid}, metric.promName, metric.helpText, value)
+					}
+				}
+				continue
+			}
+			if metric.filename == lnetSelftest {
+				content, err := readProcFile(path)
+				if err != nil {
+					return err
+				}
+				peers, err := parseLnetSelftest(string(content))
+				if err != nil {
+					return err
+				}
+				for _, peer := range peers {
+					ch <- metric.metricFunc([]string{"component", "peer"}, []string{metric.source, peer.nid}, metric.promName, metric.helpText, lnetSelftestValue(peer, metric.helpText))
+				}
+				continue
+			}
 			metricType = single
 			if metric.filename == stats {
 				metricType = stats
@@ -164,7 +217,7 @@ func (s *lustreProcsysSource) parseFile(nodeType string, metricType string, path
 	}
 	switch metricType {
 	case single:
-		value, err := os.ReadFile(filepath.Clean(path))
+		value, err := readProcFile(path)
 		if err != nil {
 			return err
 		}
@@ -174,7 +227,7 @@ func (s *lustreProcsysSource) parseFile(nodeType string, metricType string, path
 		}
 		handler(nodeType, nodeName, promName, helpText, convertedValue)
 	case stats:
-		statsFileBytes, err := os.ReadFile(filepath.Clean(path))
+		statsFileBytes, err := readProcFile(path)
 		if err != nil {
 			return err
 		}
@@ -190,29 +243,19 @@ func (s *lustreProcsysSource) parseFile(nodeType string, metricType string, path
 
 func (s *lustreProcsysSource) counterMetric(labels []string, labelValues []string, name string, helpText string, value float64) prometheus.Metric {
 	return prometheus.MustNewConstMetric(
-		prometheus.NewDesc(
-			prometheus.BuildFQName(Namespace, "", name),
-			helpText,
-			labels,
-			nil,
-		),
+		newDesc("", name, helpText, labels),
 		prometheus.CounterValue,
 		value,
-		labelValues...,
+		sanitizeLabels(labelValues)...,
 	)
 }
 
 func (s *lustreProcsysSource) gaugeMetric(labels []string, labelValues []string, name string, helpText string, value float64) prometheus.Metric {
 	return prometheus.MustNewConstMetric(
-		prometheus.NewDesc(
-			prometheus.BuildFQName(Namespace, "", name),
-			helpText,
-			labels,
-			nil,
-		),
+		newDesc("", name, helpText, labels),
 		prometheus.GaugeValue,
 		value,
-		labelValues...,
+		sanitizeLabels(labelValues)...,
 	)
 }
 
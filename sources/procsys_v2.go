@@ -32,6 +32,10 @@ func (ctx *procsysV2Ctx)release(){
 	ctx.fr.release()
 }
 
+func (ctx *procsysV2Ctx)filesRead() uint64 {
+	return ctx.fr.filesRead()
+}
+
 func (ctx *procsysV2Ctx)update(ch chan<- prometheus.Metric) {
 	for _, m := range ctx.metrics {
 		ch <- m
@@ -62,10 +66,50 @@ func (ctx *procsysV2Ctx)collect() (err error){
 		if err != nil {
 			return err
 		}
+		if paths == nil && metric.filename == lnetPeers && s.debugPath != "" {
+			paths, err = ctx.fr.glob(filepath.Join(s.debugPath, metric.filename))
+			if err != nil {
+				return err
+			}
+		}
 		if paths == nil {
 			continue
 		}
 		for _, path := range paths {
+			if metric.filename == lnetPeers {
+				content, err := ctx.fr.readFile(path)
+				if err != nil {
+					return err
+				}
+				peers, err := parseLnetPeers(string(content))
+				if err != nil {
+					return err
+				}
+				for _, peer := range peers {
+					value, ok, err := lnetPeerValue(peer, metric.promName)
+					if err != nil {
+						return err
+					}
+					if ok {
+						ctx.metrics = append(ctx.metrics, metric.metricFunc([]string{"component", "nid"}, []string{metric.source, peer.nid}, metric.promName, metric.helpText, value))
+					}
+				}
+				continue
+			}
+			if metric.filename == lnetSelftest {
+				content, err := ctx.fr.readFile(path)
+				if err != nil {
+					return err
+				}
+				peers, err := parseLnetSelftest(string(content))
+				if err != nil {
+					return err
+				}
+				for _, peer := range peers {
+					ctx.metrics = append(ctx.metrics, metric.metricFunc([]string{"component", "peer"}, []string{metric.source, peer.nid}, metric.promName, metric.helpText, lnetSelftestValue(peer, metric.helpText)))
+				}
+				continue
+			}
 			metricType = single
 			if metric.filename == stats {
 				metricType = stats
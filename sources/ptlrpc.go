@@ -0,0 +1,24 @@
+package sources
+
+const (
+	ptlrpcResendHelp   string = "Total number of RPCs to the target which were resent, from the rpcs block of the import file when Lustre reports it"
+	ptlrpcTimeoutHelp  string = "Total number of RPCs to the target which timed out, from the rpcs block of the import file"
+	ptlrpcOutOfMemHelp string = "Total number of RPCs to the target which failed to allocate memory, from the rpcs block of the import file when Lustre reports it"
+)
+
+// ptlrpcImportFields maps the ptlrpc error counters to their field in the
+// rpcs block of the import file. Only 'timeouts' is printed by every Lustre
+// version, the others are skipped when missing.
+var ptlrpcImportFields = map[string]string{
+	"ptlrpc_resend_total":     "resend",
+	"ptlrpc_timeout_total":    "timeouts",
+	"ptlrpc_out_of_mem_total": "out_of_mem",
+}
+
+func ptlrpcTemplates(s *lustreProcfsSource) []lustreHelpStruct {
+	return []lustreHelpStruct{
+		{importFile, "ptlrpc_resend_total", ptlrpcResendHelp, s.counterMetric, false, core},
+		{importFile, "ptlrpc_timeout_total", ptlrpcTimeoutHelp, s.counterMetric, false, core},
+		{importFile, "ptlrpc_out_of_mem_total", ptlrpcOutOfMemHelp, s.counterMetric, false, core},
+	}
+}
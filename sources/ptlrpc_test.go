@@ -0,0 +1,47 @@
+package sources
+
+import (
+	"testing"
+)
+
+func TestPtlrpcErrors(t *testing.T) {
+	PtlrpcEnabled = true
+	defer func() { PtlrpcEnabled = false }()
+	var s lustreProcfsSource
+	s.generateClientMetricTemplates(core)
+
+	got := map[string]map[string]float64{}
+	for _, metric := range s.lustreProcMetrics {
+		if metric.filename != importFile || metric.path != "osc/*" || ptlrpcImportFields[metric.promName] == "" {
+			continue
+		}
+		for _, target := range []string{"lustrefs-OST0003-osc-ffff88105db50000", "lustrefs-OST0000-osc-ffff88105db50000"} {
+			path := "../tests/2.12/proc/fs/lustre/osc/" + target + "/" + importFile
+			err := s.parseImport(metric.source, path, 0, metric.helpText, metric.promName, func(nodeType string, nodeName string, state string, name string, helpText string, value float64) {
+				if got[nodeName] == nil {
+					got[nodeName] = map[string]float64{}
+				}
+				got[nodeName][name] = value
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	expected := map[string]map[string]float64{
+		"lustrefs-OST0003-osc-ffff88105db50000": {"ptlrpc_resend_total": 7, "ptlrpc_timeout_total": 2, "ptlrpc_out_of_mem_total": 1},
+		// no resend and out_of_mem in this import file
+		"lustrefs-OST0000-osc-ffff88105db50000": {"ptlrpc_timeout_total": 0},
+	}
+	for target, values := range expected {
+		if len(got[target]) != len(values) {
+			t.Fatalf("Retrieved an unexpected number of ptlrpc metrics for %s. Expected: %v, Got: %v", target, values, got[target])
+		}
+		for name, value := range values {
+			if v, ok := got[target][name]; !ok || v != value {
+				t.Fatalf("Retrieved an unexpected %s for %s. Expected: %f, Got: %f", name, target, value, v)
+			}
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package sources
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	qmtGlobalDt string = "dt-0x0/glb-*"
+	qmtGlobalMd string = "md-0x0/glb-*"
+
+	qmtGlobalUsedKilobytes  string = "qmt_global_used_kilobytes"
+	qmtGlobalLimitKilobytes string = "qmt_global_limit_kilobytes"
+	qmtGlobalUsedInodes     string = "qmt_global_used_inodes"
+	qmtGlobalLimitInodes    string = "qmt_global_limit_inodes"
+)
+
+// qmtEntry is one quota ID of a 'glb-usr', 'glb-grp' or 'glb-prj' global
+// index of the quota master.
+type qmtEntry struct {
+	id      string
+	hard    float64
+	granted float64
+}
+
+// parseQmtGlobalIndex parses a global quota index:
+//
+//	global_pool0_dt_usr
+//	- id:      1000
+//	  limits:  { hard:    10485760, soft:     8388608, granted:     2097152, time:  1700000000 }
+//
+// ID 0 is left out, it carries the default grace times, not the accounting of
+// a user, group or project.
+func parseQmtGlobalIndex(content string) ([]qmtEntry, error) {
+	var entries []qmtEntry
+	id := ""
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "- id:"):
+			id = strings.TrimSpace(strings.TrimPrefix(line, "- id:"))
+		case strings.HasPrefix(line, "limits:"):
+			if id == "" {
+				return nil, fmt.Errorf("quota limits without an id: %q", line)
+			}
+			entry := qmtEntry{id: id}
+			limits := strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "limits:")), "{}")
+			for _, field := range strings.Split(limits, ",") {
+				kv := strings.SplitN(field, ":", 2)
+				if len(kv) != 2 {
+					continue
+				}
+				var target *float64
+				switch strings.TrimSpace(kv[0]) {
+				case "hard":
+					target = &entry.hard
+				case "granted":
+					target = &entry.granted
+				default:
+					continue
+				}
+				value, err := strconv.ParseFloat(strings.TrimSpace(kv[1]), 64)
+				if err != nil {
+					return nil, err
+				}
+				*target = value
+			}
+			if id != "0" {
+				entries = append(entries, entry)
+			}
+			id = ""
+		}
+	}
+	return entries, nil
+}
+
+// qmtGlobalValue returns the value of promName for entry: the space or inodes
+// granted to the ID by the quota master, or its hard limit (0 when unlimited).
+func qmtGlobalValue(promName string, entry qmtEntry) float64 {
+	switch promName {
+	case qmtGlobalLimitKilobytes, qmtGlobalLimitInodes:
+		return entry.hard
+	}
+	return entry.granted
+}
+
+// qmtElements returns the filesystem and the quota type (usr, grp or prj) of
+// a 'qmt/<fs>-QMT0000/<pool>/glb-<type>' path.
+func qmtElements(path string) (fs string, quotaType string, err error) {
+	name, nodeName, err := parseFileElements(path, 1)
+	if err != nil {
+		return "", "", err
+	}
+	if i := strings.LastIndex(nodeName, "-QMT"); i > 0 {
+		nodeName = nodeName[:i]
+	}
+	return nodeName, strings.TrimPrefix(name, "glb-"), nil
+}
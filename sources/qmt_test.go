@@ -0,0 +1,46 @@
+package sources
+
+import (
+	"os"
+	"testing"
+)
+
+func TestParseQmtGlobalIndex(t *testing.T) {
+	path := "../tests/2.12/proc/fs/lustre/qmt/lustrefs-QMT0000/dt-0x0/glb-usr"
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := parseQmtGlobalIndex(string(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// ID 0 only carries the grace times
+	expected := []qmtEntry{
+		{id: "1000", hard: 10485760, granted: 2097152},
+		{id: "1001", hard: 0, granted: 524288},
+	}
+	if len(entries) != len(expected) {
+		t.Fatalf("Retrieved an unexpected number of quota IDs. Expected: %d, Got: %d", len(expected), len(entries))
+	}
+	for i := range expected {
+		if entries[i] != expected[i] {
+			t.Fatalf("Retrieved an unexpected quota entry. Expected: %v, Got: %v", expected[i], entries[i])
+		}
+	}
+	if value := qmtGlobalValue(qmtGlobalLimitKilobytes, entries[0]); value != 10485760 {
+		t.Fatalf("Retrieved an unexpected quota limit. Expected: %d, Got: %f", 10485760, value)
+	}
+	if value := qmtGlobalValue(qmtGlobalUsedKilobytes, entries[0]); value != 2097152 {
+		t.Fatalf("Retrieved an unexpected quota usage. Expected: %d, Got: %f", 2097152, value)
+	}
+
+	fs, quotaType, err := qmtElements(path)
+	if err != nil || fs != "lustrefs" || quotaType != "usr" {
+		t.Fatalf("Retrieved unexpected quota index elements. Expected: lustrefs/usr, Got: %s/%s (%v)", fs, quotaType, err)
+	}
+
+	if _, err := parseQmtGlobalIndex("  limits:  { hard: 1, soft: 0, granted: 0, time: 0 }\n"); err == nil {
+		t.Fatal("Expected quota limits without an id to be rejected")
+	}
+}
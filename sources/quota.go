@@ -0,0 +1,244 @@
+package sources
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// quotaAcct are the accounting files of the quota slave of a target,
+	// acct_user, acct_group and acct_project, the limits are read from their
+	// limit_* sibling
+	quotaAcct string = "quota_slave/acct_*"
+
+	quotaUsedKilobytes  string = "quota_used_kilobytes"
+	quotaLimitKilobytes string = "quota_limit_kilobytes"
+	quotaUsedInodes     string = "quota_used_inodes"
+	quotaLimitInodes    string = "quota_limit_inodes"
+
+	quotaUsedKilobytesHelp  string = "Space in kilobytes used by the ID on the target, from the quota accounting of its quota slave"
+	quotaLimitKilobytesHelp string = "Hard block quota limit of the ID in kilobytes as known by the OST, 0 means no limit"
+	quotaUsedInodesHelp     string = "Inodes (objects on an OST) used by the ID on the target, from the quota accounting of its quota slave"
+	quotaLimitInodesHelp    string = "Hard inode quota limit of the ID as known by the MDT, 0 means no limit"
+)
+
+var (
+	// QuotaEnabled specifies whether to collect the per ID quota usage and
+	// limits of the OSTs and MDTs
+	QuotaEnabled bool
+	// QuotaMaxIDs caps the number of IDs reported per target and quota type
+	// to the ones using the most space, 0 for no cap
+	QuotaMaxIDs int
+	// QuotaEnforcedOnly only reports the IDs with a hard limit
+	QuotaEnforcedOnly bool
+)
+
+// quotaTypes are the quota types of the acct_* and limit_* files, used as
+// the type label.
+var quotaTypes = map[string]bool{"user": true, "group": true, "project": true}
+
+// quotaEntry is one ID of the quota tables of a target.
+type quotaEntry struct {
+	id            string
+	usedKilobytes float64
+	usedInodes    float64
+	hasUsage      bool
+	limit         float64
+	hasLimit      bool
+}
+
+func quotaTemplates(s *lustreProcfsSource) []lustreHelpStruct {
+	return []lustreHelpStruct{
+		{quotaAcct, quotaUsedKilobytes, quotaUsedKilobytesHelp, s.gaugeMetric, false, core},
+		{quotaAcct, quotaLimitKilobytes, quotaLimitKilobytesHelp, s.gaugeMetric, false, core},
+		{quotaAcct, quotaUsedInodes, quotaUsedInodesHelp, s.gaugeMetric, false, core},
+		{quotaAcct, quotaLimitInodes, quotaLimitInodesHelp, s.gaugeMetric, false, core},
+	}
+}
+
+// parseQuotaAccounting parses an accounting file of a quota slave:
+//
+//	usr_accounting:
+//	- id:      1000
+//	  usage:   { inodes:                  251, kbytes:            138926301 }
+//
+// A backend without the accounting of the type reports 'not supported', which
+// has no entry.
+func parseQuotaAccounting(content string) ([]quotaEntry, error) {
+	var entries []quotaEntry
+	id := ""
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "- id:"):
+			id = strings.TrimSpace(strings.TrimPrefix(line, "- id:"))
+		case strings.HasPrefix(line, "usage:"):
+			if id == "" {
+				return nil, fmt.Errorf("quota usage without an id: %q", line)
+			}
+			entry := quotaEntry{id: id, hasUsage: true}
+			usage := strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "usage:")), "{}")
+			for _, field := range strings.Split(usage, ",") {
+				kv := strings.SplitN(field, ":", 2)
+				if len(kv) != 2 {
+					continue
+				}
+				var target *float64
+				switch strings.TrimSpace(kv[0]) {
+				case "kbytes":
+					target = &entry.usedKilobytes
+				case "inodes":
+					target = &entry.usedInodes
+				default:
+					continue
+				}
+				value, err := strconv.ParseFloat(strings.TrimSpace(kv[1]), 64)
+				if err != nil {
+					return nil, err
+				}
+				*target = value
+			}
+			entries = append(entries, entry)
+			id = ""
+		}
+	}
+	return entries, nil
+}
+
+// quotaEntries merges the accounting and the limits (the global index copy of
+// the quota slave, parsed like the ones of the quota master) of a quota type.
+// With enforcedOnly only the IDs with a hard limit are kept, with maxIDs > 0
+// only the maxIDs IDs using the most space, then the most inodes.
+func quotaEntries(acct string, limit string, enforcedOnly bool, maxIDs int) ([]quotaEntry, error) {
+	usage, err := parseQuotaAccounting(acct)
+	if err != nil {
+		return nil, err
+	}
+	limits, err := parseQmtGlobalIndex(limit)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := map[string]*quotaEntry{}
+	var entries []*quotaEntry
+	for i := range usage {
+		byID[usage[i].id] = &usage[i]
+		entries = append(entries, &usage[i])
+	}
+	for _, l := range limits {
+		entry, ok := byID[l.id]
+		if !ok {
+			entry = &quotaEntry{id: l.id}
+			byID[l.id] = entry
+			entries = append(entries, entry)
+		}
+		entry.limit = l.hard
+		entry.hasLimit = true
+	}
+
+	var out []quotaEntry
+	for _, entry := range entries {
+		if enforcedOnly && entry.limit == 0 {
+			continue
+		}
+		out = append(out, *entry)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].usedKilobytes != out[j].usedKilobytes {
+			return out[i].usedKilobytes > out[j].usedKilobytes
+		}
+		return out[i].usedInodes > out[j].usedInodes
+	})
+	if maxIDs > 0 && len(out) > maxIDs {
+		out = out[:maxIDs]
+	}
+	return out, nil
+}
+
+// quotaValue returns the value of promName for entry. The limits of a quota
+// slave are the ones of its resource: blocks on an OST, inodes on an MDT.
+func quotaValue(promName string, nodeType string, entry quotaEntry) (float64, bool) {
+	switch promName {
+	case quotaUsedKilobytes:
+		return entry.usedKilobytes, entry.hasUsage
+	case quotaUsedInodes:
+		return entry.usedInodes, entry.hasUsage
+	case quotaLimitKilobytes:
+		return entry.limit, entry.hasLimit && nodeType == "ost"
+	case quotaLimitInodes:
+		return entry.limit, entry.hasLimit && nodeType == "mdt"
+	}
+	return 0, false
+}
+
+// quotaLimitPath returns the limit_* file of an acct_* one and its quota type.
+func quotaLimitPath(path string) (string, string, error) {
+	quotaType := strings.TrimPrefix(filepath.Base(path), "acct_")
+	if !quotaTypes[quotaType] {
+		return "", "", fmt.Errorf("unknown quota accounting file %s", path)
+	}
+	return filepath.Join(filepath.Dir(path), "limit_"+quotaType), quotaType, nil
+}
+
+// readQuotaEntries reads the quota tables of the acct_* file path with read,
+// a missing limit file is taken as no limit.
+func readQuotaEntries(path string, read func(string) ([]byte, error)) (string, []quotaEntry, error) {
+	limitPath, quotaType, err := quotaLimitPath(path)
+	if err != nil {
+		return "", nil, err
+	}
+	acct, err := read(path)
+	if err != nil {
+		return "", nil, err
+	}
+	limit, err := read(limitPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", nil, err
+	}
+	entries, err := quotaEntries(string(acct), string(limit), QuotaEnforcedOnly, QuotaMaxIDs)
+	return quotaType, entries, err
+}
+
+// quotaTable is the quota type and the entries of an acct_* file.
+type quotaTable struct {
+	quotaType string
+	entries   []quotaEntry
+	err       error
+}
+
+// quotaTables caches the quota tables read in a scrape by acct_* file, the
+// quota templates of a file share one read and parse of its tables.
+type quotaTables map[string]quotaTable
+
+// read returns the quota tables of path, read with read on the first call of
+// the scrape.
+func (q quotaTables) read(path string, read func(string) ([]byte, error)) (string, []quotaEntry, error) {
+	table, ok := q[path]
+	if !ok {
+		table.quotaType, table.entries, table.err = readQuotaEntries(path, read)
+		q[path] = table
+	}
+	return table.quotaType, table.entries, table.err
+}
+
+func (s *lustreProcfsSource) parseQuota(nodeType string, path string, directoryDepth int, helpText string, promName string, quotas quotaTables, handler func(string, string, string, string, string, string, float64)) (err error) {
+	_, nodeName, err := parseFileElements(path, directoryDepth)
+	if err != nil {
+		return err
+	}
+	quotaType, entries, err := quotas.read(path, readProcFile)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if value, ok := quotaValue(promName, nodeType, entry); ok {
+			handler(nodeType, nodeName, quotaType, entry.id, promName, helpText, value)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+package sources
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestQuotaEntries(t *testing.T) {
+	acct := `usr_accounting:
+- id:      0
+  usage:   { inodes:                  251, kbytes:            138926301 }
+- id:      1000
+  usage:   { inodes:                   10, kbytes:                 4096 }
+- id:      1001
+  usage:   { inodes:                   20, kbytes:                 8192 }
+`
+	limit := `global_index_copy:
+- id:      0
+  limits:  { hard:                    0, soft:                    0, granted:                    0, time:               604800 }
+- id:      1000
+  limits:  { hard:              1048576, soft:               524288, granted:                    0, time:                    0 }
+- id:      1002
+  limits:  { hard:              2097152, soft:                    0, granted:                    0, time:                    0 }
+`
+	entries, err := quotaEntries(acct, limit, false, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// sorted by space used, the limit of ID 0 is the default grace time
+	expected := []quotaEntry{
+		{id: "0", usedKilobytes: 138926301, usedInodes: 251, hasUsage: true},
+		{id: "1001", usedKilobytes: 8192, usedInodes: 20, hasUsage: true},
+		{id: "1000", usedKilobytes: 4096, usedInodes: 10, hasUsage: true, limit: 1048576, hasLimit: true},
+		{id: "1002", limit: 2097152, hasLimit: true},
+	}
+	if !reflect.DeepEqual(entries, expected) {
+		t.Fatalf("Retrieved unexpected quota entries. Expected: %v, Got: %v", expected, entries)
+	}
+
+	entries, err = quotaEntries(acct, limit, true, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].id != "1000" || entries[1].id != "1002" {
+		t.Fatalf("Retrieved unexpected enforced quota entries. Expected: [1000 1002], Got: %v", entries)
+	}
+
+	entries, err = quotaEntries(acct, limit, false, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].id != "0" || entries[1].id != "1001" {
+		t.Fatalf("Retrieved unexpected capped quota entries. Expected: [0 1001], Got: %v", entries)
+	}
+
+	if value, ok := quotaValue(quotaLimitKilobytes, "ost", expected[2]); !ok || value != 1048576 {
+		t.Fatalf("Retrieved an unexpected OST block limit. Expected: %d, Got: %f (found: %t)", 1048576, value, ok)
+	}
+	if _, ok := quotaValue(quotaLimitKilobytes, "mdt", expected[2]); ok {
+		t.Fatal("Expected no block limit for an MDT")
+	}
+	if _, ok := quotaValue(quotaUsedKilobytes, "ost", expected[3]); ok {
+		t.Fatal("Expected no usage for an ID without accounting")
+	}
+
+	// 'not supported' accounting
+	if entries, err := quotaEntries("not supported\n", "", false, 0); err != nil || entries != nil {
+		t.Fatalf("Retrieved unexpected quota entries. Expected: [], Got: %v (%v)", entries, err)
+	}
+	if _, err := quotaEntries("  usage:   { inodes: 1, kbytes: 1 }\n", "", false, 0); err == nil {
+		t.Fatal("Expected an error for a usage without id")
+	}
+}
+
+func TestQuotaCollect(t *testing.T) {
+	defer func(ost string, mdt string, enabled bool, maxIDs int) {
+		OstEnabled, MdtEnabled, QuotaEnabled, QuotaMaxIDs = ost, mdt, enabled, maxIDs
+	}(OstEnabled, MdtEnabled, QuotaEnabled, QuotaMaxIDs)
+	OstEnabled = disabled
+	MdtEnabled = core
+	QuotaEnabled = true
+
+	path := "../tests/mds_bigdata/proc/fs/lustre/osd-ldiskfs/public1-MDT0000/quota_slave/acct_user"
+	if _, err := os.Stat(path); err != nil {
+		t.Fatal(err)
+	}
+	quotaType, all, err := readQuotaEntries(path, os.ReadFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if quotaType != "user" || len(all) == 0 {
+		t.Fatalf("Retrieved unexpected %s quota entries: %d", quotaType, len(all))
+	}
+
+	count := func() int {
+		s := newLustreSource(Config{ProcLocation: "../tests/mds_bigdata/proc"}).(*lustreProcfsSource)
+		var quota []lustreProcMetric
+		for _, metric := range s.lustreProcMetrics {
+			if metric.filename == quotaAcct {
+				quota = append(quota, metric)
+			}
+		}
+		if len(quota) != 4 {
+			t.Fatalf("Retrieved an unexpected number of quota templates. Expected: %d, Got: %d", 4, len(quota))
+		}
+		s.lustreProcMetrics = quota
+		ctx := s.newCtx()
+		defer ctx.release()
+		if err := ctx.collect(); err != nil {
+			t.Fatal(err)
+		}
+		return countMetrics(make(chan prometheus.Metric, 100000), ctx.update)
+	}
+	if n := count(); n == 0 {
+		t.Fatal("Retrieved no quota metrics")
+	}
+	// 3 quota types, at most one ID each with a usage, inode usage and limit
+	QuotaMaxIDs = 1
+	if n := count(); n == 0 || n > 9 {
+		t.Fatalf("Retrieved an unexpected number of capped quota metrics. Expected: 1 to %d, Got: %d", 9, n)
+	}
+}
+
+func TestQuotaTables(t *testing.T) {
+	path := "../tests/quota/proc/fs/lustre/osd-ldiskfs/lustrefs-OST0000/quota_slave/acct_user"
+	reads := map[string]int{}
+	read := func(path string) ([]byte, error) {
+		reads[path]++
+		return os.ReadFile(path)
+	}
+
+	// the 4 quota templates of the file share one read
+	quotas := quotaTables{}
+	for i := 0; i < 4; i++ {
+		quotaType, entries, err := quotas.read(path, read)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if quotaType != "user" || len(entries) != 2 {
+			t.Fatalf("Retrieved unexpected %s quota entries: %v", quotaType, entries)
+		}
+	}
+	limitPath := strings.TrimSuffix(path, "acct_user") + "limit_user"
+	if reads[path] != 1 || reads[limitPath] != 1 {
+		t.Fatalf("Retrieved an unexpected number of reads. Expected: 1 of each file, Got: %v", reads)
+	}
+}
+
+func TestQuotaLimits(t *testing.T) {
+	defer func(ost string, mdt string, enabled bool) {
+		OstEnabled, MdtEnabled, QuotaEnabled = ost, mdt, enabled
+	}(OstEnabled, MdtEnabled, QuotaEnabled)
+	OstEnabled = core
+	MdtEnabled = core
+	QuotaEnabled = true
+
+	// the block limits are the ones of the OSTs, the inode limits the ones
+	// of the MDTs, ID 0 holds the grace times
+	expected := map[string]float64{
+		"quota_limit_kilobytes lustrefs-OST0000 1000": 1048576,
+		"quota_limit_inodes lustrefs-MDT0000 1000":    10000,
+	}
+	limits := func(metrics []prometheus.Metric) map[string]float64 {
+		got := map[string]float64{}
+		for _, m := range metrics {
+			name := fqNameRE.FindStringSubmatch(m.Desc().String())[1]
+			if !strings.HasPrefix(name, "lustre_quota_limit_") {
+				continue
+			}
+			var d dto.Metric
+			if err := m.Write(&d); err != nil {
+				t.Fatal(err)
+			}
+			labels := map[string]string{}
+			for _, l := range d.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			got[strings.Join([]string{strings.TrimPrefix(name, "lustre_"), labels["target"], labels["id"]}, " ")] = d.GetGauge().GetValue()
+		}
+		return got
+	}
+
+	s := newLustreSource(Config{ProcLocation: "../tests/quota/proc"}).(*lustreProcfsSource)
+	ctx := s.newCtx()
+	defer ctx.release()
+	if err := ctx.collect(); err != nil {
+		t.Fatal(err)
+	}
+	var metrics []prometheus.Metric
+	ch := make(chan prometheus.Metric, 1000)
+	ctx.update(ch)
+	close(ch)
+	for m := range ch {
+		metrics = append(metrics, m)
+	}
+	if got := limits(metrics); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Retrieved unexpected quota limits. Expected: %v, Got: %v", expected, got)
+	}
+
+	// the v1 collecting logic reports the same limits
+	ch = make(chan prometheus.Metric, 1000)
+	if err := s.Update(ch); err != nil {
+		t.Fatal(err)
+	}
+	close(ch)
+	metrics = nil
+	for m := range ch {
+		metrics = append(metrics, m)
+	}
+	if got := limits(metrics); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Retrieved unexpected v1 quota limits. Expected: %v, Got: %v", expected, got)
+	}
+}
@@ -0,0 +1,33 @@
+package sources
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ReaderStatsCollector reports the process wide counters of the file reader
+// and of the label sanitizing, shared by every source. It is registered once
+// beside the sources, which emit their series once per collector.
+type ReaderStatsCollector struct{}
+
+// NewReaderStatsCollector returns a ReaderStatsCollector.
+func NewReaderStatsCollector() ReaderStatsCollector {
+	return ReaderStatsCollector{}
+}
+
+// Describe implements prometheus.Collector.
+func (c ReaderStatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(c, ch)
+}
+
+// Collect implements prometheus.Collector.
+func (c ReaderStatsCollector) Collect(ch chan<- prometheus.Metric) {
+	if SanitizeLabels {
+		ch <- labelsSanitizedMetric()
+	}
+	for _, m := range skippedFilesMetrics() {
+		ch <- m
+	}
+	for _, m := range insFilesRead.metrics() {
+		ch <- m
+	}
+}
@@ -0,0 +1,35 @@
+package sources
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestReaderStatsCollector(t *testing.T) {
+	defer func(sanitize bool) { SanitizeLabels = sanitize }(SanitizeLabels)
+	SanitizeLabels = true
+	insFilesRead.add("procfs", 3)
+	insFilesRead.add("procsys", 1)
+
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(NewReaderStatsCollector())
+	metricFamilies, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// one series per reason and per collector, whatever the number of sources
+	expected := map[string]int{
+		"lustre_labels_sanitized_total":     1,
+		"lustre_skipped_files_total":        2,
+		"lustre_collector_files_read_total": 2,
+	}
+	if len(metricFamilies) != len(expected) {
+		t.Fatalf("Retrieved an unexpected number of metric families. Expected: %d, Got: %d", len(expected), len(metricFamilies))
+	}
+	for _, mf := range metricFamilies {
+		if n := len(mf.GetMetric()); n != expected[mf.GetName()] {
+			t.Fatalf("Retrieved an unexpected number of %s series. Expected: %d, Got: %d", mf.GetName(), expected[mf.GetName()], n)
+		}
+	}
+}
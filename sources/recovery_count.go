@@ -0,0 +1,82 @@
+package sources
+
+import (
+	"strings"
+	"sync"
+)
+
+const recoveryCountHelp string = "Number of times the target entered recovery since the exporter started, counted from the status field of recovery_status"
+
+// recoveryStatusRecovering is the status of a target whose recovery is in
+// progress.
+const recoveryStatusRecovering = "RECOVERING"
+
+type recoveryState struct {
+	status string
+	count  float64
+}
+
+// recoveryCounter counts the transitions of every target into RECOVERING.
+// Lustre does not keep such a counter, so it only covers the lifetime of
+// the exporter.
+type recoveryCounter struct {
+	mu      sync.Mutex
+	targets map[targetKey]*recoveryState
+}
+
+var insRecoveryCount = &recoveryCounter{
+	targets: map[targetKey]*recoveryState{},
+}
+
+// observe records the status of a target and returns its number of
+// recoveries. A target first seen RECOVERING counts as one, the exporter
+// started in the middle of that recovery.
+func (c *recoveryCounter) observe(key targetKey, status string) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	state, ok := c.targets[key]
+	if !ok {
+		state = &recoveryState{}
+		c.targets[key] = state
+	}
+	if status == recoveryStatusRecovering && state.status != recoveryStatusRecovering {
+		state.count++
+	}
+	state.status = status
+	return state.count
+}
+
+// recoveryStatusState returns the status field of a recovery_status file,
+// e.g. COMPLETE, RECOVERING or INACTIVE.
+func recoveryStatusState(content string) (string, bool) {
+	for _, line := range strings.Split(content, "\n") {
+		idx := strings.Index(line, ":")
+		if idx < 1 || strings.TrimSpace(line[:idx]) != "status" {
+			continue
+		}
+		return strings.TrimSpace(line[idx+1:]), true
+	}
+	return "", false
+}
+
+// recoveryStatusValue returns the value of promName for the recovery_status
+// content of a target.
+func recoveryStatusValue(key targetKey, promName string, content string) (float64, bool) {
+	if field, ok := recoveryClientFields[promName]; ok {
+		return recoveryClients(content, field)
+	}
+	if promName != "recovery_count_total" && promName != "recovery_status" {
+		value, ok := parseRecoveryStatus(content)[recoveryStatusFields[promName]]
+		return value, ok
+	}
+	status, ok := recoveryStatusState(content)
+	if !ok {
+		return 0, false
+	}
+	if promName == "recovery_status" {
+		value, ok := recoveryStates[status]
+		return value, ok
+	}
+	return insRecoveryCount.observe(key, status), true
+}
@@ -0,0 +1,99 @@
+package sources
+
+import (
+	"os"
+	"testing"
+)
+
+func TestRecoveryCount(t *testing.T) {
+	counter := &recoveryCounter{targets: map[targetKey]*recoveryState{}}
+	key := targetKey{"mdt", "lustrefs-MDT0000"}
+	other := targetKey{"ost", "lustrefs-OST0000"}
+
+	for i, step := range []struct {
+		status   string
+		expected float64
+	}{
+		{"COMPLETE", 0},
+		{"RECOVERING", 1},
+		{"RECOVERING", 1},
+		{"COMPLETE", 1},
+		{"RECOVERING", 2},
+		{"INACTIVE", 2},
+		{"COMPLETE", 2},
+	} {
+		if got := counter.observe(key, step.status); got != step.expected {
+			t.Fatalf("Retrieved an unexpected recovery count at step %d (%s). Expected: %f, Got: %f", i, step.status, step.expected, got)
+		}
+	}
+
+	// a target first seen in recovery has entered it once
+	if got := counter.observe(other, "RECOVERING"); got != 1 {
+		t.Fatalf("Retrieved an unexpected recovery count. Expected: %f, Got: %f", 1.0, got)
+	}
+	if got := counter.observe(key, "COMPLETE"); got != 2 {
+		t.Fatalf("Retrieved an unexpected recovery count. Expected: %f, Got: %f", 2.0, got)
+	}
+}
+
+func TestRecoveryStatusState(t *testing.T) {
+	for path, expected := range map[string]string{
+		"../tests/2.12/proc/fs/lustre/mdt/lustrefs-MDT0000/recovery_status":       "RECOVERING",
+		"../tests/2.12/proc/fs/lustre/obdfilter/lustrefs-OST0000/recovery_status": "COMPLETE",
+	} {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		status, ok := recoveryStatusState(string(content))
+		if !ok || status != expected {
+			t.Fatalf("Retrieved an unexpected status for %s. Expected: %s, Got: %s (found: %t)", path, expected, status, ok)
+		}
+	}
+	if _, ok := recoveryStatusState("recovery_start: 1510605701\n"); ok {
+		t.Fatal("Expected no status without a status field")
+	}
+}
+
+func TestRecoveryStatusValue(t *testing.T) {
+	mdt := "../tests/2.12/proc/fs/lustre/mdt/lustrefs-MDT0000/recovery_status"
+	ost := "../tests/2.12/proc/fs/lustre/obdfilter/lustrefs-OST0000/recovery_status"
+	for _, test := range []struct {
+		path     string
+		promName string
+		expected float64
+		ok       bool
+	}{
+		// mid-recovery
+		{mdt, "recovery_status", 2, true},
+		{mdt, "recovery_connected_clients", 1, true},
+		{mdt, "recovery_completed_clients", 0, true},
+		{mdt, "recovery_time_remaining_seconds", 120, true},
+		// complete, no time left to report
+		{ost, "recovery_status", 3, true},
+		{ost, "recovery_connected_clients", 0, false},
+		{ost, "recovery_completed_clients", 1, true},
+		{ost, "recovery_time_remaining_seconds", 0, false},
+	} {
+		content, err := os.ReadFile(test.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		value, ok := recoveryStatusValue(targetKey{"test", test.path}, test.promName, string(content))
+		if value != test.expected || ok != test.ok {
+			t.Fatalf("Retrieved an unexpected %s for %s. Expected: %f (found: %t), Got: %f (found: %t)", test.promName, test.path, test.expected, test.ok, value, ok)
+		}
+	}
+
+	for status, expected := range map[string]float64{"INACTIVE": 0, "WAITING": 1} {
+		if value, ok := recoveryStatusValue(targetKey{}, "recovery_status", "status: "+status+"\n"); !ok || value != expected {
+			t.Fatalf("Retrieved an unexpected recovery status for %s. Expected: %f, Got: %f (found: %t)", status, expected, value, ok)
+		}
+	}
+	if _, ok := recoveryStatusValue(targetKey{}, "recovery_status", "status: UNKNOWN\n"); ok {
+		t.Fatal("Expected no recovery status for an unknown status")
+	}
+	if _, ok := recoveryStatusValue(targetKey{}, "recovery_connected_clients", "connected_clients: n/a\n"); ok {
+		t.Fatal("Expected no connected clients for a non numeric field")
+	}
+}
@@ -0,0 +1,83 @@
+package sources
+
+import (
+	"strconv"
+	"strings"
+)
+
+const (
+	recoveryStatusHelp           string = "Recovery state of the target from the status field of recovery_status: 0 inactive, 1 waiting, 2 recovering, 3 complete"
+	recoveryConnectedClientsHelp string = "Number of clients which reconnected to the target during its current or last recovery, only reported when recovery_status has the field"
+	recoveryCompletedClientsHelp string = "Number of clients which completed the recovery of the target, only reported when recovery_status has the field"
+	recoveryTimeRemainingHelp    string = "Number of seconds left before the recovery window of the target closes, only reported while it is recovering"
+	recoveryEvictedClientsHelp   string = "Number of clients evicted by the current or last recovery of the target (evicted_clients), only reported when recovery_status has the field. The evictions outside of a recovery are not counted"
+)
+
+// recoveryStates maps the status field of recovery_status to the value of
+// lustre_recovery_status, the other statuses are left out.
+var recoveryStates = map[string]float64{
+	"INACTIVE":               0,
+	"WAITING":                1,
+	recoveryStatusRecovering: 2,
+	"COMPLETE":               3,
+}
+
+// recoveryStatusTemplates are the state and progress of the recovery of the
+// OSTs and MDTs, always collected.
+func recoveryStatusTemplates(s *lustreProcfsSource) []lustreHelpStruct {
+	return []lustreHelpStruct{
+		{recoveryStatus, "recovery_status", recoveryStatusHelp, s.gaugeMetric, false, core},
+		{recoveryStatus, "recovery_connected_clients", recoveryConnectedClientsHelp, s.gaugeMetric, false, core},
+		{recoveryStatus, "recovery_completed_clients", recoveryCompletedClientsHelp, s.gaugeMetric, false, core},
+		{recoveryStatus, "recovery_time_remaining_seconds", recoveryTimeRemainingHelp, s.gaugeMetric, false, core},
+	}
+}
+
+// recoveryClientFields maps the client metrics to their 'done/total' field
+// in the recovery_status file.
+var recoveryClientFields = map[string]string{
+	"recovery_connected_clients": "connected_clients",
+	"recovery_completed_clients": "completed_clients",
+}
+
+// recoveryClients returns the number of clients of a 'connected_clients:
+// 1/2' field, a plain number ('completed_clients: 0') is taken as is.
+func recoveryClients(content string, field string) (float64, bool) {
+	for _, line := range strings.Split(content, "\n") {
+		idx := strings.Index(line, ":")
+		if idx < 1 || strings.TrimSpace(line[:idx]) != field {
+			continue
+		}
+		value := strings.TrimSpace(line[idx+1:])
+		if slash := strings.Index(value, "/"); slash >= 0 {
+			value = value[:slash]
+		}
+		clients, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return 0, false
+		}
+		return clients, true
+	}
+	return 0, false
+}
+
+// recoveryFiles caches the recovery_status files read in a scrape, the
+// recovery templates of a target share one read.
+type recoveryFiles map[string]recoveryFile
+
+type recoveryFile struct {
+	content string
+	err     error
+}
+
+// read returns the content of path, read with read on the first call of the
+// scrape.
+func (r recoveryFiles) read(path string, read func(string) ([]byte, error)) (string, error) {
+	file, ok := r[path]
+	if !ok {
+		content, err := read(path)
+		file = recoveryFile{string(content), err}
+		r[path] = file
+	}
+	return file.content, file.err
+}
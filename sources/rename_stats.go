@@ -0,0 +1,59 @@
+package sources
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	renameStats string = "rename_stats"
+
+	mdtRenameSamplesHelp string = "Number of renames of the MDT by size of the directories involved (type same_dir, crossdir_src or crossdir_tgt), expensive cross-directory rename patterns"
+)
+
+// renameBucket is a bucket of a section of the 'rename_stats' histogram.
+type renameBucket struct {
+	section string
+	size    string
+	samples float64
+}
+
+var renameBucketPattern = regexp.MustCompile(`^(\S+):\s*\{\s*samples?:\s*([0-9]+)`)
+
+// parseRenameStats returns the buckets of the sections of a 'rename_stats'
+// file:
+//
+//	rename_stats:
+//	- snapshot_time:  1510781853.10473844
+//	- same_dir
+//	      4KB: { samples:       4, pct:  40, cum_pct:  40 }
+//	- crossdir_src
+//	      4KB: { samples:       2, pct:  50, cum_pct:  50 }
+//
+// The buckets before the first section are ignored.
+func parseRenameStats(content string) ([]renameBucket, error) {
+	var buckets []renameBucket
+	section := ""
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "- ") {
+			section = strings.TrimSuffix(strings.TrimSpace(strings.TrimPrefix(line, "- ")), ":")
+			if strings.Contains(section, ":") {
+				// snapshot_time and the like
+				section = ""
+			}
+			continue
+		}
+		match := renameBucketPattern.FindStringSubmatch(line)
+		if match == nil || section == "" {
+			continue
+		}
+		samples, err := strconv.ParseFloat(match[2], 64)
+		if err != nil {
+			return nil, err
+		}
+		buckets = append(buckets, renameBucket{section: section, size: match[1], samples: samples})
+	}
+	return buckets, nil
+}
@@ -0,0 +1,38 @@
+package sources
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseRenameStats(t *testing.T) {
+	content := `rename_stats:
+- snapshot_time:  1510781853. 10473844
+- same_dir
+      4KB: { samples:       4, pct:  40, cum_pct:  40 }
+      8KB: { samples:       6, pct:  60, cum_pct: 100 }
+- crossdir_src:
+      16KB: { sample:       2, pct: 100, cum_pct: 100 }
+- crossdir_tgt
+`
+	buckets, err := parseRenameStats(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []renameBucket{
+		{"same_dir", "4KB", 4},
+		{"same_dir", "8KB", 6},
+		{"crossdir_src", "16KB", 2},
+	}
+	if !reflect.DeepEqual(buckets, expected) {
+		t.Fatalf("Retrieved unexpected rename buckets. Expected: %v, Got: %v", expected, buckets)
+	}
+
+	buckets, err = parseRenameStats("rename_stats:\n- snapshot_time:  1510781853. 10473844\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(buckets) != 0 {
+		t.Fatalf("Retrieved unexpected rename buckets. Expected: none, Got: %v", buckets)
+	}
+}
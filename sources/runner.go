@@ -2,12 +2,15 @@ package sources
 
 import (
 	"lustre_exporter/log"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 )
 
+const collectorEmptyHelp string = "Returns 1 if the collector succeeded but produced no metrics this scrape, i.e. there is nothing for it on this node"
+
 var MAX_WORKER = 4
 var SHELF_LIFE = time.Second
 
@@ -34,6 +37,7 @@ type runnerCtx struct {
 	cost   time.Duration
 	name   string
 	result string
+	err    error
 	ctx  collectorCtx
 }
 
@@ -60,8 +64,12 @@ func (w *worker)run() {
 			w.ctxs = append(w.ctxs, ctx)
 			go func(ctx *runnerCtx) {
 				err := ctx.ctx.collect()
+				if c, ok := ctx.ctx.(filesReadCounter); ok {
+					insFilesRead.add(ctx.name, c.filesRead())
+				}
 				ctx.end   = time.Now()
 				ctx.cost  = ctx.end.Sub(ctx.start)
+				ctx.err   = err
 				if err != nil {
 					log.Errorf("ERROR: %q source failed after %f seconds: %s", ctx.name, ctx.cost.Seconds(), err)
 					ctx.result = "error"
@@ -88,12 +96,61 @@ func (w *worker)release() {
 func (w *worker)update(sv *prometheus.SummaryVec, ch chan<- prometheus.Metric) {
 	for _, ctx := range w.ctxs {
 		start := time.Now()
-		ctx.ctx.update(ch)
+		emitted := countMetrics(ch, ctx.ctx.update)
+		ch <- collectorEmptyMetric(ctx.name, collectorEmptyValue(ctx.result, emitted))
+		if LastScrapeError && ctx.err != nil {
+			ch <- lastScrapeErrorMetric(ctx.name, ctx.err)
+		}
+		if Strict && ctx.err != nil {
+			ch <- strictErrorMetric(ctx.name, ctx.err)
+		}
 		sv.WithLabelValues(ctx.name, ctx.result).Observe(ctx.cost.Seconds() + time.Since(start).Seconds())
 	}
 	sv.Collect(ch)
 }
 
+// countMetrics forwards what update sends to ch and returns how many metrics
+// it sent.
+func countMetrics(ch chan<- prometheus.Metric, update func(chan<- prometheus.Metric)) int {
+	n := 0
+	inner := make(chan prometheus.Metric)
+	done := make(chan struct{})
+	go func() {
+		for m := range inner {
+			n++
+			ch <- m
+		}
+		close(done)
+	}()
+	update(inner)
+	close(inner)
+	<-done
+	return n
+}
+
+// collectorEmptyValue is 1 if the collector succeeded without producing any
+// metric, i.e. it has nothing to read on this node.
+func collectorEmptyValue(result string, emitted int) float64 {
+	if result == "success" && emitted == 0 {
+		return 1
+	}
+	return 0
+}
+
+func collectorEmptyMetric(name string, value float64) prometheus.Metric {
+	return prometheus.MustNewConstMetric(
+		prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "", "collector_empty"),
+			collectorEmptyHelp,
+			[]string{"collector"},
+			nil,
+		),
+		prometheus.GaugeValue,
+		value,
+		name,
+	)
+}
+
 var insRunner = &runner{
 	workers: map[*worker]*worker{},
 }
@@ -172,7 +229,8 @@ func (r *runner)updateV1(list map[string]LustreSource, sv *prometheus.SummaryVec
 
 			result := "success"
 			begin := time.Now()
-			err := c.Update(ch)
+			var err error
+			emitted := countMetrics(ch, func(ch chan<- prometheus.Metric) { err = c.Update(ch) })
 			duration := time.Since(begin)
 			if err != nil {
 				log.Errorf("ERROR: %q source failed after %f seconds: %s", name, duration.Seconds(), err)
@@ -180,6 +238,13 @@ func (r *runner)updateV1(list map[string]LustreSource, sv *prometheus.SummaryVec
 			} else {
 				log.Debugf("OK: %q source succeeded after %f seconds: %s", name, duration.Seconds(), err)
 			}
+			ch <- collectorEmptyMetric(name, collectorEmptyValue(result, emitted))
+			if LastScrapeError && err != nil {
+				ch <- lastScrapeErrorMetric(name, err)
+			}
+			if Strict && err != nil {
+				ch <- strictErrorMetric(name, err)
+			}
 			sv.WithLabelValues(name, result).Observe(duration.Seconds())
 
 
@@ -189,3 +254,66 @@ func (r *runner)updateV1(list map[string]LustreSource, sv *prometheus.SummaryVec
 	wg.Wait()
 	sv.Collect(ch)
 }
+
+// CollectResult is how one collector did in CollectOnce.
+type CollectResult struct {
+	Collector string  `json:"collector"`
+	Success   bool    `json:"success"`
+	Seconds   float64 `json:"duration_seconds"`
+	Series    int     `json:"series"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// CollectOnce runs one collection of every source of list, outside of the
+// shared workers and their shelf life, and reports how each collector did.
+// The metrics themselves are dropped.
+func CollectOnce(list map[string]LustreSource) []CollectResult {
+	start := time.Now()
+	ctxs := make([]*runnerCtx, 0, len(list))
+	errs := make([]error, len(list))
+	var wg sync.WaitGroup
+	for name, c := range list {
+		ctx := &runnerCtx{
+			name:   name,
+			start:  start,
+			ctx:    c.newCtx(),
+			result: "success",
+		}
+		ctxs = append(ctxs, ctx)
+		wg.Add(1)
+		go func(i int, ctx *runnerCtx) {
+			defer wg.Done()
+			errs[i] = ctx.ctx.collect()
+			ctx.end = time.Now()
+			ctx.cost = ctx.end.Sub(ctx.start)
+			if errs[i] != nil {
+				ctx.result = "error"
+			}
+		}(len(ctxs)-1, ctx)
+	}
+	wg.Wait()
+
+	discard := make(chan prometheus.Metric)
+	go func() {
+		for range discard {
+		}
+	}()
+	defer close(discard)
+
+	out := make([]CollectResult, 0, len(ctxs))
+	for i, ctx := range ctxs {
+		res := CollectResult{
+			Collector: ctx.name,
+			Success:   errs[i] == nil,
+			Seconds:   ctx.cost.Seconds(),
+			Series:    countMetrics(discard, ctx.ctx.update),
+		}
+		if errs[i] != nil {
+			res.Error = errs[i].Error()
+		}
+		ctx.ctx.release()
+		out = append(out, res)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Collector < out[j].Collector })
+	return out
+}
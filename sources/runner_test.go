@@ -0,0 +1,80 @@
+package sources
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestCollectorEmpty(t *testing.T) {
+	defer func(lnet string) { LnetEnabled = lnet }(LnetEnabled)
+	LnetEnabled = extended
+
+	ctx := newLustreProcSysSource(Config{ProcLocation: t.TempDir()}).newCtx()
+	defer ctx.release()
+	result := "success"
+	if err := ctx.collect(); err != nil {
+		result = "error"
+	}
+
+	ch := make(chan prometheus.Metric, 100)
+	emitted := countMetrics(ch, ctx.update)
+	if emitted != 0 {
+		t.Fatalf("Retrieved an unexpected number of metrics. Expected: %d, Got: %d", 0, emitted)
+	}
+	if value := collectorEmptyValue(result, emitted); value != 1 {
+		t.Fatalf("Retrieved an unexpected collector_empty value. Expected: %d, Got: %f", 1, value)
+	}
+}
+
+func TestCollectorEmptyValue(t *testing.T) {
+	if value := collectorEmptyValue("success", 3); value != 0 {
+		t.Fatalf("Retrieved an unexpected collector_empty value. Expected: %d, Got: %f", 0, value)
+	}
+	// a failed collector is reported by the scrape duration result, not as empty
+	if value := collectorEmptyValue("error", 0); value != 0 {
+		t.Fatalf("Retrieved an unexpected collector_empty value. Expected: %d, Got: %f", 0, value)
+	}
+}
+
+func TestCollectorFilesRead(t *testing.T) {
+	defer func(lnet string) { LnetEnabled = lnet }(LnetEnabled)
+	LnetEnabled = extended
+
+	dir := t.TempDir()
+	lnetDir := filepath.Join(dir, "sys", "lnet")
+	if err := os.MkdirAll(lnetDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := []string{"catastrophe", "fail_err", "lnet_memused"}
+	for _, name := range files {
+		if err := os.WriteFile(filepath.Join(lnetDir, name), []byte("0\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx := newLustreProcSysSource(Config{ProcLocation: dir}).newCtx()
+	defer ctx.release()
+	if err := ctx.collect(); err != nil {
+		t.Fatal(err)
+	}
+	counter, ok := ctx.(filesReadCounter)
+	if !ok {
+		t.Fatalf("The procsys context doesn't count the files it reads")
+	}
+	if read := counter.filesRead(); read != uint64(len(files)) {
+		t.Fatalf("Retrieved an unexpected number of files read. Expected: %d, Got: %d", len(files), read)
+	}
+}
+
+func TestFilesReadTotals(t *testing.T) {
+	totals := &filesReadTotals{totals: map[string]uint64{}}
+	totals.add("procsys", 3)
+	totals.add("procsys", 2)
+	totals.add("procfs", 1)
+	if totals.totals["procsys"] != 5 || totals.totals["procfs"] != 1 {
+		t.Fatalf("Retrieved unexpected files read totals. Expected: map[procfs:1 procsys:5], Got: %v", totals.totals)
+	}
+}
@@ -0,0 +1,63 @@
+package sources
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// LastScrapeError exports the error of the collectors which failed in the
+// scrape as lustre_last_scrape_error{collector,error}.
+var LastScrapeError = false
+
+// Strict makes the whole scrape fail when a collector failed, instead of
+// serving the metrics of the others.
+var Strict = false
+
+// maxScrapeErrorLen bounds the error label, in runes, so an error carrying
+// arbitrary file content can't blow up the series size.
+const maxScrapeErrorLen = 200
+
+const lastScrapeErrorHelp string = "Returns 1 with the error of the collector in the last scrape, the label is truncated to 200 characters. The series is absent when the collector succeeded."
+
+// scrapeErrorLabel returns err as a single line of valid UTF-8 of at most
+// maxScrapeErrorLen runes.
+func scrapeErrorLabel(err error) string {
+	msg := strings.Join(strings.FieldsFunc(getValidUtf8String(err.Error()), func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsControl(r)
+	}), " ")
+	if runes := []rune(msg); len(runes) > maxScrapeErrorLen {
+		msg = string(runes[:maxScrapeErrorLen-3]) + "..."
+	}
+	return msg
+}
+
+func lastScrapeErrorMetric(collector string, err error) prometheus.Metric {
+	return prometheus.MustNewConstMetric(
+		prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "", "last_scrape_error"),
+			lastScrapeErrorHelp,
+			[]string{"collector", "error"},
+			nil,
+		),
+		prometheus.GaugeValue,
+		1,
+		collector, scrapeErrorLabel(err),
+	)
+}
+
+// strictErrorMetric is an invalid metric failing the gathering with the error
+// of the collector, see Strict.
+func strictErrorMetric(collector string, err error) prometheus.Metric {
+	return prometheus.NewInvalidMetric(
+		prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "", "collector_error"),
+			"Error of the collector, fails the scrape in strict mode",
+			nil,
+			nil,
+		),
+		fmt.Errorf("collector %s failed: %w", collector, err),
+	)
+}
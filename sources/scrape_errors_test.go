@@ -0,0 +1,36 @@
+package sources
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestScrapeErrorLabel(t *testing.T) {
+	dir := t.TempDir()
+	// a directory in place of the modules file makes its read fail, the
+	// target files failing only lower their completeness
+	path := filepath.Join(dir, "modules")
+	if err := os.MkdirAll(path, 0755); err != nil {
+		t.Fatal(err)
+	}
+	s := &lustreProcfsSource{basePath: dir, modulesPath: path}
+	ctx := insProcfsV2.newCtx(s)
+	defer ctx.release()
+	err := ctx.collect()
+	if err == nil {
+		t.Fatal("Expected the collection to fail")
+	}
+	label := scrapeErrorLabel(err)
+	if !strings.Contains(label, path) || !strings.Contains(label, "is a directory") {
+		t.Fatalf("Retrieved an unexpected error label. Expected the read failure of %s, Got: %s", path, label)
+	}
+
+	long := scrapeErrorLabel(errors.New("parsing failed:\n" + strings.Repeat("\xffé", 300)))
+	if n := utf8.RuneCountInString(long); n != maxScrapeErrorLen || !utf8.ValidString(long) || strings.Contains(long, "\n") {
+		t.Fatalf("Retrieved an unexpected long error label (%d runes): %q", n, long)
+	}
+}
@@ -0,0 +1,38 @@
+package sources
+
+import "path/filepath"
+
+// InferServerRole returns the role of the node from the Lustre devices found
+// in the procfs and sysfs trees of cfg: "oss" (obdfilter), "mds" (mdt), "mgs"
+// (mgs), "combined" when there is more than one of them, "client" (llite)
+// when there is none. It returns "" when no Lustre device is found.
+func InferServerRole(cfg Config) string {
+	present := func(device string) bool {
+		for _, base := range []string{cfg.LustreProcPath(), cfg.LustreSysPath()} {
+			if matches, _ := filepath.Glob(filepath.Join(base, device, "*")); len(matches) > 0 {
+				return true
+			}
+		}
+		return false
+	}
+
+	var roles []string
+	for _, server := range []struct{ device, role string }{
+		{"obdfilter", "oss"},
+		{"mdt", "mds"},
+		{"mgs", "mgs"},
+	} {
+		if present(server.device) {
+			roles = append(roles, server.role)
+		}
+	}
+	switch {
+	case len(roles) > 1:
+		return "combined"
+	case len(roles) == 1:
+		return roles[0]
+	case present("llite"):
+		return "client"
+	}
+	return ""
+}
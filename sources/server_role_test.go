@@ -0,0 +1,35 @@
+package sources
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestInferServerRole(t *testing.T) {
+	// the fixture has OSTs, an MDT, the MGS and a client mount
+	if role := InferServerRole(Config{ProcLocation: "../tests/2.12/proc", SysLocation: "../tests/2.12/sys"}); role != "combined" {
+		t.Fatalf("Retrieved an unexpected server role. Expected: %s, Got: %s", "combined", role)
+	}
+
+	for _, test := range []struct {
+		devices  []string
+		expected string
+	}{
+		{[]string{"obdfilter/fs-OST0000"}, "oss"},
+		{[]string{"mdt/fs-MDT0000", "llite/fs-ffff0000"}, "mds"},
+		{[]string{"mgs/MGS"}, "mgs"},
+		{[]string{"llite/fs-ffff0000"}, "client"},
+		{nil, ""},
+	} {
+		proc := t.TempDir()
+		for _, device := range test.devices {
+			if err := os.MkdirAll(filepath.Join(proc, "fs/lustre", device), 0o755); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if role := InferServerRole(Config{ProcLocation: proc, SysLocation: t.TempDir()}); role != test.expected {
+			t.Fatalf("Retrieved an unexpected server role for %v. Expected: %q, Got: %q", test.devices, test.expected, role)
+		}
+	}
+}
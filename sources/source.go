@@ -14,6 +14,12 @@
 package sources
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -24,23 +30,255 @@ var ProcLocation = "/proc"
 // SysLocation is the source to pull sys files from.
 var SysLocation = "/sys"
 
+// ProcPath is the Lustre tree of procfs, <ProcLocation>/fs/lustre (i.e.
+// '/proc/fs/lustre') when empty. Set it when the host's tree is bind mounted
+// somewhere else than below a proc mount, e.g. '/host/proc/fs/lustre'.
+var ProcPath = ""
+
+// SysPath is the Lustre tree of sysfs, <SysLocation>/fs/lustre when empty.
+var SysPath = ""
+
+// ResolveProcLocation returns procLocation, or hostProc when Lustre is not
+// found below procLocation but is below hostProc. In a container with its own
+// mount namespace the host's /proc is usually bind mounted read-only at
+// '/host/proc', while the container's /proc has no 'fs/lustre'.
+func ResolveProcLocation(procLocation string, hostProc string) string {
+	if hostProc == "" {
+		return procLocation
+	}
+	if _, err := os.Stat(filepath.Join(procLocation, "fs/lustre")); err == nil {
+		return procLocation
+	}
+	if _, err := os.Stat(filepath.Join(hostProc, "fs/lustre")); err == nil {
+		return hostProc
+	}
+	return procLocation
+}
+
 // collect version, v2 is a much more efficient version
 var CollectVersion = "v2"
 
 //Namespace defines the namespace shared by all Lustre metrics.
 const Namespace = "lustre"
 
-//Factories contains the list of all sources.
-var Factories = make(map[string]func() LustreSource)
+// Config is handed to the source factories when the exporter loads them.
+// ProcPath and SysPath override the Lustre trees below ProcLocation and
+// SysLocation, the sources build their paths from LustreProcPath and
+// LustreSysPath rather than joining 'fs/lustre' themselves.
+type Config struct {
+	ProcLocation string
+	SysLocation  string
+	ProcPath     string
+	SysPath      string
 
-//LustreSource is the interface that each source implements.
-type LustreSource interface {
+	// stateless leaves out the metrics derived from the previous scrapes
+	// (frozen counters, ping stalls, job stats resets, write rates, recovery
+	// counts, health state ages and unknown lines), whose state is shared
+	// with the HTTP path.
+	stateless bool
+}
+
+// NewConfig returns the Config of the current locations.
+func NewConfig() Config {
+	return Config{ProcLocation: ProcLocation, SysLocation: SysLocation, ProcPath: ProcPath, SysPath: SysPath}
+}
+
+// LustreProcPath returns the Lustre tree of procfs.
+func (cfg Config) LustreProcPath() string {
+	if cfg.ProcPath != "" {
+		return cfg.ProcPath
+	}
+	return filepath.Join(cfg.ProcLocation, "fs/lustre")
+}
+
+// LustreSysPath returns the Lustre tree of sysfs.
+func (cfg Config) LustreSysPath() string {
+	if cfg.SysPath != "" {
+		return cfg.SysPath
+	}
+	return filepath.Join(cfg.SysLocation, "fs/lustre")
+}
+
+// LustreDebugPath returns the Lustre tree of debugfs.
+func (cfg Config) LustreDebugPath() string {
+	return filepath.Join(cfg.SysLocation, "kernel/debug/lustre")
+}
+
+// Collector is the interface a source registered with Register implements.
+type Collector interface {
 	Update(ch chan<- prometheus.Metric) (err error)
-	newCtx()                            collectorCtx
+}
+
+// LustreSource is the interface that each built-in source implements, the v2
+// runner collects them through their own context.
+type LustreSource interface {
+	Collector
+	newCtx() collectorCtx
+}
+
+// factories contains the list of all registered sources.
+var factories = make(map[string]func(Config) Collector)
+
+// Factories contains the list of all registered sources, created with the
+// package level locations.
+//
+// Deprecated: use Registered and NewSource, which take the locations as a
+// Config. Factories is filled by Register and must not be modified.
+var Factories = make(map[string]func() LustreSource)
+
+// Register makes the source created by factory available under name, it is
+// meant to be called from an init() function. Registering the same name twice
+// panics.
+func Register(name string, factory func(Config) Collector) {
+	if _, ok := factories[name]; ok {
+		panic(fmt.Sprintf("source %q registered twice", name))
+	}
+	factories[name] = factory
+	Factories[name] = func() LustreSource {
+		s, _ := NewSource(name, NewConfig())
+		return s
+	}
+}
+
+// Registered returns the sorted names of all registered sources.
+func Registered() []string {
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SelectSources returns the registered sources named in the comma separated
+// list, all of them if the list is empty.
+func SelectSources(list string) ([]string, error) {
+	var names []string
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if _, ok := factories[name]; !ok {
+			return nil, fmt.Errorf("unknown source %q, valid sources: %s", name, strings.Join(Registered(), ", "))
+		}
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return Registered(), nil
+	}
+	return names, nil
+}
+
+// NewSource creates the registered source name.
+func NewSource(name string, cfg Config) (LustreSource, error) {
+	factory, ok := factories[name]
+	if !ok {
+		return nil, fmt.Errorf("source %q not available", name)
+	}
+	c := factory(cfg)
+	if s, ok := c.(LustreSource); ok {
+		return s, nil
+	}
+	return &collectorSource{c: c}, nil
+}
+
+// collectorSource runs a Collector registered from outside the package, which
+// can't implement newCtx, like the built-in sources.
+type collectorSource struct {
+	c Collector
+}
+
+func (s *collectorSource) Update(ch chan<- prometheus.Metric) (err error) {
+	return s.c.Update(ch)
+}
+
+func (s *collectorSource) newCtx() collectorCtx {
+	return &collectorSourceCtx{c: s.c}
+}
+
+// collectorSourceCtx keeps the metrics of one Update call until the worker
+// sends them.
+type collectorSourceCtx struct {
+	c       Collector
+	metrics []prometheus.Metric
+}
+
+func (ctx *collectorSourceCtx) collect() error {
+	ch := make(chan prometheus.Metric)
+	done := make(chan struct{})
+	go func() {
+		for m := range ch {
+			ctx.metrics = append(ctx.metrics, m)
+		}
+		close(done)
+	}()
+	err := ctx.c.Update(ch)
+	close(ch)
+	<-done
+	return err
+}
+
+func (ctx *collectorSourceCtx) update(ch chan<- prometheus.Metric) {
+	for _, m := range ctx.metrics {
+		ch <- m
+	}
+}
+
+func (ctx *collectorSourceCtx) release() {
+	ctx.metrics = nil
 }
 
 type collectorCtx interface {
 	collect()  error
 	update(ch chan<- prometheus.Metric)
 	release()
-}
\ No newline at end of file
+}
+
+// componentFlags maps the names accepted by --collector.components to the
+// level variables they control. "oss" is accepted as an alias of "ost".
+var componentFlags = map[string]*string{
+	"ost":     &OstEnabled,
+	"oss":     &OstEnabled,
+	"mdt":     &MdtEnabled,
+	"mgs":     &MgsEnabled,
+	"mds":     &MdsEnabled,
+	"client":  &ClientEnabled,
+	"generic": &GenericEnabled,
+	"lnet":    &LnetEnabled,
+	"ldlm":    &LdlmEnabled,
+	"health":  &HealthStatusEnabled,
+}
+
+// ApplyComponents sets the collectors named in the comma separated list to
+// 'extended' and disables all the others. Nothing is changed if the list
+// contains an unknown name.
+func ApplyComponents(list string) error {
+	var names []string
+	for _, name := range strings.Split(list, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if _, ok := componentFlags[name]; !ok {
+			valid := make([]string, 0, len(componentFlags))
+			for n := range componentFlags {
+				valid = append(valid, n)
+			}
+			sort.Strings(valid)
+			return fmt.Errorf("unknown component %q, valid components: %s", name, strings.Join(valid, ", "))
+		}
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("no component given")
+	}
+
+	for _, level := range componentFlags {
+		*level = disabled
+	}
+	for _, name := range names {
+		*componentFlags[name] = extended
+	}
+	return nil
+}
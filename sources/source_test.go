@@ -0,0 +1,196 @@
+package sources
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestApplyComponents(t *testing.T) {
+	all := []*string{&OstEnabled, &MdtEnabled, &MgsEnabled, &MdsEnabled, &ClientEnabled, &GenericEnabled, &LnetEnabled, &LdlmEnabled, &HealthStatusEnabled}
+	saved := make([]string, len(all))
+	for i, level := range all {
+		saved[i] = *level
+		*level = extended
+	}
+	defer func() {
+		for i, level := range all {
+			*level = saved[i]
+		}
+	}()
+
+	if err := ApplyComponents("ost, oss,generic"); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[*string]string{
+		&OstEnabled:          extended,
+		&MdtEnabled:          disabled,
+		&MgsEnabled:          disabled,
+		&MdsEnabled:          disabled,
+		&ClientEnabled:       disabled,
+		&GenericEnabled:      extended,
+		&LnetEnabled:         disabled,
+		&LdlmEnabled:         disabled,
+		&HealthStatusEnabled: disabled,
+	}
+	for level, want := range expected {
+		if *level != want {
+			t.Fatalf("Retrieved an unexpected collector level. Expected: %s, Got: %s", want, *level)
+		}
+	}
+
+	// a typo must fail without touching the current levels
+	if err := ApplyComponents("ost,lnte"); err == nil {
+		t.Fatal("Expected an error for an unknown component")
+	}
+	if MdtEnabled != disabled || OstEnabled != extended {
+		t.Fatal("Levels were changed by an invalid component list")
+	}
+
+	if err := ApplyComponents(" , "); err == nil {
+		t.Fatal("Expected an error for an empty component list")
+	}
+}
+
+type dummyCollector struct {
+	cfg   Config
+	calls int
+}
+
+func (c *dummyCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	c.calls++
+	ch <- collectorEmptyMetric("dummy", 0)
+	ch <- collectorEmptyMetric("dummy", 1)
+	return nil
+}
+
+func TestRegister(t *testing.T) {
+	dummy := &dummyCollector{}
+	Register("dummy", func(cfg Config) Collector {
+		dummy.cfg = cfg
+		return dummy
+	})
+	defer delete(factories, "dummy")
+	defer delete(Factories, "dummy")
+
+	names, err := SelectSources("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, name := range names {
+		found = found || name == "dummy"
+	}
+	if !found {
+		t.Fatalf("Registered source missing from the enabled sources: %v", names)
+	}
+	if names, err = SelectSources("procfs, sysfs"); err != nil || len(names) != 2 {
+		t.Fatalf("Retrieved unexpected sources. Expected: %v, Got: %v (%v)", []string{"procfs", "sysfs"}, names, err)
+	}
+	if _, err := SelectSources("procfs,dumy"); err == nil {
+		t.Fatal("Expected an error for an unknown source")
+	}
+
+	source, err := NewSource("dummy", Config{ProcLocation: "proc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dummy.cfg.ProcLocation != "proc" {
+		t.Fatalf("Retrieved an unexpected config. Expected: %s, Got: %s", "proc", dummy.cfg.ProcLocation)
+	}
+
+	// the deprecated Factories creates it with the package level locations
+	defer func(proc string) { ProcLocation = proc }(ProcLocation)
+	ProcLocation = "legacy"
+	if legacy := Factories["dummy"](); legacy == nil || dummy.cfg.ProcLocation != "legacy" {
+		t.Fatalf("Retrieved an unexpected config from Factories. Expected: %s, Got: %s", "legacy", dummy.cfg.ProcLocation)
+	}
+	if _, ok := Factories["procfs"]; !ok {
+		t.Fatal("Built-in source missing from Factories")
+	}
+
+	// the v2 runner goes through the collector context
+	ctx := source.newCtx()
+	defer ctx.release()
+	if err := ctx.collect(); err != nil {
+		t.Fatal(err)
+	}
+	ch := make(chan prometheus.Metric, 10)
+	if emitted := countMetrics(ch, ctx.update); emitted != 2 || dummy.calls != 1 {
+		t.Fatalf("Retrieved an unexpected number of metrics. Expected: %d (1 call), Got: %d (%d calls)", 2, emitted, dummy.calls)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Expected a panic when registering a name twice")
+		}
+	}()
+	Register("dummy", func(cfg Config) Collector { return dummy })
+}
+
+func TestResolveProcLocation(t *testing.T) {
+	// tests/hostns/host/proc is the 2.12 proc tree as bind mounted in a container
+	hostProc := "../tests/hostns/host/proc"
+	procLocation := t.TempDir()
+
+	if got := ResolveProcLocation(procLocation, hostProc); got != hostProc {
+		t.Fatalf("Retrieved an unexpected proc location. Expected: %s, Got: %s", hostProc, got)
+	}
+	if got := ResolveProcLocation("../tests/2.12/proc", hostProc); got != "../tests/2.12/proc" {
+		t.Fatalf("Retrieved an unexpected proc location. Expected: %s, Got: %s", "../tests/2.12/proc", got)
+	}
+	if got := ResolveProcLocation(procLocation, ""); got != procLocation {
+		t.Fatalf("Retrieved an unexpected proc location. Expected: %s, Got: %s", procLocation, got)
+	}
+
+	defer func(ost string) { OstEnabled = ost }(OstEnabled)
+	OstEnabled = core
+	ctx := newLustreSource(Config{ProcLocation: ResolveProcLocation(procLocation, hostProc)}).newCtx()
+	defer ctx.release()
+	if err := ctx.collect(); err != nil {
+		t.Fatal(err)
+	}
+	if n := countMetrics(make(chan prometheus.Metric, 10000), ctx.update); n == 0 {
+		t.Fatal("Retrieved no metrics from the host proc location")
+	}
+}
+
+func TestConfigLustrePaths(t *testing.T) {
+	cfg := Config{ProcLocation: "/proc", SysLocation: "/sys"}
+	if got := cfg.LustreProcPath(); got != "/proc/fs/lustre" {
+		t.Fatalf("Retrieved an unexpected procfs path. Expected: %s, Got: %s", "/proc/fs/lustre", got)
+	}
+	if got := cfg.LustreSysPath(); got != "/sys/fs/lustre" {
+		t.Fatalf("Retrieved an unexpected sysfs path. Expected: %s, Got: %s", "/sys/fs/lustre", got)
+	}
+
+	// the host's tree bind mounted in a container, without a proc mount
+	// around it
+	cfg = Config{ProcLocation: t.TempDir(), SysLocation: t.TempDir(), ProcPath: "../tests/hostns/host/proc/fs/lustre"}
+	if got := cfg.LustreProcPath(); got != cfg.ProcPath {
+		t.Fatalf("Retrieved an unexpected procfs path. Expected: %s, Got: %s", cfg.ProcPath, got)
+	}
+
+	defer func(ost string) { OstEnabled = ost }(OstEnabled)
+	OstEnabled = core
+	ctx := newLustreSource(cfg).newCtx()
+	defer ctx.release()
+	if err := ctx.collect(); err != nil {
+		t.Fatal(err)
+	}
+	if n := countMetrics(make(chan prometheus.Metric, 10000), ctx.update); n == 0 {
+		t.Fatal("Retrieved no metrics from the procfs path")
+	}
+	if role := InferServerRole(cfg); role != "combined" {
+		t.Fatalf("Retrieved an unexpected role. Expected: %s, Got: %s", "combined", role)
+	}
+
+	// the extra params are looked up in the sysfs tree of cfg, not of the
+	// package defaults
+	cfg.SysPath = "../tests/hostns/host/sys/fs/lustre"
+	roots := newLustreSource(cfg).(*lustreProcfsSource).extraParamRoots()
+	if expected := []string{cfg.SysPath, cfg.ProcPath}; !reflect.DeepEqual(roots, expected) {
+		t.Fatalf("Retrieved unexpected extra param roots. Expected: %v, Got: %v", expected, roots)
+	}
+}
@@ -0,0 +1,55 @@
+package sources
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const targetStatsResetHelp string = "Number of seconds since the stats of the target were started or last cleared, from the elapsed_time (or snapshot_time - start_time) header of its stats file. Only reported by the Lustre versions which have these headers"
+
+// statsResetAge returns the age of the counters of a stats file, from the
+// header lines:
+//
+//	snapshot_time             1510782606.789180921 secs.nsecs
+//	start_time                1510605701.000000000 secs.nsecs
+//	elapsed_time              176905.789180921 secs.nsecs
+//
+// elapsed_time is used when present, older versions only have snapshot_time
+// and no age can be derived.
+func statsResetAge(content string) (float64, bool) {
+	header := map[string]float64{}
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		switch fields[0] {
+		case "snapshot_time", "start_time", "elapsed_time":
+			value, err := strconv.ParseFloat(fields[1], 64)
+			if err != nil {
+				continue
+			}
+			header[fields[0]] = value
+		}
+	}
+	if elapsed, ok := header["elapsed_time"]; ok {
+		return elapsed, true
+	}
+	start, okStart := header["start_time"]
+	snapshot, okSnapshot := header["snapshot_time"]
+	if okStart && okSnapshot && snapshot >= start {
+		return snapshot - start, true
+	}
+	return 0, false
+}
+
+func statsResetMetric(component string, target string, age float64) prometheus.Metric {
+	return prometheus.MustNewConstMetric(
+		newDesc(component, "target_stats_reset_seconds", targetStatsResetHelp, []string{"component", "target"}),
+		prometheus.GaugeValue,
+		age,
+		sanitizeLabels([]string{component, target})...,
+	)
+}
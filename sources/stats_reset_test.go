@@ -0,0 +1,31 @@
+package sources
+
+import (
+	"os"
+	"testing"
+)
+
+func TestStatsResetAge(t *testing.T) {
+	content, err := os.ReadFile("../tests/2.12/proc/fs/lustre/obdfilter/lustrefs-OST0000/stats")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if age, ok := statsResetAge(string(content)); !ok || age != 176905.789180921 {
+		t.Fatalf("Retrieved an unexpected stats age. Expected: %f, Got: %f (found: %t)", 176905.789180921, age, ok)
+	}
+
+	// without elapsed_time the age is derived from start_time
+	age, ok := statsResetAge("snapshot_time             1000.5 secs.nsecs\nstart_time                900.5 secs.nsecs\n")
+	if !ok || age != 100 {
+		t.Fatalf("Retrieved an unexpected stats age. Expected: %f, Got: %f (found: %t)", 100.0, age, ok)
+	}
+
+	// versions with only snapshot_time can't tell
+	content, err = os.ReadFile("../tests/2.12/proc/fs/lustre/obdfilter/lustrefs-OST0002/stats")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if age, ok := statsResetAge(string(content)); ok {
+		t.Fatalf("Expected no stats age without start/elapsed time, Got: %f", age)
+	}
+}
@@ -0,0 +1,49 @@
+package sources
+
+import (
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// SubsystemNamespace inserts the name of the collector (ost, mdt, mgs, mds,
+// client, generic, ldlm, lnet, health) as the subsystem of its metrics, e.g.
+// lustre_ost_stats_total instead of lustre_stats_total. This renames the
+// metrics, the exporter-wide ones (lustre_exporter_*, the collector
+// diagnostics and the extra param metrics) are left unchanged.
+var SubsystemNamespace = false
+
+// subsystemName returns name under subsystem if SubsystemNamespace is set. A
+// name already starting with its subsystem (health_check) is kept as is.
+func subsystemName(subsystem string, name string) string {
+	if !SubsystemNamespace || subsystem == "" || strings.HasPrefix(name, subsystem+"_") {
+		return name
+	}
+	return subsystem + "_" + name
+}
+
+// newDesc returns the Desc of the metric name of the subsystem collector.
+// Every metric of the collectors is built on it, the template ones get their
+// subsystem from subsystemMetricFunc.
+func newDesc(subsystem string, name string, helpText string, labels []string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(Namespace, "", subsystemName(subsystem, name)), helpText, labels, nil)
+}
+
+// subsystemMetricFunc returns a metric function emitting under subsystem.
+func subsystemMetricFunc(metricFunc prometheusType, subsystem string) prometheusType {
+	return func(labels []string, labelValues []string, name string, helpText string, value float64) prometheus.Metric {
+		return metricFunc(labels, labelValues, subsystemName(subsystem, name), helpText, value)
+	}
+}
+
+// applySubsystems wraps, if SubsystemNamespace is set, the metric function of
+// the templates with the one of their collector. It is called once all the
+// templates, unit conversions included, are generated.
+func applySubsystems(metrics []lustreProcMetric) {
+	if !SubsystemNamespace {
+		return
+	}
+	for i, metric := range metrics {
+		metrics[i].metricFunc = subsystemMetricFunc(metric.metricFunc, metric.source)
+	}
+}
@@ -0,0 +1,71 @@
+package sources
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var fqNameRE = regexp.MustCompile(`fqName: "([^"]+)"`)
+
+// collectedNames returns the metric names the sources of the HTTP path emit
+// on the 2.12 fixture with every collector extended, the stateful derived
+// metrics included.
+func collectedNames(t *testing.T) map[string]bool {
+	list := map[string]LustreSource{}
+	for _, name := range Registered() {
+		s, err := NewSource(name, NewConfig())
+		if err != nil {
+			t.Fatal(err)
+		}
+		list[name] = s
+	}
+	metrics, err := collectAll(context.Background(), list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := map[string]bool{}
+	for _, m := range metrics {
+		names[fqNameRE.FindStringSubmatch(m.Desc().String())[1]] = true
+	}
+	return names
+}
+
+func TestSubsystemNamespace(t *testing.T) {
+	defer func(proc, sys string, levels []string) {
+		ProcLocation, SysLocation = proc, sys
+		OstEnabled, MdtEnabled, MgsEnabled, MdsEnabled, ClientEnabled, GenericEnabled, LdlmEnabled, LnetEnabled = levels[0], levels[1], levels[2], levels[3], levels[4], levels[5], levels[6], levels[7]
+		SubsystemNamespace = false
+	}(ProcLocation, SysLocation, []string{OstEnabled, MdtEnabled, MgsEnabled, MdsEnabled, ClientEnabled, GenericEnabled, LdlmEnabled, LnetEnabled})
+	ProcLocation, SysLocation = "../tests/2.12/proc", "../tests/2.12/sys"
+	OstEnabled, MdtEnabled, MgsEnabled, MdsEnabled, ClientEnabled, GenericEnabled, LdlmEnabled, LnetEnabled = extended, extended, extended, extended, extended, extended, extended, extended
+
+	names := collectedNames(t)
+	for _, name := range []string{"lustre_stats_total", "lustre_health_check", "lustre_brw_size_consistent", "lustre_ost_space_imbalance_ratio", "lustre_jobstats_resets_total", "lustre_recovery_time_hard_seconds"} {
+		if !names[name] {
+			t.Fatalf("Retrieved no %s without subsystem", name)
+		}
+	}
+
+	SubsystemNamespace = true
+	names = collectedNames(t)
+	// the template, derived and aggregated metrics are all renamed, a name
+	// already starting with its subsystem is kept
+	for _, name := range []string{"lustre_ost_stats_total", "lustre_health_check", "lustre_ost_brw_size_consistent", "lustre_ost_space_imbalance_ratio", "lustre_ost_jobstats_resets_total", "lustre_mdt_jobstats_resets_total", "lustre_ost_recovery_time_hard_seconds"} {
+		if !names[name] {
+			t.Fatalf("Retrieved no %s with subsystem", name)
+		}
+	}
+	// only the collector diagnostics keep their name
+	diagnostics := map[string]bool{"lustre_parse_unknown_lines_total": true, "lustre_target_metrics_completeness": true}
+	subsystems := regexp.MustCompile(`^lustre_(ost|mdt|mgs|mds|client|generic|ldlm|lnet|health)_`)
+	for name := range names {
+		if !diagnostics[name] && !subsystems.MatchString(name) {
+			t.Fatalf("Retrieved an unexpected name without subsystem: %s", name)
+		}
+		if strings.HasPrefix(name, "lustre_health_health") {
+			t.Fatalf("Retrieved an unexpected doubled subsystem: %s", name)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package sources
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"lustre_exporter/log"
+)
+
+const (
+	summaryTargetsHelp  string = "Number of local OSTs or MDTs"
+	summaryCapacityHelp string = "Capacity in kilobytes of the local OSTs or MDTs"
+	summaryFreeHelp     string = "Free kilobytes of the local OSTs or MDTs"
+	summaryHealthyHelp  string = "Whether the Lustre health_check of the node reports healthy: 1 for healthy, 0 otherwise"
+)
+
+// SummaryCollector collects the overview metrics served apart from the full
+// set: the number, capacity and free space of the local targets and the
+// health of the node. It only reads a handful of single value files whatever
+// the collector levels, so it can be scraped at a high frequency.
+type SummaryCollector struct {
+	// roots are the Lustre trees read by preference, as the procfs source
+	roots []string
+}
+
+// NewSummaryCollector returns a SummaryCollector reading the files below the
+// locations of cfg.
+func NewSummaryCollector(cfg Config) *SummaryCollector {
+	return &SummaryCollector{roots: lustreRoots(cfg)}
+}
+
+type componentSummary struct {
+	targets  float64
+	capacity float64
+	free     float64
+}
+
+type summary struct {
+	components map[string]*componentSummary
+	healthy    float64
+	hasHealth  bool
+}
+
+// summarize reads the space of the local targets from their 'osd-*' device
+// and the health from 'health_check', in the trees of the procfs source.
+func (c *SummaryCollector) summarize() summary {
+	out := summary{components: map[string]*componentSummary{}}
+	paths, _ := globRoots(c.roots, "osd-*/*", filepath.Glob)
+	for _, path := range paths {
+		component := ""
+		switch name := filepath.Base(path); {
+		case strings.Contains(name, "-OST"):
+			component = "ost"
+		case strings.Contains(name, "-MDT"):
+			component = "mdt"
+		default:
+			continue
+		}
+		if out.components[component] == nil {
+			out.components[component] = &componentSummary{}
+		}
+		sum := out.components[component]
+		sum.targets++
+		target, err := filepath.Rel(filepath.Dir(filepath.Dir(path)), path)
+		if err != nil {
+			continue
+		}
+		for file, value := range map[string]*float64{"kbytestotal": &sum.capacity, "kbytesfree": &sum.free} {
+			content, path, err := c.read(filepath.Join(target, file))
+			if err != nil {
+				log.Debugf("skipping %s in the summary: %s", path, err)
+				continue
+			}
+			v, err := strconv.ParseFloat(strings.TrimSpace(string(content)), 64)
+			if err != nil {
+				log.Debugf("skipping %s in the summary: %s", path, err)
+				continue
+			}
+			*value += v
+		}
+	}
+
+	content, path, err := c.read("health_check")
+	switch {
+	case err == nil:
+		out.hasHealth = true
+		if strings.TrimSpace(string(content)) == "healthy" {
+			out.healthy = 1
+		}
+	case !os.IsNotExist(err):
+		log.Debugf("skipping %s in the summary: %s", path, err)
+	}
+	return out
+}
+
+// read reads the file rel from the first root holding it, the path returned
+// is the one read or, if none is there, the procfs one.
+func (c *SummaryCollector) read(rel string) ([]byte, string, error) {
+	var path string
+	var err error
+	for _, root := range c.roots {
+		path = filepath.Join(root, rel)
+		var content []byte
+		if content, err = readProcFile(path); !os.IsNotExist(err) {
+			return content, path, err
+		}
+	}
+	return nil, path, err
+}
+
+// Describe implements prometheus.Collector.
+func (c *SummaryCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(c, ch)
+}
+
+// Collect implements prometheus.Collector.
+func (c *SummaryCollector) Collect(ch chan<- prometheus.Metric) {
+	sum := c.summarize()
+	targetsDesc := prometheus.NewDesc(prometheus.BuildFQName(Namespace, "summary", "targets"), summaryTargetsHelp, []string{"component"}, nil)
+	capacityDesc := prometheus.NewDesc(prometheus.BuildFQName(Namespace, "summary", "capacity_kilobytes"), summaryCapacityHelp, []string{"component"}, nil)
+	freeDesc := prometheus.NewDesc(prometheus.BuildFQName(Namespace, "summary", "free_kilobytes"), summaryFreeHelp, []string{"component"}, nil)
+	for component, s := range sum.components {
+		ch <- prometheus.MustNewConstMetric(targetsDesc, prometheus.GaugeValue, s.targets, component)
+		ch <- prometheus.MustNewConstMetric(capacityDesc, prometheus.GaugeValue, s.capacity, component)
+		ch <- prometheus.MustNewConstMetric(freeDesc, prometheus.GaugeValue, s.free, component)
+	}
+	if sum.hasHealth {
+		ch <- prometheus.MustNewConstMetric(
+			prometheus.NewDesc(prometheus.BuildFQName(Namespace, "summary", "healthy"), summaryHealthyHelp, nil, nil),
+			prometheus.GaugeValue,
+			sum.healthy,
+		)
+	}
+}
@@ -0,0 +1,58 @@
+package sources
+
+import "testing"
+
+func TestSummarize(t *testing.T) {
+	c := NewSummaryCollector(Config{ProcLocation: "../tests/2.12/proc", SysLocation: "../tests/2.12/sys"})
+	sum := c.summarize()
+
+	expected := map[string]componentSummary{
+		"ost": {targets: 4, capacity: 47168367616 + 47168409600 + 31445606400 + 31445606400, free: 47029276672 + 47168398336 + 31445595136 + 31445595136},
+		"mdt": {targets: 1, capacity: 2241506560, free: 2241500416},
+	}
+	if len(sum.components) != len(expected) {
+		t.Fatalf("Retrieved an unexpected number of components. Expected: %d, Got: %d", len(expected), len(sum.components))
+	}
+	for component, e := range expected {
+		if got := sum.components[component]; got == nil || *got != e {
+			t.Fatalf("Retrieved an unexpected summary for %s. Expected: %v, Got: %v", component, e, got)
+		}
+	}
+	if !sum.hasHealth || sum.healthy != 1 {
+		t.Fatalf("Retrieved an unexpected health. Expected: %d, Got: %f (%t)", 1, sum.healthy, sum.hasHealth)
+	}
+
+	empty := NewSummaryCollector(Config{ProcLocation: t.TempDir(), SysLocation: t.TempDir()}).summarize()
+	if len(empty.components) != 0 || empty.hasHealth {
+		t.Fatalf("Retrieved an unexpected summary without Lustre: %v", empty)
+	}
+}
+
+func TestSummarizeSysfsLayout(t *testing.T) {
+	defer func() { PreferSysfs = true }()
+	cfg := Config{ProcLocation: "../tests/sysfs_layout/proc", SysLocation: "../tests/sysfs_layout/sys"}
+
+	// the OST and the health are read from sysfs, the MDT only is in procfs
+	sum := NewSummaryCollector(cfg).summarize()
+	expected := map[string]componentSummary{
+		"ost": {targets: 1, capacity: 2000, free: 1500},
+		"mdt": {targets: 1, capacity: 1000, free: 800},
+	}
+	for component, e := range expected {
+		if got := sum.components[component]; got == nil || *got != e {
+			t.Fatalf("Retrieved an unexpected summary for %s. Expected: %v, Got: %v", component, e, got)
+		}
+	}
+	if !sum.hasHealth || sum.healthy != 1 {
+		t.Fatalf("Retrieved an unexpected health. Expected: %d, Got: %f (%t)", 1, sum.healthy, sum.hasHealth)
+	}
+
+	PreferSysfs = false
+	sum = NewSummaryCollector(cfg).summarize()
+	if got := sum.components["ost"]; got == nil || *got != (componentSummary{targets: 1, free: 9999}) {
+		t.Fatalf("Retrieved an unexpected procfs summary for ost. Got: %v", got)
+	}
+	if !sum.hasHealth || sum.healthy != 0 {
+		t.Fatalf("Retrieved an unexpected procfs health. Expected: %d, Got: %f (%t)", 0, sum.healthy, sum.hasHealth)
+	}
+}
@@ -14,7 +14,6 @@
 package sources
 
 import (
-	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -34,11 +33,12 @@ var (
 )
 
 func init() {
-	Factories["sysfs"] = newLustreSysSource
+	Register("sysfs", func(cfg Config) Collector { return newLustreSysSource(cfg) })
 }
 
 type lustreSysSource struct {
 	lustreProcMetrics []lustreProcMetric
+	cfg               Config
 	basePath          string
 }
 
@@ -48,6 +48,9 @@ func (s *lustreSysSource) generateHealthStatusTemplates(filter string) {
 			{"health_check", "health_check", "Current health status for the indicated instance: " + healthCheckHealthy + " refers to 'healthy', " + healthCheckUnhealthy + " refers to 'unhealthy'", s.gaugeMetric, false, core},
 		},
 	}
+	if HealthStateAge && !s.cfg.stateless {
+		metricMap[""] = append(metricMap[""], healthStateAgeTemplate("health_check", s.gaugeMetric))
+	}
 	for path := range metricMap {
 		for _, item := range metricMap[path] {
 			if filter == extended || item.priorityLevel == core {
@@ -58,12 +61,14 @@ func (s *lustreSysSource) generateHealthStatusTemplates(filter string) {
 	}
 }
 
-func newLustreSysSource() LustreSource {
+func newLustreSysSource(cfg Config) LustreSource {
 	var l lustreSysSource
-	l.basePath = filepath.Join(SysLocation, "fs/lustre")
+	l.cfg = cfg
+	l.basePath = cfg.LustreSysPath()
 	if HealthStatusEnabled != disabled {
 		l.generateHealthStatusTemplates(HealthStatusEnabled)
 	}
+	applySubsystems(l.lustreProcMetrics)
 	return &l
 }
 
@@ -72,7 +77,7 @@ func (s *lustreSysSource) Update(ch chan<- prometheus.Metric) (err error) {
 
 	for _, metric := range s.lustreProcMetrics {
 		directoryDepth = strings.Count(metric.filename, "/")
-		paths, err := filepath.Glob(filepath.Join(s.basePath, metric.path, metric.filename))
+		paths, err := globPaths(filepath.Join(s.basePath, metric.path, metric.filename))
 		if err != nil {
 			return err
 		}
@@ -99,7 +104,7 @@ func (s *lustreSysSource) parseTextFile(nodeType string, metricType string, path
 	if err != nil {
 		return err
 	}
-	fileBytes, err := os.ReadFile(filepath.Clean(path))
+	fileBytes, err := readProcFile(path)
 	if err != nil {
 		return err
 	}
@@ -125,15 +130,10 @@ func (s *lustreSysSource) parseTextFile(nodeType string, metricType string, path
 
 func (s *lustreSysSource) gaugeMetric(labels []string, labelValues []string, name string, helpText string, value float64) prometheus.Metric {
 	return prometheus.MustNewConstMetric(
-		prometheus.NewDesc(
-			prometheus.BuildFQName(Namespace, "", name),
-			helpText,
-			labels,
-			nil,
-		),
+		newDesc("", name, helpText, labels),
 		prometheus.GaugeValue,
 		value,
-		labelValues...,
+		sanitizeLabels(labelValues)...,
 	)
 }
 
@@ -0,0 +1,65 @@
+package sources
+
+import (
+	"path/filepath"
+)
+
+// PreferSysfs makes the procfs source look every file up in the sysfs tree,
+// then in the debugfs one, and fall back to the procfs one. Lustre 2.12+
+// moved most of the tunables to sysfs and the stats (brw_stats, ...) to
+// debugfs. It matches the default of --collector.prefer-sysfs.
+var PreferSysfs = true
+
+// sysfsBasePaths are the sysfs and debugfs trees the procfs source reads
+// before its base path, none when it only reads procfs.
+func sysfsBasePaths(cfg Config) []string {
+	if !PreferSysfs || (cfg.SysLocation == "" && cfg.SysPath == "") {
+		return nil
+	}
+	paths := []string{cfg.LustreSysPath()}
+	if cfg.SysLocation != "" {
+		paths = append(paths, cfg.LustreDebugPath())
+	}
+	return paths
+}
+
+// lustreRoots are the trees of cfg the Lustre files are read from, by
+// preference.
+func lustreRoots(cfg Config) []string {
+	return append(sysfsBasePaths(cfg), cfg.LustreProcPath())
+}
+
+// roots are the trees the files of the source are read from, by preference.
+func (s *lustreProcfsSource) roots() []string {
+	return append(append([]string{}, s.sysBasePaths...), s.basePath)
+}
+
+// globRoots returns the paths matching pattern, relative to the roots, in
+// the first root holding each of them, so a file found in several trees is
+// read once.
+func globRoots(roots []string, pattern string, glob func(string) ([]string, error)) ([]string, error) {
+	var paths []string
+	seen := map[string]bool{}
+	for _, root := range roots {
+		found, err := glob(filepath.Join(root, pattern))
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range found {
+			rel, err := filepath.Rel(root, path)
+			if err != nil || seen[rel] {
+				continue
+			}
+			seen[rel] = true
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
+
+// metricGlobs returns the files of metric, the ones under sysfs then the
+// procfs ones which have no sysfs counterpart, so a file found in both trees
+// is read once.
+func (s *lustreProcfsSource) metricGlobs(metric *lustreProcMetric, glob func(string) ([]string, error)) ([]string, error) {
+	return globRoots(s.roots(), filepath.Join(metric.path, metric.filename), glob)
+}
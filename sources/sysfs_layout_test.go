@@ -0,0 +1,71 @@
+package sources
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func obdfilterTemplate(t *testing.T, s *lustreProcfsSource, promName string) *lustreProcMetric {
+	for i := range s.lustreProcMetrics {
+		if s.lustreProcMetrics[i].path == "obdfilter/*" && s.lustreProcMetrics[i].promName == promName {
+			return &s.lustreProcMetrics[i]
+		}
+	}
+	t.Fatalf("No obdfilter template for %s", promName)
+	return nil
+}
+
+func TestMetricGlobsPreferSysfs(t *testing.T) {
+	defer func() { PreferSysfs = true }()
+
+	const base = "../tests/sysfs_layout"
+	cfg := Config{ProcLocation: filepath.Join(base, "proc"), SysLocation: filepath.Join(base, "sys")}
+	proc := filepath.Join(base, "proc/fs/lustre/obdfilter")
+	sys := filepath.Join(base, "sys/fs/lustre/obdfilter")
+	debug := filepath.Join(base, "sys/kernel/debug/lustre/obdfilter")
+
+	s := newLustreSource(cfg).(*lustreProcfsSource)
+	expected := map[string][]string{
+		// in both trees, sysfs wins, the target only in procfs is still read
+		"free_kilobytes": {filepath.Join(sys, "lustrefs-OST0000/kbytesfree"), filepath.Join(proc, "lustrefs-OST0001/kbytesfree")},
+		// only in sysfs
+		"capacity_kilobytes": {filepath.Join(sys, "lustrefs-OST0000/kbytestotal")},
+		// only in procfs
+		"inodes_free": {filepath.Join(proc, "lustrefs-OST0000/filesfree")},
+		// only in debugfs
+		"disk_io_total": {filepath.Join(debug, "lustrefs-OST0001/brw_stats")},
+	}
+	for promName, want := range expected {
+		paths, err := s.metricGlobs(obdfilterTemplate(t, s, promName), filepath.Glob)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(paths, want) {
+			t.Fatalf("Retrieved unexpected %s files. Expected: %v, Got: %v", promName, want, paths)
+		}
+	}
+
+	// OST0000 read its kbytestotal from sysfs, only OST0001 misses it, under
+	// the first tree holding its directory
+	metric := obdfilterTemplate(t, s, "capacity_kilobytes")
+	read := map[string]bool{filepath.Join(sys, "lustrefs-OST0000/kbytestotal"): true}
+	missing, err := missingExpectedFiles(s.roots(), metric, read)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{filepath.Join(debug, "lustrefs-OST0001/kbytestotal")}; !reflect.DeepEqual(missing, want) {
+		t.Fatalf("Retrieved unexpected missing files. Expected: %v, Got: %v", want, missing)
+	}
+
+	// the procfs layout alone is read as before
+	PreferSysfs = false
+	s = newLustreSource(cfg).(*lustreProcfsSource)
+	paths, err := s.metricGlobs(obdfilterTemplate(t, s, "free_kilobytes"), filepath.Glob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{filepath.Join(proc, "lustrefs-OST0000/kbytesfree"), filepath.Join(proc, "lustrefs-OST0001/kbytesfree")}; !reflect.DeepEqual(paths, want) {
+		t.Fatalf("Retrieved unexpected free_kilobytes files. Expected: %v, Got: %v", want, paths)
+	}
+}
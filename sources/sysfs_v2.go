@@ -31,6 +31,10 @@ func (ctx *sysfsV2Ctx)release(){
 	ctx.fr.release()
 }
 
+func (ctx *sysfsV2Ctx)filesRead() uint64 {
+	return ctx.fr.filesRead()
+}
+
 func (ctx *sysfsV2Ctx)update(ch chan<- prometheus.Metric) {
 	for _, m := range ctx.metrics {
 		ch <- m
@@ -45,7 +49,7 @@ func (ctx *sysfsV2Ctx)collect() (err error){
 
 	for _, metric := range s.lustreProcMetrics {
 		directoryDepth = strings.Count(metric.filename, "/")
-		paths, err := filepath.Glob(filepath.Join(s.basePath, metric.path, metric.filename))
+		paths, err := globPaths(filepath.Join(s.basePath, metric.path, metric.filename))
 		if err != nil {
 			return err
 		}
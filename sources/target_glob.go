@@ -0,0 +1,44 @@
+package sources
+
+import (
+	"fmt"
+	"path"
+)
+
+// TargetGlobs holds the shell patterns set by ApplyTargetGlobs (e.g.
+// "lustrefs-OST*", "*-OST0004"). When not empty, only the OSTs and MDTs whose
+// name matches one of them are collected.
+var TargetGlobs []string
+
+// ApplyTargetGlobs validates the patterns of --collector.target-glob and sets
+// TargetGlobs.
+func ApplyTargetGlobs(patterns []string) error {
+	globs := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		if pattern == "" {
+			continue
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("%q: %w", pattern, err)
+		}
+		globs = append(globs, pattern)
+	}
+	TargetGlobs = globs
+	return nil
+}
+
+// targetSelected tells if the target of component is to be collected. The
+// patterns are matched against the whole target name like lctl does, so
+// "OST0004" does not match "lustrefs-OST0004" while a regexp would. Only
+// the OSTs and MDTs are filtered.
+func targetSelected(component string, target string) bool {
+	if len(TargetGlobs) == 0 || (component != "ost" && component != "mdt") {
+		return true
+	}
+	for _, pattern := range TargetGlobs {
+		if ok, _ := path.Match(pattern, target); ok {
+			return true
+		}
+	}
+	return false
+}
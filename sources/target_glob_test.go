@@ -0,0 +1,78 @@
+package sources
+
+import (
+	"path/filepath"
+	"regexp"
+	"sort"
+	"testing"
+)
+
+func TestTargetGlobs(t *testing.T) {
+	defer func(prev []string) { TargetGlobs = prev }(TargetGlobs)
+
+	paths, err := filepath.Glob("../tests/2.12/proc/fs/lustre/obdfilter/*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var targets []string
+	for _, path := range paths {
+		targets = append(targets, filepath.Base(path))
+	}
+	selected := func(component string) (out []string) {
+		for _, target := range targets {
+			if targetSelected(component, target) {
+				out = append(out, target)
+			}
+		}
+		sort.Strings(out)
+		return out
+	}
+
+	for _, item := range []struct {
+		glob     string
+		regex    string
+		expected []string
+	}{
+		{"lustrefs-OST*", "lustrefs-OST", []string{"lustrefs-OST0000", "lustrefs-OST0002", "lustrefs-OST0004", "lustrefs-OST0006"}},
+		{"*-OST0004", "-OST0004$", []string{"lustrefs-OST0004"}},
+		{"*-OST000[02]", "-OST000[02]$", []string{"lustrefs-OST0000", "lustrefs-OST0002"}},
+		{"lustrefs-OST000?", "^lustrefs-OST000.$", []string{"lustrefs-OST0000", "lustrefs-OST0002", "lustrefs-OST0004", "lustrefs-OST0006"}},
+	} {
+		if err := ApplyTargetGlobs([]string{item.glob}); err != nil {
+			t.Fatal(err)
+		}
+		got := selected("ost")
+		if len(got) != len(item.expected) {
+			t.Fatalf("Retrieved unexpected targets for %q. Expected: %v, Got: %v", item.glob, item.expected, got)
+		}
+		re := regexp.MustCompile(item.regex)
+		for i, target := range got {
+			if target != item.expected[i] || !re.MatchString(target) {
+				t.Fatalf("Retrieved unexpected targets for %q. Expected: %v, Got: %v", item.glob, item.expected, got)
+			}
+		}
+	}
+
+	// a glob matches the whole name, a regexp any part of it
+	if err := ApplyTargetGlobs([]string{"OST0004"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := selected("ost"); len(got) != 0 {
+		t.Fatalf("Retrieved unexpected targets for %q. Expected: %v, Got: %v", "OST0004", []string{}, got)
+	}
+	if !regexp.MustCompile("OST0004").MatchString("lustrefs-OST0004") {
+		t.Fatal("Expected the regexp to match a part of the name")
+	}
+
+	// only the OSTs and MDTs are filtered
+	if !targetSelected("client", "lustrefs-ffff88105db50000") || targetSelected("mdt", "lustrefs-MDT0000") {
+		t.Fatal("Expected the globs to only filter the OSTs and MDTs")
+	}
+
+	if err := ApplyTargetGlobs([]string{"lustrefs-OST[0"}); err == nil {
+		t.Fatal("Expected an invalid pattern to be rejected")
+	}
+	if err := ApplyTargetGlobs(nil); err != nil || !targetSelected("ost", "lustrefs-OST0004") {
+		t.Fatalf("Expected every target to be selected without globs, Got: %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+package sources
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ThroughputRates enables lustre_write_bytes_rate, the write throughput of
+// every target computed by the exporter between two of its scrapes.
+var ThroughputRates = false
+
+const writeBytesRateHelp string = "Bytes written per second by the target between the two last scrapes of the exporter, delta(write_bytes_total) / delta(scrape time). Computed exporter side, a counter reset counts from zero like rate() does"
+
+// writeBytesTotals holds the write_bytes_total of every target of a scrape.
+type writeBytesTotals map[targetKey]float64
+
+func (w writeBytesTotals) add(component string, target string, name string, value float64) {
+	if !ThroughputRates || name != "write_bytes_total" || target == "" {
+		return
+	}
+	w[targetKey{component, target}] = value
+}
+
+type throughputSample struct {
+	value float64
+	at    time.Time
+}
+
+// throughputTracker remembers the counters of the previous scrape.
+type throughputTracker struct {
+	mu   sync.Mutex
+	last map[targetKey]throughputSample
+}
+
+var insWriteThroughput = &throughputTracker{
+	last: map[targetKey]throughputSample{},
+}
+
+// observe returns the rate of every target which was also seen in the
+// previous scrape. Targets missing from a scrape are forgotten.
+func (t *throughputTracker) observe(current writeBytesTotals, now time.Time) map[targetKey]float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	out := map[targetKey]float64{}
+	next := make(map[targetKey]throughputSample, len(current))
+	for key, value := range current {
+		next[key] = throughputSample{value, now}
+		prev, ok := t.last[key]
+		if !ok {
+			continue
+		}
+		elapsed := now.Sub(prev.at).Seconds()
+		if elapsed <= 0 {
+			continue
+		}
+		delta := value - prev.value
+		if delta < 0 {
+			// the counter was reset in between
+			delta = value
+		}
+		out[key] = delta / elapsed
+	}
+	t.last = next
+	return out
+}
+
+func writeBytesRateMetric(key targetKey, rate float64) prometheus.Metric {
+	return prometheus.MustNewConstMetric(
+		newDesc(key.component, "write_bytes_rate", writeBytesRateHelp, []string{"component", "target"}),
+		prometheus.GaugeValue,
+		rate,
+		sanitizeLabels([]string{key.component, key.target})...,
+	)
+}
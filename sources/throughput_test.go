@@ -0,0 +1,45 @@
+package sources
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWriteThroughput(t *testing.T) {
+	defer func(prev bool) { ThroughputRates = prev }(ThroughputRates)
+	ThroughputRates = true
+
+	tracker := &throughputTracker{last: map[targetKey]throughputSample{}}
+	ost := targetKey{"ost", "lustrefs-OST0000"}
+	start := time.Unix(1510782606, 0)
+
+	scrape := func(value float64) writeBytesTotals {
+		w := writeBytesTotals{}
+		w.add("ost", "lustrefs-OST0000", "write_bytes_total", value)
+		w.add("ost", "lustrefs-OST0000", "read_bytes_total", 1)
+		return w
+	}
+
+	if rates := tracker.observe(scrape(16552048697344), start); len(rates) != 0 {
+		t.Fatalf("Retrieved unexpected rates on the first scrape. Expected: none, Got: %v", rates)
+	}
+	// 3000 MB in 5 minutes
+	rates := tracker.observe(scrape(16552048697344+3000*1000*1000), start.Add(5*time.Minute))
+	if len(rates) != 1 || rates[ost] != 10*1000*1000 {
+		t.Fatalf("Retrieved an unexpected write rate. Expected: %f, Got: %v", 10e6, rates)
+	}
+	// after a reset the counter counts from zero
+	rates = tracker.observe(scrape(600), start.Add(5*time.Minute+time.Minute))
+	if rates[ost] != 10 {
+		t.Fatalf("Retrieved an unexpected write rate after a reset. Expected: %f, Got: %v", 10.0, rates)
+	}
+	// a scrape at the same time can't be divided
+	if rates = tracker.observe(scrape(900), start.Add(5*time.Minute+time.Minute)); len(rates) != 0 {
+		t.Fatalf("Retrieved unexpected rates without elapsed time. Expected: none, Got: %v", rates)
+	}
+
+	ThroughputRates = false
+	if w := scrape(1); len(w) != 0 {
+		t.Fatalf("Expected no write totals when disabled, Got: %v", w)
+	}
+}
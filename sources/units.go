@@ -0,0 +1,70 @@
+package sources
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// NormalizeUnits adds a byte-denominated copy of the few metrics Lustre
+// reports in kilobytes or megabytes, next to the originals.
+var NormalizeUnits = false
+
+type unitConversion struct {
+	promName string
+	helpText string
+	factor   float64
+}
+
+// unitConversions maps the kilobyte and megabyte metrics to their bytes
+// equivalent.
+var unitConversions = map[string]unitConversion{
+	"available_kilobytes":               {"available_bytes", "Number of bytes readily available in the pool", 1024},
+	"free_kilobytes":                    {"free_bytes", "Number of bytes allocated to the pool", 1024},
+	"capacity_kilobytes":                {"capacity_bytes", "Capacity of the pool in bytes", 1024},
+	"brw_size_megabytes":                {"brw_size_bytes", "Block read/write size in bytes", 1024 * 1024},
+	"client_max_read_ahead_mb":          {"client_max_read_ahead_bytes", "Maximum number of bytes the client reads ahead, across all files (max_read_ahead_mb)", 1024 * 1024},
+	"client_max_read_ahead_per_file_mb": {"client_max_read_ahead_per_file_bytes", "Maximum number of bytes the client reads ahead for a single file (max_read_ahead_per_file_mb)", 1024 * 1024},
+	"client_max_read_ahead_whole_mb":    {"client_max_read_ahead_whole_bytes", "Maximum size in bytes of a file the client reads in its entirety (max_read_ahead_whole_mb)", 1024 * 1024},
+	"debug_megabytes":                   {"debug_bytes", "Maximum buffer size in bytes for the LNET debug messages", 1024 * 1024},
+}
+
+// scaleMetricFunc returns a metric function emitting the value multiplied by
+// factor.
+func scaleMetricFunc(metricFunc prometheusType, factor float64) prometheusType {
+	return func(labels []string, labelValues []string, name string, helpText string, value float64) prometheus.Metric {
+		return metricFunc(labels, labelValues, name, helpText, value*factor)
+	}
+}
+
+// appendUnitConversions adds, if NormalizeUnits is set, a bytes template for
+// every template of metrics which has a conversion, but the osd duplicates of
+// the obdfilter ones. The file is read once more per scrape for it (from the
+// cache in v2).
+func appendUnitConversions(metrics []lustreProcMetric) []lustreProcMetric {
+	if !NormalizeUnits {
+		return metrics
+	}
+	for i, metric := range metrics {
+		conv, ok := unitConversions[metric.promName]
+		if !ok || osdDuplicate(&metrics[i], metrics) {
+			continue
+		}
+		metrics = append(metrics, newLustreProcMetric(metric.filename, conv.promName, metric.source, metric.path, conv.helpText, metric.hasMultipleVals, scaleMetricFunc(metric.metricFunc, conv.factor)))
+	}
+	return metrics
+}
+
+// osdDuplicate reports whether metric is an osd-*/*OST* template of a file and
+// metric also read by an obdfilter/* template, obdfilter showing the values of
+// the osd of its OST. The series derived from them are only built from the
+// obdfilter one, which every OSS has, so they are not emitted twice.
+func osdDuplicate(metric *lustreProcMetric, metrics []lustreProcMetric) bool {
+	if metric.path != "osd-*/*OST*" {
+		return false
+	}
+	for _, other := range metrics {
+		if other.path == "obdfilter/*" && other.filename == metric.filename && other.promName == metric.promName {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package sources
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestNormalizeUnits(t *testing.T) {
+	defer func(prev bool) { NormalizeUnits = prev }(NormalizeUnits)
+
+	var gotName string
+	var gotValue float64
+	capture := func(labels []string, labelValues []string, name string, helpText string, value float64) prometheus.Metric {
+		gotName, gotValue = name, value
+		return nil
+	}
+	templates := []lustreProcMetric{
+		newLustreProcMetric("brw_size", "brw_size_megabytes", "ost", "obdfilter/*", "Block read/write size in megabytes", false, capture),
+		newLustreProcMetric("blocksize", "blocksize_bytes", "ost", "obdfilter/*", "Filesystem block size in bytes", false, capture),
+	}
+
+	NormalizeUnits = false
+	if out := appendUnitConversions(templates); len(out) != len(templates) {
+		t.Fatalf("Retrieved an unexpected number of templates. Expected: %d, Got: %d", len(templates), len(out))
+	}
+
+	NormalizeUnits = true
+	out := appendUnitConversions(templates)
+	if len(out) != len(templates)+1 {
+		t.Fatalf("Retrieved an unexpected number of templates. Expected: %d, Got: %d", len(templates)+1, len(out))
+	}
+	converted := out[len(out)-1]
+	if converted.promName != "brw_size_bytes" || converted.filename != "brw_size" {
+		t.Fatalf("Retrieved an unexpected template. Expected: %s from %s, Got: %s from %s", "brw_size_bytes", "brw_size", converted.promName, converted.filename)
+	}
+
+	content, err := os.ReadFile("../tests/2.12/proc/fs/lustre/obdfilter/lustrefs-OST0000/brw_size")
+	if err != nil {
+		t.Fatal(err)
+	}
+	megabytes, err := strconv.ParseFloat(strings.TrimSpace(string(content)), 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	converted.metricFunc(nil, nil, converted.promName, converted.helpText, megabytes)
+	if gotName != "brw_size_bytes" || gotValue != megabytes*1048576 {
+		t.Fatalf("Retrieved an unexpected %s. Expected: %f, Got: %f", gotName, megabytes*1048576, gotValue)
+	}
+}
+
+func TestNormalizeUnitsOsdDuplicate(t *testing.T) {
+	defer func(prev bool) { NormalizeUnits = prev }(NormalizeUnits)
+	NormalizeUnits = true
+
+	templates := []lustreProcMetric{
+		newLustreProcMetric("kbytesfree", "free_kilobytes", "ost", "osd-*/*OST*", "", false, nil),
+		newLustreProcMetric("kbytesfree", "free_kilobytes", "ost", "obdfilter/*", "", false, nil),
+		newLustreProcMetric("kbytesfree", "free_kilobytes", "mdt", "osd-*/*-MDT*", "", false, nil),
+	}
+	// the obdfilter and the MDT ones are converted, not the osd copy of the OST
+	out := appendUnitConversions(templates)
+	if len(out) != len(templates)+2 {
+		t.Fatalf("Retrieved an unexpected number of templates. Expected: %d, Got: %d", len(templates)+2, len(out))
+	}
+	for _, converted := range out[len(templates):] {
+		if converted.path == "osd-*/*OST*" {
+			t.Fatalf("Retrieved an unexpected conversion of the osd duplicate of %s", converted.promName)
+		}
+	}
+}
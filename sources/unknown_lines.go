@@ -0,0 +1,177 @@
+package sources
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const parseUnknownLinesHelp string = "Total number of lines the parser did not recognize in structured files, a growing value usually means the Lustre version added fields the exporter does not know about yet"
+
+// knownStatsKeys lists every counter name we know to appear in the 'stats' and
+// 'md_stats' files we parse, exported or not.
+var knownStatsKeys = map[string]bool{
+	"snapshot_time": true, "start_time": true, "elapsed_time": true,
+	"read_bytes": true, "write_bytes": true,
+	"open": true, "close": true, "mknod": true, "link": true, "unlink": true,
+	"mkdir": true, "rmdir": true, "rename": true, "samedir_rename": true, "crossdir_rename": true,
+	"getattr": true, "setattr": true, "getxattr": true, "getxattr_hits": true, "setxattr": true,
+	"removexattr": true, "listxattr": true, "statfs": true, "sync": true, "fsync": true,
+	"seek": true, "readdir": true, "truncate": true, "alloc_inode": true, "inode_permission": true,
+	"create": true, "destroy": true, "punch": true, "get_info": true, "set_info": true,
+	"set_info_async": true, "quotactl": true, "connect": true, "reconnect": true, "disconnect": true,
+	"ping": true, "preprw": true, "commitrw": true, "flock": true, "mmap": true,
+	"read": true, "write": true, "ioctl": true, "fallocate": true,
+	"intent_getattr_async": true, "intent_lock": true, "read_page": true, "revalidate_lock": true,
+}
+
+// knownHistogramKeys lists the 'key: value' lines and the section titles of
+// the 'brw_stats' and 'rpc_stats' files.
+var knownHistogramKeys = []string{
+	"snapshot_time", "start_time", "elapsed_time",
+	"read RPCs in flight", "write RPCs in flight", "pending write pages", "pending read pages",
+	"pages per bulk r/w", "discontiguous pages", "discontiguous blocks", "disk fragmented I/Os",
+	"disk I/Os in flight", "I/O time", "disk I/O size", "block maps msec",
+	"pages per rpc", "rpcs in flight", "offset",
+}
+
+// countUnknownStatsLines returns the number of lines of a 'stats' file whose
+// counter name is not in knownStatsKeys.
+func countUnknownStatsLines(content string) int {
+	unknown := 0
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if strings.HasPrefix(fields[0], reintPrefix) {
+			continue
+		}
+		if !knownStatsKeys[normalizeOperation(strings.TrimSuffix(fields[0], opErrorsSuffix))] {
+			unknown++
+		}
+	}
+	return unknown
+}
+
+// countUnknownHistogramLines returns the number of lines of a 'brw_stats' or
+// 'rpc_stats' file which are neither blank, a known 'key: value' line, a
+// column header, a known section title nor a bucket row.
+func countUnknownHistogramLines(content string) int {
+	unknown := 0
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == "read" && fields[len(fields)-1] == "write" {
+			continue // column header
+		}
+		if isHistogramBucket(fields) {
+			continue
+		}
+		trimmed := strings.TrimSpace(line)
+		known := false
+		for _, key := range knownHistogramKeys {
+			if strings.HasPrefix(trimmed, key) {
+				known = true
+				break
+			}
+		}
+		if !known {
+			unknown++
+		}
+	}
+	return unknown
+}
+
+// isHistogramBucket matches rows like "1K:  0   0 100   | 4059303  94 100".
+func isHistogramBucket(fields []string) bool {
+	if !strings.HasSuffix(fields[0], ":") || len(fields) < 2 {
+		return false
+	}
+	for _, field := range fields[1:] {
+		if field == "|" {
+			continue
+		}
+		if _, err := strconv.ParseFloat(field, 64); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// unknownLinesKey is the label set of lustre_parse_unknown_lines_total: the
+// component and the name of the parsed file (stats, brw_stats, ...), so the
+// series don't grow with the number of targets.
+type unknownLinesKey struct {
+	component string
+	file      string
+}
+
+// scrapeUnknownLines holds the unknown lines found in one scrape, each file
+// being counted once no matter how many templates read it.
+type scrapeUnknownLines struct {
+	paths  map[string]bool
+	counts map[unknownLinesKey]int
+}
+
+func newScrapeUnknownLines() *scrapeUnknownLines {
+	return &scrapeUnknownLines{paths: map[string]bool{}, counts: map[unknownLinesKey]int{}}
+}
+
+func (u *scrapeUnknownLines) add(component string, path string, content string, count func(string) int) {
+	if u.paths[path] {
+		return
+	}
+	u.paths[path] = true
+	u.counts[unknownLinesKey{component, filepath.Base(path)}] += count(content)
+}
+
+// metrics adds the lines of the scrape to the totals and returns the ones of
+// the files read in this scrape with unknown lines, the files no longer read
+// are not reported.
+func (u *scrapeUnknownLines) metrics() []prometheus.Metric {
+	var out []prometheus.Metric
+	for key, n := range u.counts {
+		if total := insUnknownLines.add(key, n); total > 0 {
+			out = append(out, unknownLinesMetric(key, total))
+		}
+	}
+	return out
+}
+
+// unknownLinesCounter keeps the totals across scrapes.
+type unknownLinesCounter struct {
+	mu     sync.Mutex
+	counts map[unknownLinesKey]float64
+}
+
+var insUnknownLines = &unknownLinesCounter{
+	counts: map[unknownLinesKey]float64{},
+}
+
+func (c *unknownLinesCounter) add(key unknownLinesKey, n int) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.counts[key] += float64(n)
+	return c.counts[key]
+}
+
+func unknownLinesMetric(key unknownLinesKey, total float64) prometheus.Metric {
+	return prometheus.MustNewConstMetric(
+		prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "", "parse_unknown_lines_total"),
+			parseUnknownLinesHelp,
+			[]string{"component", "file"},
+			nil,
+		),
+		prometheus.CounterValue,
+		total,
+		key.component, key.file,
+	)
+}
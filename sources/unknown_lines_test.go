@@ -0,0 +1,104 @@
+package sources
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCountUnknownLines(t *testing.T) {
+	statsText := `snapshot_time             1556000000.123456789 secs.usecs
+open                      15 samples [reqs]
+close                     14 samples [reqs]
+getattr                   8 samples [reqs]
+shiny_new_op              3 samples [reqs]
+`
+	if got := countUnknownStatsLines(statsText); got != 1 {
+		t.Fatalf("Retrieved an unexpected number of unknown stats lines. Expected: %d, Got: %d", 1, got)
+	}
+
+	brwText := `snapshot_time:         1556000000.123456789 (secs.usecs)
+
+                           read      |     write
+pages per bulk r/w     rpcs  % cum % |  rpcs        % cum %
+1:                       0   0   0   |    0   0   0
+256:                     5 100 100   |   12 100 100
+
+                           read      |     write
+disk I/O latency       ios   % cum % |  ios         % cum %
+1:                       0   0   0   |    0   0   0
+`
+	if got := countUnknownHistogramLines(brwText); got != 1 {
+		t.Fatalf("Retrieved an unexpected number of unknown brw_stats lines. Expected: %d, Got: %d", 1, got)
+	}
+}
+
+func TestCountUnknownLinesFixtures(t *testing.T) {
+	for _, pattern := range []string{
+		"../tests/2.12/proc/fs/lustre/obdfilter/*/stats",
+		"../tests/2.12/proc/fs/lustre/mdt/*/md_stats",
+		"../tests/2.12/proc/fs/lustre/llite/*/stats",
+		"../tests/2.12/proc/fs/lustre/obdfilter/*/brw_stats",
+		"../tests/2.12/proc/fs/lustre/osc/*/rpc_stats",
+	} {
+		paths, err := filepath.Glob(pattern)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, path := range paths {
+			content, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			count := countUnknownStatsLines
+			if filepath.Base(path) != "stats" && filepath.Base(path) != "md_stats" {
+				count = countUnknownHistogramLines
+			}
+			if got := count(string(content)); got != 0 {
+				t.Fatalf("Retrieved unknown lines in fixture %s. Expected: %d, Got: %d", path, 0, got)
+			}
+		}
+	}
+}
+
+func TestUnknownLinesCounterIncrements(t *testing.T) {
+	c := &unknownLinesCounter{counts: map[unknownLinesKey]float64{}}
+	key := unknownLinesKey{"ost", "stats"}
+
+	if got := c.add(key, 1); got != 1 {
+		t.Fatalf("Retrieved an unexpected counter value. Expected: %v, Got: %v", 1, got)
+	}
+	if got := c.add(key, 1); got != 2 {
+		t.Fatalf("Retrieved an unexpected counter value. Expected: %v, Got: %v", 2, got)
+	}
+	if got := c.add(key, 0); got != 2 {
+		t.Fatalf("Retrieved an unexpected counter value. Expected: %v, Got: %v", 2, got)
+	}
+}
+
+func TestScrapeUnknownLines(t *testing.T) {
+	defer func(prev *unknownLinesCounter) { insUnknownLines = prev }(insUnknownLines)
+	insUnknownLines = &unknownLinesCounter{counts: map[unknownLinesKey]float64{}}
+
+	one := func(string) int { return 1 }
+	none := func(string) int { return 0 }
+	lines := newScrapeUnknownLines()
+	// two templates of the same file, two targets of the same file name
+	lines.add("ost", "/proc/fs/lustre/obdfilter/lustrefs-OST0000/stats", "", one)
+	lines.add("ost", "/proc/fs/lustre/obdfilter/lustrefs-OST0000/stats", "", one)
+	lines.add("ost", "/proc/fs/lustre/obdfilter/lustrefs-OST0001/stats", "", one)
+	lines.add("ost", "/proc/fs/lustre/obdfilter/lustrefs-OST0000/brw_stats", "", none)
+
+	metrics := lines.metrics()
+	if len(metrics) != 1 {
+		t.Fatalf("Retrieved an unexpected number of metrics. Expected: %d, Got: %d", 1, len(metrics))
+	}
+	if got := insUnknownLines.counts[unknownLinesKey{"ost", "stats"}]; got != 2 {
+		t.Fatalf("Retrieved an unexpected counter value. Expected: %v, Got: %v", 2, got)
+	}
+
+	// a scrape not reading the file anymore doesn't report it
+	if metrics := newScrapeUnknownLines().metrics(); len(metrics) != 0 {
+		t.Fatalf("Retrieved an unexpected number of metrics. Expected: %d, Got: %d", 0, len(metrics))
+	}
+}
@@ -0,0 +1,122 @@
+package sources
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const targetInfoHelp string = "Information about the OST or MDT target, always 1, its uuid label is read from the uuid file of the target. Join on component and target to follow a series across target renames"
+
+// AddUUIDLabel exposes the uuid of the OST and MDT targets, read from their
+// 'uuid' file, as a 'uuid' label of lustre_target_info. The label is kept
+// off the metrics themselves, the same families are also emitted by targets
+// without uuid file (osd of the MGS and MDS, ...) and from other sources.
+var AddUUIDLabel = false
+
+// targetUUIDs caches the uuid of the OST and MDT targets seen by the metrics
+// of a collection, it is emptied at the start of every collection so a
+// renamed or removed target is picked up on the next scrape.
+type targetUUIDs struct {
+	mu       sync.Mutex
+	basePath string
+	uuids    map[targetKey]string
+}
+
+func newTargetUUIDs(basePath string) *targetUUIDs {
+	return &targetUUIDs{basePath: basePath, uuids: map[targetKey]string{}}
+}
+
+func (t *targetUUIDs) reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.uuids = map[targetKey]string{}
+}
+
+// see records a target of component, reading its uuid the first time it is
+// seen in the collection. A target without readable uuid file is not kept.
+func (t *targetUUIDs) see(component string, target string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := targetKey{component, target}
+	if _, ok := t.uuids[key]; ok {
+		return
+	}
+	dir := ""
+	if strings.Contains(target, "-OST") {
+		dir = "obdfilter"
+	} else if strings.Contains(target, "-MDT") {
+		dir = "mdt"
+	}
+	uuid := ""
+	if dir != "" {
+		if content, err := readProcFile(filepath.Join(t.basePath, dir, target, "uuid")); err == nil {
+			uuid = strings.TrimSpace(string(content))
+		}
+	}
+	t.uuids[key] = uuid
+}
+
+// metrics returns the lustre_target_info of the targets seen with a uuid.
+func (t *targetUUIDs) metrics() []prometheus.Metric {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	keys := make([]targetKey, 0, len(t.uuids))
+	for key, uuid := range t.uuids {
+		if uuid != "" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].component != keys[j].component {
+			return keys[i].component < keys[j].component
+		}
+		return keys[i].target < keys[j].target
+	})
+	desc := prometheus.NewDesc(prometheus.BuildFQName(Namespace, "target", "info"), targetInfoHelp, []string{"component", "target", "uuid"}, nil)
+	out := make([]prometheus.Metric, 0, len(keys))
+	for _, key := range keys {
+		out = append(out, prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, 1, key.component, key.target, t.uuids[key]))
+	}
+	return out
+}
+
+// uuidMetricFunc returns a metric function recording the component and target
+// labels of the metrics it builds in uuids, the labels are left unchanged.
+func uuidMetricFunc(metricFunc prometheusType, uuids *targetUUIDs) prometheusType {
+	return func(labels []string, labelValues []string, name string, helpText string, value float64) prometheus.Metric {
+		component, target := "", ""
+		for i, label := range labels {
+			switch label {
+			case "component":
+				component = labelValues[i]
+			case "target":
+				target = labelValues[i]
+			}
+		}
+		if component != "" && target != "" {
+			uuids.see(component, target)
+		}
+		return metricFunc(labels, labelValues, name, helpText, value)
+	}
+}
+
+// appendUUIDLabels wraps, if AddUUIDLabel is set, the metric function of the
+// OST and MDT templates to find the targets to report in lustre_target_info.
+func (s *lustreProcfsSource) appendUUIDLabels() {
+	if !AddUUIDLabel {
+		return
+	}
+	s.uuids = newTargetUUIDs(s.basePath)
+	for i, metric := range s.lustreProcMetrics {
+		if metric.source != "ost" && metric.source != "mdt" {
+			continue
+		}
+		s.lustreProcMetrics[i].metricFunc = uuidMetricFunc(metric.metricFunc, s.uuids)
+	}
+}
@@ -0,0 +1,67 @@
+package sources
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestUUIDLabel(t *testing.T) {
+	var gotLabels []string
+	record := func(labels []string, labelValues []string, name string, helpText string, value float64) prometheus.Metric {
+		gotLabels = labels
+		return nil
+	}
+	uuids := newTargetUUIDs("../tests/2.12/proc/fs/lustre")
+	metricFunc := uuidMetricFunc(record, uuids)
+
+	expected := map[string]string{}
+	for target, file := range map[string]string{
+		"lustrefs-OST0000": "../tests/2.12/proc/fs/lustre/obdfilter/lustrefs-OST0000/uuid",
+		"lustrefs-MDT0000": "../tests/2.12/proc/fs/lustre/mdt/lustrefs-MDT0000/uuid",
+	} {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected[target] = strings.TrimSpace(string(content))
+		component := "ost"
+		if strings.Contains(target, "-MDT") {
+			component = "mdt"
+		}
+		metricFunc([]string{"component", "target"}, []string{component, target}, "free_kilobytes", "", 1)
+		// the metric itself keeps its labels
+		if !reflect.DeepEqual(gotLabels, []string{"component", "target"}) {
+			t.Fatalf("Retrieved unexpected labels. Expected: %v, Got: %v", []string{"component", "target"}, gotLabels)
+		}
+	}
+	// the per filesystem metrics have no target, a target without uuid file
+	// has no info
+	metricFunc([]string{"fs"}, []string{"lustrefs"}, "default_stripe_count", "", 1)
+	metricFunc([]string{"component", "target"}, []string{"ost", "lustrefs-OST0009"}, "free_kilobytes", "", 1)
+
+	got := map[string]string{}
+	for _, m := range uuids.metrics() {
+		var d dto.Metric
+		if err := m.Write(&d); err != nil {
+			t.Fatal(err)
+		}
+		labels := map[string]string{}
+		for _, l := range d.GetLabel() {
+			labels[l.GetName()] = l.GetValue()
+		}
+		got[labels["target"]] = labels["uuid"]
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Retrieved unexpected target uuids. Expected: %v, Got: %v", expected, got)
+	}
+
+	uuids.reset()
+	if n := len(uuids.metrics()); n != 0 {
+		t.Fatalf("Retrieved an unexpected number of target infos after a reset. Expected: %d, Got: %d", 0, n)
+	}
+}
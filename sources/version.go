@@ -0,0 +1,65 @@
+package sources
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LustreVersion returns the major.minor version of the loaded Lustre modules,
+// e.g. "2.12". It reads 'version' in the sysfs tree (Lustre 2.10+) and falls
+// back to the one of the procfs tree.
+func LustreVersion() (string, error) {
+	var lastErr error
+	cfg := NewConfig()
+	for _, path := range []string{
+		filepath.Join(cfg.LustreSysPath(), "version"),
+		filepath.Join(cfg.LustreProcPath(), "version"),
+	} {
+		content, err := os.ReadFile(filepath.Clean(path))
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return parseLustreVersion(string(content))
+	}
+	return "", lastErr
+}
+
+// parseLustreVersion accepts both the sysfs format ("2.15.3") and the older
+// procfs one ("lustre: 2.7.19.8\nkernel: ...\nbuild: ...").
+func parseLustreVersion(content string) (string, error) {
+	version := ""
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) == 2 && fields[0] == "lustre:" {
+			version = fields[1]
+			break
+		}
+		if len(fields) == 1 && version == "" {
+			version = fields[0]
+		}
+	}
+
+	parts := strings.SplitN(version, ".", 3)
+	if len(parts) < 2 || !isDigits(parts[0]) || !isDigits(parts[1]) {
+		return "", fmt.Errorf("unable to parse lustre version from %q", strings.TrimSpace(content))
+	}
+	return parts[0] + "." + parts[1], nil
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
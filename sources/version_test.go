@@ -0,0 +1,42 @@
+package sources
+
+import (
+	"testing"
+)
+
+func TestParseLustreVersion(t *testing.T) {
+	for content, expected := range map[string]string{
+		"2.15.3\n": "2.15",
+		"2.12.5":   "2.12",
+		"2.10.1\n": "2.10",
+		"lustre: 2.7.19.8\nkernel: patchless_client\nbuild:  2.7.19.8-RC1\n": "2.7",
+	} {
+		version, err := parseLustreVersion(content)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if version != expected {
+			t.Fatalf("Retrieved an unexpected version. Expected: %s, Got: %s", expected, version)
+		}
+	}
+
+	for _, content := range []string{"", "unknown\n", "2\n", "lustre: x.y\n"} {
+		if _, err := parseLustreVersion(content); err == nil {
+			t.Fatalf("Expected an error for version content %q", content)
+		}
+	}
+}
+
+func TestLustreVersion(t *testing.T) {
+	savedSys, savedProc := SysLocation, ProcLocation
+	defer func() { SysLocation, ProcLocation = savedSys, savedProc }()
+
+	SysLocation, ProcLocation = "../tests/2.12/sys", "../tests/2.12/proc"
+	version, err := LustreVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != "2.10" {
+		t.Fatalf("Retrieved an unexpected version. Expected: %s, Got: %s", "2.10", version)
+	}
+}